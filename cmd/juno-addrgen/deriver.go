@@ -0,0 +1,172 @@
+//go:build !addrgen_purego
+
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Abdullah1738/juno-addrgen/internal/cli"
+	"github.com/Abdullah1738/juno-addrgen/pkg/addrgen"
+	"github.com/Abdullah1738/juno-addrgen/pkg/diversifier"
+)
+
+// deriver is the cli.Deriver backed by pkg/addrgen, and so by the Rust
+// library, linked in or (with the addrgen_wasm tag) as WebAssembly.
+type deriver struct{}
+
+func newDeriver() cli.Deriver {
+	return deriver{}
+}
+
+func (deriver) Network(ufvk string) (string, error) {
+	n, err := addrgen.DetectNetwork(ufvk)
+	return string(n), err
+}
+
+func (deriver) Derive(ufvk string, index diversifier.Index, opts cli.Options) (string, diversifier.Index, error) {
+	k, err := addrgen.ParseUFVK(ufvk)
+	if err != nil {
+		return "", diversifier.Index{}, err
+	}
+	defer k.Close()
+
+	if transparentOnly(opts) {
+		i, ok := index.Uint32()
+		if !ok {
+			return "", diversifier.Index{}, &addrgen.Error{Code: addrgen.ErrRangeOverflow}
+		}
+		a, err := k.DeriveTransparent(i, options(opts)...)
+		return a.Encoded, diversifier.FromUint32(a.Index), err
+	}
+	a, err := k.DeriveAddressIndex(index, options(opts)...)
+	return a.Encoded, a.Index, err
+}
+
+func (deriver) Stream(ctx context.Context, ufvk string, start diversifier.Index, count uint64, opts cli.Options, fn func(diversifier.Index, string) error) error {
+	if !transparentOnly(opts) {
+		return addrgen.StreamIndexContext(ctx, ufvk, start, count, fn, options(opts)...)
+	}
+
+	s, ok := start.Uint32()
+	if !ok {
+		return &addrgen.Error{Code: addrgen.ErrRangeOverflow}
+	}
+	k, err := addrgen.ParseUFVK(ufvk)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+
+	return k.StreamTransparentContext(ctx, s, count, func(index uint32, address string) error {
+		return fn(diversifier.FromUint32(index), address)
+	}, options(opts)...)
+}
+
+// transparentOnly reports whether opts select standalone transparent addresses.
+func transparentOnly(opts cli.Options) bool {
+	return len(opts.Receivers) == 1 && opts.Receivers[0] == "p2pkh"
+}
+
+// options converts the CLI's derivation settings, which it has already
+// validated.
+func options(opts cli.Options) []addrgen.Option {
+	out := []addrgen.Option{addrgen.WithParallelism(opts.Jobs), addrgen.WithScope(addrgen.Scope(opts.Scope))}
+	if len(opts.Receivers) > 0 {
+		types := make([]addrgen.Typecode, len(opts.Receivers))
+		for i, r := range opts.Receivers {
+			switch r {
+			case "sapling":
+				types[i] = addrgen.TypecodeSapling
+			case "p2pkh":
+				types[i] = addrgen.TypecodeP2PKH
+			default:
+				types[i] = addrgen.TypecodeOrchard
+			}
+		}
+		out = append(out, addrgen.WithReceivers(types...))
+	}
+	return out
+}
+
+func (deriver) IndexOf(ufvk, address string) (diversifier.Index, string, error) {
+	index, scope, err := addrgen.IndexOf(ufvk, address)
+	return index, string(scope), err
+}
+
+func (deriver) Owner(keys []cli.KeyEntry, address string) (string, diversifier.Index, string, error) {
+	ring := addrgen.NewKeyRing()
+	defer ring.Close()
+
+	for _, k := range keys {
+		if err := ring.Add(k.Label, k.UFVK); err != nil {
+			return "", diversifier.Index{}, "", err
+		}
+	}
+	label, index, scope, err := ring.Owner(address)
+	return label, index, string(scope), err
+}
+
+func (deriver) ParseAddress(address string) (string, []string, error) {
+	p, err := addrgen.ParseAddress(address)
+	if err != nil {
+		return "", nil, err
+	}
+	receivers := make([]string, len(p.Receivers))
+	for i, r := range p.Receivers {
+		receivers[i] = r.Typecode.String()
+	}
+	return string(p.Network), receivers, nil
+}
+
+func (deriver) ParseTransparentAddress(address string) (string, error) {
+	p, err := addrgen.ParseTransparentAddress(address)
+	if err != nil {
+		return "", err
+	}
+	return string(p.Network), nil
+}
+
+func (deriver) Inspect(ufvk string) (cli.UFVKInfo, error) {
+	info, err := addrgen.InspectUFVK(ufvk)
+	if err != nil {
+		return cli.UFVKInfo{}, err
+	}
+
+	out := cli.UFVKInfo{
+		Network: string(info.Network),
+		Items:   make([]cli.UFVKItem, len(info.Items)),
+	}
+	for i, item := range info.Items {
+		name := "unknown"
+		if item.Typecode.Known() {
+			name = item.Typecode.String()
+		}
+		out.Items[i] = cli.UFVKItem{Typecode: uint64(item.Typecode), Name: name, Len: item.Len}
+	}
+	if info.FVKErr != nil {
+		out.FVKError = string(addrgen.ErrInternal)
+		var ae *addrgen.Error
+		if errors.As(info.FVKErr, &ae) {
+			out.FVKError = string(ae.Code)
+		}
+		return out, nil
+	}
+	out.Fingerprint = info.Fingerprint.String()
+	out.Address = info.Address
+	return out, nil
+}
+
+func (deriver) KeyID(ufvk string) (string, error) {
+	fp, err := addrgen.Fingerprint(ufvk)
+	return fp.String(), err
+}
+
+func (deriver) ExportUIVK(ufvk string) (string, error) {
+	return addrgen.ExportUIVK(ufvk)
+}
+
+func (deriver) Keygen(seed []byte, account uint32, network string) (string, []byte, error) {
+	keys, err := addrgen.DeriveAccountKeys(seed, account, addrgen.Network(network))
+	return keys.UFVK, keys.SpendingKey[:], err
+}
@@ -1,22 +1,17 @@
 package main
 
 import (
+	"context"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/Abdullah1738/juno-addrgen/internal/cli"
-	"github.com/Abdullah1738/juno-addrgen/pkg/addrgen"
 )
 
-type deriver struct{}
-
-func (deriver) Derive(ufvk string, index uint32) (string, error) {
-	return addrgen.Derive(ufvk, index)
-}
-
-func (deriver) Batch(ufvk string, start uint32, count uint32) ([]string, error) {
-	return addrgen.Batch(ufvk, start, count)
-}
-
 func main() {
-	os.Exit(cli.Run(os.Args[1:], deriver{}))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := cli.RunContext(ctx, os.Args[1:], newDeriver(), os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
 }
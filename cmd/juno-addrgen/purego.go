@@ -0,0 +1,14 @@
+//go:build addrgen_purego
+
+package main
+
+import (
+	"github.com/Abdullah1738/juno-addrgen/internal/cli"
+	"github.com/Abdullah1738/juno-addrgen/internal/native"
+)
+
+// newDeriver returns the pure-Go deriver, which needs neither cgo nor the
+// Rust library but derives Orchard receivers only.
+func newDeriver() cli.Deriver {
+	return native.Deriver{}
+}
@@ -1,4 +1,7 @@
 module github.com/Abdullah1738/juno-addrgen
 
-go 1.22
+go 1.23.0
 
+require github.com/tetratelabs/wazero v1.9.0
+
+require golang.org/x/text v0.28.0
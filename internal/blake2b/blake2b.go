@@ -0,0 +1,162 @@
+// Package blake2b implements unkeyed BLAKE2b (RFC 7693) with the
+// personalization parameter Zcash protocols rely on, which
+// golang.org/x/crypto/blake2b does not expose.
+package blake2b
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// BlockSize is the BLAKE2b block size in bytes.
+const BlockSize = 128
+
+// MaxSize is the largest BLAKE2b digest size in bytes.
+const MaxSize = 64
+
+// PersonalSize is the length of the personalization parameter in bytes.
+const PersonalSize = 16
+
+var iv = [8]uint64{
+	0x6a09e667f3bcc908, 0xbb67ae8584caa73b, 0x3c6ef372fe94f82b, 0xa54ff53a5f1d36f1,
+	0x510e527fade682d1, 0x9b05688c2b3e6c1f, 0x1f83d9abfb41bd6b, 0x5be0cd19137e2179,
+}
+
+var sigma = [12][16]byte{
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+	{14, 10, 4, 8, 9, 15, 13, 6, 1, 12, 0, 2, 11, 7, 5, 3},
+	{11, 8, 12, 0, 5, 2, 15, 13, 10, 14, 3, 6, 7, 1, 9, 4},
+	{7, 9, 3, 1, 13, 12, 11, 14, 2, 6, 5, 10, 4, 0, 15, 8},
+	{9, 0, 5, 7, 2, 4, 10, 15, 14, 1, 11, 12, 6, 8, 3, 13},
+	{2, 12, 6, 10, 0, 11, 8, 3, 4, 13, 7, 5, 15, 14, 1, 9},
+	{12, 5, 1, 15, 14, 13, 4, 10, 0, 7, 6, 3, 9, 2, 8, 11},
+	{13, 11, 7, 14, 12, 1, 3, 9, 5, 0, 15, 4, 8, 6, 2, 10},
+	{6, 15, 14, 9, 11, 3, 0, 8, 12, 2, 13, 7, 1, 4, 10, 5},
+	{10, 2, 8, 4, 7, 6, 1, 5, 15, 11, 9, 14, 3, 12, 13, 0},
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+	{14, 10, 4, 8, 9, 15, 13, 6, 1, 12, 0, 2, 11, 7, 5, 3},
+}
+
+// Digest is a running BLAKE2b hash. It implements hash.Hash.
+type Digest struct {
+	h    [8]uint64
+	init [8]uint64
+	t    uint64
+	buf  [BlockSize]byte
+	n    int
+	size int
+}
+
+// New returns a BLAKE2b hash with a size-byte digest and the given
+// personalization, zero-padded to PersonalSize bytes. It panics if size is
+// not in [1, MaxSize] or personal is longer than PersonalSize: both are
+// protocol constants, never input.
+func New(size int, personal []byte) *Digest {
+	if size < 1 || size > MaxSize || len(personal) > PersonalSize {
+		panic("blake2b: invalid parameters")
+	}
+	d := &Digest{size: size}
+	d.init = iv
+	// Parameter block: digest length, key length 0, fanout 1, depth 1.
+	d.init[0] ^= 0x01010000 ^ uint64(size)
+	var p [PersonalSize]byte
+	copy(p[:], personal)
+	d.init[6] ^= binary.LittleEndian.Uint64(p[0:8])
+	d.init[7] ^= binary.LittleEndian.Uint64(p[8:16])
+	d.Reset()
+	return d
+}
+
+// Sum returns the size-byte BLAKE2b digest of the concatenation of parts.
+func Sum(size int, personal []byte, parts ...[]byte) []byte {
+	d := New(size, personal)
+	for _, p := range parts {
+		d.Write(p)
+	}
+	return d.Sum(nil)
+}
+
+// Reset restores the initial state.
+func (d *Digest) Reset() {
+	d.h = d.init
+	d.t = 0
+	d.n = 0
+}
+
+// Size returns the digest size in bytes.
+func (d *Digest) Size() int { return d.size }
+
+// BlockSize returns BlockSize.
+func (d *Digest) BlockSize() int { return BlockSize }
+
+// Write absorbs p. It never fails.
+func (d *Digest) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		// The last block is compressed by Sum with the final flag set, so a
+		// full buffer is only flushed once more input arrives.
+		if d.n == BlockSize {
+			d.t += BlockSize
+			d.compress(false)
+			d.n = 0
+		}
+		c := copy(d.buf[d.n:], p)
+		d.n += c
+		p = p[c:]
+	}
+	return n, nil
+}
+
+// Sum appends the digest to b. It does not change the running hash.
+func (d *Digest) Sum(b []byte) []byte {
+	f := *d
+	f.t += uint64(f.n)
+	clear(f.buf[f.n:])
+	f.compress(true)
+
+	var out [MaxSize]byte
+	for i, v := range f.h {
+		binary.LittleEndian.PutUint64(out[8*i:], v)
+	}
+	return append(b, out[:f.size]...)
+}
+
+func (d *Digest) compress(last bool) {
+	var m [16]uint64
+	for i := range m {
+		m[i] = binary.LittleEndian.Uint64(d.buf[8*i:])
+	}
+	var v [16]uint64
+	copy(v[:8], d.h[:])
+	copy(v[8:], iv[:])
+	// Inputs here never reach 2^64 bytes, so the high counter word stays 0.
+	v[12] ^= d.t
+	if last {
+		v[14] = ^v[14]
+	}
+
+	g := func(a, b, c, e int, x, y uint64) {
+		v[a] += v[b] + x
+		v[e] = bits.RotateLeft64(v[e]^v[a], -32)
+		v[c] += v[e]
+		v[b] = bits.RotateLeft64(v[b]^v[c], -24)
+		v[a] += v[b] + y
+		v[e] = bits.RotateLeft64(v[e]^v[a], -16)
+		v[c] += v[e]
+		v[b] = bits.RotateLeft64(v[b]^v[c], -63)
+	}
+	for r := range sigma {
+		s := &sigma[r]
+		g(0, 4, 8, 12, m[s[0]], m[s[1]])
+		g(1, 5, 9, 13, m[s[2]], m[s[3]])
+		g(2, 6, 10, 14, m[s[4]], m[s[5]])
+		g(3, 7, 11, 15, m[s[6]], m[s[7]])
+		g(0, 5, 10, 15, m[s[8]], m[s[9]])
+		g(1, 6, 11, 12, m[s[10]], m[s[11]])
+		g(2, 7, 8, 13, m[s[12]], m[s[13]])
+		g(3, 4, 9, 14, m[s[14]], m[s[15]])
+	}
+	for i := range d.h {
+		d.h[i] ^= v[i] ^ v[i+8]
+	}
+}
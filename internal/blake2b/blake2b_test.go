@@ -0,0 +1,41 @@
+package blake2b
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestSum_RFC7693(t *testing.T) {
+	// RFC 7693, Appendix A.
+	want := "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923"
+	if got := hex.EncodeToString(Sum(64, nil, []byte("abc"))); got != want {
+		t.Fatalf("BLAKE2b-512(abc) = %s", got)
+	}
+}
+
+func TestDigest_Streaming(t *testing.T) {
+	msg := bytes.Repeat([]byte{0x5a}, 3*BlockSize+17)
+	personal := []byte("ZcashOrchardFVFP")
+	want := Sum(32, personal, msg)
+
+	// Block-aligned and odd-sized writes, and Sum mid-stream, give the same
+	// digest as one call.
+	for _, step := range []int{1, 7, BlockSize, 2 * BlockSize} {
+		d := New(32, personal)
+		for i := 0; i < len(msg); i += step {
+			d.Write(msg[i:min(i+step, len(msg))])
+			_ = d.Sum(nil)
+		}
+		if got := d.Sum(nil); !bytes.Equal(got, want) {
+			t.Fatalf("step %d: digest %x, want %x", step, got, want)
+		}
+	}
+
+	if bytes.Equal(Sum(32, nil, msg), want) {
+		t.Fatalf("personalization had no effect")
+	}
+	if bytes.Equal(Sum(32, personal[:8], msg), want) {
+		t.Fatalf("personalization length had no effect")
+	}
+}
@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"flag"
@@ -8,12 +10,92 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+
+	"github.com/Abdullah1738/juno-addrgen/pkg/diversifier"
 )
 
 type Deriver interface {
-	Derive(ufvk string, index uint32) (string, error)
-	Batch(ufvk string, start uint32, count uint32) ([]string, error)
+	// Network reports the network ("mainnet", "testnet" or "regtest") a UFVK
+	// belongs to. Network, Derive, Stream, IndexOf and KeyID also accept a
+	// UIVK wherever they take a ufvk.
+	Network(ufvk string) (string, error)
+	// Derive returns the address at the first index at or after index that
+	// is valid for opts.Receivers, and that index. Without a Sapling receiver
+	// it is in practice always index. Receivers of just "p2pkh" derive a
+	// standalone transparent address, at BIP-44 address index index.
+	Derive(ufvk string, index diversifier.Index, opts Options) (address string, used diversifier.Index, err error)
+	// Stream calls fn for each of the count consecutive indices starting at
+	// start that are valid for opts.Receivers, in index order, and stops at
+	// the first error or once ctx is done.
+	Stream(ctx context.Context, ufvk string, start diversifier.Index, count uint64, opts Options, fn func(index diversifier.Index, address string) error) error
+	// IndexOf reports the index and scope at which ufvk derives address, or
+	// an "address_not_owned" error.
+	IndexOf(ufvk, address string) (index diversifier.Index, scope string, err error)
+	// Owner reports which of keys derives address, at which index and scope,
+	// or an "address_not_owned" error.
+	Owner(keys []KeyEntry, address string) (label string, index diversifier.Index, scope string, err error)
+	// ParseAddress decodes a unified address, reporting its network and the
+	// type of each receiver ("p2pkh", "p2sh", "sapling", "orchard", or the
+	// hex typecode of an unknown type).
+	ParseAddress(address string) (network string, receivers []string, err error)
+	// ParseTransparentAddress decodes a standalone transparent P2PKH address
+	// (t1... / tm...), reporting its network. Testnet and regtest share the
+	// tm prefix, so such addresses report "testnet".
+	ParseTransparentAddress(address string) (network string, err error)
+	// Inspect describes a UFVK without revealing it. It fails only if the
+	// UFVK's container does not decode.
+	Inspect(ufvk string) (UFVKInfo, error)
+	// KeyID returns the UFVK's fingerprint in hex, a non-secret identifier
+	// of the key.
+	KeyID(ufvk string) (string, error)
+	// ExportUIVK converts a UFVK to a UIVK on the same network.
+	ExportUIVK(ufvk string) (string, error)
+	// Keygen derives the Orchard spending key of ZIP-32 account account of
+	// seed, under Juno Cash's coin type, and returns it with its
+	// Orchard-only UFVK for network.
+	Keygen(seed []byte, account uint32, network string) (ufvk string, spendingKey []byte, err error)
+}
+
+// UFVKInfo is what inspect reports about a UFVK.
+type UFVKInfo struct {
+	Network string
+	Items   []UFVKItem
+	// FVKError is the error code the UFVK fails to parse with, or "" if it
+	// holds a usable Orchard FVK. Fingerprint and Address are set only then.
+	// Sapling and transparent items do not affect it.
+	FVKError    string
+	Fingerprint string
+	// Address is the external address at index 0.
+	Address string
+}
+
+// UFVKItem is one item of a UFVK. Name is "p2pkh", "sapling", "orchard", ...
+// or "unknown".
+type UFVKItem struct {
+	Typecode uint64
+	Name     string
+	Len      int
+}
+
+// KeyEntry is one labeled UFVK from a --keyring file.
+type KeyEntry struct {
+	Label string
+	UFVK  string
+}
+
+// Options are the derivation settings passed to a Deriver.
+type Options struct {
+	// Scope is "external" or "internal".
+	Scope string
+	// Jobs is the number of worker threads for Stream (0 = one per CPU core).
+	Jobs int
+	// Receivers lists the receiver types of each address ("orchard",
+	// "sapling", "p2pkh"); empty means Orchard only. "p2pkh" alone means
+	// standalone transparent addresses instead of unified ones.
+	Receivers []string
 }
 
 const jsonVersionV1 = "v1"
@@ -23,6 +105,12 @@ func Run(args []string, deriver Deriver) int {
 }
 
 func RunWithIO(args []string, deriver Deriver, stdout, stderr io.Writer) int {
+	return RunContext(context.Background(), args, deriver, stdout, stderr)
+}
+
+// RunContext is like RunWithIO, but stops a running batch once ctx is done and
+// reports it as "interrupted".
+func RunContext(ctx context.Context, args []string, deriver Deriver, stdout, stderr io.Writer) int {
 	if len(args) == 0 {
 		writeUsage(stdout)
 		return 2
@@ -41,7 +129,42 @@ func RunWithIO(args []string, deriver Deriver, stdout, stderr io.Writer) int {
 		if deriver == nil {
 			return writeErr(stdout, stderr, false, "internal", "missing deriver")
 		}
-		return runBatch(args[1:], deriver, stdout, stderr)
+		return runBatch(ctx, args[1:], deriver, stdout, stderr)
+	case "owns":
+		if deriver == nil {
+			return writeErr(stdout, stderr, false, "internal", "missing deriver")
+		}
+		return runOwns(args[1:], deriver, stdout, stderr)
+	case "whois":
+		if deriver == nil {
+			return writeErr(stdout, stderr, false, "internal", "missing deriver")
+		}
+		return runWhois(args[1:], deriver, stdout, stderr)
+	case "validate":
+		if deriver == nil {
+			return writeErr(stdout, stderr, false, "internal", "missing deriver")
+		}
+		return runValidate(args[1:], deriver, stdout, stderr)
+	case "inspect":
+		if deriver == nil {
+			return writeErr(stdout, stderr, false, "internal", "missing deriver")
+		}
+		return runInspect(args[1:], deriver, stdout, stderr)
+	case "export-uivk":
+		if deriver == nil {
+			return writeErr(stdout, stderr, false, "internal", "missing deriver")
+		}
+		return runExportUIVK(args[1:], deriver, stdout, stderr)
+	case "keygen":
+		if deriver == nil {
+			return writeErr(stdout, stderr, false, "internal", "missing deriver")
+		}
+		return runKeygen(ctx, args[1:], deriver, stdout, stderr)
+	case "selftest":
+		if deriver == nil {
+			return writeErr(stdout, stderr, false, "internal", "missing deriver")
+		}
+		return runSelftest(ctx, args[1:], deriver, stdout, stderr)
 	default:
 		fmt.Fprintf(stderr, "unknown command: %s\n\n", args[0])
 		writeUsage(stderr)
@@ -55,29 +178,57 @@ func writeUsage(w io.Writer) {
 	fmt.Fprintln(w, "Offline address derivation (UFVK + index -> j*1...) for Juno Cash.")
 	fmt.Fprintln(w, "")
 	fmt.Fprintln(w, "Usage:")
-	fmt.Fprintln(w, "  juno-addrgen derive --ufvk <jview*1...> --index <n> [--json]")
-	fmt.Fprintln(w, "  juno-addrgen batch  --ufvk <jview*1...> --start <n> --count <k> [--json]")
+	fmt.Fprintln(w, "  juno-addrgen derive --ufvk <jview*1...> --index <n> [--scope <scope>] [--receivers <types>] [--network <net>] [--json]")
+	fmt.Fprintln(w, "  juno-addrgen batch  --ufvk <jview*1...> --start <n> --count <k> [--scope <scope>] [--receivers <types>] [--jobs <n>] [--network <net>] [--progress] [--json]")
+	fmt.Fprintln(w, "  juno-addrgen owns   --ufvk <jview*1...> --address <j*1...> [--network <net>] [--json]")
+	fmt.Fprintln(w, "  juno-addrgen whois  --keyring <keys.json> --address <j*1...> [--json]")
+	fmt.Fprintln(w, "  juno-addrgen validate [--address <j*1... | t1... | tm...> | --file <path>] [--expect-network <net>]")
+	fmt.Fprintln(w, "  juno-addrgen inspect --ufvk-file <path> [--json]")
+	fmt.Fprintln(w, "  juno-addrgen export-uivk --ufvk-file <path> [--json]")
+	fmt.Fprintln(w, "  juno-addrgen keygen (--mnemonic-file <path> [--passphrase-file <path>] | --seed-file <path>) [--account <n>|<a>-<b>] [--network <net>] [--seed-fingerprint] [--json]")
+	fmt.Fprintln(w, "  juno-addrgen selftest [--json]")
 	fmt.Fprintln(w, "")
 	fmt.Fprintln(w, "Notes:")
 	fmt.Fprintln(w, "  - UFVKs are sensitive (watch-only, but reveal incoming transaction details).")
 	fmt.Fprintln(w, "  - This tool is offline; it never talks to junocashd or the network.")
+	fmt.Fprintln(w, "  - --scope internal derives change addresses; the default is external (addresses handed out to payers).")
+	fmt.Fprintln(w, "  - --network mainnet|testnet|regtest fails with network_mismatch unless the UFVK is on that network.")
+	fmt.Fprintln(w, "  - --index and --start take any 88-bit diversifier index, in decimal or 0x-prefixed hex.")
+	fmt.Fprintln(w, "  - batch streams its output; --count may reach the last index (start + count <= 2^88).")
+	fmt.Fprintln(w, "  - owns prints the index and scope of an address derived by the UFVK, or fails with address_not_owned.")
+	fmt.Fprintln(w, "  - whois does the same across a keyring file ({\"<label>\": \"<ufvk>\", ...}) and also prints the owning label.")
+	fmt.Fprintln(w, "  - validate checks addresses (one per line, from stdin by default) and prints a JSON result per line; it exits 1 if any fails.")
+	fmt.Fprintln(w, "  - inspect describes a UFVK (network, items, fingerprint, address 0) without printing it.")
+	fmt.Fprintln(w, "  - export-uivk converts a UFVK to a UIVK (jivk*1...), which derives the same external addresses but no change addresses.")
+	fmt.Fprintln(w, "    The UIVK also carries the UFVK's Sapling IVK and transparent external chain key, if it has those items.")
+	fmt.Fprintln(w, "  - keygen derives each account's UFVK (Orchard, ZIP-32 m/32'/8133'/account') from a BIP-39 mnemonic or hex seed; the")
+	fmt.Fprintln(w, "    -env variants of its flags read an env var, and - reads stdin. Spending keys are printed only with")
+	fmt.Fprintln(w, "    --dangerously-print-spending-keys. Run it offline: the mnemonic controls the funds.")
+	fmt.Fprintln(w, "  - selftest checks the build against every golden vector embedded in it and reports each vectors file as pass,")
+	fmt.Fprintln(w, "    fail or skip (receiver types the build does not support); it exits 1 if any fails.")
+	fmt.Fprintln(w, "  - derive, batch and owns accept a UIVK (--uivk, --uivk-file, --uivk-env) instead of a UFVK, in the external scope only.")
+	fmt.Fprintln(w, "  - --receivers orchard,sapling adds a Sapling receiver (UFVK with a Sapling key, external scope); indices with an invalid")
+	fmt.Fprintln(w, "    Sapling diversifier are skipped, so derive reports the index used and batch may return fewer than --count addresses.")
+	fmt.Fprintln(w, "  - --receivers orchard,p2pkh adds a transparent receiver (UFVK with a P2PKH key); --receivers p2pkh derives standalone")
+	fmt.Fprintln(w, "    transparent t-addresses instead. Either way indices stop below 2^31 (BIP-44 m/44'/8133'/account'/change/index).")
 }
 
 func runDerive(args []string, deriver Deriver, stdout, stderr io.Writer) int {
 	fs := flag.NewFlagSet("derive", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
 
-	var ufvkFlag string
-	var ufvkFile string
-	var ufvkEnv string
-	var index uint64
+	var keys keyFlags
+	var index string
+	var scope string
+	var receivers string
+	var network string
 	var jsonOut bool
 
-	fs.StringVar(&ufvkFlag, "ufvk", "", "UFVK (jview*1...)")
-	fs.StringVar(&ufvkFlag, "uvfk", "", "Alias for --ufvk")
-	fs.StringVar(&ufvkFile, "ufvk-file", "", "Read UFVK from file")
-	fs.StringVar(&ufvkEnv, "ufvk-env", "", "Read UFVK from env var (name)")
-	fs.Uint64Var(&index, "index", 0, "Diversifier index (0..2^32-1)")
+	keys.register(fs)
+	fs.StringVar(&index, "index", "0", "Diversifier index (0..2^88-1, decimal or 0x-hex)")
+	fs.StringVar(&scope, "scope", "external", "Key scope (external|internal)")
+	fs.StringVar(&receivers, "receivers", "orchard", "Receiver types (orchard[,sapling][,p2pkh]|p2pkh)")
+	fs.StringVar(&network, "network", "", "Require the key to be on this network (mainnet|testnet|regtest)")
 	fs.BoolVar(&jsonOut, "json", false, "JSON output")
 
 	if err := fs.Parse(args); err != nil {
@@ -85,52 +236,274 @@ func runDerive(args []string, deriver Deriver, stdout, stderr io.Writer) int {
 		return 2
 	}
 
-	ufvk, err := readUFVK(ufvkFlag, ufvkFile, ufvkEnv)
+	ufvk, err := keys.read()
 	if err != nil {
 		fmt.Fprintln(stderr, err.Error())
 		return 2
 	}
 
-	idx, ok := uint64ToUint32(index)
+	idx, err := diversifier.Parse(index)
+	if err != nil {
+		return writeErr(stdout, stderr, jsonOut, "index_invalid", indexMessage("index", err))
+	}
+	scope, ok := parseScope(scope)
+	if !ok {
+		return writeErr(stdout, stderr, jsonOut, "scope_invalid", "scope must be external or internal")
+	}
+	types, ok := parseReceivers(receivers)
 	if !ok {
-		return writeErr(stdout, stderr, jsonOut, "index_invalid", "index out of range")
+		return writeErr(stdout, stderr, jsonOut, "receivers_invalid", receiversMessage)
+	}
+	if code := checkNetwork(deriver, ufvk, network, stdout, stderr, jsonOut); code != 0 {
+		return code
 	}
 
-	address, err := deriver.Derive(ufvk, idx)
+	address, used, err := deriver.Derive(ufvk, idx, Options{Scope: scope, Receivers: types})
 	if err != nil {
 		return writeDeriverErr(stdout, stderr, jsonOut, err)
 	}
 
 	if jsonOut {
+		keyID, err := deriver.KeyID(ufvk)
+		if err != nil {
+			return writeDeriverErr(stdout, stderr, jsonOut, err)
+		}
 		_ = json.NewEncoder(stdout).Encode(map[string]any{
-			"version": jsonVersionV1,
-			"status":  "ok",
-			"address": address,
+			"version":   jsonVersionV1,
+			"status":    "ok",
+			"address":   address,
+			"index":     used,
+			"index_str": used.String(),
+			"key_id":    keyID,
 		})
 		return 0
 	}
 
+	if hasSapling(types) {
+		fmt.Fprintf(stdout, "address=%s index=%s\n", address, used)
+		return 0
+	}
 	fmt.Fprintln(stdout, address)
 	return 0
 }
 
-func runBatch(args []string, deriver Deriver, stdout, stderr io.Writer) int {
+func runBatch(ctx context.Context, args []string, deriver Deriver, stdout, stderr io.Writer) int {
 	fs := flag.NewFlagSet("batch", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
 
+	var keys keyFlags
+	var start string
+	var count uint64
+	var jobs int
+	var scope string
+	var receivers string
+	var network string
+	var showProgress bool
+	var jsonOut bool
+
+	keys.register(fs)
+	fs.StringVar(&start, "start", "0", "Start diversifier index (0..2^88-1, decimal or 0x-hex)")
+	fs.Uint64Var(&count, "count", 0, "Number of indices (>= 1, start+count <= 2^88)")
+	fs.IntVar(&jobs, "jobs", 1, "Worker threads (0 = one per CPU core)")
+	fs.StringVar(&scope, "scope", "external", "Key scope (external|internal)")
+	fs.StringVar(&receivers, "receivers", "orchard", "Receiver types (orchard[,sapling][,p2pkh]|p2pkh)")
+	fs.StringVar(&network, "network", "", "Require the key to be on this network (mainnet|testnet|regtest)")
+	fs.BoolVar(&showProgress, "progress", false, "Show progress on stderr (default: when stderr is a terminal)")
+	fs.BoolVar(&jsonOut, "json", false, "JSON output")
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 2
+	}
+	if !flagSet(fs, "progress") {
+		showProgress = isTerminal(stderr)
+	}
+
+	ufvk, err := keys.read()
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 2
+	}
+
+	s, err := diversifier.Parse(start)
+	if err != nil {
+		return writeErr(stdout, stderr, jsonOut, "index_invalid", indexMessage("start", err))
+	}
+	if count == 0 {
+		return writeErr(stdout, stderr, jsonOut, "count_invalid", "count must be >= 1")
+	}
+	if diversifier.CheckRange(s, count) != nil {
+		return writeErr(stdout, stderr, jsonOut, "range_overflow", "start + count exceeds 2^88")
+	}
+	if jobs < 0 {
+		return writeErr(stdout, stderr, jsonOut, "jobs_invalid", "jobs must be >= 0")
+	}
+	scope, ok := parseScope(scope)
+	if !ok {
+		return writeErr(stdout, stderr, jsonOut, "scope_invalid", "scope must be external or internal")
+	}
+	types, ok := parseReceivers(receivers)
+	if !ok {
+		return writeErr(stdout, stderr, jsonOut, "receivers_invalid", receiversMessage)
+	}
+	if slices.Contains(types, "p2pkh") && !transparentRange(s, count) {
+		return writeErr(stdout, stderr, jsonOut, "range_overflow", "with p2pkh receivers, start + count must not exceed 2^31")
+	}
+	if code := checkNetwork(deriver, ufvk, network, stdout, stderr, jsonOut); code != 0 {
+		return code
+	}
+
+	var keyID string
+	if jsonOut {
+		if keyID, err = deriver.KeyID(ufvk); err != nil {
+			return writeDeriverErr(stdout, stderr, jsonOut, err)
+		}
+	}
+
+	out := newBatchWriter(stdout, jsonOut, keyID, s, count, hasSapling(types))
+	prog := newProgress(stderr, count, showProgress)
+	err = deriver.Stream(ctx, ufvk, s, count, Options{Scope: scope, Jobs: jobs, Receivers: types}, func(index diversifier.Index, address string) error {
+		if err := out.address(index, address); err != nil {
+			return err
+		}
+		prog.reach(s, index)
+		return nil
+	})
+	if err == nil {
+		prog.complete()
+	}
+	prog.finish()
+
+	if err != nil {
+		if out.written == 0 {
+			return writeDeriverErr(stdout, stderr, jsonOut, err)
+		}
+		return out.fail(stderr, err)
+	}
+	if err := out.finish(); err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	return 0
+}
+
+func runOwns(args []string, deriver Deriver, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("owns", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var keys keyFlags
+	var address string
+	var network string
+	var jsonOut bool
+
+	keys.register(fs)
+	fs.StringVar(&address, "address", "", "Unified address (j*1...)")
+	fs.StringVar(&network, "network", "", "Require the key to be on this network (mainnet|testnet|regtest)")
+	fs.BoolVar(&jsonOut, "json", false, "JSON output")
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 2
+	}
+
+	ufvk, err := keys.read()
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 2
+	}
+	address = strings.TrimSpace(address)
+	if address == "" {
+		fmt.Fprintln(stderr, "address is required (use --address)")
+		return 2
+	}
+	if code := checkNetwork(deriver, ufvk, network, stdout, stderr, jsonOut); code != 0 {
+		return code
+	}
+
+	index, scope, err := deriver.IndexOf(ufvk, address)
+	if err != nil {
+		return writeDeriverErr(stdout, stderr, jsonOut, err)
+	}
+
+	if jsonOut {
+		_ = json.NewEncoder(stdout).Encode(map[string]any{
+			"version":   jsonVersionV1,
+			"status":    "ok",
+			"address":   address,
+			"index":     index,
+			"index_str": index.String(),
+			"scope":     scope,
+		})
+		return 0
+	}
+
+	fmt.Fprintf(stdout, "index=%s scope=%s\n", index, scope)
+	return 0
+}
+
+func runWhois(args []string, deriver Deriver, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("whois", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var keyringFile string
+	var address string
+	var jsonOut bool
+
+	fs.StringVar(&keyringFile, "keyring", "", "JSON file mapping labels to UFVKs")
+	fs.StringVar(&address, "address", "", "Unified address (j*1...)")
+	fs.BoolVar(&jsonOut, "json", false, "JSON output")
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 2
+	}
+
+	keys, err := readKeyRing(keyringFile)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 2
+	}
+	address = strings.TrimSpace(address)
+	if address == "" {
+		fmt.Fprintln(stderr, "address is required (use --address)")
+		return 2
+	}
+
+	label, index, scope, err := deriver.Owner(keys, address)
+	if err != nil {
+		return writeDeriverErr(stdout, stderr, jsonOut, err)
+	}
+
+	if jsonOut {
+		_ = json.NewEncoder(stdout).Encode(map[string]any{
+			"version":   jsonVersionV1,
+			"status":    "ok",
+			"address":   address,
+			"label":     label,
+			"index":     index,
+			"index_str": index.String(),
+			"scope":     scope,
+		})
+		return 0
+	}
+
+	fmt.Fprintf(stdout, "label=%s index=%s scope=%s\n", label, index, scope)
+	return 0
+}
+
+func runInspect(args []string, deriver Deriver, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
 	var ufvkFlag string
 	var ufvkFile string
 	var ufvkEnv string
-	var start uint64
-	var count uint64
 	var jsonOut bool
 
 	fs.StringVar(&ufvkFlag, "ufvk", "", "UFVK (jview*1...)")
 	fs.StringVar(&ufvkFlag, "uvfk", "", "Alias for --ufvk")
 	fs.StringVar(&ufvkFile, "ufvk-file", "", "Read UFVK from file")
 	fs.StringVar(&ufvkEnv, "ufvk-env", "", "Read UFVK from env var (name)")
-	fs.Uint64Var(&start, "start", 0, "Start diversifier index (0..2^32-1)")
-	fs.Uint64Var(&count, "count", 0, "Number of addresses (1..100000)")
 	fs.BoolVar(&jsonOut, "json", false, "JSON output")
 
 	if err := fs.Parse(args); err != nil {
@@ -144,37 +517,248 @@ func runBatch(args []string, deriver Deriver, stdout, stderr io.Writer) int {
 		return 2
 	}
 
-	s, ok := uint64ToUint32(start)
-	if !ok {
-		return writeErr(stdout, stderr, jsonOut, "index_invalid", "start out of range")
+	info, err := deriver.Inspect(ufvk)
+	if err != nil {
+		return writeDeriverErr(stdout, stderr, jsonOut, err)
 	}
-	c, ok := uint64ToUint32(count)
-	if !ok || c == 0 {
-		return writeErr(stdout, stderr, jsonOut, "count_invalid", "count out of range")
+
+	if jsonOut {
+		items := make([]map[string]any, len(info.Items))
+		for i, item := range info.Items {
+			items[i] = map[string]any{
+				"typecode": item.Typecode,
+				"name":     item.Name,
+				"len":      item.Len,
+			}
+		}
+		resp := map[string]any{
+			"version":   jsonVersionV1,
+			"status":    "ok",
+			"network":   info.Network,
+			"items":     items,
+			"fvk_valid": info.FVKError == "",
+		}
+		if info.FVKError != "" {
+			resp["fvk_error"] = info.FVKError
+		} else {
+			resp["fingerprint"] = info.Fingerprint
+			resp["address"] = info.Address
+			resp["index"] = 0
+			resp["index_str"] = "0"
+		}
+		_ = json.NewEncoder(stdout).Encode(resp)
+		return 0
+	}
+
+	fmt.Fprintf(stdout, "network=%s\n", info.Network)
+	for _, item := range info.Items {
+		fmt.Fprintf(stdout, "item typecode=%#02x name=%s len=%d\n", item.Typecode, item.Name, item.Len)
+	}
+	if info.FVKError != "" {
+		fmt.Fprintf(stdout, "fvk=%s\n", info.FVKError)
+		return 0
+	}
+	fmt.Fprintln(stdout, "fvk=ok")
+	fmt.Fprintf(stdout, "fingerprint=%s\n", info.Fingerprint)
+	fmt.Fprintf(stdout, "address=%s index=0\n", info.Address)
+	return 0
+}
+
+func runExportUIVK(args []string, deriver Deriver, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("export-uivk", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var ufvkFlag string
+	var ufvkFile string
+	var ufvkEnv string
+	var jsonOut bool
+
+	fs.StringVar(&ufvkFlag, "ufvk", "", "UFVK (jview*1...)")
+	fs.StringVar(&ufvkFlag, "uvfk", "", "Alias for --ufvk")
+	fs.StringVar(&ufvkFile, "ufvk-file", "", "Read UFVK from file")
+	fs.StringVar(&ufvkEnv, "ufvk-env", "", "Read UFVK from env var (name)")
+	fs.BoolVar(&jsonOut, "json", false, "JSON output")
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 2
+	}
+
+	ufvk, err := readUFVK(ufvkFlag, ufvkFile, ufvkEnv)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 2
 	}
 
-	addresses, err := deriver.Batch(ufvk, s, c)
+	uivk, err := deriver.ExportUIVK(ufvk)
 	if err != nil {
 		return writeDeriverErr(stdout, stderr, jsonOut, err)
 	}
 
 	if jsonOut {
+		network, err := deriver.Network(uivk)
+		if err != nil {
+			return writeDeriverErr(stdout, stderr, jsonOut, err)
+		}
+		keyID, err := deriver.KeyID(uivk)
+		if err != nil {
+			return writeDeriverErr(stdout, stderr, jsonOut, err)
+		}
 		_ = json.NewEncoder(stdout).Encode(map[string]any{
-			"version":   jsonVersionV1,
-			"status":    "ok",
-			"start":     s,
-			"count":     c,
-			"addresses": addresses,
+			"version": jsonVersionV1,
+			"status":  "ok",
+			"uivk":    uivk,
+			"network": network,
+			"key_id":  keyID,
 		})
 		return 0
 	}
 
-	for _, a := range addresses {
-		fmt.Fprintln(stdout, a)
+	fmt.Fprintln(stdout, uivk)
+	return 0
+}
+
+// readKeyRing reads a keyring file: a JSON object mapping labels to UFVKs,
+// each label at most once. Entries are returned sorted by label.
+func readKeyRing(path string) ([]KeyEntry, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, fmt.Errorf("keyring is required (use --keyring)")
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read keyring file (%s): %w", filepath.Base(path), err)
+	}
+
+	// Walk the tokens rather than decode into a map, which would keep only
+	// the last of two entries with the same label.
+	base := filepath.Base(path)
+	parseErr := fmt.Errorf("parse keyring file (%s): expected {\"<label>\": \"<ufvk>\", ...}", base)
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil, parseErr
+	}
+	var keys []KeyEntry
+	seen := make(map[string]bool)
+	for dec.More() {
+		t, err := dec.Token()
+		label, ok := t.(string)
+		if err != nil || !ok {
+			return nil, parseErr
+		}
+		var ufvk string
+		if err := dec.Decode(&ufvk); err != nil {
+			return nil, parseErr
+		}
+		if seen[label] {
+			return nil, fmt.Errorf("keyring file (%s) repeats label %q", base, label)
+		}
+		seen[label] = true
+		keys = append(keys, KeyEntry{Label: label, UFVK: strings.TrimSpace(ufvk)})
+	}
+	if t, err := dec.Token(); err != nil || t != json.Delim('}') {
+		return nil, parseErr
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, parseErr
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("keyring file (%s) has no keys", base)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Label < keys[j].Label })
+	return keys, nil
+}
+
+func parseScope(s string) (string, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "external", "internal":
+		return s, true
+	default:
+		return "", false
+	}
+}
+
+const receiversMessage = "receivers must be orchard, optionally with sapling and/or p2pkh, or p2pkh alone"
+
+// parseReceivers parses a comma-separated --receivers list. Every unified
+// address has an Orchard receiver, to which sapling and p2pkh can be added;
+// p2pkh alone selects standalone transparent addresses.
+func parseReceivers(s string) ([]string, bool) {
+	var orchard, sapling, p2pkh bool
+	for _, t := range strings.Split(s, ",") {
+		switch strings.ToLower(strings.TrimSpace(t)) {
+		case "orchard":
+			orchard = true
+		case "sapling":
+			sapling = true
+		case "p2pkh":
+			p2pkh = true
+		default:
+			return nil, false
+		}
+	}
+	if !orchard {
+		if p2pkh && !sapling {
+			return []string{"p2pkh"}, true
+		}
+		return nil, false
+	}
+	types := []string{"orchard"}
+	if sapling {
+		types = append(types, "sapling")
+	}
+	if p2pkh {
+		types = append(types, "p2pkh")
+	}
+	return types, true
+}
+
+func hasSapling(receivers []string) bool {
+	return slices.Contains(receivers, "sapling")
+}
+
+// transparentRange reports whether [start, start+count) lies below 2^31, the
+// end of the BIP-44 address indices P2PKH receivers are derived at.
+func transparentRange(start diversifier.Index, count uint64) bool {
+	const end = uint64(1) << 31
+	s, ok := start.Uint32()
+	return ok && uint64(s) < end && count <= end-uint64(s)
+}
+
+// checkNetwork enforces --network. It returns 0 if want is empty or matches the
+// UFVK's network, and otherwise writes the error and returns the exit code.
+func checkNetwork(deriver Deriver, ufvk, want string, stdout, stderr io.Writer, jsonOut bool) int {
+	want = strings.ToLower(strings.TrimSpace(want))
+	switch want {
+	case "":
+		return 0
+	case "mainnet", "testnet", "regtest":
+	default:
+		return writeErr(stdout, stderr, jsonOut, "network_invalid", "network must be mainnet, testnet or regtest")
+	}
+
+	got, err := deriver.Network(ufvk)
+	if err != nil {
+		return writeDeriverErr(stdout, stderr, jsonOut, err)
+	}
+	if got != want {
+		return writeErr(stdout, stderr, jsonOut, "network_mismatch", fmt.Sprintf("ufvk is for %s, expected %s", got, want))
 	}
 	return 0
 }
 
+func flagSet(fs *flag.FlagSet, name string) bool {
+	var set bool
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
 func readUFVK(ufvkFlag, ufvkFile, ufvkEnv string) (string, error) {
 	var sources int
 	if strings.TrimSpace(ufvkFlag) != "" {
@@ -192,28 +776,75 @@ func readUFVK(ufvkFlag, ufvkFile, ufvkEnv string) (string, error) {
 	if sources > 1 {
 		return "", fmt.Errorf("ufvk source conflict (use only one of --ufvk, --ufvk-file, --ufvk-env)")
 	}
+	return readKeySource("ufvk", ufvkFlag, ufvkFile, ufvkEnv)
+}
 
-	if strings.TrimSpace(ufvkFlag) != "" {
-		return strings.TrimSpace(ufvkFlag), nil
+// keyFlags are the key sources of commands that need no more than an incoming
+// viewing key: a UFVK, or a UIVK for the external scope only. The deriver
+// tells the two apart by their HRP.
+type keyFlags struct {
+	ufvk, ufvkFile, ufvkEnv string
+	uivk, uivkFile, uivkEnv string
+}
+
+func (k *keyFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&k.ufvk, "ufvk", "", "UFVK (jview*1...)")
+	fs.StringVar(&k.ufvk, "uvfk", "", "Alias for --ufvk")
+	fs.StringVar(&k.ufvkFile, "ufvk-file", "", "Read UFVK from file")
+	fs.StringVar(&k.ufvkEnv, "ufvk-env", "", "Read UFVK from env var (name)")
+	fs.StringVar(&k.uivk, "uivk", "", "UIVK (jivk*1...), instead of a UFVK")
+	fs.StringVar(&k.uivkFile, "uivk-file", "", "Read UIVK from file")
+	fs.StringVar(&k.uivkEnv, "uivk-env", "", "Read UIVK from env var (name)")
+}
+
+func (k *keyFlags) read() (string, error) {
+	var ufvkSources, uivkSources int
+	for _, v := range []string{k.ufvk, k.ufvkFile, k.ufvkEnv} {
+		if strings.TrimSpace(v) != "" {
+			ufvkSources++
+		}
+	}
+	for _, v := range []string{k.uivk, k.uivkFile, k.uivkEnv} {
+		if strings.TrimSpace(v) != "" {
+			uivkSources++
+		}
+	}
+	switch {
+	case ufvkSources+uivkSources == 0:
+		return "", fmt.Errorf("ufvk is required (use --ufvk, --ufvk-file, --ufvk-env, or a UIVK with --uivk, --uivk-file, --uivk-env)")
+	case ufvkSources+uivkSources > 1:
+		return "", fmt.Errorf("key source conflict (use only one of --ufvk, --ufvk-file, --ufvk-env, --uivk, --uivk-file, --uivk-env)")
+	case uivkSources == 1:
+		return readKeySource("uivk", k.uivk, k.uivkFile, k.uivkEnv)
+	default:
+		return readKeySource("ufvk", k.ufvk, k.ufvkFile, k.ufvkEnv)
 	}
+}
 
-	if strings.TrimSpace(ufvkEnv) != "" {
-		return strings.TrimSpace(os.Getenv(strings.TrimSpace(ufvkEnv))), nil
+// readKeySource reads a key of the given kind ("ufvk" or "uivk") from the
+// one non-empty source.
+func readKeySource(kind, value, file, env string) (string, error) {
+	if strings.TrimSpace(value) != "" {
+		return strings.TrimSpace(value), nil
+	}
+
+	if strings.TrimSpace(env) != "" {
+		return strings.TrimSpace(os.Getenv(strings.TrimSpace(env))), nil
 	}
 
-	path := strings.TrimSpace(ufvkFile)
+	path := strings.TrimSpace(file)
 	b, err := os.ReadFile(path)
 	if err != nil {
-		return "", fmt.Errorf("read ufvk file (%s): %w", filepath.Base(path), err)
+		return "", fmt.Errorf("read %s file (%s): %w", kind, filepath.Base(path), err)
 	}
 	return strings.TrimSpace(string(b)), nil
 }
 
-func uint64ToUint32(v uint64) (uint32, bool) {
-	if v > uint64(^uint32(0)) {
-		return 0, false
+func indexMessage(flag string, err error) string {
+	if errors.Is(err, diversifier.ErrRange) {
+		return flag + " out of range (max 2^88-1)"
 	}
-	return uint32(v), true
+	return flag + " must be a decimal or 0x-prefixed hex integer"
 }
 
 type codedError interface {
@@ -222,11 +853,23 @@ type codedError interface {
 }
 
 func writeDeriverErr(stdout, stderr io.Writer, jsonOut bool, err error) int {
+	code, message := errCode(err)
+	return writeErr(stdout, stderr, jsonOut, code, message)
+}
+
+func errCode(err error) (code, message string) {
+	if errors.Is(err, context.Canceled) {
+		return "interrupted", ""
+	}
 	var ce codedError
 	if errors.As(err, &ce) {
-		return writeErr(stdout, stderr, jsonOut, ce.CodeString(), "")
+		// A wrapped code carries context (e.g. which keyring entry failed).
+		if error(ce) != err {
+			return ce.CodeString(), err.Error()
+		}
+		return ce.CodeString(), ""
 	}
-	return writeErr(stdout, stderr, jsonOut, "internal", err.Error())
+	return "internal", err.Error()
 }
 
 func writeErr(stdout, stderr io.Writer, jsonOut bool, code, message string) int {
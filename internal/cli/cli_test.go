@@ -2,35 +2,173 @@ package cli
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+
+	"github.com/Abdullah1738/juno-addrgen/pkg/diversifier"
+	"github.com/Abdullah1738/juno-addrgen/pkg/orchard"
+	"github.com/Abdullah1738/juno-addrgen/pkg/zip316"
+	"github.com/Abdullah1738/juno-addrgen/vectors"
 )
 
 type fakeDeriver struct {
+	network    string
+	networkErr error
+
 	deriveUFVK  string
-	deriveIndex uint32
+	deriveIndex diversifier.Index
+	deriveScope string
 	deriveAddr  string
-	deriveErr   error
+	// deriveUsed is the index Derive reports using, if not the one asked for.
+	deriveUsed *diversifier.Index
+	deriveErr  error
+	receivers  []string
 
 	batchUFVK  string
-	batchStart uint32
-	batchCount uint32
+	batchStart diversifier.Index
+	batchCount uint64
+	batchJobs  int
+	batchScope string
 	batchAddrs []string
-	batchErr   error
+	// batchIndices are the indices of batchAddrs, if not consecutive from
+	// the start.
+	batchIndices []diversifier.Index
+	batchErr     error
+
+	ownsAddress string
+	ownsIndex   diversifier.Index
+	ownsScope   string
+	ownsErr     error
+
+	whoisKeys  []KeyEntry
+	whoisLabel string
+	whoisErr   error
+
+	// parsed maps the addresses ParseAddress accepts to their network and
+	// receivers; any other address is "address_invalid_bech32m".
+	parsed map[string]fakeAddress
+
+	inspectUFVK string
+	inspect     UFVKInfo
+	inspectErr  error
+
+	keyID    string
+	keyIDErr error
+
+	exportUFVK string
+	uivk       string
+	uivkErr    error
+
+	keygenErr error
+}
+
+type fakeAddress struct {
+	network   string
+	receivers []string
+}
+
+func (f *fakeDeriver) Network(string) (string, error) {
+	return f.network, f.networkErr
 }
 
-func (f *fakeDeriver) Derive(ufvk string, index uint32) (string, error) {
+func (f *fakeDeriver) Derive(ufvk string, index diversifier.Index, opts Options) (string, diversifier.Index, error) {
 	f.deriveUFVK = ufvk
 	f.deriveIndex = index
-	return f.deriveAddr, f.deriveErr
+	f.deriveScope = opts.Scope
+	f.receivers = opts.Receivers
+	if f.deriveUsed != nil {
+		index = *f.deriveUsed
+	}
+	return f.deriveAddr, index, f.deriveErr
 }
 
-func (f *fakeDeriver) Batch(ufvk string, start uint32, count uint32) ([]string, error) {
+// Stream emits batchAddrs and then returns batchErr, so a non-nil batchErr
+// with addresses simulates a failure part-way through a batch. Like the real
+// deriver, it checks ctx after each address.
+func (f *fakeDeriver) Stream(ctx context.Context, ufvk string, start diversifier.Index, count uint64, opts Options, fn func(diversifier.Index, string) error) error {
 	f.batchUFVK = ufvk
 	f.batchStart = start
 	f.batchCount = count
-	return f.batchAddrs, f.batchErr
+	f.batchJobs = opts.Jobs
+	f.batchScope = opts.Scope
+	f.receivers = opts.Receivers
+	for i, a := range f.batchAddrs {
+		index, _ := start.Add(uint64(i))
+		if f.batchIndices != nil {
+			index = f.batchIndices[i]
+		}
+		if err := fn(index, a); err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	return f.batchErr
+}
+
+func (f *fakeDeriver) IndexOf(ufvk, address string) (diversifier.Index, string, error) {
+	f.ownsAddress = address
+	return f.ownsIndex, f.ownsScope, f.ownsErr
+}
+
+func (f *fakeDeriver) Owner(keys []KeyEntry, address string) (string, diversifier.Index, string, error) {
+	f.whoisKeys = keys
+	f.ownsAddress = address
+	return f.whoisLabel, f.ownsIndex, f.ownsScope, f.whoisErr
+}
+
+func (f *fakeDeriver) ParseAddress(address string) (string, []string, error) {
+	a, ok := f.parsed[address]
+	if !ok {
+		return "", nil, codedErr("address_invalid_bech32m")
+	}
+	return a.network, a.receivers, nil
+}
+
+func (f *fakeDeriver) ParseTransparentAddress(address string) (string, error) {
+	a, ok := f.parsed[address]
+	if !ok || len(a.receivers) != 1 || a.receivers[0] != "p2pkh" {
+		return "", codedErr("address_transparent_invalid")
+	}
+	return a.network, nil
+}
+
+func (f *fakeDeriver) Inspect(ufvk string) (UFVKInfo, error) {
+	f.inspectUFVK = ufvk
+	return f.inspect, f.inspectErr
+}
+
+func (f *fakeDeriver) KeyID(string) (string, error) {
+	return f.keyID, f.keyIDErr
+}
+
+func (f *fakeDeriver) ExportUIVK(ufvk string) (string, error) {
+	f.exportUFVK = ufvk
+	return f.uivk, f.uivkErr
+}
+
+// Keygen derives real keys, with pkg/orchard, so that keygen's output can be
+// checked against the vectors.
+func (f *fakeDeriver) Keygen(seed []byte, account uint32, network string) (string, []byte, error) {
+	if f.keygenErr != nil {
+		return "", nil, f.keygenErr
+	}
+	hrps := map[string]string{"mainnet": "jview", "testnet": "jviewtest", "regtest": "jviewregtest"}
+	sk, err := orchard.SpendingKeyFromSeed(seed, 8133, account)
+	if err != nil {
+		return "", nil, err
+	}
+	fvk := sk.FullViewingKey().Bytes()
+	ufvk, err := zip316.EncodeUnifiedContainer(hrps[network], 3, fvk[:])
+	b := sk.Bytes()
+	return ufvk, b[:], err
 }
 
 type codedErr string
@@ -55,13 +193,13 @@ func TestDerive_Plain(t *testing.T) {
 	if d.deriveUFVK != "jview1test" {
 		t.Fatalf("unexpected ufvk: %q", d.deriveUFVK)
 	}
-	if d.deriveIndex != 5 {
-		t.Fatalf("unexpected index: %d", d.deriveIndex)
+	if d.deriveIndex != diversifier.FromUint32(5) {
+		t.Fatalf("unexpected index: %s", d.deriveIndex)
 	}
 }
 
 func TestDerive_JSON(t *testing.T) {
-	d := &fakeDeriver{deriveAddr: "j1abc"}
+	d := &fakeDeriver{deriveAddr: "j1abc", keyID: "e37f"}
 	var out, err bytes.Buffer
 
 	code := RunWithIO([]string{"derive", "--ufvk", "jview1test", "--index", "0", "--json"}, d, &out, &err)
@@ -73,9 +211,16 @@ func TestDerive_JSON(t *testing.T) {
 	if e := json.Unmarshal(out.Bytes(), &v); e != nil {
 		t.Fatalf("invalid json: %v (%q)", e, out.String())
 	}
-	if v["version"] != "v1" || v["status"] != "ok" || v["address"] != "j1abc" {
+	if v["version"] != "v1" || v["status"] != "ok" || v["address"] != "j1abc" || v["index"] != 0.0 || v["key_id"] != "e37f" {
 		t.Fatalf("unexpected json: %v", v)
 	}
+
+	d.keyIDErr = codedErr("internal")
+	out.Reset()
+	code = RunWithIO([]string{"derive", "--ufvk", "jview1test", "--index", "0", "--json"}, d, &out, &err)
+	if code != 1 || !strings.Contains(out.String(), `"status":"err"`) || strings.Contains(out.String(), "j1abc") {
+		t.Fatalf("unexpected result: code=%d out=%q", code, out.String())
+	}
 }
 
 func TestDerive_ErrorCode(t *testing.T) {
@@ -93,14 +238,45 @@ func TestDerive_ErrorCode(t *testing.T) {
 
 func TestDerive_IndexOutOfRange(t *testing.T) {
 	d := &fakeDeriver{deriveAddr: "j1abc"}
-	var out, err bytes.Buffer
 
-	code := RunWithIO([]string{"derive", "--ufvk", "jview1test", "--index", "4294967296"}, d, &out, &err)
-	if code != 1 {
-		t.Fatalf("unexpected exit code: %d", code)
+	for _, index := range []string{"309485009821345068724781056", "-1", "12abc"} {
+		var out, err bytes.Buffer
+		code := RunWithIO([]string{"derive", "--ufvk", "jview1test", "--index", index}, d, &out, &err)
+		if code != 1 {
+			t.Fatalf("--index %s: unexpected exit code: %d", index, code)
+		}
+		if !strings.Contains(err.String(), "index_invalid") {
+			t.Fatalf("--index %s: unexpected stderr: %q", index, err.String())
+		}
 	}
-	if !strings.Contains(err.String(), "index_invalid") {
-		t.Fatalf("unexpected stderr: %q", err.String())
+}
+
+func TestDerive_WideIndex(t *testing.T) {
+	d := &fakeDeriver{deriveAddr: "j1abc"}
+
+	for _, index := range []string{"4294967296", "0x100000000"} {
+		var out, err bytes.Buffer
+		code := RunWithIO([]string{"derive", "--ufvk", "jview1test", "--index", index}, d, &out, &err)
+		if code != 0 {
+			t.Fatalf("--index %s: unexpected exit code: %d (stderr=%q)", index, code, err.String())
+		}
+		if n, _ := d.deriveIndex.Uint64(); n != 1<<32 {
+			t.Fatalf("--index %s: unexpected index: %s", index, d.deriveIndex)
+		}
+	}
+
+	// index is always a JSON number and index_str the same index as a
+	// string, which float64 decoders keep exact.
+	for index, want := range map[string]string{
+		"9007199254740991":         `"index":9007199254740991,"index_str":"9007199254740991",`,
+		"9007199254740992":         `"index":9007199254740992,"index_str":"9007199254740992",`,
+		"0xffffffffffffffffffffff": `"index":309485009821345068724781055,"index_str":"309485009821345068724781055",`,
+	} {
+		var out, err bytes.Buffer
+		code := RunWithIO([]string{"derive", "--ufvk", "jview1test", "--index", index, "--json"}, d, &out, &err)
+		if code != 0 || !strings.Contains(out.String(), want) {
+			t.Fatalf("--index %s: code=%d stdout=%q", index, code, out.String())
+		}
 	}
 }
 
@@ -108,7 +284,7 @@ func TestDerive_JSON_ErrorIncludesMessage(t *testing.T) {
 	d := &fakeDeriver{deriveAddr: "j1abc"}
 	var out, err bytes.Buffer
 
-	code := RunWithIO([]string{"derive", "--ufvk", "jview1test", "--index", "4294967296", "--json"}, d, &out, &err)
+	code := RunWithIO([]string{"derive", "--ufvk", "jview1test", "--index", "309485009821345068724781056", "--json"}, d, &out, &err)
 	if code != 1 {
 		t.Fatalf("unexpected exit code: %d", code)
 	}
@@ -139,49 +315,1268 @@ func TestBatch_Plain(t *testing.T) {
 	if got := out.String(); got != "j1a\nj1b\n" {
 		t.Fatalf("unexpected stdout: %q", got)
 	}
-	if d.batchUFVK != "jview1test" || d.batchStart != 10 || d.batchCount != 2 {
-		t.Fatalf("unexpected batch call: ufvk=%q start=%d count=%d", d.batchUFVK, d.batchStart, d.batchCount)
+	if d.batchUFVK != "jview1test" || d.batchStart != diversifier.FromUint32(10) || d.batchCount != 2 || d.batchJobs != 1 {
+		t.Fatalf("unexpected batch call: ufvk=%q start=%s count=%d jobs=%d", d.batchUFVK, d.batchStart, d.batchCount, d.batchJobs)
 	}
 }
 
-func TestUFVKEnv(t *testing.T) {
-	t.Setenv("JUNO_TEST_UFVK", "jview1fromenv")
+func TestBatch_JSON(t *testing.T) {
+	d := &fakeDeriver{batchAddrs: []string{"j1a", "j1b"}, keyID: "e37f"}
+	var out, err bytes.Buffer
 
-	d := &fakeDeriver{deriveAddr: "j1abc"}
+	code := RunWithIO([]string{"batch", "--ufvk", "jview1test", "--start", "10", "--count", "2", "--json"}, d, &out, &err)
+	if code != 0 {
+		t.Fatalf("unexpected exit code: %d (stderr=%q)", code, err.String())
+	}
+
+	var v struct {
+		Version   string   `json:"version"`
+		Status    string   `json:"status"`
+		KeyID     string   `json:"key_id"`
+		Start     uint32   `json:"start"`
+		Count     uint64   `json:"count"`
+		Addresses []string `json:"addresses"`
+	}
+	if e := json.Unmarshal(out.Bytes(), &v); e != nil {
+		t.Fatalf("invalid json: %v (%q)", e, out.String())
+	}
+	if v.Version != "v1" || v.Status != "ok" || v.KeyID != "e37f" || v.Start != 10 || v.Count != 2 {
+		t.Fatalf("unexpected json: %+v", v)
+	}
+	if len(v.Addresses) != 2 || v.Addresses[0] != "j1a" || v.Addresses[1] != "j1b" {
+		t.Fatalf("unexpected addresses: %v", v.Addresses)
+	}
+}
+
+func TestBatch_JSON_ErrorAfterPartialOutput(t *testing.T) {
+	d := &fakeDeriver{batchAddrs: []string{"j1a"}, batchErr: codedErr("internal")}
 	var out, err bytes.Buffer
 
-	code := RunWithIO([]string{"derive", "--ufvk-env", "JUNO_TEST_UFVK", "--index", "0"}, d, &out, &err)
+	code := RunWithIO([]string{"batch", "--ufvk", "jview1test", "--start", "0", "--count", "2", "--json"}, d, &out, &err)
+	if code != 1 {
+		t.Fatalf("unexpected exit code: %d", code)
+	}
+
+	var v map[string]any
+	if e := json.Unmarshal(out.Bytes(), &v); e != nil {
+		t.Fatalf("invalid json: %v (%q)", e, out.String())
+	}
+	if v["version"] != "v1" || v["status"] != "err" || v["error"] != "internal" {
+		t.Fatalf("unexpected json: %v", v)
+	}
+}
+
+func TestBatch_JSON_ErrorBeforeOutput(t *testing.T) {
+	d := &fakeDeriver{batchErr: codedErr("ufvk_invalid_bech32m")}
+	var out, err bytes.Buffer
+
+	code := RunWithIO([]string{"batch", "--ufvk", "bad", "--start", "0", "--count", "2", "--json"}, d, &out, &err)
+	if code != 1 {
+		t.Fatalf("unexpected exit code: %d", code)
+	}
+
+	var v map[string]any
+	if e := json.Unmarshal(out.Bytes(), &v); e != nil {
+		t.Fatalf("invalid json: %v (%q)", e, out.String())
+	}
+	if v["status"] != "err" || v["error"] != "ufvk_invalid_bech32m" {
+		t.Fatalf("unexpected json: %v", v)
+	}
+	if _, ok := v["addresses"]; ok {
+		t.Fatalf("unexpected addresses in error response: %v", v)
+	}
+}
+
+func TestBatch_JSON_Interrupted(t *testing.T) {
+	d := &fakeDeriver{batchAddrs: []string{"j1a", "j1b"}}
+	var out, err bytes.Buffer
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	code := RunContext(ctx, []string{"batch", "--ufvk", "jview1test", "--start", "0", "--count", "2", "--json"}, d, &out, &err)
+	if code != 1 {
+		t.Fatalf("unexpected exit code: %d", code)
+	}
+
+	var v struct {
+		Status    string   `json:"status"`
+		Error     string   `json:"error"`
+		Addresses []string `json:"addresses"`
+	}
+	if e := json.Unmarshal(out.Bytes(), &v); e != nil {
+		t.Fatalf("invalid json: %v (%q)", e, out.String())
+	}
+	if v.Status != "err" || v.Error != "interrupted" {
+		t.Fatalf("unexpected json: %+v", v)
+	}
+	if len(v.Addresses) != 1 || v.Addresses[0] != "j1a" {
+		t.Fatalf("unexpected addresses: %v", v.Addresses)
+	}
+}
+
+func TestBatch_CountRange(t *testing.T) {
+	d := &fakeDeriver{batchAddrs: []string{"j1a"}}
+	var out, err bytes.Buffer
+
+	code := RunWithIO([]string{"batch", "--ufvk", "jview1test", "--start", "0", "--count", "4294967297"}, d, &out, &err)
 	if code != 0 {
 		t.Fatalf("unexpected exit code: %d (stderr=%q)", code, err.String())
 	}
-	if d.deriveUFVK != "jview1fromenv" {
-		t.Fatalf("unexpected ufvk: %q", d.deriveUFVK)
+	if d.batchCount != 1<<32+1 {
+		t.Fatalf("unexpected count: %d", d.batchCount)
+	}
+
+	// The last two indices of the 88-bit space.
+	out.Reset()
+	err.Reset()
+	code = RunWithIO([]string{"batch", "--ufvk", "jview1test", "--start", "0xfffffffffffffffffffffe", "--count", "2", "--json"}, d, &out, &err)
+	if code != 0 {
+		t.Fatalf("unexpected exit code: %d (stderr=%q)", code, err.String())
+	}
+	if !strings.HasPrefix(out.String(), `{"version":"v1","start":309485009821345068724781054,"start_str":"309485009821345068724781054","count":2,`) {
+		t.Fatalf("unexpected stdout: %q", out.String())
+	}
+
+	for _, tc := range []struct{ start, count, want string }{
+		{"0", "0", "count_invalid"},
+		{"0xfffffffffffffffffffffe", "3", "range_overflow"},
+		{"309485009821345068724781055", "18446744073709551615", "range_overflow"},
+		{"0x1000000000000000000000000", "1", "index_invalid"},
+	} {
+		out.Reset()
+		err.Reset()
+		code = RunWithIO([]string{"batch", "--ufvk", "jview1test", "--start", tc.start, "--count", tc.count}, d, &out, &err)
+		if code != 1 || !strings.Contains(err.String(), tc.want) {
+			t.Fatalf("start=%s count=%s: unexpected result: code=%d stderr=%q", tc.start, tc.count, code, err.String())
+		}
 	}
 }
 
-func TestUFVKSourceConflict(t *testing.T) {
-	d := &fakeDeriver{deriveAddr: "j1abc"}
+func TestBatch_Progress(t *testing.T) {
+	d := &fakeDeriver{batchAddrs: []string{"j1a", "j1b"}}
 	var out, err bytes.Buffer
 
-	code := RunWithIO([]string{"derive", "--ufvk", "jview1a", "--ufvk-env", "JUNO_TEST_UFVK", "--index", "0"}, d, &out, &err)
-	if code != 2 {
+	code := RunWithIO([]string{"batch", "--ufvk", "jview1test", "--start", "0", "--count", "2", "--progress"}, d, &out, &err)
+	if code != 0 {
+		t.Fatalf("unexpected exit code: %d (stderr=%q)", code, err.String())
+	}
+	if got := out.String(); got != "j1a\nj1b\n" {
+		t.Fatalf("unexpected stdout: %q", got)
+	}
+	if !strings.Contains(err.String(), "2/2") {
+		t.Fatalf("expected progress on stderr, got: %q", err.String())
+	}
+}
+
+func TestBatch_Jobs(t *testing.T) {
+	d := &fakeDeriver{batchAddrs: []string{"j1a"}}
+	var out, err bytes.Buffer
+
+	code := RunWithIO([]string{"batch", "--ufvk", "jview1test", "--start", "0", "--count", "1", "--jobs", "0"}, d, &out, &err)
+	if code != 0 {
+		t.Fatalf("unexpected exit code: %d (stderr=%q)", code, err.String())
+	}
+	if d.batchJobs != 0 {
+		t.Fatalf("unexpected jobs: %d", d.batchJobs)
+	}
+
+	out.Reset()
+	err.Reset()
+	code = RunWithIO([]string{"batch", "--ufvk", "jview1test", "--start", "0", "--count", "1", "--jobs", "-1"}, d, &out, &err)
+	if code != 1 || !strings.Contains(err.String(), "jobs_invalid") {
+		t.Fatalf("unexpected result: code=%d stderr=%q", code, err.String())
+	}
+}
+
+func TestNetworkAssertion(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		network    string
+		networkErr error
+		wantCode   int
+		wantErr    string
+	}{
+		{name: "derive_match", args: []string{"derive", "--index", "0"}, network: "mainnet", wantCode: 0},
+		{name: "derive_mismatch", args: []string{"derive", "--index", "0"}, network: "testnet", wantCode: 1, wantErr: "network_mismatch"},
+		{name: "batch_match", args: []string{"batch", "--start", "0", "--count", "1"}, network: "mainnet", wantCode: 0},
+		{name: "batch_mismatch", args: []string{"batch", "--start", "0", "--count", "1"}, network: "regtest", wantCode: 1, wantErr: "network_mismatch"},
+		{name: "detect_error", args: []string{"derive", "--index", "0"}, networkErr: codedErr("network_unknown"), wantCode: 1, wantErr: "network_unknown"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &fakeDeriver{network: tc.network, networkErr: tc.networkErr, deriveAddr: "j1a", batchAddrs: []string{"j1a"}}
+			var out, err bytes.Buffer
+
+			args := append(tc.args, "--ufvk", "jview1test", "--network", "mainnet", "--json")
+			code := RunWithIO(args, d, &out, &err)
+			if code != tc.wantCode {
+				t.Fatalf("unexpected exit code: %d (%q)", code, out.String())
+			}
+			if tc.wantErr == "" {
+				return
+			}
+
+			var v map[string]any
+			if e := json.Unmarshal(out.Bytes(), &v); e != nil {
+				t.Fatalf("invalid json: %v (%q)", e, out.String())
+			}
+			if v["status"] != "err" || v["error"] != tc.wantErr {
+				t.Fatalf("unexpected json: %v", v)
+			}
+		})
+	}
+}
+
+func TestNetworkAssertion_InvalidValue(t *testing.T) {
+	d := &fakeDeriver{network: "mainnet"}
+	var out, err bytes.Buffer
+
+	code := RunWithIO([]string{"derive", "--ufvk", "jview1test", "--index", "0", "--network", "signet"}, d, &out, &err)
+	if code != 1 {
 		t.Fatalf("unexpected exit code: %d", code)
 	}
-	if err.Len() == 0 {
-		t.Fatalf("expected stderr")
+	if !strings.HasPrefix(err.String(), "network_invalid") {
+		t.Fatalf("unexpected stderr: %q", err.String())
+	}
+	if d.deriveUFVK != "" {
+		t.Fatalf("derive should not run")
 	}
-	_ = out
 }
 
-func TestHelp(t *testing.T) {
-	d := &fakeDeriver{deriveAddr: "j1abc"}
+func TestScope(t *testing.T) {
+	d := &fakeDeriver{deriveAddr: "j1a", batchAddrs: []string{"j1a"}}
 	var out, err bytes.Buffer
 
-	code := RunWithIO([]string{"--help"}, d, &out, &err)
+	if code := RunWithIO([]string{"derive", "--ufvk", "jview1test", "--index", "0"}, d, &out, &err); code != 0 {
+		t.Fatalf("unexpected exit code: %d", code)
+	}
+	if d.deriveScope != "external" {
+		t.Fatalf("unexpected default scope: %q", d.deriveScope)
+	}
+
+	if code := RunWithIO([]string{"derive", "--ufvk", "jview1test", "--index", "0", "--scope", "internal"}, d, &out, &err); code != 0 {
+		t.Fatalf("unexpected exit code: %d", code)
+	}
+	if d.deriveScope != "internal" {
+		t.Fatalf("unexpected scope: %q", d.deriveScope)
+	}
+
+	if code := RunWithIO([]string{"batch", "--ufvk", "jview1test", "--start", "0", "--count", "1", "--scope", "INTERNAL"}, d, &out, &err); code != 0 {
+		t.Fatalf("unexpected exit code: %d", code)
+	}
+	if d.batchScope != "internal" {
+		t.Fatalf("unexpected batch scope: %q", d.batchScope)
+	}
+
+	out.Reset()
+	code := RunWithIO([]string{"batch", "--ufvk", "jview1test", "--start", "0", "--count", "1", "--scope", "change", "--json"}, d, &out, &err)
+	if code != 1 {
+		t.Fatalf("unexpected exit code: %d", code)
+	}
+	var v map[string]any
+	if e := json.Unmarshal(out.Bytes(), &v); e != nil {
+		t.Fatalf("invalid json: %v (%q)", e, out.String())
+	}
+	if v["status"] != "err" || v["error"] != "scope_invalid" {
+		t.Fatalf("unexpected json: %v", v)
+	}
+}
+
+func TestOwns(t *testing.T) {
+	wide, _ := diversifier.Parse("4294967296")
+	d := &fakeDeriver{ownsIndex: wide, ownsScope: "internal"}
+	var out, err bytes.Buffer
+
+	code := RunWithIO([]string{"owns", "--ufvk", "jview1test", "--address", " j1abc "}, d, &out, &err)
+	if code != 0 {
+		t.Fatalf("unexpected exit code: %d (stderr=%q)", code, err.String())
+	}
+	if got := out.String(); got != "index=4294967296 scope=internal\n" {
+		t.Fatalf("unexpected stdout: %q", got)
+	}
+	if d.ownsAddress != "j1abc" {
+		t.Fatalf("unexpected address: %q", d.ownsAddress)
+	}
+
+	out.Reset()
+	code = RunWithIO([]string{"owns", "--ufvk", "jview1test", "--address", "j1abc", "--json"}, d, &out, &err)
 	if code != 0 {
 		t.Fatalf("unexpected exit code: %d", code)
 	}
-	if !strings.Contains(out.String(), "Usage:") {
-		t.Fatalf("expected usage, got: %q", out.String())
+	var v struct {
+		Status string      `json:"status"`
+		Index  json.Number `json:"index"`
+		Scope  string      `json:"scope"`
+	}
+	if e := json.Unmarshal(out.Bytes(), &v); e != nil {
+		t.Fatalf("invalid json: %v (%q)", e, out.String())
+	}
+	if v.Status != "ok" || v.Index != "4294967296" || v.Scope != "internal" {
+		t.Fatalf("unexpected json: %+v", v)
+	}
+}
+
+func TestOwns_NotOwned(t *testing.T) {
+	d := &fakeDeriver{ownsErr: codedErr("address_not_owned")}
+	var out, err bytes.Buffer
+
+	code := RunWithIO([]string{"owns", "--ufvk", "jview1test", "--address", "j1abc", "--json"}, d, &out, &err)
+	if code != 1 {
+		t.Fatalf("unexpected exit code: %d", code)
+	}
+	var v map[string]any
+	if e := json.Unmarshal(out.Bytes(), &v); e != nil {
+		t.Fatalf("invalid json: %v (%q)", e, out.String())
+	}
+	if v["status"] != "err" || v["error"] != "address_not_owned" {
+		t.Fatalf("unexpected json: %v", v)
+	}
+
+	out.Reset()
+	code = RunWithIO([]string{"owns", "--ufvk", "jview1test"}, d, &out, &err)
+	if code != 2 || !strings.Contains(err.String(), "address is required") {
+		t.Fatalf("unexpected result: code=%d stderr=%q", code, err.String())
+	}
+}
+
+func writeKeyRing(t *testing.T, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("write keyring: %v", err)
+	}
+	return path
+}
+
+func TestWhois(t *testing.T) {
+	d := &fakeDeriver{whoisLabel: "treasury", ownsIndex: diversifier.FromUint32(7), ownsScope: "external"}
+	path := writeKeyRing(t, `{"treasury": " jview1b ", "retail": "jview1a"}`)
+	var out, err bytes.Buffer
+
+	code := RunWithIO([]string{"whois", "--keyring", path, "--address", "j1abc"}, d, &out, &err)
+	if code != 0 {
+		t.Fatalf("unexpected exit code: %d (stderr=%q)", code, err.String())
+	}
+	if got := out.String(); got != "label=treasury index=7 scope=external\n" {
+		t.Fatalf("unexpected stdout: %q", got)
+	}
+	want := []KeyEntry{{Label: "retail", UFVK: "jview1a"}, {Label: "treasury", UFVK: "jview1b"}}
+	if len(d.whoisKeys) != 2 || d.whoisKeys[0] != want[0] || d.whoisKeys[1] != want[1] {
+		t.Fatalf("unexpected keys: %+v", d.whoisKeys)
+	}
+
+	out.Reset()
+	code = RunWithIO([]string{"whois", "--keyring", path, "--address", "j1abc", "--json"}, d, &out, &err)
+	if code != 0 {
+		t.Fatalf("unexpected exit code: %d", code)
+	}
+	var v map[string]any
+	if e := json.Unmarshal(out.Bytes(), &v); e != nil {
+		t.Fatalf("invalid json: %v (%q)", e, out.String())
+	}
+	if v["status"] != "ok" || v["label"] != "treasury" || v["index"] != float64(7) || v["scope"] != "external" {
+		t.Fatalf("unexpected json: %v", v)
+	}
+}
+
+func TestWhois_Errors(t *testing.T) {
+	d := &fakeDeriver{whoisErr: fmt.Errorf("keyring entry %q: %w", "retail", codedErr("ufvk_invalid_bech32m"))}
+	path := writeKeyRing(t, `{"retail": "jview1a"}`)
+	var out, err bytes.Buffer
+
+	code := RunWithIO([]string{"whois", "--keyring", path, "--address", "j1abc", "--json"}, d, &out, &err)
+	if code != 1 {
+		t.Fatalf("unexpected exit code: %d", code)
+	}
+	var v map[string]any
+	if e := json.Unmarshal(out.Bytes(), &v); e != nil {
+		t.Fatalf("invalid json: %v (%q)", e, out.String())
+	}
+	if v["error"] != "ufvk_invalid_bech32m" || !strings.Contains(v["message"].(string), "retail") {
+		t.Fatalf("unexpected json: %v", v)
+	}
+
+	// The deriver rejects two labels holding the same key.
+	out.Reset()
+	d.whoisErr = fmt.Errorf("keyring entry %q: %w", "retail-copy", codedErr("keyring_key_duplicate"))
+	path = writeKeyRing(t, `{"retail": "jview1a", "retail-copy": "jview1a"}`)
+	code = RunWithIO([]string{"whois", "--keyring", path, "--address", "j1abc", "--json"}, d, &out, &err)
+	if code != 1 || !strings.Contains(out.String(), `"error":"keyring_key_duplicate"`) {
+		t.Fatalf("unexpected result: code=%d stdout=%q", code, out.String())
+	}
+	if len(d.whoisKeys) != 2 || d.whoisKeys[0].Label != "retail" || d.whoisKeys[1].Label != "retail-copy" {
+		t.Fatalf("unexpected keys: %+v", d.whoisKeys)
+	}
+
+	for contents, want := range map[string]string{
+		`{}`:                  "has no keys",
+		`["jview1a"]`:         "expected",
+		`not json`:            "expected",
+		`{"a": "jview1a"} {}`: "expected",
+		`{"a": 1}`:            "expected",
+		`{"retail": "jview1a", "retail": "jview1b"}`: `repeats label "retail"`,
+	} {
+		err.Reset()
+		d.whoisKeys = nil
+		path := writeKeyRing(t, contents)
+		code := RunWithIO([]string{"whois", "--keyring", path, "--address", "j1abc"}, d, &out, &err)
+		if code != 2 || !strings.Contains(err.String(), "keyring") || !strings.Contains(err.String(), want) {
+			t.Fatalf("%s: unexpected result: code=%d stderr=%q", contents, code, err.String())
+		}
+		if d.whoisKeys != nil {
+			t.Fatalf("%s: deriver called", contents)
+		}
+	}
+}
+
+func validateDeriver() *fakeDeriver {
+	return &fakeDeriver{parsed: map[string]fakeAddress{
+		"j1main":  {network: "mainnet", receivers: []string{"orchard"}},
+		"jtest1a": {network: "testnet", receivers: []string{"p2pkh", "orchard", "0x42"}},
+		"t1main":  {network: "mainnet", receivers: []string{"p2pkh"}},
+		"tmtest":  {network: "testnet", receivers: []string{"p2pkh"}},
+	}}
+}
+
+func decodeLines(t *testing.T, out *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var lines []map[string]any
+	dec := json.NewDecoder(out)
+	for dec.More() {
+		var v map[string]any
+		if e := dec.Decode(&v); e != nil {
+			t.Fatalf("invalid json: %v", e)
+		}
+		lines = append(lines, v)
+	}
+	return lines
+}
+
+func TestValidate_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "addresses.txt")
+	if e := os.WriteFile(path, []byte("j1main\n\n  jtest1a  \nj1typo\n"), 0o600); e != nil {
+		t.Fatalf("write addresses: %v", e)
+	}
+	var out, err bytes.Buffer
+
+	code := RunWithIO([]string{"validate", "--file", path}, validateDeriver(), &out, &err)
+	if code != 1 {
+		t.Fatalf("unexpected exit code: %d (stderr=%q)", code, err.String())
+	}
+	lines := decodeLines(t, &out)
+	if len(lines) != 3 {
+		t.Fatalf("unexpected output: %v", lines)
+	}
+	if v := lines[0]; v["status"] != "ok" || v["line"] != float64(1) || v["network"] != "mainnet" {
+		t.Fatalf("unexpected line 1: %v", v)
+	}
+	if v := lines[1]; v["status"] != "ok" || v["line"] != float64(3) || v["address"] != "jtest1a" || fmt.Sprint(v["receivers"]) != "[p2pkh orchard 0x42]" {
+		t.Fatalf("unexpected line 3: %v", v)
+	}
+	if v := lines[2]; v["status"] != "err" || v["line"] != float64(4) || v["error"] != "address_invalid_bech32m" {
+		t.Fatalf("unexpected line 4: %v", v)
+	}
+}
+
+func TestValidate_LongLine(t *testing.T) {
+	var out, err bytes.Buffer
+
+	stdin = strings.NewReader("j1main\n" + strings.Repeat("j", 3*maxAddressLine) + "\njtest1a\r\n")
+	t.Cleanup(func() { stdin = os.Stdin })
+
+	code := RunWithIO([]string{"validate"}, validateDeriver(), &out, &err)
+	if code != 1 || err.Len() != 0 {
+		t.Fatalf("unexpected result: code=%d stderr=%q", code, err.String())
+	}
+	lines := decodeLines(t, &out)
+	if len(lines) != 3 || lines[0]["status"] != "ok" {
+		t.Fatalf("unexpected output: %v", lines)
+	}
+	if v := lines[1]; v["status"] != "err" || v["line"] != float64(2) || v["error"] != "address_invalid_bech32m" || v["address"] != nil {
+		t.Fatalf("unexpected line 2: %v", v)
+	}
+	if v := lines[2]; v["status"] != "ok" || v["line"] != float64(3) || v["address"] != "jtest1a" {
+		t.Fatalf("unexpected line 3: %v", v)
+	}
+}
+
+func TestValidate_ExpectNetwork(t *testing.T) {
+	var out, err bytes.Buffer
+
+	stdin = strings.NewReader("j1main\njtest1a\n")
+	t.Cleanup(func() { stdin = os.Stdin })
+
+	code := RunWithIO([]string{"validate", "--expect-network", "Mainnet"}, validateDeriver(), &out, &err)
+	if code != 1 {
+		t.Fatalf("unexpected exit code: %d (stderr=%q)", code, err.String())
+	}
+	lines := decodeLines(t, &out)
+	if len(lines) != 2 || lines[0]["status"] != "ok" {
+		t.Fatalf("unexpected output: %v", lines)
+	}
+	if v := lines[1]; v["status"] != "err" || v["error"] != "network_mismatch" || v["network"] != "testnet" {
+		t.Fatalf("unexpected line 2: %v", v)
+	}
+
+	out.Reset()
+	code = RunWithIO([]string{"validate", "--address", "j1main", "--expect-network", "mainnet"}, validateDeriver(), &out, &err)
+	if code != 0 {
+		t.Fatalf("unexpected exit code: %d (stderr=%q)", code, err.String())
+	}
+	if lines := decodeLines(t, &out); len(lines) != 1 || lines[0]["status"] != "ok" {
+		t.Fatalf("unexpected output: %v", lines)
+	}
+
+	out.Reset()
+	code = RunWithIO([]string{"validate", "--address", "j1main", "--expect-network", "zcash"}, validateDeriver(), &out, &err)
+	if lines := decodeLines(t, &out); code != 1 || len(lines) != 1 || lines[0]["error"] != "network_invalid" {
+		t.Fatalf("unexpected result: code=%d out=%v", code, lines)
+	}
+}
+
+func TestValidate_Transparent(t *testing.T) {
+	var out, err bytes.Buffer
+
+	stdin = strings.NewReader("t1main\ntmtest\nt1typo\n")
+	t.Cleanup(func() { stdin = os.Stdin })
+
+	code := RunWithIO([]string{"validate", "--expect-network", "regtest"}, validateDeriver(), &out, &err)
+	if code != 1 {
+		t.Fatalf("unexpected exit code: %d (stderr=%q)", code, err.String())
+	}
+	lines := decodeLines(t, &out)
+	if len(lines) != 3 {
+		t.Fatalf("unexpected output: %v", lines)
+	}
+	if v := lines[0]; v["status"] != "err" || v["error"] != "network_mismatch" || v["type"] != "transparent" || v["network"] != "mainnet" {
+		t.Fatalf("unexpected line 1: %v", v)
+	}
+	// Regtest shares testnet's transparent prefix.
+	if v := lines[1]; v["status"] != "ok" || v["type"] != "transparent" || v["network"] != "testnet" || fmt.Sprint(v["receivers"]) != "[p2pkh]" {
+		t.Fatalf("unexpected line 2: %v", v)
+	}
+	if v := lines[2]; v["status"] != "err" || v["error"] != "address_transparent_invalid" {
+		t.Fatalf("unexpected line 3: %v", v)
+	}
+
+	out.Reset()
+	code = RunWithIO([]string{"validate", "--address", "j1main"}, validateDeriver(), &out, &err)
+	if lines := decodeLines(t, &out); code != 0 || len(lines) != 1 || lines[0]["type"] != "unified" {
+		t.Fatalf("unexpected result: code=%d out=%v", code, lines)
+	}
+}
+
+func TestValidate_InputErrors(t *testing.T) {
+	var out, err bytes.Buffer
+
+	code := RunWithIO([]string{"validate", "--address", "j1main", "--file", "addresses.txt"}, validateDeriver(), &out, &err)
+	if code != 2 || !strings.Contains(err.String(), "conflict") {
+		t.Fatalf("unexpected result: code=%d stderr=%q", code, err.String())
+	}
+
+	stdin = strings.NewReader("\n  \n")
+	t.Cleanup(func() { stdin = os.Stdin })
+
+	err.Reset()
+	code = RunWithIO([]string{"validate"}, validateDeriver(), &out, &err)
+	if code != 2 || !strings.Contains(err.String(), "no addresses") {
+		t.Fatalf("unexpected result: code=%d stderr=%q", code, err.String())
+	}
+	if out.Len() != 0 {
+		t.Fatalf("unexpected stdout: %q", out.String())
+	}
+}
+
+func TestInspect(t *testing.T) {
+	d := &fakeDeriver{inspect: UFVKInfo{
+		Network:     "testnet",
+		Items:       []UFVKItem{{Typecode: 0x03, Name: "orchard", Len: 96}, {Typecode: 0xdead, Name: "unknown", Len: 3}},
+		Fingerprint: "e37f",
+		Address:     "jtest1abc",
+	}}
+	path := filepath.Join(t.TempDir(), "ufvk.txt")
+	if e := os.WriteFile(path, []byte("jviewtest1secret\n"), 0o600); e != nil {
+		t.Fatalf("write ufvk: %v", e)
+	}
+	var out, err bytes.Buffer
+
+	code := RunWithIO([]string{"inspect", "--ufvk-file", path}, d, &out, &err)
+	if code != 0 {
+		t.Fatalf("unexpected exit code: %d (stderr=%q)", code, err.String())
+	}
+	want := "network=testnet\n" +
+		"item typecode=0x03 name=orchard len=96\n" +
+		"item typecode=0xdead name=unknown len=3\n" +
+		"fvk=ok\n" +
+		"fingerprint=e37f\n" +
+		"address=jtest1abc index=0\n"
+	if got := out.String(); got != want {
+		t.Fatalf("unexpected stdout: %q", got)
+	}
+	if d.inspectUFVK != "jviewtest1secret" {
+		t.Fatalf("unexpected ufvk: %q", d.inspectUFVK)
+	}
+
+	out.Reset()
+	code = RunWithIO([]string{"inspect", "--ufvk-file", path, "--json"}, d, &out, &err)
+	if code != 0 {
+		t.Fatalf("unexpected exit code: %d", code)
+	}
+	if strings.Contains(out.String(), "secret") {
+		t.Fatalf("output contains the ufvk: %q", out.String())
+	}
+	var v struct {
+		Status      string `json:"status"`
+		Network     string `json:"network"`
+		FVKValid    bool   `json:"fvk_valid"`
+		Fingerprint string `json:"fingerprint"`
+		Address     string `json:"address"`
+		Items       []struct {
+			Typecode uint64 `json:"typecode"`
+			Name     string `json:"name"`
+			Len      int    `json:"len"`
+		} `json:"items"`
+	}
+	if e := json.Unmarshal(out.Bytes(), &v); e != nil {
+		t.Fatalf("invalid json: %v (%q)", e, out.String())
+	}
+	if v.Status != "ok" || v.Network != "testnet" || !v.FVKValid || v.Fingerprint != "e37f" || v.Address != "jtest1abc" {
+		t.Fatalf("unexpected json: %+v", v)
+	}
+	if len(v.Items) != 2 || v.Items[1].Typecode != 0xdead || v.Items[1].Name != "unknown" || v.Items[1].Len != 3 {
+		t.Fatalf("unexpected items: %+v", v.Items)
+	}
+}
+
+func TestInspect_UnusableFVK(t *testing.T) {
+	d := &fakeDeriver{inspect: UFVKInfo{
+		Network:  "mainnet",
+		Items:    []UFVKItem{{Typecode: 0x03, Name: "orchard", Len: 95}},
+		FVKError: "ufvk_value_len_invalid",
+	}}
+	var out, err bytes.Buffer
+
+	code := RunWithIO([]string{"inspect", "--ufvk", "jview1test"}, d, &out, &err)
+	if code != 0 {
+		t.Fatalf("unexpected exit code: %d (stderr=%q)", code, err.String())
+	}
+	if got := out.String(); !strings.HasSuffix(got, "fvk=ufvk_value_len_invalid\n") || strings.Contains(got, "address=") {
+		t.Fatalf("unexpected stdout: %q", got)
+	}
+
+	out.Reset()
+	code = RunWithIO([]string{"inspect", "--ufvk", "jview1test", "--json"}, d, &out, &err)
+	var v map[string]any
+	if e := json.Unmarshal(out.Bytes(), &v); e != nil || code != 0 {
+		t.Fatalf("unexpected result: code=%d err=%v (%q)", code, e, out.String())
+	}
+	if v["fvk_valid"] != false || v["fvk_error"] != "ufvk_value_len_invalid" || v["address"] != nil {
+		t.Fatalf("unexpected json: %v", v)
+	}
+
+	d.inspectErr = codedErr("ufvk_invalid_bech32m")
+	out.Reset()
+	code = RunWithIO([]string{"inspect", "--ufvk", "jview1test", "--json"}, d, &out, &err)
+	if code != 1 || !strings.Contains(out.String(), "ufvk_invalid_bech32m") {
+		t.Fatalf("unexpected result: code=%d out=%q", code, out.String())
+	}
+}
+
+func TestExportUIVK(t *testing.T) {
+	d := &fakeDeriver{uivk: "jivktest1abc", network: "testnet", keyID: "d61b"}
+	path := filepath.Join(t.TempDir(), "ufvk.txt")
+	if e := os.WriteFile(path, []byte("jviewtest1secret\n"), 0o600); e != nil {
+		t.Fatalf("write ufvk: %v", e)
+	}
+	var out, err bytes.Buffer
+
+	code := RunWithIO([]string{"export-uivk", "--ufvk-file", path}, d, &out, &err)
+	if code != 0 {
+		t.Fatalf("unexpected exit code: %d (stderr=%q)", code, err.String())
+	}
+	if got := out.String(); got != "jivktest1abc\n" {
+		t.Fatalf("unexpected stdout: %q", got)
+	}
+	if d.exportUFVK != "jviewtest1secret" {
+		t.Fatalf("unexpected ufvk: %q", d.exportUFVK)
+	}
+
+	out.Reset()
+	code = RunWithIO([]string{"export-uivk", "--ufvk-file", path, "--json"}, d, &out, &err)
+	var v map[string]any
+	if e := json.Unmarshal(out.Bytes(), &v); e != nil || code != 0 {
+		t.Fatalf("unexpected result: code=%d err=%v (%q)", code, e, out.String())
+	}
+	if v["status"] != "ok" || v["uivk"] != "jivktest1abc" || v["network"] != "testnet" || v["key_id"] != "d61b" {
+		t.Fatalf("unexpected json: %v", v)
+	}
+
+	d.uivkErr = codedErr("ufvk_hrp_mismatch")
+	out.Reset()
+	code = RunWithIO([]string{"export-uivk", "--ufvk", "j1notakey", "--json"}, d, &out, &err)
+	if code != 1 || !strings.Contains(out.String(), "ufvk_hrp_mismatch") {
+		t.Fatalf("unexpected result: code=%d out=%q", code, out.String())
+	}
+}
+
+func TestUIVKKeySource(t *testing.T) {
+	t.Setenv("JUNO_TEST_UIVK", "jivk1fromenv")
+
+	d := &fakeDeriver{deriveAddr: "j1abc", ownsScope: "external"}
+	var out, err bytes.Buffer
+
+	code := RunWithIO([]string{"derive", "--uivk-env", "JUNO_TEST_UIVK", "--index", "3"}, d, &out, &err)
+	if code != 0 {
+		t.Fatalf("unexpected exit code: %d (stderr=%q)", code, err.String())
+	}
+	if d.deriveUFVK != "jivk1fromenv" {
+		t.Fatalf("unexpected key: %q", d.deriveUFVK)
+	}
+
+	d.batchAddrs = []string{"j1a"}
+	code = RunWithIO([]string{"batch", "--uivk", "jivk1flag", "--start", "0", "--count", "1"}, d, &out, &err)
+	if code != 0 || d.batchUFVK != "jivk1flag" {
+		t.Fatalf("unexpected result: code=%d key=%q (stderr=%q)", code, d.batchUFVK, err.String())
+	}
+
+	code = RunWithIO([]string{"owns", "--uivk", "jivk1flag", "--address", "j1a"}, d, &out, &err)
+	if code != 0 || d.ownsAddress != "j1a" {
+		t.Fatalf("unexpected result: code=%d (stderr=%q)", code, err.String())
+	}
+
+	// A UIVK has no internal scope; the deriver's error is passed through.
+	d.deriveErr = codedErr("scope_unavailable")
+	out.Reset()
+	code = RunWithIO([]string{"derive", "--uivk", "jivk1flag", "--scope", "internal", "--json"}, d, &out, &err)
+	if code != 1 || !strings.Contains(out.String(), "scope_unavailable") {
+		t.Fatalf("unexpected result: code=%d out=%q", code, out.String())
+	}
+
+	err.Reset()
+	code = RunWithIO([]string{"derive", "--ufvk", "jview1a", "--uivk", "jivk1a"}, d, &out, &err)
+	if code != 2 || !strings.Contains(err.String(), "key source conflict") {
+		t.Fatalf("unexpected result: code=%d stderr=%q", code, err.String())
+	}
+
+	err.Reset()
+	code = RunWithIO([]string{"derive", "--uivk-file", filepath.Join(t.TempDir(), "missing")}, d, &out, &err)
+	if code != 2 || !strings.Contains(err.String(), "read uivk file (missing)") {
+		t.Fatalf("unexpected result: code=%d stderr=%q", code, err.String())
+	}
+}
+
+func TestUFVKEnv(t *testing.T) {
+	t.Setenv("JUNO_TEST_UFVK", "jview1fromenv")
+
+	d := &fakeDeriver{deriveAddr: "j1abc"}
+	var out, err bytes.Buffer
+
+	code := RunWithIO([]string{"derive", "--ufvk-env", "JUNO_TEST_UFVK", "--index", "0"}, d, &out, &err)
+	if code != 0 {
+		t.Fatalf("unexpected exit code: %d (stderr=%q)", code, err.String())
+	}
+	if d.deriveUFVK != "jview1fromenv" {
+		t.Fatalf("unexpected ufvk: %q", d.deriveUFVK)
+	}
+}
+
+func TestReceivers_Derive(t *testing.T) {
+	used := diversifier.FromUint32(7)
+	d := &fakeDeriver{deriveAddr: "j1both", deriveUsed: &used, keyID: "e37f"}
+	var out, err bytes.Buffer
+
+	code := RunWithIO([]string{"derive", "--ufvk", "jview1test", "--index", "5", "--receivers", "Orchard, sapling"}, d, &out, &err)
+	if code != 0 {
+		t.Fatalf("unexpected exit code: %d (stderr=%q)", code, err.String())
+	}
+	if !slices.Equal(d.receivers, []string{"orchard", "sapling"}) {
+		t.Fatalf("unexpected receivers: %q", d.receivers)
+	}
+	if out.String() != "address=j1both index=7\n" {
+		t.Fatalf("unexpected stdout: %q", out.String())
+	}
+
+	out.Reset()
+	code = RunWithIO([]string{"derive", "--ufvk", "jview1test", "--index", "5", "--receivers", "orchard,sapling", "--json"}, d, &out, &err)
+	if code != 0 {
+		t.Fatalf("unexpected exit code: %d (stderr=%q)", code, err.String())
+	}
+	var v map[string]any
+	if e := json.Unmarshal(out.Bytes(), &v); e != nil {
+		t.Fatalf("invalid json: %v (%q)", e, out.String())
+	}
+	if v["address"] != "j1both" || v["index"] != 7.0 {
+		t.Fatalf("unexpected json: %v", v)
+	}
+
+	for _, receivers := range []string{"sapling", "p2pkh,sapling", "orchard,p2sh", ""} {
+		d = &fakeDeriver{deriveAddr: "j1both"}
+		out.Reset()
+		code = RunWithIO([]string{"derive", "--ufvk", "jview1test", "--receivers", receivers, "--json"}, d, &out, &err)
+		if code != 1 || !strings.Contains(out.String(), `"error":"receivers_invalid"`) || d.deriveUFVK != "" {
+			t.Fatalf("%q: unexpected result: code=%d out=%q", receivers, code, out.String())
+		}
+	}
+}
+
+func TestReceivers_Batch(t *testing.T) {
+	d := &fakeDeriver{
+		batchAddrs:   []string{"j1a", "j1b"},
+		batchIndices: []diversifier.Index{diversifier.FromUint32(11), diversifier.FromUint32(14)},
+		keyID:        "e37f",
+	}
+	var out, err bytes.Buffer
+
+	code := RunWithIO([]string{"batch", "--ufvk", "jview1test", "--start", "10", "--count", "6", "--receivers", "orchard,sapling"}, d, &out, &err)
+	if code != 0 {
+		t.Fatalf("unexpected exit code: %d (stderr=%q)", code, err.String())
+	}
+	if out.String() != "address=j1a index=11\naddress=j1b index=14\n" {
+		t.Fatalf("unexpected stdout: %q", out.String())
+	}
+
+	out.Reset()
+	code = RunWithIO([]string{"batch", "--ufvk", "jview1test", "--start", "10", "--count", "6", "--receivers", "orchard,sapling", "--json"}, d, &out, &err)
+	if code != 0 {
+		t.Fatalf("unexpected exit code: %d (stderr=%q)", code, err.String())
+	}
+	want := `{"version":"v1","start":10,"start_str":"10","count":6,"key_id":"e37f","addresses":[{"address":"j1a","index":11,"index_str":"11"},{"address":"j1b","index":14,"index_str":"14"}],"status":"ok"}` + "\n"
+	if out.String() != want {
+		t.Fatalf("unexpected stdout:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	// Every index in the range may be skipped.
+	d = &fakeDeriver{keyID: "e37f"}
+	out.Reset()
+	code = RunWithIO([]string{"batch", "--ufvk", "jview1test", "--start", "3", "--count", "1", "--receivers", "orchard,sapling", "--json"}, d, &out, &err)
+	if code != 0 {
+		t.Fatalf("unexpected exit code: %d (stderr=%q)", code, err.String())
+	}
+	want = `{"version":"v1","start":3,"start_str":"3","count":1,"key_id":"e37f","addresses":[],"status":"ok"}` + "\n"
+	if out.String() != want {
+		t.Fatalf("unexpected stdout: %q", out.String())
+	}
+}
+
+func TestReceivers_Transparent(t *testing.T) {
+	d := &fakeDeriver{deriveAddr: "t1abc"}
+	var out, err bytes.Buffer
+
+	code := RunWithIO([]string{"derive", "--ufvk", "jview1test", "--index", "5", "--receivers", "P2PKH"}, d, &out, &err)
+	if code != 0 {
+		t.Fatalf("unexpected exit code: %d (stderr=%q)", code, err.String())
+	}
+	if !slices.Equal(d.receivers, []string{"p2pkh"}) || out.String() != "t1abc\n" {
+		t.Fatalf("unexpected result: receivers=%q stdout=%q", d.receivers, out.String())
+	}
+
+	out.Reset()
+	code = RunWithIO([]string{"derive", "--ufvk", "jview1test", "--receivers", "p2pkh, sapling ,orchard"}, d, &out, &err)
+	if code != 0 || !slices.Equal(d.receivers, []string{"orchard", "sapling", "p2pkh"}) {
+		t.Fatalf("unexpected result: code=%d receivers=%q", code, d.receivers)
+	}
+
+	d = &fakeDeriver{batchAddrs: []string{"t1a", "t1b"}}
+	out.Reset()
+	code = RunWithIO([]string{"batch", "--ufvk", "jview1test", "--start", "2147483646", "--count", "2", "--receivers", "p2pkh"}, d, &out, &err)
+	if code != 0 {
+		t.Fatalf("unexpected exit code: %d (stderr=%q)", code, err.String())
+	}
+	if out.String() != "t1a\nt1b\n" || !slices.Equal(d.receivers, []string{"p2pkh"}) {
+		t.Fatalf("unexpected result: receivers=%q stdout=%q", d.receivers, out.String())
+	}
+
+	// P2PKH receivers stop at BIP-44 address index 2^31 - 1.
+	for _, args := range [][]string{
+		{"--start", "2147483647", "--count", "2", "--receivers", "p2pkh"},
+		{"--start", "0x100000000", "--count", "1", "--receivers", "orchard,p2pkh"},
+	} {
+		d = &fakeDeriver{}
+		out.Reset()
+		code = RunWithIO(append([]string{"batch", "--ufvk", "jview1test", "--json"}, args...), d, &out, &err)
+		if code != 1 || !strings.Contains(out.String(), `"error":"range_overflow"`) || d.batchUFVK != "" {
+			t.Fatalf("%q: unexpected result: code=%d out=%q", args, code, out.String())
+		}
+	}
+}
+
+func TestUFVKSourceConflict(t *testing.T) {
+	d := &fakeDeriver{deriveAddr: "j1abc"}
+	var out, err bytes.Buffer
+
+	code := RunWithIO([]string{"derive", "--ufvk", "jview1a", "--ufvk-env", "JUNO_TEST_UFVK", "--index", "0"}, d, &out, &err)
+	if code != 2 {
+		t.Fatalf("unexpected exit code: %d", code)
+	}
+	if err.Len() == 0 {
+		t.Fatalf("expected stderr")
+	}
+	_ = out
+}
+
+func TestHelp(t *testing.T) {
+	d := &fakeDeriver{deriveAddr: "j1abc"}
+	var out, err bytes.Buffer
+
+	code := RunWithIO([]string{"--help"}, d, &out, &err)
+	if code != 0 {
+		t.Fatalf("unexpected exit code: %d", code)
+	}
+	if !strings.Contains(out.String(), "Usage:") {
+		t.Fatalf("expected usage, got: %q", out.String())
+	}
+}
+
+func TestKeygen_Seed(t *testing.T) {
+	// vectors/v1.json holds account 0 of the seed [7; 64].
+	b, e := os.ReadFile(filepath.Join("..", "..", "vectors", "v1.json"))
+	if e != nil {
+		t.Fatalf("read vectors: %v", e)
+	}
+	var v struct {
+		UFVK string `json:"ufvk"`
+	}
+	if e := json.Unmarshal(b, &v); e != nil {
+		t.Fatalf("parse vectors: %v", e)
+	}
+	t.Setenv("JUNO_TEST_SEED", strings.Repeat("07", 64))
+
+	d := &fakeDeriver{deriveAddr: "j1abc", keyID: "e37f"}
+	var out, err bytes.Buffer
+	code := RunWithIO([]string{"keygen", "--seed-env", "JUNO_TEST_SEED"}, d, &out, &err)
+	if code != 0 {
+		t.Fatalf("unexpected exit code: %d (stderr=%q)", code, err.String())
+	}
+	if got := out.String(); got != v.UFVK+"\n" {
+		t.Fatalf("unexpected stdout: %q", got)
+	}
+	if d.deriveUFVK != v.UFVK || d.deriveIndex != (diversifier.Index{}) || d.deriveScope != "external" {
+		t.Fatalf("unexpected derive call: %q %s %q", d.deriveUFVK, d.deriveIndex, d.deriveScope)
+	}
+
+	out.Reset()
+	code = RunWithIO([]string{"keygen", "--seed-env", "JUNO_TEST_SEED", "--account", "0-2", "--network", "regtest"}, d, &out, &err)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if code != 0 || len(lines) != 3 {
+		t.Fatalf("unexpected result: code=%d out=%q", code, out.String())
+	}
+	for i, line := range lines {
+		if !strings.HasPrefix(line, fmt.Sprintf("account=%d ufvk=jviewregtest1", i)) {
+			t.Fatalf("unexpected line: %q", line)
+		}
+	}
+}
+
+func TestKeygen_Mnemonic(t *testing.T) {
+	dir := t.TempDir()
+	mnemonic := filepath.Join(dir, "mnemonic.txt")
+	passphrase := filepath.Join(dir, "passphrase.txt")
+	if e := os.WriteFile(mnemonic, []byte("abandon abandon abandon abandon abandon abandon\nabandon abandon abandon abandon abandon about\n"), 0o600); e != nil {
+		t.Fatalf("write mnemonic: %v", e)
+	}
+	if e := os.WriteFile(passphrase, []byte("TREZOR\n"), 0o600); e != nil {
+		t.Fatalf("write passphrase: %v", e)
+	}
+
+	d := &fakeDeriver{deriveAddr: "j1abc", keyID: "00ff"}
+	var out, err bytes.Buffer
+	code := RunWithIO([]string{"keygen", "--mnemonic-file", mnemonic, "--passphrase-file", passphrase, "--account", "3-4", "--seed-fingerprint", "--json"}, d, &out, &err)
+	var v struct {
+		Status          string           `json:"status"`
+		Network         string           `json:"network"`
+		CoinType        int              `json:"coin_type"`
+		SeedFingerprint string           `json:"seed_fingerprint"`
+		Accounts        []map[string]any `json:"accounts"`
+	}
+	if e := json.Unmarshal(out.Bytes(), &v); e != nil || code != 0 {
+		t.Fatalf("unexpected result: code=%d err=%v (%q, stderr=%q)", code, e, out.String(), err.String())
+	}
+	if v.Status != "ok" || v.Network != "mainnet" || v.CoinType != 8133 || len(v.SeedFingerprint) != 64 || len(v.Accounts) != 2 {
+		t.Fatalf("unexpected json: %+v", v)
+	}
+	for i, a := range v.Accounts {
+		if a["account"] != float64(3+i) || a["key_id"] != "00ff" || a["address"] != "j1abc" || !strings.HasPrefix(a["ufvk"].(string), "jview1") {
+			t.Fatalf("unexpected account: %v", a)
+		}
+		if _, ok := a["orchard_spending_key"]; ok {
+			t.Fatalf("spending key printed without --dangerously-print-spending-keys")
+		}
+	}
+	if v.Accounts[0]["ufvk"] == v.Accounts[1]["ufvk"] {
+		t.Fatalf("accounts share a UFVK")
+	}
+
+	// The passphrase changes the seed.
+	out.Reset()
+	code = RunWithIO([]string{"keygen", "--mnemonic-file", mnemonic, "--account", "3", "--seed-fingerprint"}, d, &out, &err)
+	if code != 0 || strings.Contains(out.String(), v.SeedFingerprint) || strings.Contains(out.String(), v.Accounts[0]["ufvk"].(string)) {
+		t.Fatalf("unexpected result: code=%d out=%q", code, out.String())
+	}
+}
+
+func TestKeygen_SpendingKeys(t *testing.T) {
+	t.Setenv("JUNO_TEST_SEED", strings.Repeat("07", 64))
+
+	d := &fakeDeriver{deriveAddr: "j1abc"}
+	var out, err bytes.Buffer
+	code := RunWithIO([]string{"keygen", "--seed-env", "JUNO_TEST_SEED", "--dangerously-print-spending-keys"}, d, &out, &err)
+	if code != 0 || !strings.Contains(out.String(), " orchard_spending_key=") {
+		t.Fatalf("unexpected result: code=%d out=%q", code, out.String())
+	}
+	if !strings.Contains(err.String(), "warning: spending keys were printed") {
+		t.Fatalf("no warning on stderr: %q", err.String())
+	}
+}
+
+func TestKeygen_Errors(t *testing.T) {
+	t.Setenv("JUNO_TEST_SEED", strings.Repeat("07", 64))
+	t.Setenv("JUNO_TEST_MNEMONIC", "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon")
+	t.Setenv("JUNO_TEST_SHORT_SEED", strings.Repeat("07", 31))
+	t.Setenv("JUNO_TEST_PASSPHRASE", "x")
+
+	for _, tc := range []struct {
+		args []string
+		code int
+		want string
+	}{
+		{[]string{}, 2, "seed is required"},
+		{[]string{"--seed-env", "JUNO_TEST_SEED", "--mnemonic-env", "JUNO_TEST_MNEMONIC"}, 2, "seed source conflict"},
+		{[]string{"--seed-env", "JUNO_TEST_SEED", "--passphrase-env", "JUNO_TEST_PASSPHRASE"}, 2, "passphrase applies only to a mnemonic"},
+		{[]string{"--seed-env", "JUNO_TEST_UNSET"}, 2, "not set"},
+		{[]string{"--mnemonic-env", "JUNO_TEST_MNEMONIC", "--json"}, 1, `"error":"mnemonic_invalid"`},
+		{[]string{"--seed-env", "JUNO_TEST_SHORT_SEED", "--json"}, 1, `"error":"seed_invalid"`},
+		{[]string{"--seed-env", "JUNO_TEST_SEED", "--account", "2147483648", "--json"}, 1, `"error":"account_invalid"`},
+		{[]string{"--seed-env", "JUNO_TEST_SEED", "--account", "2147483647-2147483648", "--json"}, 1, `"error":"account_invalid"`},
+		{[]string{"--seed-env", "JUNO_TEST_SEED", "--account", "4294967296", "--json"}, 1, `"error":"account_invalid"`},
+		{[]string{"--seed-env", "JUNO_TEST_SEED", "--account", "5-4", "--json"}, 1, `"error":"account_invalid"`},
+		{[]string{"--seed-env", "JUNO_TEST_SEED", "--account", "0-1000", "--json"}, 2, "at most 1000 accounts"},
+		{[]string{"--seed-env", "JUNO_TEST_SEED", "--account", "0-2147483647", "--json"}, 2, "at most 1000 accounts"},
+		{[]string{"--seed-env", "JUNO_TEST_SEED", "--network", "mainnet2", "--json"}, 1, `"error":"network_invalid"`},
+	} {
+		var out, err bytes.Buffer
+		code := RunWithIO(append([]string{"keygen"}, tc.args...), &fakeDeriver{}, &out, &err)
+		if code != tc.code || !strings.Contains(out.String()+err.String(), tc.want) {
+			t.Fatalf("%v: code=%d out=%q stderr=%q", tc.args, code, out.String(), err.String())
+		}
+	}
+
+	// The deriver's errors are reported with its codes, except an account
+	// without a valid key.
+	for _, tc := range []struct {
+		err  error
+		want string
+	}{
+		{codedErr("spending_key_invalid"), `"error":"key_invalid"`},
+		{codedErr("selftest_failed"), `"error":"selftest_failed"`},
+	} {
+		var out, err bytes.Buffer
+		code := RunWithIO([]string{"keygen", "--seed-env", "JUNO_TEST_SEED", "--json"}, &fakeDeriver{keygenErr: tc.err}, &out, &err)
+		if code != 1 || !strings.Contains(out.String(), tc.want) {
+			t.Fatalf("%v: code=%d out=%q stderr=%q", tc.err, code, out.String(), err.String())
+		}
+	}
+}
+
+// vectorDeriver answers Derive, Stream and IndexOf from the embedded vectors
+// files, Orchard only, like the pure-Go deriver. Addresses in wrong are
+// derived incorrectly.
+type vectorDeriver struct {
+	fakeDeriver
+	networks  map[string]string
+	addresses map[vectorKey]string
+	indices   map[string]vectorKey
+	wrong     map[string]bool
+}
+
+type vectorKey struct {
+	ufvk, scope string
+	index       diversifier.Index
+}
+
+func newVectorDeriver(t *testing.T) *vectorDeriver {
+	t.Helper()
+
+	d := &vectorDeriver{
+		networks:  map[string]string{},
+		addresses: map[vectorKey]string{},
+		indices:   map[string]vectorKey{},
+		wrong:     map[string]bool{},
+	}
+	for _, name := range vectors.Names() {
+		f, err := vectors.Load(name)
+		if err != nil {
+			t.Fatalf("load %s: %v", name, err)
+		}
+		d.networks[f.UFVK] = f.Network
+		scope := "external"
+		if f.Scope != "" {
+			scope = f.Scope
+		}
+		add := func(index diversifier.Index, address string) {
+			k := vectorKey{f.UFVK, scope, index}
+			d.addresses[k] = address
+			d.indices[address] = k
+		}
+		if f.Transparent != nil || f.Receivers != nil {
+			continue
+		}
+		for i, a := range f.Addresses {
+			add(diversifier.FromUint32(uint32(i)), a)
+		}
+		for _, v := range f.Indexed {
+			index, err := diversifier.Parse(v.Index)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			add(index, v.Address)
+		}
+	}
+	return d
+}
+
+func (d *vectorDeriver) Network(ufvk string) (string, error) {
+	n, ok := d.networks[ufvk]
+	if !ok {
+		return "", codedErr("ufvk_invalid_bech32m")
+	}
+	return n, nil
+}
+
+func (d *vectorDeriver) Derive(ufvk string, index diversifier.Index, opts Options) (string, diversifier.Index, error) {
+	if len(opts.Receivers) > 0 && !slices.Equal(opts.Receivers, []string{"orchard"}) {
+		return "", diversifier.Index{}, codedErr("receivers_unsupported")
+	}
+	a, ok := d.addresses[vectorKey{ufvk, opts.Scope, index}]
+	if !ok {
+		return "", diversifier.Index{}, codedErr("internal")
+	}
+	if d.wrong[a] {
+		return "j1wrong", index, nil
+	}
+	return a, index, nil
+}
+
+func (d *vectorDeriver) Stream(ctx context.Context, ufvk string, start diversifier.Index, count uint64, opts Options, fn func(diversifier.Index, string) error) error {
+	for i := range count {
+		index, _ := start.Add(i)
+		a, _, err := d.Derive(ufvk, index, opts)
+		if err != nil {
+			return err
+		}
+		if err := fn(index, a); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+func (d *vectorDeriver) IndexOf(ufvk, address string) (diversifier.Index, string, error) {
+	k, ok := d.indices[address]
+	if !ok || k.ufvk != ufvk {
+		return diversifier.Index{}, "", codedErr("address_not_owned")
+	}
+	return k.index, k.scope, nil
+}
+
+func TestSelftest(t *testing.T) {
+	d := newVectorDeriver(t)
+	var out, err bytes.Buffer
+
+	code := RunWithIO([]string{"selftest"}, d, &out, &err)
+	if code != 0 || err.Len() != 0 {
+		t.Fatalf("unexpected result: code=%d stderr=%q", code, err.String())
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != len(vectors.Names()) {
+		t.Fatalf("unexpected stdout: %q", out.String())
+	}
+	for _, line := range lines {
+		want := "pass "
+		for _, name := range []string{"v1_sapling.json", "v1_transparent.json"} {
+			if strings.Contains(line, name) {
+				want = "skip " + name + " checks=1 receivers_unsupported: "
+			}
+		}
+		if !strings.HasPrefix(line, want) {
+			t.Fatalf("unexpected line: %q", line)
+		}
+	}
+	if !slices.Contains(lines, "pass v1.json checks=301") {
+		t.Fatalf("unexpected stdout: %q", out.String())
+	}
+
+	out.Reset()
+	code = RunWithIO([]string{"selftest", "--json"}, d, &out, &err)
+	var resp struct {
+		Version string           `json:"version"`
+		Status  string           `json:"status"`
+		Vectors []map[string]any `json:"vectors"`
+	}
+	if e := json.Unmarshal(out.Bytes(), &resp); e != nil || code != 0 {
+		t.Fatalf("unexpected result: code=%d err=%v out=%q", code, e, out.String())
+	}
+	if resp.Version != "v1" || resp.Status != "ok" || len(resp.Vectors) != len(lines) {
+		t.Fatalf("unexpected json: %q", out.String())
+	}
+	if v := resp.Vectors[0]; v["name"] != "v1.json" || v["network"] != "mainnet" || v["status"] != "pass" || v["checks"] != float64(301) {
+		t.Fatalf("unexpected vector: %v", v)
+	}
+}
+
+func TestSelftest_Mismatch(t *testing.T) {
+	d := newVectorDeriver(t)
+	f, e := vectors.Load("v1_regtest_internal.json")
+	if e != nil {
+		t.Fatalf("load vectors: %v", e)
+	}
+	d.wrong[f.Addresses[7]] = true
+	var out, err bytes.Buffer
+
+	code := RunWithIO([]string{"selftest"}, d, &out, &err)
+	if code != 1 || err.String() != "selftest_failed: 1 of 9 vectors files failed\n" {
+		t.Fatalf("unexpected result: code=%d stderr=%q", code, err.String())
+	}
+	want := "fail v1_regtest_internal.json checks=15 vector_mismatch: index 7 derives j1wrong at 7, want " + f.Addresses[7] + "\n"
+	if !strings.Contains(out.String(), want) {
+		t.Fatalf("unexpected stdout: %q", out.String())
+	}
+
+	out.Reset()
+	code = RunWithIO([]string{"selftest", "--json"}, d, &out, &err)
+	var resp map[string]any
+	if e := json.Unmarshal(out.Bytes(), &resp); e != nil || code != 1 {
+		t.Fatalf("unexpected result: code=%d err=%v out=%q", code, e, out.String())
+	}
+	if resp["status"] != "err" || resp["error"] != "selftest_failed" {
+		t.Fatalf("unexpected json: %v", resp)
+	}
+}
+
+func TestSelftest_Errors(t *testing.T) {
+	d := newVectorDeriver(t)
+	for ufvk := range d.networks {
+		d.networks[ufvk] = "mainnet"
+	}
+	var out, err bytes.Buffer
+
+	code := RunWithIO([]string{"selftest", "--json"}, d, &out, &err)
+	if code != 1 || !strings.Contains(out.String(), `"message":"network is mainnet, want testnet"`) {
+		t.Fatalf("unexpected result: code=%d out=%q", code, out.String())
+	}
+
+	clear(d.networks)
+	out.Reset()
+	code = RunWithIO([]string{"selftest", "--json"}, d, &out, &err)
+	if code != 1 || !strings.Contains(out.String(), `"error":"ufvk_invalid_bech32m","message":"network: ufvk_invalid_bech32m"`) {
+		t.Fatalf("unexpected result: code=%d out=%q", code, out.String())
+	}
+
+	code = RunWithIO([]string{"selftest", "--bogus"}, d, &out, &err)
+	if code != 2 {
+		t.Fatalf("unexpected exit code: %d", code)
 	}
 }
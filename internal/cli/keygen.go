@@ -0,0 +1,310 @@
+package cli
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Abdullah1738/juno-addrgen/pkg/bip39"
+	"github.com/Abdullah1738/juno-addrgen/pkg/diversifier"
+	"github.com/Abdullah1738/juno-addrgen/pkg/zip32"
+)
+
+// junoCoinType is Juno Cash's SLIP-44 coin type, used on every network as
+// the Rust library's tests do.
+const junoCoinType = 8133
+
+// maxKeygenAccounts caps the size of an --account range. keygen holds every
+// account until it has generated them all, so that a failure prints nothing
+// but the error.
+const maxKeygenAccounts = 1000
+
+// keygenAccount is one account generated by keygen.
+type keygenAccount struct {
+	account     uint32
+	ufvk        string
+	keyID       string
+	address     string
+	spendingKey string
+}
+
+// runKeygen derives the UFVK of each account of a ZIP-32 seed, given as a
+// BIP-39 mnemonic or in hex. The deriver generates the keys: the Rust
+// library's, with the orchard crate, except in the addrgen_purego build,
+// which uses pkg/orchard. It then parses each UFVK for its key ID and
+// address 0.
+func runKeygen(ctx context.Context, args []string, deriver Deriver, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("keygen", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var mnemonicFile, mnemonicEnv string
+	var seedFile, seedEnv string
+	var passphraseFile, passphraseEnv string
+	var accounts string
+	var network string
+	var seedFingerprint bool
+	var printSpendingKeys bool
+	var jsonOut bool
+
+	fs.StringVar(&mnemonicFile, "mnemonic-file", "", "Read a BIP-39 mnemonic from file (- for stdin)")
+	fs.StringVar(&mnemonicEnv, "mnemonic-env", "", "Read a BIP-39 mnemonic from env var (name)")
+	fs.StringVar(&seedFile, "seed-file", "", "Read a hex seed from file (- for stdin)")
+	fs.StringVar(&seedEnv, "seed-env", "", "Read a hex seed from env var (name)")
+	fs.StringVar(&passphraseFile, "passphrase-file", "", "Read the BIP-39 passphrase from file")
+	fs.StringVar(&passphraseEnv, "passphrase-env", "", "Read the BIP-39 passphrase from env var (name)")
+	fs.StringVar(&accounts, "account", "0", "ZIP-32 account, or an inclusive range a-b of at most 1000 (below 2^31)")
+	fs.StringVar(&network, "network", "mainnet", "Network of the UFVKs (mainnet|testnet|regtest)")
+	fs.BoolVar(&seedFingerprint, "seed-fingerprint", false, "Also print the ZIP-32 seed fingerprint")
+	fs.BoolVar(&printSpendingKeys, "dangerously-print-spending-keys", false, "Also print each account's Orchard spending key, which can spend its funds")
+	fs.BoolVar(&jsonOut, "json", false, "JSON output")
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 2
+	}
+
+	seed, err := readSeed(mnemonicFile, mnemonicEnv, seedFile, seedEnv, passphraseFile, passphraseEnv)
+	if err != nil {
+		var ke *keygenError
+		if errors.As(err, &ke) {
+			return writeErr(stdout, stderr, jsonOut, ke.code, ke.message)
+		}
+		fmt.Fprintln(stderr, err.Error())
+		return 2
+	}
+	defer clear(seed)
+
+	first, last, ok := parseAccounts(accounts)
+	if !ok {
+		return writeErr(stdout, stderr, jsonOut, "account_invalid", "account must be a number or a range a-b, below 2^31")
+	}
+	if uint64(last)-uint64(first) >= maxKeygenAccounts {
+		fmt.Fprintf(stderr, "--account range is too large (at most %d accounts)\n", maxKeygenAccounts)
+		return 2
+	}
+	network = strings.ToLower(strings.TrimSpace(network))
+	switch network {
+	case "mainnet", "testnet", "regtest":
+	default:
+		return writeErr(stdout, stderr, jsonOut, "network_invalid", "network must be mainnet, testnet or regtest")
+	}
+
+	var fp string
+	if seedFingerprint {
+		// readSeed checked the seed's length.
+		b, _ := zip32.SeedFingerprint(seed)
+		fp = hex.EncodeToString(b[:])
+	}
+
+	var out []keygenAccount
+	for account := uint64(first); account <= uint64(last); account++ {
+		if err := ctx.Err(); err != nil {
+			return writeDeriverErr(stdout, stderr, jsonOut, err)
+		}
+		a, err := generateAccount(seed, network, uint32(account), deriver)
+		var ke *keygenError
+		if errors.As(err, &ke) {
+			return writeErr(stdout, stderr, jsonOut, ke.code, ke.message)
+		}
+		if err != nil {
+			return writeDeriverErr(stdout, stderr, jsonOut, err)
+		}
+		out = append(out, a)
+	}
+
+	if printSpendingKeys {
+		fmt.Fprintln(stderr, "warning: spending keys were printed; anyone who sees them can spend these accounts' funds")
+	}
+
+	if jsonOut {
+		list := make([]map[string]any, len(out))
+		for i, a := range out {
+			list[i] = map[string]any{
+				"account":   a.account,
+				"ufvk":      a.ufvk,
+				"key_id":    a.keyID,
+				"address":   a.address,
+				"index":     0,
+				"index_str": "0",
+			}
+			if printSpendingKeys {
+				list[i]["orchard_spending_key"] = a.spendingKey
+			}
+		}
+		resp := map[string]any{
+			"version":   jsonVersionV1,
+			"status":    "ok",
+			"network":   network,
+			"coin_type": junoCoinType,
+			"accounts":  list,
+		}
+		if seedFingerprint {
+			resp["seed_fingerprint"] = fp
+		}
+		_ = json.NewEncoder(stdout).Encode(resp)
+		return 0
+	}
+
+	if len(out) == 1 && !seedFingerprint && !printSpendingKeys {
+		fmt.Fprintln(stdout, out[0].ufvk)
+		return 0
+	}
+	if seedFingerprint {
+		fmt.Fprintf(stdout, "seed_fingerprint=%s\n", fp)
+	}
+	for _, a := range out {
+		if printSpendingKeys {
+			fmt.Fprintf(stdout, "account=%d ufvk=%s orchard_spending_key=%s\n", a.account, a.ufvk, a.spendingKey)
+			continue
+		}
+		fmt.Fprintf(stdout, "account=%d ufvk=%s\n", a.account, a.ufvk)
+	}
+	return 0
+}
+
+// generateAccount has deriver generate an account's Orchard-only UFVK and
+// spending key, then parse the UFVK.
+func generateAccount(seed []byte, network string, account uint32, deriver Deriver) (keygenAccount, error) {
+	ufvk, sk, err := deriver.Keygen(seed, account, network)
+	if err != nil {
+		if code, _ := errCode(err); code == "spending_key_invalid" {
+			return keygenAccount{}, &keygenError{code: "key_invalid", message: fmt.Sprintf("account %d has no valid key at this seed", account)}
+		}
+		return keygenAccount{}, err
+	}
+	defer clear(sk)
+
+	keyID, err := deriver.KeyID(ufvk)
+	if err != nil {
+		return keygenAccount{}, err
+	}
+	address, _, err := deriver.Derive(ufvk, diversifier.Index{}, Options{Scope: "external"})
+	if err != nil {
+		return keygenAccount{}, err
+	}
+	return keygenAccount{
+		account:     account,
+		ufvk:        ufvk,
+		keyID:       keyID,
+		address:     address,
+		spendingKey: hex.EncodeToString(sk),
+	}, nil
+}
+
+// keygenError is a keygen failure that is not the deriver's, with its error
+// code.
+type keygenError struct {
+	code, message string
+}
+
+func (e *keygenError) Error() string { return e.message }
+
+// readSeed reads the seed from the one mnemonic or seed source, applying the
+// passphrase to a mnemonic. A *keygenError is invalid input; other errors are
+// usage errors.
+func readSeed(mnemonicFile, mnemonicEnv, seedFile, seedEnv, passphraseFile, passphraseEnv string) ([]byte, error) {
+	var sources int
+	for _, v := range []string{mnemonicFile, mnemonicEnv, seedFile, seedEnv} {
+		if strings.TrimSpace(v) != "" {
+			sources++
+		}
+	}
+	switch {
+	case sources == 0:
+		return nil, fmt.Errorf("seed is required (use --mnemonic-file, --mnemonic-env, --seed-file, or --seed-env)")
+	case sources > 1:
+		return nil, fmt.Errorf("seed source conflict (use only one of --mnemonic-file, --mnemonic-env, --seed-file, --seed-env)")
+	}
+	mnemonic := strings.TrimSpace(mnemonicFile) != "" || strings.TrimSpace(mnemonicEnv) != ""
+	hasPassphrase := strings.TrimSpace(passphraseFile) != "" || strings.TrimSpace(passphraseEnv) != ""
+	if hasPassphrase && !mnemonic {
+		return nil, fmt.Errorf("a passphrase applies only to a mnemonic (--mnemonic-file or --mnemonic-env)")
+	}
+	if strings.TrimSpace(passphraseFile) != "" && strings.TrimSpace(passphraseEnv) != "" {
+		return nil, fmt.Errorf("passphrase source conflict (use only one of --passphrase-file, --passphrase-env)")
+	}
+
+	if !mnemonic {
+		s, err := readSecret("seed", seedFile, seedEnv)
+		if err != nil {
+			return nil, err
+		}
+		seed, err := hex.DecodeString(strings.TrimSpace(s))
+		if err != nil || zip32.CheckSeed(seed) != nil {
+			return nil, &keygenError{code: "seed_invalid", message: "seed must be 32 to 252 bytes, in hex"}
+		}
+		return seed, nil
+	}
+
+	words, err := readSecret("mnemonic", mnemonicFile, mnemonicEnv)
+	if err != nil {
+		return nil, err
+	}
+	var passphrase string
+	if hasPassphrase {
+		if passphrase, err = readSecret("passphrase", passphraseFile, passphraseEnv); err != nil {
+			return nil, err
+		}
+		// A passphrase file ends in a newline, which is not part of it.
+		if strings.TrimSpace(passphraseFile) != "" {
+			passphrase = strings.TrimSuffix(strings.TrimSuffix(passphrase, "\n"), "\r")
+		}
+	}
+	seed, err := bip39.Seed(words, passphrase)
+	if err != nil {
+		return nil, &keygenError{code: "mnemonic_invalid", message: strings.TrimPrefix(err.Error(), "bip39: ")}
+	}
+	return seed, nil
+}
+
+// readSecret reads the one non-empty source of a secret, as is: a file (or
+// stdin, for "-") or an environment variable.
+func readSecret(kind, file, env string) (string, error) {
+	if name := strings.TrimSpace(env); name != "" {
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("%s env var %s is not set", kind, name)
+		}
+		return v, nil
+	}
+
+	path := strings.TrimSpace(file)
+	if path == "-" {
+		b, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", fmt.Errorf("read %s from stdin: %w", kind, err)
+		}
+		return string(b), nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read %s file (%s): %w", kind, filepath.Base(path), err)
+	}
+	return string(b), nil
+}
+
+// parseAccounts parses --account: a ZIP-32 account number, or an inclusive
+// range a-b of them. Accounts are hardened indices, so must be below 2^31.
+func parseAccounts(s string) (first, last uint32, ok bool) {
+	lo, hi, isRange := strings.Cut(strings.TrimSpace(s), "-")
+	a, err := strconv.ParseUint(strings.TrimSpace(lo), 10, 32)
+	if err != nil || a >= zip32.Hardened {
+		return 0, 0, false
+	}
+	b := a
+	if isRange {
+		b, err = strconv.ParseUint(strings.TrimSpace(hi), 10, 32)
+		if err != nil || b < a || b >= zip32.Hardened {
+			return 0, 0, false
+		}
+	}
+	return uint32(a), uint32(b), true
+}
@@ -0,0 +1,228 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/Abdullah1738/juno-addrgen/pkg/diversifier"
+	"github.com/Abdullah1738/juno-addrgen/vectors"
+)
+
+// runSelftest checks the deriver against every golden vector embedded in the
+// binary and reports each vectors file as pass, fail or skip (receiver types
+// the deriver does not support). It exits 1 if any file fails: the binary
+// must not be trusted with addresses.
+func runSelftest(ctx context.Context, args []string, deriver Deriver, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("selftest", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var jsonOut bool
+	fs.BoolVar(&jsonOut, "json", false, "JSON output")
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 2
+	}
+
+	names := vectors.Names()
+	results := make([]map[string]any, 0, len(names))
+	var failed int
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return writeDeriverErr(stdout, stderr, jsonOut, err)
+		}
+		result := map[string]any{"name": name}
+		f, err := vectors.Load(name)
+		var checks int
+		if err == nil {
+			result["network"] = f.Network
+			checks, err = checkVectors(ctx, deriver, f)
+		}
+		if errors.Is(err, context.Canceled) {
+			return writeDeriverErr(stdout, stderr, jsonOut, err)
+		}
+		status, code, message := vectorStatus(err)
+		result["status"] = status
+		result["checks"] = checks
+		if code != "" {
+			result["error"] = code
+			result["message"] = message
+		}
+		if status == "fail" {
+			failed++
+		}
+		results = append(results, result)
+	}
+
+	summary := fmt.Sprintf("%d of %d vectors files failed", failed, len(results))
+	if jsonOut {
+		resp := map[string]any{
+			"version": jsonVersionV1,
+			"status":  "ok",
+			"vectors": results,
+		}
+		if failed > 0 {
+			resp["status"] = "err"
+			resp["error"] = "selftest_failed"
+			resp["message"] = summary
+		}
+		_ = json.NewEncoder(stdout).Encode(resp)
+		if failed > 0 {
+			return 1
+		}
+		return 0
+	}
+
+	for _, r := range results {
+		if r["error"] == nil {
+			fmt.Fprintf(stdout, "%s %s checks=%d\n", r["status"], r["name"], r["checks"])
+			continue
+		}
+		fmt.Fprintf(stdout, "%s %s checks=%d %s: %s\n", r["status"], r["name"], r["checks"], r["error"], r["message"])
+	}
+	if failed > 0 {
+		return writeErr(stdout, stderr, false, "selftest_failed", summary)
+	}
+	return 0
+}
+
+// checkVectors checks one vectors file against the deriver: each address
+// derived one at a time and streamed, and found again by IndexOf. It returns
+// the number of checks that passed.
+func checkVectors(ctx context.Context, deriver Deriver, f vectors.File) (int, error) {
+	var checks int
+	network, err := deriver.Network(f.UFVK)
+	if err != nil {
+		return checks, fmt.Errorf("network: %w", err)
+	}
+	if network != f.Network {
+		return checks, &vectorMismatch{fmt.Sprintf("network is %s, want %s", network, f.Network)}
+	}
+	checks++
+
+	scope := "external"
+	if f.Scope != "" {
+		scope = f.Scope
+	}
+	opts := Options{Scope: scope, Jobs: 1, Receivers: f.Receivers}
+
+	switch {
+	case f.Transparent != nil:
+		// A transparent vectors file holds Orchard + P2PKH unified addresses
+		// and standalone P2PKH addresses in both scopes.
+		for _, list := range []struct {
+			receivers []string
+			scope     string
+			addresses []string
+		}{
+			{[]string{"orchard", "p2pkh"}, "external", f.Addresses},
+			{[]string{"p2pkh"}, "external", f.Transparent},
+			{[]string{"p2pkh"}, "internal", f.TransparentInternal},
+		} {
+			n, err := checkStream(ctx, deriver, f.UFVK, Options{Scope: list.scope, Jobs: 1, Receivers: list.receivers}, list.addresses)
+			checks += n
+			if err != nil {
+				return checks, err
+			}
+		}
+		return checks, nil
+
+	case f.Indexed != nil:
+		// Indexed addresses are at scattered indices: beyond 32 bits, or
+		// the ones whose Sapling diversifier is valid.
+		for _, v := range f.Indexed {
+			index, err := diversifier.Parse(v.Index)
+			if err != nil {
+				return checks, fmt.Errorf("index %s: %w", v.Index, err)
+			}
+			n, err := checkAddress(deriver, f.UFVK, index, opts, v.Address)
+			checks += n
+			if err != nil {
+				return checks, err
+			}
+		}
+		return checks, nil
+	}
+
+	if len(f.Addresses) == 0 {
+		return checks, &vectorMismatch{"no addresses"}
+	}
+	for i, address := range f.Addresses {
+		n, err := checkAddress(deriver, f.UFVK, diversifier.FromUint32(uint32(i)), opts, address)
+		checks += n
+		if err != nil {
+			return checks, err
+		}
+	}
+	n, err := checkStream(ctx, deriver, f.UFVK, opts, f.Addresses)
+	return checks + n, err
+}
+
+// checkAddress checks that the deriver derives want at index, and finds it
+// there.
+func checkAddress(deriver Deriver, ufvk string, index diversifier.Index, opts Options, want string) (int, error) {
+	got, used, err := deriver.Derive(ufvk, index, opts)
+	if err != nil {
+		return 0, fmt.Errorf("index %s: %w", index, err)
+	}
+	if got != want || used != index {
+		return 0, &vectorMismatch{fmt.Sprintf("index %s derives %s at %s, want %s", index, got, used, want)}
+	}
+
+	found, scope, err := deriver.IndexOf(ufvk, want)
+	if err != nil {
+		return 1, fmt.Errorf("owner of index %s: %w", index, err)
+	}
+	if found != index || scope != opts.Scope {
+		return 1, &vectorMismatch{fmt.Sprintf("address at index %s is found at %s (%s)", index, found, scope)}
+	}
+	return 2, nil
+}
+
+// checkStream checks that the deriver streams want, from index 0.
+func checkStream(ctx context.Context, deriver Deriver, ufvk string, opts Options, want []string) (int, error) {
+	var checks int
+	err := deriver.Stream(ctx, ufvk, diversifier.Index{}, uint64(len(want)), opts, func(index diversifier.Index, address string) error {
+		if checks >= len(want) || index != diversifier.FromUint32(uint32(checks)) || address != want[checks] {
+			return &vectorMismatch{fmt.Sprintf("streamed address %d is %s at %s", checks, address, index)}
+		}
+		checks++
+		return nil
+	})
+	if err == nil && checks != len(want) {
+		err = &vectorMismatch{fmt.Sprintf("streamed %d addresses, want %d", checks, len(want))}
+	}
+	var m *vectorMismatch
+	if err != nil && !errors.As(err, &m) {
+		err = fmt.Errorf("stream: %w", err)
+	}
+	return checks, err
+}
+
+// vectorMismatch is a deriver result that differs from a golden vector.
+type vectorMismatch struct {
+	message string
+}
+
+func (e *vectorMismatch) Error() string { return e.message }
+
+// vectorStatus returns the status of a vectors file given the error its
+// checks stopped at, and the error's code and message.
+func vectorStatus(err error) (status, code, message string) {
+	if err == nil {
+		return "pass", "", ""
+	}
+	var m *vectorMismatch
+	if errors.As(err, &m) {
+		return "fail", "vector_mismatch", m.message
+	}
+	code, message = errCode(err)
+	if code == "receivers_unsupported" {
+		return "skip", code, message
+	}
+	return "fail", code, message
+}
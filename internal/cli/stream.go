@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/Abdullah1738/juno-addrgen/pkg/diversifier"
+)
+
+// batchWriter writes batch results to stdout as they are derived, so output
+// size is independent of the batch size.
+//
+// In JSON mode the v1 batch object is written incrementally with "status" as
+// the last member: if derivation fails after some addresses were written, the
+// document is closed with "status":"err" and still parses.
+//
+// When indices may be skipped (Sapling receivers), each address is written
+// with its index: as "address=... index=..." lines, or as {"address","index"}
+// objects in the JSON "addresses" array.
+type batchWriter struct {
+	w       *bufio.Writer
+	jsonOut bool
+	keyID   string
+	start   diversifier.Index
+	count   uint64
+	indexed bool
+	opened  bool
+	written uint64
+}
+
+func newBatchWriter(stdout io.Writer, jsonOut bool, keyID string, start diversifier.Index, count uint64, indexed bool) *batchWriter {
+	return &batchWriter{
+		w:       bufio.NewWriter(stdout),
+		jsonOut: jsonOut,
+		keyID:   keyID,
+		start:   start,
+		count:   count,
+		indexed: indexed,
+	}
+}
+
+func (b *batchWriter) address(index diversifier.Index, address string) error {
+	if !b.jsonOut {
+		b.written++
+		if b.indexed {
+			_, err := fmt.Fprintf(b.w, "address=%s index=%s\n", address, index)
+			return err
+		}
+		_, err := fmt.Fprintln(b.w, address)
+		return err
+	}
+
+	if !b.opened {
+		if err := b.open(); err != nil {
+			return err
+		}
+	} else if err := b.w.WriteByte(','); err != nil {
+		return err
+	}
+	b.written++
+
+	var v any = address
+	if b.indexed {
+		v = map[string]any{"address": address, "index": index, "index_str": index.String()}
+	}
+	enc, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = b.w.Write(enc)
+	return err
+}
+
+// open writes the members before the addresses.
+func (b *batchWriter) open() error {
+	b.opened = true
+	_, err := fmt.Fprintf(b.w, `{"version":%q,"start":%s,"start_str":%q,"count":%d,"key_id":%q,"addresses":[`, jsonVersionV1, b.start, b.start, b.count, b.keyID)
+	return err
+}
+
+func (b *batchWriter) finish() error {
+	if b.jsonOut {
+		// A range of skipped indices has no addresses, but is still a result.
+		if !b.opened {
+			if err := b.open(); err != nil {
+				return err
+			}
+		}
+		if _, err := io.WriteString(b.w, `],"status":"ok"}`+"\n"); err != nil {
+			return err
+		}
+	}
+	return b.w.Flush()
+}
+
+// fail ends a batch that has already written addresses.
+func (b *batchWriter) fail(stderr io.Writer, err error) int {
+	code, message := errCode(err)
+	if !b.jsonOut {
+		_ = b.w.Flush()
+		return writeErr(nil, stderr, false, code, message)
+	}
+
+	tail, _ := json.Marshal(map[string]any{
+		"error":   code,
+		"message": message,
+	})
+	// Splice the error members into the open object: `],"status":"err",<tail without '{'>`.
+	fmt.Fprintf(b.w, `],"status":"err",%s`+"\n", tail[1:])
+	_ = b.w.Flush()
+	return 1
+}
+
+const progressInterval = 200 * time.Millisecond
+
+// progress renders a single-line progress indicator on stderr.
+type progress struct {
+	w       io.Writer
+	total   uint64
+	done    uint64
+	last    time.Time
+	printed bool
+}
+
+func newProgress(w io.Writer, total uint64, enabled bool) *progress {
+	if !enabled {
+		return nil
+	}
+	return &progress{w: w, total: total}
+}
+
+// reach records that every index from start through index has been derived
+// or skipped.
+func (p *progress) reach(start, index diversifier.Index) {
+	if p == nil {
+		return
+	}
+	done := new(big.Int).Sub(index.Big(), start.Big()).Uint64() + 1
+	p.add(done - p.done)
+}
+
+// complete records the whole range as done, including skipped indices after
+// the last address.
+func (p *progress) complete() {
+	if p == nil || p.done == p.total {
+		return
+	}
+	p.add(p.total - p.done)
+}
+
+func (p *progress) add(n uint64) {
+	if p == nil {
+		return
+	}
+	p.done += n
+	if now := time.Now(); p.done == p.total || now.Sub(p.last) >= progressInterval {
+		p.last = now
+		p.printed = true
+		fmt.Fprintf(p.w, "\rderived %d/%d (%.1f%%)", p.done, p.total, 100*float64(p.done)/float64(p.total))
+	}
+}
+
+func (p *progress) finish() {
+	if p == nil || !p.printed {
+		return
+	}
+	fmt.Fprintln(p.w)
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// stdin is where validate reads addresses from without --address or --file.
+var stdin io.Reader = os.Stdin
+
+// maxAddressLine bounds one input line. Real addresses are a few hundred
+// bytes; a longer line is reported as an invalid address without being read
+// into memory, and validation goes on with the next line.
+const maxAddressLine = 64 << 10
+
+func runValidate(args []string, deriver Deriver, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var address string
+	var file string
+	var expectNetwork string
+
+	fs.StringVar(&address, "address", "", "Address to validate (j*1..., or transparent t1... / tm...)")
+	fs.StringVar(&file, "file", "", "Read addresses from file, one per line (- for stdin)")
+	fs.StringVar(&expectNetwork, "expect-network", "", "Fail addresses not on this network (mainnet|testnet|regtest)")
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 2
+	}
+	if strings.TrimSpace(address) != "" && strings.TrimSpace(file) != "" {
+		fmt.Fprintln(stderr, "address source conflict (use only one of --address, --file)")
+		return 2
+	}
+
+	expectNetwork = strings.ToLower(strings.TrimSpace(expectNetwork))
+	switch expectNetwork {
+	case "", "mainnet", "testnet", "regtest":
+	default:
+		return writeErr(stdout, stderr, true, "network_invalid", "network must be mainnet, testnet or regtest")
+	}
+
+	var in io.Reader
+	switch path := strings.TrimSpace(file); {
+	case strings.TrimSpace(address) != "":
+		in = strings.NewReader(address)
+	case path == "" || path == "-":
+		in = stdin
+	default:
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "read address file (%s): %v\n", filepath.Base(path), err)
+			return 2
+		}
+		defer f.Close()
+		in = f
+	}
+
+	enc := json.NewEncoder(stdout)
+	r := bufio.NewReaderSize(in, maxAddressLine)
+
+	var lines, failed int
+	for line := 1; ; line++ {
+		text, tooLong, err := readAddressLine(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "read addresses: %v\n", err)
+			return 1
+		}
+
+		var result map[string]any
+		if tooLong {
+			result = map[string]any{
+				"version": jsonVersionV1,
+				"status":  "err",
+				"error":   "address_invalid_bech32m",
+				"message": fmt.Sprintf("line is longer than %d bytes", maxAddressLine),
+			}
+		} else {
+			address := strings.TrimSpace(text)
+			if address == "" {
+				continue
+			}
+			result = validateAddress(deriver, address, expectNetwork)
+		}
+		lines++
+		result["line"] = line
+		if result["status"] != "ok" {
+			failed++
+		}
+		_ = enc.Encode(result)
+	}
+	if lines == 0 {
+		fmt.Fprintln(stderr, "no addresses to validate (use --address, --file, or stdin)")
+		return 2
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// readAddressLine reads one line from r, which must buffer maxAddressLine
+// bytes. A longer line is discarded up to its end and reported as tooLong.
+// It returns io.EOF only once every line has been read.
+func readAddressLine(r *bufio.Reader) (line string, tooLong bool, err error) {
+	b, isPrefix, err := r.ReadLine()
+	if err != nil {
+		return "", false, err
+	}
+	if !isPrefix {
+		return string(b), false, nil
+	}
+	for isPrefix {
+		if _, isPrefix, err = r.ReadLine(); err == io.EOF {
+			break
+		} else if err != nil {
+			return "", true, err
+		}
+	}
+	return "", true, nil
+}
+
+// validateAddress returns the JSON result for one address. Unified addresses
+// start with j (u for Zcash ones), so anything starting with t is checked as a
+// standalone transparent P2PKH address instead.
+func validateAddress(deriver Deriver, address, expectNetwork string) map[string]any {
+	result := map[string]any{
+		"version": jsonVersionV1,
+		"address": address,
+	}
+
+	kind := "unified"
+	var network string
+	var receivers []string
+	var err error
+	if strings.HasPrefix(address, "t") {
+		kind = "transparent"
+		receivers = []string{"p2pkh"}
+		network, err = deriver.ParseTransparentAddress(address)
+	} else {
+		network, receivers, err = deriver.ParseAddress(address)
+	}
+	if err != nil {
+		code, message := errCode(err)
+		result["status"] = "err"
+		result["error"] = code
+		result["message"] = message
+		return result
+	}
+
+	result["type"] = kind
+	result["network"] = network
+	result["receivers"] = receivers
+	// Regtest transparent addresses use the testnet prefix.
+	sameNetwork := network == expectNetwork ||
+		kind == "transparent" && network == "testnet" && expectNetwork == "regtest"
+	if expectNetwork != "" && !sameNetwork {
+		result["status"] = "err"
+		result["error"] = "network_mismatch"
+		result["message"] = fmt.Sprintf("address is for %s, expected %s", network, expectNetwork)
+		return result
+	}
+	result["status"] = "ok"
+	return result
+}
@@ -5,9 +5,11 @@ package e2e
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -16,13 +18,20 @@ type vectorsV1 struct {
 	Version   int      `json:"version"`
 	UFVK      string   `json:"ufvk"`
 	Addresses []string `json:"addresses"`
+	// Transparent is set in v1_transparent.json only.
+	Transparent []string `json:"transparent"`
 }
 
 func loadVectors(t *testing.T) vectorsV1 {
 	t.Helper()
+	return loadVectorsFile(t, "v1.json")
+}
+
+func loadVectorsFile(t *testing.T, name string) vectorsV1 {
+	t.Helper()
 
 	root := filepath.Join("..", "..")
-	b, err := os.ReadFile(filepath.Join(root, "vectors", "v1.json"))
+	b, err := os.ReadFile(filepath.Join(root, "vectors", name))
 	if err != nil {
 		t.Fatalf("read vectors: %v", err)
 	}
@@ -101,3 +110,518 @@ func TestCLI_DeriveAndBatch(t *testing.T) {
 		t.Fatalf("batch mismatch: %q", stdout)
 	}
 }
+
+func TestCLI_BatchParallelMatchesVectors(t *testing.T) {
+	v := loadVectors(t)
+
+	bin := filepath.Join("..", "..", "bin", "juno-addrgen")
+	if _, err := os.Stat(bin); err != nil {
+		t.Fatalf("missing binary: %v", err)
+	}
+
+	stdout, stderr, code := run(t, bin, "batch", "--ufvk", v.UFVK, "--start", "0", "--count", "100", "--jobs", "0")
+	if code != 0 || stderr != "" {
+		t.Fatalf("batch failed: code=%d stderr=%q stdout=%q", code, stderr, stdout)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != len(v.Addresses) {
+		t.Fatalf("unexpected line count: %d", len(lines))
+	}
+	for i := range lines {
+		if lines[i] != v.Addresses[i] {
+			t.Fatalf("mismatch at %d\nwant: %s\ngot:  %s", i, v.Addresses[i], lines[i])
+		}
+	}
+}
+
+func TestCLI_NetworkAssertion(t *testing.T) {
+	v := loadVectors(t)
+
+	bin := filepath.Join("..", "..", "bin", "juno-addrgen")
+	if _, err := os.Stat(bin); err != nil {
+		t.Fatalf("missing binary: %v", err)
+	}
+
+	stdout, stderr, code := run(t, bin, "derive", "--ufvk", v.UFVK, "--index", "0", "--network", "mainnet")
+	if code != 0 || strings.TrimSpace(stdout) != v.Addresses[0] {
+		t.Fatalf("derive failed: code=%d stderr=%q stdout=%q", code, stderr, stdout)
+	}
+
+	stdout, stderr, code = run(t, bin, "batch", "--ufvk", v.UFVK, "--start", "0", "--count", "2", "--network", "testnet", "--json")
+	if code != 1 {
+		t.Fatalf("expected exit code 1: code=%d stderr=%q stdout=%q", code, stderr, stdout)
+	}
+	var resp map[string]any
+	if err := json.Unmarshal([]byte(stdout), &resp); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if resp["status"] != "err" || resp["error"] != "network_mismatch" {
+		t.Fatalf("unexpected json: %v", resp)
+	}
+}
+
+func TestCLI_InternalScopeMatchesVectors(t *testing.T) {
+	v := loadVectorsFile(t, "v1_internal.json")
+
+	bin := filepath.Join("..", "..", "bin", "juno-addrgen")
+	if _, err := os.Stat(bin); err != nil {
+		t.Fatalf("missing binary: %v", err)
+	}
+
+	stdout, stderr, code := run(t, bin, "derive", "--ufvk", v.UFVK, "--index", "5", "--scope", "internal")
+	if code != 0 || strings.TrimSpace(stdout) != v.Addresses[5] {
+		t.Fatalf("derive failed: code=%d stderr=%q stdout=%q", code, stderr, stdout)
+	}
+
+	stdout, stderr, code = run(t, bin, "batch", "--ufvk", v.UFVK, "--start", "0", "--count", "100", "--scope", "internal", "--jobs", "0")
+	if code != 0 || stderr != "" {
+		t.Fatalf("batch failed: code=%d stderr=%q stdout=%q", code, stderr, stdout)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != len(v.Addresses) {
+		t.Fatalf("unexpected address count: %d", len(lines))
+	}
+	for i := range lines {
+		if lines[i] != v.Addresses[i] {
+			t.Fatalf("mismatch at %d\nwant: %s\ngot:  %s", i, v.Addresses[i], lines[i])
+		}
+	}
+}
+
+func TestCLI_WideIndicesMatchVectors(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join("..", "..", "vectors", "v1_wide.json"))
+	if err != nil {
+		t.Fatalf("read vectors: %v", err)
+	}
+	var v struct {
+		UFVK    string `json:"ufvk"`
+		Indexed []struct {
+			Index   string `json:"index"`
+			Address string `json:"address"`
+		} `json:"indexed"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil || len(v.Indexed) < 3 {
+		t.Fatalf("invalid vectors: %v", err)
+	}
+
+	bin := filepath.Join("..", "..", "bin", "juno-addrgen")
+	if _, err := os.Stat(bin); err != nil {
+		t.Fatalf("missing binary: %v", err)
+	}
+
+	for _, tc := range v.Indexed {
+		stdout, stderr, code := run(t, bin, "derive", "--ufvk", v.UFVK, "--index", tc.Index)
+		if code != 0 || strings.TrimSpace(stdout) != tc.Address {
+			t.Fatalf("derive %s failed: code=%d stderr=%q stdout=%q", tc.Index, code, stderr, stdout)
+		}
+	}
+
+	// The first three vectors straddle 2^32.
+	stdout, stderr, code := run(t, bin, "batch", "--ufvk", v.UFVK, "--start", v.Indexed[0].Index, "--count", "3")
+	if code != 0 || stderr != "" {
+		t.Fatalf("batch failed: code=%d stderr=%q stdout=%q", code, stderr, stdout)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	for i, tc := range v.Indexed[:3] {
+		if i >= len(lines) || lines[i] != tc.Address {
+			t.Fatalf("mismatch at %s: %q", tc.Index, stdout)
+		}
+	}
+}
+
+func TestCLI_OwnsRecoversIndexAndScope(t *testing.T) {
+	v := loadVectors(t)
+	internal := loadVectorsFile(t, "v1_internal.json")
+
+	bin := filepath.Join("..", "..", "bin", "juno-addrgen")
+	if _, err := os.Stat(bin); err != nil {
+		t.Fatalf("missing binary: %v", err)
+	}
+
+	stdout, stderr, code := run(t, bin, "owns", "--ufvk", v.UFVK, "--address", v.Addresses[17])
+	if code != 0 || strings.TrimSpace(stdout) != "index=17 scope=external" {
+		t.Fatalf("owns failed: code=%d stderr=%q stdout=%q", code, stderr, stdout)
+	}
+
+	stdout, stderr, code = run(t, bin, "owns", "--ufvk", v.UFVK, "--address", internal.Addresses[3], "--json")
+	if code != 0 {
+		t.Fatalf("owns failed: code=%d stderr=%q stdout=%q", code, stderr, stdout)
+	}
+	var resp map[string]any
+	if err := json.Unmarshal([]byte(stdout), &resp); err != nil {
+		t.Fatalf("invalid json: %v (%q)", err, stdout)
+	}
+	if resp["status"] != "ok" || resp["index"] != float64(3) || resp["scope"] != "internal" {
+		t.Fatalf("unexpected json: %v", resp)
+	}
+
+	// v1 address 0 with one diversifier bit flipped.
+	const foreign = "j17qx3r0gf3sm28ltngthgzu3ke5453e5qnql3pxfkc0skkphqwrex6e6sfml4msnllfsk4z60jq9p2m5dxj4xhnpn0vehp9uhlqc2wdwf"
+	_, stderr, code = run(t, bin, "owns", "--ufvk", v.UFVK, "--address", foreign)
+	if code != 1 || !strings.Contains(stderr, "address_not_owned") {
+		t.Fatalf("unexpected result: code=%d stderr=%q", code, stderr)
+	}
+}
+
+func TestCLI_WhoisRoutesToOwningKey(t *testing.T) {
+	v := loadVectors(t)
+	internal := loadVectorsFile(t, "v1_internal.json")
+
+	bin := filepath.Join("..", "..", "bin", "juno-addrgen")
+	if _, err := os.Stat(bin); err != nil {
+		t.Fatalf("missing binary: %v", err)
+	}
+
+	// "other" is the vectors' FVK with a different rivk, so it owns none of
+	// the vector addresses.
+	const other = "jview1rdqr9e0k6tleza5lnfzwxy9r8uqz3kkjxqejrhw8kvr3mq7typhmcz4hx0n9tnqx3tf0nep28jfuye0a8yrxpwqj466c7wplvzqgrrqdnaeww6jlwv79wjw42nwlysww86c5xjmacndzh57mwnvdf620zx93mfhss08h5hsqn42drzwfzz22xestn2hjv"
+	keyring, err := json.Marshal(map[string]string{"other": other, "vectors": v.UFVK})
+	if err != nil {
+		t.Fatalf("marshal keyring: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := os.WriteFile(path, keyring, 0o600); err != nil {
+		t.Fatalf("write keyring: %v", err)
+	}
+
+	stdout, stderr, code := run(t, bin, "whois", "--keyring", path, "--address", internal.Addresses[42])
+	if code != 0 || strings.TrimSpace(stdout) != "label=vectors index=42 scope=internal" {
+		t.Fatalf("whois failed: code=%d stderr=%q stdout=%q", code, stderr, stdout)
+	}
+
+	const foreign = "j17qx3r0gf3sm28ltngthgzu3ke5453e5qnql3pxfkc0skkphqwrex6e6sfml4msnllfsk4z60jq9p2m5dxj4xhnpn0vehp9uhlqc2wdwf"
+	stdout, _, code = run(t, bin, "whois", "--keyring", path, "--address", foreign, "--json")
+	if code != 1 || !strings.Contains(stdout, `"error":"address_not_owned"`) {
+		t.Fatalf("unexpected result: code=%d stdout=%q", code, stdout)
+	}
+}
+
+func TestCLI_ValidateReportsEachLine(t *testing.T) {
+	v := loadVectors(t)
+
+	bin := filepath.Join("..", "..", "bin", "juno-addrgen")
+	if _, err := os.Stat(bin); err != nil {
+		t.Fatalf("missing binary: %v", err)
+	}
+
+	// v1 address 0's receiver, encoded for Zcash mainnet and for Juno testnet.
+	const zcash = "u1g5cetvll76pmqsfhdlgvwn6e6lnp45ldntkufejcz3r6wu0xk9xerpprxq36wwr2kzggyp8vt6ankx76d68xmvczwld8zjsjsvsfcrtw"
+	const testnet = "jtest158cgua0efdr2jweerrapng6uz48rjmsg2hy69t7peulekq4c6hrytspm0rvpr33kwcrvjgdvsjlkat3pv0gmsqsf3gs7est7ugc7003m"
+	path := filepath.Join(t.TempDir(), "addresses.txt")
+	contents := strings.Join([]string{v.Addresses[0], zcash, testnet, v.UFVK}, "\n")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("write addresses: %v", err)
+	}
+
+	stdout, stderr, code := run(t, bin, "validate", "--file", path, "--expect-network", "mainnet")
+	if code != 1 {
+		t.Fatalf("unexpected exit code: %d (stderr=%q)", code, stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	want := []string{"", "address_zcash", "network_mismatch", "address_hrp_mismatch"}
+	if len(lines) != len(want) {
+		t.Fatalf("unexpected output: %q", stdout)
+	}
+	for i, line := range lines {
+		var resp map[string]any
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("invalid json: %v (%q)", err, line)
+		}
+		if want[i] == "" {
+			if resp["status"] != "ok" || resp["network"] != "mainnet" || fmt.Sprint(resp["receivers"]) != "[orchard]" {
+				t.Fatalf("unexpected line %d: %v", i+1, resp)
+			}
+			continue
+		}
+		if resp["status"] != "err" || resp["error"] != want[i] {
+			t.Fatalf("unexpected line %d: %v", i+1, resp)
+		}
+	}
+
+	_, stderr, code = run(t, bin, "validate", "--address", v.Addresses[1])
+	if code != 0 {
+		t.Fatalf("validate failed: code=%d stderr=%q", code, stderr)
+	}
+}
+
+func TestCLI_InspectNeverPrintsUFVK(t *testing.T) {
+	v := loadVectors(t)
+
+	bin := filepath.Join("..", "..", "bin", "juno-addrgen")
+	if _, err := os.Stat(bin); err != nil {
+		t.Fatalf("missing binary: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "ufvk.txt")
+	if err := os.WriteFile(path, []byte(v.UFVK+"\n"), 0o600); err != nil {
+		t.Fatalf("write ufvk: %v", err)
+	}
+
+	stdout, stderr, code := run(t, bin, "inspect", "--ufvk-file", path)
+	if code != 0 {
+		t.Fatalf("inspect failed: code=%d stderr=%q", code, stderr)
+	}
+	if !strings.Contains(stdout, "network=mainnet\n") || !strings.Contains(stdout, "address="+v.Addresses[0]+" index=0\n") {
+		t.Fatalf("unexpected stdout: %q", stdout)
+	}
+
+	stdout, stderr, code = run(t, bin, "inspect", "--ufvk-file", path, "--json")
+	if code != 0 {
+		t.Fatalf("inspect failed: code=%d stderr=%q", code, stderr)
+	}
+	var resp map[string]any
+	if err := json.Unmarshal([]byte(stdout), &resp); err != nil {
+		t.Fatalf("invalid json: %v (%q)", err, stdout)
+	}
+	if resp["fvk_valid"] != true || resp["address"] != v.Addresses[0] || fmt.Sprint(resp["items"]) != "[map[len:96 name:orchard typecode:3]]" {
+		t.Fatalf("unexpected json: %v", resp)
+	}
+
+	for _, out := range []string{stdout, stderr} {
+		if strings.Contains(out, v.UFVK) || strings.Contains(out, v.UFVK[len(v.UFVK)-20:]) {
+			t.Fatalf("output contains the ufvk")
+		}
+	}
+}
+
+func TestCLI_KeyIDIsTheFingerprint(t *testing.T) {
+	v := loadVectors(t)
+
+	bin := filepath.Join("..", "..", "bin", "juno-addrgen")
+	if _, err := os.Stat(bin); err != nil {
+		t.Fatalf("missing binary: %v", err)
+	}
+
+	// The ZIP-32 Orchard FVK fingerprint of the v1 vectors' UFVK.
+	const want = "e37feab45ad86988f554c78392b95cd1ab94e1e0f407262f3c2477c772414738"
+
+	for _, args := range [][]string{
+		{"derive", "--ufvk", v.UFVK, "--index", "3", "--json"},
+		{"batch", "--ufvk", v.UFVK, "--start", "0", "--count", "2", "--json"},
+		{"inspect", "--ufvk", v.UFVK, "--json"},
+	} {
+		stdout, stderr, code := run(t, bin, args...)
+		if code != 0 {
+			t.Fatalf("%s failed: code=%d stderr=%q", args[0], code, stderr)
+		}
+		var resp map[string]any
+		if err := json.Unmarshal([]byte(stdout), &resp); err != nil {
+			t.Fatalf("invalid json: %v (%q)", err, stdout)
+		}
+		got := resp["key_id"]
+		if args[0] == "inspect" {
+			got = resp["fingerprint"]
+		}
+		if got != want {
+			t.Fatalf("%s: unexpected key id: %v", args[0], got)
+		}
+	}
+}
+
+func TestCLI_ExportUIVKDerivesExternalAddresses(t *testing.T) {
+	v := loadVectors(t)
+
+	bin := filepath.Join("..", "..", "bin", "juno-addrgen")
+	if _, err := os.Stat(bin); err != nil {
+		t.Fatalf("missing binary: %v", err)
+	}
+
+	stdout, stderr, code := run(t, bin, "export-uivk", "--ufvk", v.UFVK, "--json")
+	if code != 0 {
+		t.Fatalf("export-uivk failed: code=%d stderr=%q", code, stderr)
+	}
+	var resp struct {
+		Status  string `json:"status"`
+		UIVK    string `json:"uivk"`
+		Network string `json:"network"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &resp); err != nil {
+		t.Fatalf("invalid json: %v (%q)", err, stdout)
+	}
+	if resp.Status != "ok" || resp.Network != "mainnet" || !strings.HasPrefix(resp.UIVK, "jivk1") {
+		t.Fatalf("unexpected json: %+v", resp)
+	}
+
+	stdout, stderr, code = run(t, bin, "batch", "--uivk", resp.UIVK, "--start", "0", "--count", "3")
+	if code != 0 {
+		t.Fatalf("batch failed: code=%d stderr=%q", code, stderr)
+	}
+	if want := strings.Join(v.Addresses[:3], "\n") + "\n"; stdout != want {
+		t.Fatalf("unexpected stdout: %q", stdout)
+	}
+
+	stdout, stderr, code = run(t, bin, "owns", "--uivk", resp.UIVK, "--address", v.Addresses[2])
+	if code != 0 || stdout != "index=2 scope=external\n" {
+		t.Fatalf("owns failed: code=%d stdout=%q stderr=%q", code, stdout, stderr)
+	}
+
+	_, stderr, code = run(t, bin, "derive", "--uivk", resp.UIVK, "--scope", "internal")
+	if code != 1 || !strings.Contains(stderr, "scope_unavailable") {
+		t.Fatalf("unexpected result: code=%d stderr=%q", code, stderr)
+	}
+}
+
+// saplingVectorsV1 is vectors/v1_sapling.json.
+type saplingVectorsV1 struct {
+	UFVK    string `json:"ufvk"`
+	Indexed []struct {
+		Index   string `json:"index"`
+		Address string `json:"address"`
+	} `json:"indexed"`
+}
+
+func TestCLI_SaplingReceiversReportIndices(t *testing.T) {
+	v := loadVectors(t)
+
+	b, err := os.ReadFile(filepath.Join("..", "..", "vectors", "v1_sapling.json"))
+	if err != nil {
+		t.Fatalf("read vectors: %v", err)
+	}
+	var sv saplingVectorsV1
+	if err := json.Unmarshal(b, &sv); err != nil || len(sv.Indexed) == 0 {
+		t.Fatalf("parse vectors: %v", err)
+	}
+	saplingUFVK := sv.UFVK
+
+	bin := filepath.Join("..", "..", "bin", "juno-addrgen")
+	if _, err := os.Stat(bin); err != nil {
+		t.Fatalf("missing binary: %v", err)
+	}
+
+	stdout, stderr, code := run(t, bin, "batch", "--ufvk", saplingUFVK, "--start", "0", "--count", "16", "--receivers", "orchard,sapling", "--json")
+	if code != 0 {
+		t.Fatalf("batch failed: code=%d stderr=%q", code, stderr)
+	}
+	var batch struct {
+		Status    string `json:"status"`
+		Addresses []struct {
+			Address string `json:"address"`
+			Index   uint32 `json:"index"`
+		} `json:"addresses"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &batch); err != nil {
+		t.Fatalf("invalid json: %v (%q)", err, stdout)
+	}
+	if batch.Status != "ok" {
+		t.Fatalf("unexpected batch: %+v", batch)
+	}
+	// The vectors hold every valid index, in order; the batch holds those
+	// below 16.
+	for i, a := range batch.Addresses {
+		if want := sv.Indexed[i]; a.Address != want.Address || strconv.FormatUint(uint64(a.Index), 10) != want.Index {
+			t.Fatalf("batch %d = %s at %d, want %s at %s", i, a.Address, a.Index, want.Address, want.Index)
+		}
+	}
+	n := len(batch.Addresses)
+	if n == 0 || n == 16 || n == len(sv.Indexed) {
+		t.Fatalf("unexpected batch: %+v", batch)
+	}
+	if after, err := strconv.ParseUint(sv.Indexed[n].Index, 10, 32); err != nil || after < 16 {
+		t.Fatalf("batch stops before index %s", sv.Indexed[n].Index)
+	}
+
+	next := uint32(0)
+	for _, a := range batch.Addresses {
+		stdout, stderr, code = run(t, bin, "derive", "--ufvk", saplingUFVK, "--index", strconv.FormatUint(uint64(next), 10), "--receivers", "orchard,sapling")
+		if want := fmt.Sprintf("address=%s index=%d\n", a.Address, a.Index); code != 0 || stdout != want {
+			t.Fatalf("derive from %d: code=%d stdout=%q stderr=%q, want %q", next, code, stdout, stderr, want)
+		}
+
+		stdout, stderr, code = run(t, bin, "owns", "--ufvk", saplingUFVK, "--address", a.Address)
+		if want := fmt.Sprintf("index=%d scope=external\n", a.Index); code != 0 || stdout != want {
+			t.Fatalf("owns failed: code=%d stdout=%q stderr=%q", code, stdout, stderr)
+		}
+		next = a.Index + 1
+	}
+
+	stdout, _, code = run(t, bin, "validate", "--address", batch.Addresses[0].Address)
+	if code != 0 || !strings.Contains(stdout, `"receivers":["sapling","orchard"]`) {
+		t.Fatalf("validate failed: code=%d stdout=%q", code, stdout)
+	}
+
+	// Orchard-only output is unchanged by the Sapling key.
+	stdout, stderr, code = run(t, bin, "derive", "--ufvk", saplingUFVK, "--index", "4")
+	if code != 0 || stdout != v.Addresses[4]+"\n" {
+		t.Fatalf("derive failed: code=%d stdout=%q stderr=%q", code, stdout, stderr)
+	}
+
+	_, stderr, code = run(t, bin, "derive", "--ufvk", v.UFVK, "--receivers", "orchard,sapling")
+	if code != 1 || !strings.Contains(stderr, "receivers_unavailable") {
+		t.Fatalf("unexpected result: code=%d stderr=%q", code, stderr)
+	}
+}
+
+func TestCLI_TransparentReceivers(t *testing.T) {
+	v := loadVectorsFile(t, "v1_transparent.json")
+
+	bin := filepath.Join("..", "..", "bin", "juno-addrgen")
+	if _, err := os.Stat(bin); err != nil {
+		t.Fatalf("missing binary: %v", err)
+	}
+
+	stdout, stderr, code := run(t, bin, "batch", "--ufvk", v.UFVK, "--start", "0", "--count", "100", "--receivers", "p2pkh")
+	if code != 0 || stdout != strings.Join(v.Transparent, "\n")+"\n" {
+		t.Fatalf("batch --receivers p2pkh failed: code=%d stderr=%q", code, stderr)
+	}
+	stdout, stderr, code = run(t, bin, "batch", "--ufvk", v.UFVK, "--start", "0", "--count", "100", "--receivers", "orchard,p2pkh")
+	if code != 0 || stdout != strings.Join(v.Addresses, "\n")+"\n" {
+		t.Fatalf("batch --receivers orchard,p2pkh failed: code=%d stderr=%q", code, stderr)
+	}
+
+	stdout, stderr, code = run(t, bin, "derive", "--ufvk", v.UFVK, "--index", "9", "--receivers", "p2pkh", "--json")
+	if code != 0 || !strings.Contains(stdout, `"address":"`+v.Transparent[9]+`"`) || !strings.Contains(stdout, `"index":9`) {
+		t.Fatalf("derive failed: code=%d stdout=%q stderr=%q", code, stdout, stderr)
+	}
+
+	stdout, stderr, code = run(t, bin, "owns", "--ufvk", v.UFVK, "--address", v.Addresses[9])
+	if code != 0 || stdout != "index=9 scope=external\n" {
+		t.Fatalf("owns failed: code=%d stdout=%q stderr=%q", code, stdout, stderr)
+	}
+
+	stdout, _, code = run(t, bin, "validate", "--address", v.Addresses[0])
+	if code != 0 || !strings.Contains(stdout, `"receivers":["p2pkh","orchard"]`) {
+		t.Fatalf("validate failed: code=%d stdout=%q", code, stdout)
+	}
+
+	_, stderr, code = run(t, bin, "derive", "--ufvk", v.UFVK, "--index", "2147483648", "--receivers", "p2pkh")
+	if code != 1 || !strings.Contains(stderr, "range_overflow") {
+		t.Fatalf("unexpected result: code=%d stderr=%q", code, stderr)
+	}
+	_, stderr, code = run(t, bin, "derive", "--ufvk", loadVectors(t).UFVK, "--receivers", "p2pkh")
+	if code != 1 || !strings.Contains(stderr, "receivers_unavailable") {
+		t.Fatalf("unexpected result: code=%d stderr=%q", code, stderr)
+	}
+}
+
+func TestCLI_SelftestPassesEveryVector(t *testing.T) {
+	bin := filepath.Join("..", "..", "bin", "juno-addrgen")
+	if _, err := os.Stat(bin); err != nil {
+		t.Fatalf("missing binary: %v", err)
+	}
+
+	stdout, stderr, code := run(t, bin, "selftest", "--json")
+	if code != 0 || stderr != "" {
+		t.Fatalf("selftest failed: code=%d stderr=%q stdout=%q", code, stderr, stdout)
+	}
+	var resp struct {
+		Version string `json:"version"`
+		Status  string `json:"status"`
+		Vectors []struct {
+			Name   string `json:"name"`
+			Status string `json:"status"`
+			Checks int    `json:"checks"`
+		} `json:"vectors"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &resp); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if resp.Version != "v1" || resp.Status != "ok" || len(resp.Vectors) < 9 {
+		t.Fatalf("unexpected json: %s", stdout)
+	}
+	// The Rust library supports every receiver type, so nothing is skipped.
+	for _, v := range resp.Vectors {
+		if v.Status != "pass" || v.Checks == 0 {
+			t.Fatalf("vector %s: status=%s checks=%d", v.Name, v.Status, v.Checks)
+		}
+	}
+}
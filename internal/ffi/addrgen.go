@@ -1,3 +1,5 @@
+//go:build !addrgen_wasm
+
 package ffi
 
 /*
@@ -10,34 +12,342 @@ package ffi
 import "C"
 
 import (
-	"errors"
 	"unsafe"
 )
 
-var errNull = errors.New("addrgen: null response")
+// AddressMaxLen is the size of one address slot in the library's output buffers.
+const AddressMaxLen = C.JUNO_ADDRGEN_ADDRESS_MAX_LEN
+
+// ReceiverLen is the length of a raw Orchard receiver.
+const ReceiverLen = C.JUNO_ADDRGEN_ORCHARD_RECEIVER_LEN
+
+// P2PKHReceiverLen is the length of a raw P2PKH receiver.
+const P2PKHReceiverLen = C.JUNO_ADDRGEN_P2PKH_RECEIVER_LEN
+
+// FingerprintLen is the length of a ZIP-32 Orchard FVK fingerprint.
+const FingerprintLen = C.JUNO_ADDRGEN_FVK_FINGERPRINT_LEN
+
+// SpendingKeyLen is the length of an Orchard spending key.
+const SpendingKeyLen = C.JUNO_ADDRGEN_ORCHARD_SPENDING_KEY_LEN
+
+// IndexLen is the length of a little-endian ZIP-32 diversifier index.
+const IndexLen = C.JUNO_ADDRGEN_DIVERSIFIER_INDEX_LEN
+
+// Scope identifiers accepted by the Key methods.
+const (
+	ScopeExternal uint32 = C.JUNO_ADDRGEN_SCOPE_EXTERNAL
+	ScopeInternal uint32 = C.JUNO_ADDRGEN_SCOPE_INTERNAL
+)
+
+// Receiver-type bits accepted by DeriveReceivers and BatchReceivers.
+const (
+	ReceiversP2PKH   uint32 = C.JUNO_ADDRGEN_RECEIVERS_P2PKH
+	ReceiversSapling uint32 = C.JUNO_ADDRGEN_RECEIVERS_SAPLING
+	ReceiversOrchard uint32 = C.JUNO_ADDRGEN_RECEIVERS_ORCHARD
+)
+
+func statusErr(rc C.int32_t) error {
+	if rc == C.JUNO_ADDRGEN_OK {
+		return nil
+	}
+	return CodeError(C.GoString(C.juno_addrgen_error_name(rc)))
+}
+
+func networkName(id C.uint32_t) string {
+	switch id {
+	case C.JUNO_ADDRGEN_NETWORK_MAINNET:
+		return "mainnet"
+	case C.JUNO_ADDRGEN_NETWORK_TESTNET:
+		return "testnet"
+	case C.JUNO_ADDRGEN_NETWORK_REGTEST:
+		return "regtest"
+	default:
+		return ""
+	}
+}
+
+func networkID(name string) C.uint32_t {
+	switch name {
+	case "mainnet":
+		return C.JUNO_ADDRGEN_NETWORK_MAINNET
+	case "testnet":
+		return C.JUNO_ADDRGEN_NETWORK_TESTNET
+	case "regtest":
+		return C.JUNO_ADDRGEN_NETWORK_REGTEST
+	default:
+		return 0
+	}
+}
+
+func Derive(ufvk string, index uint32) (string, error) {
+	cUFVK := C.CString(ufvk)
+	defer C.free(unsafe.Pointer(cUFVK))
+
+	var buf [AddressMaxLen]byte
+	var n C.size_t
+	rc := C.juno_addrgen_derive(cUFVK, C.uint32_t(index), (*C.char)(unsafe.Pointer(&buf[0])), C.size_t(len(buf)), &n)
+	if err := statusErr(rc); err != nil {
+		return "", err
+	}
+	return string(buf[:n]), nil
+}
+
+func Batch(ufvk string, start uint32, count uint32, jobs uint32) ([]string, error) {
+	cUFVK := C.CString(ufvk)
+	defer C.free(unsafe.Pointer(cUFVK))
+
+	buf, lens := batchBuffers(count)
+	rc := C.juno_addrgen_batch(cUFVK, C.uint32_t(start), C.uint32_t(count), C.uint32_t(jobs), bufPtr(buf), C.size_t(len(buf)), lensPtr(lens))
+	if err := statusErr(rc); err != nil {
+		return nil, err
+	}
+	return splitSlots(buf, lens), nil
+}
+
+// Keygen derives the Orchard spending key of ZIP-32 account account of seed
+// and returns it with its Orchard-only UFVK for network.
+func Keygen(seed []byte, account uint32, network string) (string, [SpendingKeyLen]byte, error) {
+	var seedPtr *C.uint8_t
+	if len(seed) > 0 {
+		seedPtr = (*C.uint8_t)(unsafe.Pointer(&seed[0]))
+	}
+
+	var buf [AddressMaxLen]byte
+	var n C.size_t
+	var sk [SpendingKeyLen]byte
+	rc := C.juno_addrgen_keygen(seedPtr, C.size_t(len(seed)), C.uint32_t(account), networkID(network), (*C.char)(unsafe.Pointer(&buf[0])), C.size_t(len(buf)), &n, (*C.uint8_t)(unsafe.Pointer(&sk[0])))
+	if err := statusErr(rc); err != nil {
+		return "", sk, err
+	}
+	return string(buf[:n]), sk, nil
+}
+
+// DecodeAddress decodes a unified address into its network and raw Orchard
+// receiver.
+func DecodeAddress(address string) (string, [ReceiverLen]byte, error) {
+	cAddr := C.CString(address)
+	defer C.free(unsafe.Pointer(cAddr))
+
+	var network C.uint32_t
+	var receiver [ReceiverLen]byte
+	rc := C.juno_addrgen_address_decode(cAddr, &network, (*C.uint8_t)(unsafe.Pointer(&receiver[0])))
+	if err := statusErr(rc); err != nil {
+		return "", receiver, err
+	}
+	return networkName(network), receiver, nil
+}
+
+// DecodeTransparentAddress decodes a standalone transparent P2PKH address into
+// its network (testnet for tm... addresses) and raw receiver.
+func DecodeTransparentAddress(address string) (string, [P2PKHReceiverLen]byte, error) {
+	cAddr := C.CString(address)
+	defer C.free(unsafe.Pointer(cAddr))
+
+	var network C.uint32_t
+	var receiver [P2PKHReceiverLen]byte
+	rc := C.juno_addrgen_transparent_address_decode(cAddr, &network, (*C.uint8_t)(unsafe.Pointer(&receiver[0])))
+	if err := statusErr(rc); err != nil {
+		return "", receiver, err
+	}
+	return networkName(network), receiver, nil
+}
+
+// ParseAddress decodes every item of a unified address, in encoding order,
+// and reports its network.
+func ParseAddress(address string) (string, []Item, error) {
+	cAddr := C.CString(address)
+	defer C.free(unsafe.Pointer(cAddr))
+
+	var network C.uint32_t
+	var typecodes [C.JUNO_ADDRGEN_ADDRESS_MAX_RECEIVERS]C.uint64_t
+	var lens [C.JUNO_ADDRGEN_ADDRESS_MAX_RECEIVERS]C.size_t
+	var n C.size_t
+	// The decoded items are always shorter than their encoding.
+	data := make([]byte, len(address)+1)
+	rc := C.juno_addrgen_address_parse(cAddr, &network, &typecodes[0], &lens[0], C.size_t(len(typecodes)), &n, (*C.uint8_t)(unsafe.Pointer(&data[0])), C.size_t(len(data)))
+	if err := statusErr(rc); err != nil {
+		return "", nil, err
+	}
+
+	items := make([]Item, n)
+	for i := range items {
+		items[i] = Item{Typecode: uint64(typecodes[i]), Value: data[:lens[i]:lens[i]]}
+		data = data[lens[i]:]
+	}
+	return networkName(network), items, nil
+}
 
-func DeriveJSON(ufvk string, index uint32) (string, error) {
+// InspectUFVK decodes the container of ufvk, reporting its network and items.
+// fvkErr is the error ParseKey would fail with, or nil if ufvk holds a usable
+// Orchard FVK; err is set only if the container itself does not decode.
+func InspectUFVK(ufvk string) (network string, items []ItemInfo, fvkErr error, err error) {
 	cUFVK := C.CString(ufvk)
 	defer C.free(unsafe.Pointer(cUFVK))
 
-	out := C.juno_addrgen_derive_json(cUFVK, C.uint32_t(index))
-	if out == nil {
-		return "", errNull
+	var id C.uint32_t
+	var typecodes [C.JUNO_ADDRGEN_ADDRESS_MAX_RECEIVERS]C.uint64_t
+	var lens [C.JUNO_ADDRGEN_ADDRESS_MAX_RECEIVERS]C.size_t
+	var n C.size_t
+	var fvkStatus C.int32_t
+	rc := C.juno_addrgen_ufvk_inspect(cUFVK, &id, &typecodes[0], &lens[0], C.size_t(len(typecodes)), &n, &fvkStatus)
+	if err := statusErr(rc); err != nil {
+		return "", nil, nil, err
 	}
-	defer C.juno_addrgen_string_free(out)
 
-	return C.GoString(out), nil
+	items = make([]ItemInfo, n)
+	for i := range items {
+		items[i] = ItemInfo{Typecode: uint64(typecodes[i]), Len: int(lens[i])}
+	}
+	return networkName(id), items, statusErr(fvkStatus), nil
+}
+
+// Key wraps a parsed UFVK (or UIVK) handle owned by the Rust library.
+type Key struct {
+	ptr *C.juno_addrgen_key
 }
 
-func BatchJSON(ufvk string, start uint32, count uint32) (string, error) {
+// ParseKey parses a UFVK or UIVK into a key handle and reports its network.
+// The handle must be released with Free.
+func ParseKey(ufvk string) (*Key, string, error) {
 	cUFVK := C.CString(ufvk)
 	defer C.free(unsafe.Pointer(cUFVK))
 
-	out := C.juno_addrgen_batch_json(cUFVK, C.uint32_t(start), C.uint32_t(count))
-	if out == nil {
-		return "", errNull
+	var ptr *C.juno_addrgen_key
+	var network C.uint32_t
+	rc := C.juno_addrgen_key_parse(cUFVK, &ptr, &network)
+	if err := statusErr(rc); err != nil {
+		return nil, "", err
+	}
+	return &Key{ptr: ptr}, networkName(network), nil
+}
+
+// DeriveReceivers derives the address with the given receiver types at the
+// first index at or after index that is valid for all of them, returning that
+// index and the raw Orchard receiver.
+func (k *Key) DeriveReceivers(scope uint32, receivers uint32, index [IndexLen]byte) (string, [IndexLen]byte, [ReceiverLen]byte, error) {
+	var buf [AddressMaxLen]byte
+	var n C.size_t
+	var used [IndexLen]byte
+	var receiver [ReceiverLen]byte
+	rc := C.juno_addrgen_key_derive_receivers(k.ptr, C.uint32_t(scope), C.uint32_t(receivers), indexPtr(&index), (*C.char)(unsafe.Pointer(&buf[0])), C.size_t(len(buf)), &n, indexPtr(&used), (*C.uint8_t)(unsafe.Pointer(&receiver[0])))
+	if err := statusErr(rc); err != nil {
+		return "", used, receiver, err
+	}
+	return string(buf[:n]), used, receiver, nil
+}
+
+// BatchReceivers derives the addresses with the given receiver types at the
+// indices in [start, start+count) valid for all of them, returning their
+// indices and raw Orchard receivers.
+func (k *Key) BatchReceivers(scope uint32, receivers uint32, start [IndexLen]byte, count uint32, jobs uint32) ([]string, [][IndexLen]byte, [][ReceiverLen]byte, error) {
+	buf, lens := batchBuffers(count)
+	indices := make([][IndexLen]byte, len(lens))
+	raw := make([][ReceiverLen]byte, len(lens))
+	var n C.size_t
+	rc := C.juno_addrgen_key_batch_receivers(k.ptr, C.uint32_t(scope), C.uint32_t(receivers), indexPtr(&start), C.uint32_t(count), C.uint32_t(jobs), bufPtr(buf), C.size_t(len(buf)), lensPtr(lens), indexPtr(&indices[0]), (*C.uint8_t)(unsafe.Pointer(&raw[0])), C.size_t(len(raw)*ReceiverLen), &n)
+	if err := statusErr(rc); err != nil {
+		return nil, nil, nil, err
+	}
+	return splitSlots(buf, lens[:n]), indices[:n], raw[:n], nil
+}
+
+// DeriveTransparent derives the standalone transparent P2PKH address at the
+// first BIP-44 address index at or after index that BIP-32 can derive, on the
+// given scope's chain, returning that index.
+func (k *Key) DeriveTransparent(scope uint32, index [IndexLen]byte) (string, [IndexLen]byte, error) {
+	var buf [AddressMaxLen]byte
+	var n C.size_t
+	var used [IndexLen]byte
+	rc := C.juno_addrgen_key_derive_transparent(k.ptr, C.uint32_t(scope), indexPtr(&index), (*C.char)(unsafe.Pointer(&buf[0])), C.size_t(len(buf)), &n, indexPtr(&used))
+	if err := statusErr(rc); err != nil {
+		return "", used, err
+	}
+	return string(buf[:n]), used, nil
+}
+
+// BatchTransparent derives the standalone transparent P2PKH addresses at the
+// BIP-44 address indices in [start, start+count) that BIP-32 can derive, on
+// the given scope's chain, returning their indices.
+func (k *Key) BatchTransparent(scope uint32, start [IndexLen]byte, count uint32, jobs uint32) ([]string, [][IndexLen]byte, error) {
+	buf, lens := batchBuffers(count)
+	indices := make([][IndexLen]byte, len(lens))
+	var n C.size_t
+	rc := C.juno_addrgen_key_batch_transparent(k.ptr, C.uint32_t(scope), indexPtr(&start), C.uint32_t(count), C.uint32_t(jobs), bufPtr(buf), C.size_t(len(buf)), lensPtr(lens), indexPtr(&indices[0]), &n)
+	if err := statusErr(rc); err != nil {
+		return nil, nil, err
+	}
+	return splitSlots(buf, lens[:n]), indices[:n], nil
+}
+
+// Fingerprint returns the key's ZIP-32 Orchard FVK fingerprint, or its IVK
+// fingerprint for a key parsed from a UIVK.
+func (k *Key) Fingerprint() ([FingerprintLen]byte, error) {
+	var fp [FingerprintLen]byte
+	rc := C.juno_addrgen_key_fingerprint(k.ptr, (*C.uint8_t)(unsafe.Pointer(&fp[0])))
+	return fp, statusErr(rc)
+}
+
+// ExportUIVK encodes the key's external-scope IVKs as a UIVK.
+func (k *Key) ExportUIVK() (string, error) {
+	var buf [C.JUNO_ADDRGEN_UIVK_MAX_LEN]byte
+	var n C.size_t
+	rc := C.juno_addrgen_key_export_uivk(k.ptr, (*C.char)(unsafe.Pointer(&buf[0])), C.size_t(len(buf)), &n)
+	if err := statusErr(rc); err != nil {
+		return "", err
+	}
+	return string(buf[:n]), nil
+}
+
+// IndexOf reports the scope and diversifier index at which the key derives
+// address.
+func (k *Key) IndexOf(address string) (uint32, [IndexLen]byte, error) {
+	cAddr := C.CString(address)
+	defer C.free(unsafe.Pointer(cAddr))
+
+	var scope C.uint32_t
+	var index [IndexLen]byte
+	rc := C.juno_addrgen_key_index_of(k.ptr, cAddr, &scope, indexPtr(&index))
+	if err := statusErr(rc); err != nil {
+		return 0, index, err
+	}
+	return uint32(scope), index, nil
+}
+
+// Free releases the handle. It is safe to call more than once.
+func (k *Key) Free() {
+	if k.ptr == nil {
+		return
 	}
-	defer C.juno_addrgen_string_free(out)
+	C.juno_addrgen_key_free(k.ptr)
+	k.ptr = nil
+}
+
+func batchBuffers(count uint32) ([]byte, []C.size_t) {
+	// Invalid counts are rejected by the library before it touches the buffers;
+	// don't allocate for them.
+	if count == 0 || count > maxBufferedCount {
+		return make([]byte, 1), make([]C.size_t, 1)
+	}
+	return make([]byte, int(count)*AddressMaxLen), make([]C.size_t, count)
+}
+
+func bufPtr(buf []byte) *C.char {
+	return (*C.char)(unsafe.Pointer(&buf[0]))
+}
 
-	return C.GoString(out), nil
+func indexPtr(index *[IndexLen]byte) *C.uint8_t {
+	return (*C.uint8_t)(unsafe.Pointer(&index[0]))
+}
+
+func lensPtr(lens []C.size_t) *C.size_t {
+	return &lens[0]
+}
+
+func splitSlots(buf []byte, lens []C.size_t) []string {
+	out := make([]string, len(lens))
+	for i, n := range lens {
+		off := i * AddressMaxLen
+		out[i] = string(buf[off : off+int(n)])
+	}
+	return out
 }
@@ -0,0 +1,599 @@
+//go:build addrgen_wasm
+
+package ffi
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// wasmBinary is the library built for wasm32-unknown-unknown. It is not
+// checked in: `make wasm` builds it and copies it here.
+//
+//go:embed juno_addrgen.wasm
+var wasmBinary []byte
+
+// The constants below mirror juno_addrgen.h, which the cgo backend reads
+// directly.
+
+// AddressMaxLen is the size of one address slot in the library's output buffers.
+const AddressMaxLen = 256
+
+// ReceiverLen is the length of a raw Orchard receiver.
+const ReceiverLen = 43
+
+// P2PKHReceiverLen is the length of a raw P2PKH receiver.
+const P2PKHReceiverLen = 20
+
+// FingerprintLen is the length of a ZIP-32 Orchard FVK fingerprint.
+const FingerprintLen = 32
+
+// SpendingKeyLen is the length of an Orchard spending key.
+const SpendingKeyLen = 32
+
+// IndexLen is the length of a little-endian ZIP-32 diversifier index.
+const IndexLen = 11
+
+// Scope identifiers accepted by the Key methods.
+const (
+	ScopeExternal uint32 = 0
+	ScopeInternal uint32 = 1
+)
+
+// Receiver-type bits accepted by DeriveReceivers and BatchReceivers.
+const (
+	ReceiversP2PKH   uint32 = 0x01
+	ReceiversSapling uint32 = 0x04
+	ReceiversOrchard uint32 = 0x08
+)
+
+const (
+	statusOK            = 0
+	addressMaxReceivers = 16
+	uivkMaxLen          = 512
+
+	networkMainnet = 1
+	networkTestnet = 2
+	networkRegtest = 3
+)
+
+// size_t and pointers are 32 bits on wasm32.
+const (
+	sizeofSizeT   = 4
+	sizeofPointer = 4
+	sizeofUint32  = 4
+	sizeofUint64  = 8
+)
+
+func networkName(id uint32) string {
+	switch id {
+	case networkMainnet:
+		return "mainnet"
+	case networkTestnet:
+		return "testnet"
+	case networkRegtest:
+		return "regtest"
+	default:
+		return ""
+	}
+}
+
+func networkID(name string) uint32 {
+	switch name {
+	case "mainnet":
+		return networkMainnet
+	case "testnet":
+		return networkTestnet
+	case "regtest":
+		return networkRegtest
+	default:
+		return 0
+	}
+}
+
+// instance is the one running copy of the library. A WebAssembly instance
+// runs one call at a time, and key handles point into its memory, so calls
+// are serialized rather than spread over a pool of instances.
+type instance struct {
+	mu  sync.Mutex
+	mod api.Module
+	// trapped is set once a call traps, which is how a Rust panic ends on
+	// wasm32. The instance's state is unknown from then on, so every later
+	// call fails with "internal".
+	trapped bool
+}
+
+var (
+	loadOnce sync.Once
+	loaded   *instance
+	loadErr  error
+)
+
+// load compiles and instantiates the embedded module on first use.
+func load() (*instance, error) {
+	loadOnce.Do(func() {
+		ctx := context.Background()
+		r := wazero.NewRuntime(ctx)
+		mod, err := r.Instantiate(ctx, wasmBinary)
+		if err != nil {
+			r.Close(ctx)
+			loadErr = fmt.Errorf("ffi: instantiate wasm module: %w", err)
+			return
+		}
+		loaded = &instance{mod: mod}
+	})
+	return loaded, loadErr
+}
+
+// call runs f with the instance to itself, then frees whatever f allocated in
+// its memory.
+func call(f func(c *frame) error) error {
+	inst, err := load()
+	if err != nil {
+		return err
+	}
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	if inst.trapped {
+		return CodeError("internal")
+	}
+
+	c := &frame{inst: inst, mem: inst.mod.Memory()}
+	defer c.release()
+	return f(c)
+}
+
+// frame tracks the allocations of one call. Its methods return pointers as
+// uint64 so they can be passed straight to invoke. An allocation failure is
+// sticky: it is returned by the next invoke, so callers can allocate all their
+// buffers before checking for errors.
+type frame struct {
+	inst   *instance
+	mem    api.Memory
+	allocs [][2]uint64
+	err    error
+}
+
+func (c *frame) invoke(name string, args ...uint64) (uint64, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	if c.inst.trapped {
+		return 0, CodeError("internal")
+	}
+	fn := c.inst.mod.ExportedFunction(name)
+	if fn == nil {
+		return 0, fmt.Errorf("ffi: wasm module does not export %s", name)
+	}
+	res, err := fn.Call(context.Background(), args...)
+	if err != nil {
+		c.inst.trapped = true
+		return 0, CodeError("internal")
+	}
+	if len(res) == 0 {
+		return 0, nil
+	}
+	return res[0], nil
+}
+
+// status calls a binary-ABI function and converts its return code.
+func (c *frame) status(name string, args ...uint64) error {
+	rc, err := c.invoke(name, args...)
+	if err != nil {
+		return err
+	}
+	return c.statusErr(api.DecodeI32(rc))
+}
+
+func (c *frame) statusErr(rc int32) error {
+	if rc == statusOK {
+		return nil
+	}
+	name, err := c.invoke("juno_addrgen_error_name", api.EncodeI32(rc))
+	if err != nil {
+		return err
+	}
+	return CodeError(c.cstringAt(uint32(name)))
+}
+
+func (c *frame) alloc(n int) uint64 {
+	if c.err != nil {
+		return 0
+	}
+	ptr, err := c.invoke("juno_addrgen_wasm_alloc", uint64(n))
+	if err == nil && ptr == 0 {
+		err = CodeError("internal")
+	}
+	if err != nil {
+		c.err = err
+		return 0
+	}
+	c.allocs = append(c.allocs, [2]uint64{ptr, uint64(n)})
+	return ptr
+}
+
+// cstring copies s into the instance's memory, NUL-terminated.
+func (c *frame) cstring(s string) uint64 {
+	ptr := c.alloc(len(s) + 1)
+	if ptr != 0 {
+		c.write(ptr, append([]byte(s), 0))
+	}
+	return ptr
+}
+
+// bytesIn copies b into the instance's memory.
+func (c *frame) bytesIn(b []byte) uint64 {
+	ptr := c.alloc(len(b))
+	if ptr != 0 {
+		c.write(ptr, b)
+	}
+	return ptr
+}
+
+func (c *frame) write(ptr uint64, b []byte) {
+	if !c.mem.Write(uint32(ptr), b) {
+		panic("ffi: wasm memory write out of range")
+	}
+}
+
+// bytes returns a copy of the n bytes at ptr.
+func (c *frame) bytes(ptr uint64, n int) []byte {
+	b, ok := c.mem.Read(uint32(ptr), uint32(n))
+	if !ok {
+		panic("ffi: wasm memory read out of range")
+	}
+	return append([]byte(nil), b...)
+}
+
+func (c *frame) string(ptr uint64, n int) string {
+	return string(c.bytes(ptr, n))
+}
+
+func (c *frame) uint32At(ptr uint64) uint32 {
+	v, ok := c.mem.ReadUint32Le(uint32(ptr))
+	if !ok {
+		panic("ffi: wasm memory read out of range")
+	}
+	return v
+}
+
+func (c *frame) uint64At(ptr uint64) uint64 {
+	v, ok := c.mem.ReadUint64Le(uint32(ptr))
+	if !ok {
+		panic("ffi: wasm memory read out of range")
+	}
+	return v
+}
+
+// size reads the size_t at ptr.
+func (c *frame) size(ptr uint64) int {
+	return int(c.uint32At(ptr))
+}
+
+func (c *frame) cstringAt(ptr uint32) string {
+	var b []byte
+	for {
+		ch, ok := c.mem.ReadByte(ptr)
+		if !ok {
+			panic("ffi: wasm memory read out of range")
+		}
+		if ch == 0 {
+			return string(b)
+		}
+		b = append(b, ch)
+		ptr++
+	}
+}
+
+// slots reads n addresses written to buf, with their lengths in the size_t
+// array lens.
+func (c *frame) slots(buf, lens uint64, n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = c.string(buf+uint64(i*AddressMaxLen), c.size(lens+uint64(i*sizeofSizeT)))
+	}
+	return out
+}
+
+func (c *frame) release() {
+	if c.inst.trapped {
+		return
+	}
+	// A failed allocation must not stop the others from being freed.
+	c.err = nil
+	for _, a := range c.allocs {
+		if _, err := c.invoke("juno_addrgen_wasm_free", a[0], a[1]); err != nil {
+			return
+		}
+	}
+}
+
+// bufferedSlots is the number of address slots to allocate for a batch of
+// count. Invalid counts are rejected by the library before it touches the
+// buffers; don't allocate for them.
+func bufferedSlots(count uint32) int {
+	if count == 0 || count > maxBufferedCount {
+		return 1
+	}
+	return int(count)
+}
+
+func Derive(ufvk string, index uint32) (address string, err error) {
+	err = call(func(c *frame) error {
+		cUFVK, buf, n := c.cstring(ufvk), c.alloc(AddressMaxLen), c.alloc(sizeofSizeT)
+		if err := c.status("juno_addrgen_derive", cUFVK, api.EncodeU32(index), buf, AddressMaxLen, n); err != nil {
+			return err
+		}
+		address = c.string(buf, c.size(n))
+		return nil
+	})
+	return address, err
+}
+
+func Batch(ufvk string, start uint32, count uint32, jobs uint32) (addresses []string, err error) {
+	err = call(func(c *frame) error {
+		slots := bufferedSlots(count)
+		cUFVK, buf, lens := c.cstring(ufvk), c.alloc(slots*AddressMaxLen), c.alloc(slots*sizeofSizeT)
+		if err := c.status("juno_addrgen_batch", cUFVK, api.EncodeU32(start), api.EncodeU32(count), api.EncodeU32(jobs), buf, uint64(slots*AddressMaxLen), lens); err != nil {
+			return err
+		}
+		addresses = c.slots(buf, lens, slots)
+		return nil
+	})
+	return addresses, err
+}
+
+// Keygen derives the Orchard spending key of ZIP-32 account account of seed
+// and returns it with its Orchard-only UFVK for network.
+func Keygen(seed []byte, account uint32, network string) (ufvk string, sk [SpendingKeyLen]byte, err error) {
+	err = call(func(c *frame) error {
+		cSeed, buf, n, cSK := c.bytesIn(seed), c.alloc(AddressMaxLen), c.alloc(sizeofSizeT), c.alloc(SpendingKeyLen)
+		if err := c.status("juno_addrgen_keygen", cSeed, uint64(len(seed)), api.EncodeU32(account), api.EncodeU32(networkID(network)), buf, AddressMaxLen, n, cSK); err != nil {
+			return err
+		}
+		ufvk = c.string(buf, c.size(n))
+		copy(sk[:], c.bytes(cSK, SpendingKeyLen))
+		return nil
+	})
+	return ufvk, sk, err
+}
+
+// DecodeAddress decodes a unified address into its network and raw Orchard
+// receiver.
+func DecodeAddress(address string) (network string, receiver [ReceiverLen]byte, err error) {
+	err = call(func(c *frame) error {
+		cAddr, id, raw := c.cstring(address), c.alloc(sizeofUint32), c.alloc(ReceiverLen)
+		if err := c.status("juno_addrgen_address_decode", cAddr, id, raw); err != nil {
+			return err
+		}
+		network = networkName(c.uint32At(id))
+		copy(receiver[:], c.bytes(raw, ReceiverLen))
+		return nil
+	})
+	return network, receiver, err
+}
+
+// DecodeTransparentAddress decodes a standalone transparent P2PKH address into
+// its network (testnet for tm... addresses) and raw receiver.
+func DecodeTransparentAddress(address string) (network string, receiver [P2PKHReceiverLen]byte, err error) {
+	err = call(func(c *frame) error {
+		cAddr, id, raw := c.cstring(address), c.alloc(sizeofUint32), c.alloc(P2PKHReceiverLen)
+		if err := c.status("juno_addrgen_transparent_address_decode", cAddr, id, raw); err != nil {
+			return err
+		}
+		network = networkName(c.uint32At(id))
+		copy(receiver[:], c.bytes(raw, P2PKHReceiverLen))
+		return nil
+	})
+	return network, receiver, err
+}
+
+// ParseAddress decodes every item of a unified address, in encoding order,
+// and reports its network.
+func ParseAddress(address string) (network string, items []Item, err error) {
+	err = call(func(c *frame) error {
+		cAddr, id := c.cstring(address), c.alloc(sizeofUint32)
+		typecodes, lens := c.alloc(addressMaxReceivers*sizeofUint64), c.alloc(addressMaxReceivers*sizeofSizeT)
+		n := c.alloc(sizeofSizeT)
+		// The decoded items are always shorter than their encoding.
+		dataCap := len(address) + 1
+		data := c.alloc(dataCap)
+		if err := c.status("juno_addrgen_address_parse", cAddr, id, typecodes, lens, addressMaxReceivers, n, data, uint64(dataCap)); err != nil {
+			return err
+		}
+
+		network = networkName(c.uint32At(id))
+		items = make([]Item, c.size(n))
+		for i := range items {
+			size := c.size(lens + uint64(i*sizeofSizeT))
+			items[i] = Item{Typecode: c.uint64At(typecodes + uint64(i*sizeofUint64)), Value: c.bytes(data, size)}
+			data += uint64(size)
+		}
+		return nil
+	})
+	return network, items, err
+}
+
+// InspectUFVK decodes the container of ufvk, reporting its network and items.
+// fvkErr is the error ParseKey would fail with, or nil if ufvk holds a usable
+// Orchard FVK; err is set only if the container itself does not decode.
+func InspectUFVK(ufvk string) (network string, items []ItemInfo, fvkErr error, err error) {
+	err = call(func(c *frame) error {
+		cUFVK, id := c.cstring(ufvk), c.alloc(sizeofUint32)
+		typecodes, lens := c.alloc(addressMaxReceivers*sizeofUint64), c.alloc(addressMaxReceivers*sizeofSizeT)
+		n, fvkStatus := c.alloc(sizeofSizeT), c.alloc(sizeofUint32)
+		if err := c.status("juno_addrgen_ufvk_inspect", cUFVK, id, typecodes, lens, addressMaxReceivers, n, fvkStatus); err != nil {
+			return err
+		}
+
+		network = networkName(c.uint32At(id))
+		items = make([]ItemInfo, c.size(n))
+		for i := range items {
+			items[i] = ItemInfo{Typecode: c.uint64At(typecodes + uint64(i*sizeofUint64)), Len: c.size(lens + uint64(i*sizeofSizeT))}
+		}
+		fvkErr = c.statusErr(int32(c.uint32At(fvkStatus)))
+		return nil
+	})
+	return network, items, fvkErr, err
+}
+
+// Key wraps a parsed UFVK (or UIVK) handle owned by the Rust library, as a
+// pointer into the instance's memory.
+type Key struct {
+	ptr uint32
+}
+
+// ParseKey parses a UFVK or UIVK into a key handle and reports its network.
+// The handle must be released with Free.
+func ParseKey(ufvk string) (key *Key, network string, err error) {
+	err = call(func(c *frame) error {
+		cUFVK, ptr, id := c.cstring(ufvk), c.alloc(sizeofPointer), c.alloc(sizeofUint32)
+		if err := c.status("juno_addrgen_key_parse", cUFVK, ptr, id); err != nil {
+			return err
+		}
+		key, network = &Key{ptr: c.uint32At(ptr)}, networkName(c.uint32At(id))
+		return nil
+	})
+	return key, network, err
+}
+
+// DeriveReceivers derives the address with the given receiver types at the
+// first index at or after index that is valid for all of them, returning that
+// index and the raw Orchard receiver.
+func (k *Key) DeriveReceivers(scope uint32, receivers uint32, index [IndexLen]byte) (address string, used [IndexLen]byte, receiver [ReceiverLen]byte, err error) {
+	err = call(func(c *frame) error {
+		in, buf, n := c.bytesIn(index[:]), c.alloc(AddressMaxLen), c.alloc(sizeofSizeT)
+		out, raw := c.alloc(IndexLen), c.alloc(ReceiverLen)
+		if err := c.status("juno_addrgen_key_derive_receivers", uint64(k.ptr), api.EncodeU32(scope), api.EncodeU32(receivers), in, buf, AddressMaxLen, n, out, raw); err != nil {
+			return err
+		}
+		address = c.string(buf, c.size(n))
+		copy(used[:], c.bytes(out, IndexLen))
+		copy(receiver[:], c.bytes(raw, ReceiverLen))
+		return nil
+	})
+	return address, used, receiver, err
+}
+
+// BatchReceivers derives the addresses with the given receiver types at the
+// indices in [start, start+count) valid for all of them, returning their
+// indices and raw Orchard receivers.
+func (k *Key) BatchReceivers(scope uint32, receivers uint32, start [IndexLen]byte, count uint32, jobs uint32) (addresses []string, indices [][IndexLen]byte, raw [][ReceiverLen]byte, err error) {
+	err = call(func(c *frame) error {
+		slots := bufferedSlots(count)
+		in, buf, lens := c.bytesIn(start[:]), c.alloc(slots*AddressMaxLen), c.alloc(slots*sizeofSizeT)
+		out, rawOut, n := c.alloc(slots*IndexLen), c.alloc(slots*ReceiverLen), c.alloc(sizeofSizeT)
+		if err := c.status("juno_addrgen_key_batch_receivers", uint64(k.ptr), api.EncodeU32(scope), api.EncodeU32(receivers), in, api.EncodeU32(count), api.EncodeU32(jobs), buf, uint64(slots*AddressMaxLen), lens, out, rawOut, uint64(slots*ReceiverLen), n); err != nil {
+			return err
+		}
+
+		derived := c.size(n)
+		addresses = c.slots(buf, lens, derived)
+		indices = make([][IndexLen]byte, derived)
+		raw = make([][ReceiverLen]byte, derived)
+		for i := range derived {
+			copy(indices[i][:], c.bytes(out+uint64(i*IndexLen), IndexLen))
+			copy(raw[i][:], c.bytes(rawOut+uint64(i*ReceiverLen), ReceiverLen))
+		}
+		return nil
+	})
+	return addresses, indices, raw, err
+}
+
+// DeriveTransparent derives the standalone transparent P2PKH address at the
+// first BIP-44 address index at or after index that BIP-32 can derive, on the
+// given scope's chain, returning that index.
+func (k *Key) DeriveTransparent(scope uint32, index [IndexLen]byte) (address string, used [IndexLen]byte, err error) {
+	err = call(func(c *frame) error {
+		in, buf, n, out := c.bytesIn(index[:]), c.alloc(AddressMaxLen), c.alloc(sizeofSizeT), c.alloc(IndexLen)
+		if err := c.status("juno_addrgen_key_derive_transparent", uint64(k.ptr), api.EncodeU32(scope), in, buf, AddressMaxLen, n, out); err != nil {
+			return err
+		}
+		address = c.string(buf, c.size(n))
+		copy(used[:], c.bytes(out, IndexLen))
+		return nil
+	})
+	return address, used, err
+}
+
+// BatchTransparent derives the standalone transparent P2PKH addresses at the
+// BIP-44 address indices in [start, start+count) that BIP-32 can derive, on
+// the given scope's chain, returning their indices.
+func (k *Key) BatchTransparent(scope uint32, start [IndexLen]byte, count uint32, jobs uint32) (addresses []string, indices [][IndexLen]byte, err error) {
+	err = call(func(c *frame) error {
+		slots := bufferedSlots(count)
+		in, buf, lens := c.bytesIn(start[:]), c.alloc(slots*AddressMaxLen), c.alloc(slots*sizeofSizeT)
+		out, n := c.alloc(slots*IndexLen), c.alloc(sizeofSizeT)
+		if err := c.status("juno_addrgen_key_batch_transparent", uint64(k.ptr), api.EncodeU32(scope), in, api.EncodeU32(count), api.EncodeU32(jobs), buf, uint64(slots*AddressMaxLen), lens, out, n); err != nil {
+			return err
+		}
+
+		derived := c.size(n)
+		addresses = c.slots(buf, lens, derived)
+		indices = make([][IndexLen]byte, derived)
+		for i := range derived {
+			copy(indices[i][:], c.bytes(out+uint64(i*IndexLen), IndexLen))
+		}
+		return nil
+	})
+	return addresses, indices, err
+}
+
+// Fingerprint returns the key's ZIP-32 Orchard FVK fingerprint, or its IVK
+// fingerprint for a key parsed from a UIVK.
+func (k *Key) Fingerprint() (fp [FingerprintLen]byte, err error) {
+	err = call(func(c *frame) error {
+		out := c.alloc(FingerprintLen)
+		if err := c.status("juno_addrgen_key_fingerprint", uint64(k.ptr), out); err != nil {
+			return err
+		}
+		copy(fp[:], c.bytes(out, FingerprintLen))
+		return nil
+	})
+	return fp, err
+}
+
+// ExportUIVK encodes the key's external-scope IVKs as a UIVK.
+func (k *Key) ExportUIVK() (uivk string, err error) {
+	err = call(func(c *frame) error {
+		buf, n := c.alloc(uivkMaxLen), c.alloc(sizeofSizeT)
+		if err := c.status("juno_addrgen_key_export_uivk", uint64(k.ptr), buf, uivkMaxLen, n); err != nil {
+			return err
+		}
+		uivk = c.string(buf, c.size(n))
+		return nil
+	})
+	return uivk, err
+}
+
+// IndexOf reports the scope and diversifier index at which the key derives
+// address.
+func (k *Key) IndexOf(address string) (scope uint32, index [IndexLen]byte, err error) {
+	err = call(func(c *frame) error {
+		cAddr, scopeOut, out := c.cstring(address), c.alloc(sizeofUint32), c.alloc(IndexLen)
+		if err := c.status("juno_addrgen_key_index_of", uint64(k.ptr), cAddr, scopeOut, out); err != nil {
+			return err
+		}
+		scope = c.uint32At(scopeOut)
+		copy(index[:], c.bytes(out, IndexLen))
+		return nil
+	})
+	return scope, index, err
+}
+
+// Free releases the handle. It is safe to call more than once.
+func (k *Key) Free() {
+	if k.ptr == 0 {
+		return
+	}
+	_ = call(func(c *frame) error {
+		_, err := c.invoke("juno_addrgen_key_free", uint64(k.ptr))
+		return err
+	})
+	k.ptr = 0
+}
@@ -0,0 +1,28 @@
+// Package ffi binds the Rust library. By default it links the static library
+// with cgo (addrgen.go); built with the addrgen_wasm tag, it instead runs the
+// library compiled to WebAssembly in a pure-Go runtime (addrgen_wazero.go).
+// Both expose the same API and give the same results.
+package ffi
+
+// CodeError is a non-zero status returned by the library, carrying its stable
+// name (e.g. "ufvk_empty").
+type CodeError string
+
+func (e CodeError) Error() string {
+	return string(e)
+}
+
+// Item is one typecode/value item of a unified address.
+type Item struct {
+	Typecode uint64
+	Value    []byte
+}
+
+// ItemInfo describes one item of a UFVK without its (sensitive) value.
+type ItemInfo struct {
+	Typecode uint64
+	Len      int
+}
+
+// maxBufferedCount mirrors the library's per-call batch limit.
+const maxBufferedCount = 100_000
@@ -0,0 +1,561 @@
+package native
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Abdullah1738/juno-addrgen/pkg/diversifier"
+	"github.com/Abdullah1738/juno-addrgen/pkg/orchard"
+	"github.com/Abdullah1738/juno-addrgen/pkg/zip316"
+)
+
+// The error codes this package returns: the Rust library's, plus
+// codeReceiversUnsupported.
+const (
+	codeUFVKEmpty                  = "ufvk_empty"
+	codeUFVKInvalidBech32m         = "ufvk_invalid_bech32m"
+	codeUFVKHrpMismatch            = "ufvk_hrp_mismatch"
+	codeUFVKTlvInvalid             = "ufvk_tlv_invalid"
+	codeUFVKTypecodeUnsupported    = "ufvk_typecode_unsupported"
+	codeUFVKValueLenInvalid        = "ufvk_value_len_invalid"
+	codeUFVKFVKBytesInvalid        = "ufvk_fvk_bytes_invalid"
+	codeCountZero                  = "count_zero"
+	codeRangeOverflow              = "range_overflow"
+	codeAddressEmpty               = "address_empty"
+	codeAddressInvalidBech32m      = "address_invalid_bech32m"
+	codeAddressHrpMismatch         = "address_hrp_mismatch"
+	codeAddressTlvInvalid          = "address_tlv_invalid"
+	codeAddressTypecodeUnsupported = "address_typecode_unsupported"
+	codeAddressValueLenInvalid     = "address_value_len_invalid"
+	codeAddressReceiverInvalid     = "address_receiver_invalid"
+	codeAddressNotOwned            = "address_not_owned"
+	codeAddressChecksumInvalid     = "address_checksum_invalid"
+	codeAddressZcash               = "address_zcash"
+	codeAddressPaddingInvalid      = "address_padding_invalid"
+	codeAddressTypecodeDuplicate   = "address_typecode_duplicate"
+	codeAddressReceiversUnknown    = "address_receivers_unknown"
+	codeAddressTransparentOnly     = "address_transparent_only"
+	codeAddressP2PKHAndP2SH        = "address_p2pkh_and_p2sh"
+	codeAddressTransparentInvalid  = "address_transparent_invalid"
+	codeScopeInvalid               = "scope_invalid"
+	codeScopeUnavailable           = "scope_unavailable"
+	codeReceiversInvalid           = "receivers_invalid"
+	codeReceiversUnavailable       = "receivers_unavailable"
+	codeSeedInvalid                = "seed_invalid"
+	codeAccountInvalid             = "account_invalid"
+	codeSpendingKeyInvalid         = "spending_key_invalid"
+	codeNetworkUnknown             = "network_unknown"
+	codeKeyRingLabelEmpty          = "keyring_label_empty"
+	codeKeyRingLabelDuplicate      = "keyring_label_duplicate"
+	codeKeyRingKeyDuplicate        = "keyring_key_duplicate"
+	codeInternal                   = "internal"
+	// codeReceiversUnsupported is returned for Sapling and P2PKH receivers,
+	// which this backend cannot derive or check, and for the UIVKs of keys
+	// with Sapling or P2PKH items, which it cannot compute.
+	codeReceiversUnsupported = "receivers_unsupported"
+)
+
+// Error is a failure with one of the codes above.
+type Error struct {
+	Code string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("native: %s", e.Code)
+}
+
+func (e *Error) CodeString() string {
+	return e.Code
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return e.Code == t.Code
+}
+
+// KeyRingError reports which --keyring entry an error is about.
+type KeyRingError struct {
+	Label string
+	Err   error
+}
+
+func (e *KeyRingError) Error() string {
+	return fmt.Sprintf("keyring entry %q: %v", e.Label, e.Err)
+}
+
+func (e *KeyRingError) Unwrap() error {
+	return e.Err
+}
+
+// Typecodes of ZIP-316 items.
+const (
+	typecodeP2PKH   = 0
+	typecodeP2SH    = 1
+	typecodeSapling = 2
+	typecodeOrchard = 3
+)
+
+// Lengths of the UFVK and UIVK items this backend does not decode, and of
+// transparent receivers. A transparent item is 65 bytes in both.
+const (
+	saplingDFVKLen      = 128
+	saplingIVKLen       = 64
+	transparentKeyLen   = 65
+	transparentRecvLen  = 20
+	shieldedReceiverLen = orchard.RawAddressLen
+)
+
+// maxTransparentIndex is the largest non-hardened BIP-32 index, which caps
+// the diversifier indices that carry a P2PKH receiver.
+const maxTransparentIndex = 1<<31 - 1
+
+// network holds the HRPs of one Juno network.
+type network struct {
+	name    string
+	ufvk    string
+	uivk    string
+	address string
+}
+
+var networks = []network{
+	{name: "mainnet", ufvk: "jview", uivk: "jivk", address: "j"},
+	{name: "testnet", ufvk: "jviewtest", uivk: "jivktest", address: "jtest"},
+	{name: "regtest", ufvk: "jviewregtest", uivk: "jivkregtest", address: "jregtest"},
+}
+
+// zcashAddressHRPs are Zcash's unified address HRPs, reported as
+// "address_zcash".
+var zcashAddressHRPs = []string{"u", "utest", "uregtest"}
+
+// key is a decoded UFVK or UIVK. A key decoded from a UIVK has no FVK and
+// derives external addresses only.
+type key struct {
+	network     *network
+	fvk         *orchard.FullViewingKey
+	ivk         *orchard.IncomingViewingKey
+	sapling     bool
+	transparent bool
+
+	// saplingErr and transparentErr hold the error of a malformed Sapling or
+	// transparent item, reported only by the calls that need that item.
+	saplingErr     error
+	transparentErr error
+}
+
+// parseKey decodes a UFVK, or failing that a UIVK, with the Rust library's
+// rules and error codes. Sapling and transparent items are checked for
+// length only, and only when their receivers are requested.
+func parseKey(s string) (*key, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, &Error{Code: codeUFVKEmpty}
+	}
+	k, err := parseUFVK(s)
+	if errors.Is(err, &Error{Code: codeUFVKHrpMismatch}) {
+		return parseUIVK(s)
+	}
+	return k, err
+}
+
+// decodeKeyContainer decodes s with the first network HRP hrp accepts, and
+// returns that network.
+func decodeKeyContainer(s string, hrp func(n *network) string) (*network, []zip316.Item, error) {
+	for i := range networks {
+		n := &networks[i]
+		items, err := zip316.DecodeTLVContainer(hrp(n), s)
+		if errors.Is(err, zip316.ErrHRPMismatch) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, keyErr(err)
+		}
+		return n, items, nil
+	}
+	return nil, nil, &Error{Code: codeUFVKHrpMismatch}
+}
+
+func parseUFVK(s string) (*key, error) {
+	n, items, err := decodeKeyContainer(s, func(n *network) string { return n.ufvk })
+	if err != nil {
+		return nil, err
+	}
+
+	// Items are set, possibly to an empty value, once seen.
+	var orchardItem, saplingItem, transparentItem *[]byte
+	for _, item := range items {
+		var slot **[]byte
+		switch item.Typecode {
+		case typecodeOrchard:
+			slot = &orchardItem
+		case typecodeSapling:
+			slot = &saplingItem
+		case typecodeP2PKH:
+			slot = &transparentItem
+		default:
+			continue
+		}
+		if *slot != nil {
+			return nil, &Error{Code: codeUFVKTlvInvalid}
+		}
+		*slot = &item.Value
+	}
+
+	if orchardItem == nil {
+		return nil, &Error{Code: codeUFVKTypecodeUnsupported}
+	}
+	if len(*orchardItem) != orchard.FullViewingKeyLen {
+		return nil, &Error{Code: codeUFVKValueLenInvalid}
+	}
+	fvk, err := orchard.NewFullViewingKey(*orchardItem)
+	if err != nil {
+		return nil, &Error{Code: codeUFVKFVKBytesInvalid}
+	}
+	k := &key{
+		network:     n,
+		fvk:         fvk,
+		ivk:         fvk.IncomingViewingKey(orchard.ScopeExternal),
+		sapling:     saplingItem != nil,
+		transparent: transparentItem != nil,
+	}
+	if saplingItem != nil && len(*saplingItem) != saplingDFVKLen {
+		k.saplingErr = &Error{Code: codeUFVKValueLenInvalid}
+	}
+	if transparentItem != nil && len(*transparentItem) != transparentKeyLen {
+		k.transparentErr = &Error{Code: codeUFVKValueLenInvalid}
+	}
+	return k, nil
+}
+
+func parseUIVK(s string) (*key, error) {
+	n, items, err := decodeKeyContainer(s, func(n *network) string { return n.uivk })
+	if err != nil {
+		return nil, err
+	}
+
+	// Items are set, possibly to an empty value, once seen.
+	var orchardItem, saplingItem, transparentItem *[]byte
+	for _, item := range items {
+		var slot **[]byte
+		switch item.Typecode {
+		case typecodeOrchard:
+			slot = &orchardItem
+		case typecodeSapling:
+			slot = &saplingItem
+		case typecodeP2PKH:
+			slot = &transparentItem
+		default:
+			continue
+		}
+		if *slot != nil {
+			return nil, &Error{Code: codeUFVKTlvInvalid}
+		}
+		*slot = &item.Value
+	}
+
+	if orchardItem == nil {
+		return nil, &Error{Code: codeUFVKTypecodeUnsupported}
+	}
+	if len(*orchardItem) != orchard.IncomingViewingKeyLen {
+		return nil, &Error{Code: codeUFVKValueLenInvalid}
+	}
+	ivk, err := orchard.NewIncomingViewingKey(*orchardItem)
+	if err != nil {
+		return nil, &Error{Code: codeUFVKFVKBytesInvalid}
+	}
+	k := &key{
+		network:     n,
+		ivk:         ivk,
+		sapling:     saplingItem != nil,
+		transparent: transparentItem != nil,
+	}
+	if saplingItem != nil && len(*saplingItem) != saplingIVKLen {
+		k.saplingErr = &Error{Code: codeUFVKValueLenInvalid}
+	}
+	if transparentItem != nil && len(*transparentItem) != transparentKeyLen {
+		k.transparentErr = &Error{Code: codeUFVKValueLenInvalid}
+	}
+	return k, nil
+}
+
+// scoped returns the key's IVK in scope ("" means external).
+func (k *key) scoped(scope string) (*orchard.IncomingViewingKey, error) {
+	switch scope {
+	case "", "external":
+		return k.ivk, nil
+	case "internal":
+		if k.fvk == nil {
+			return nil, &Error{Code: codeScopeUnavailable}
+		}
+		return k.fvk.IncomingViewingKey(orchard.ScopeInternal), nil
+	default:
+		return nil, &Error{Code: codeScopeInvalid}
+	}
+}
+
+// checkReceivers applies the library's checks on the requested receiver
+// types, in its order, then turns down the ones this backend cannot derive.
+func (k *key) checkReceivers(scope string, receivers []string) error {
+	var hasOrchard, hasSapling, hasP2PKH bool
+	for _, r := range receivers {
+		switch r {
+		case "orchard":
+			hasOrchard = true
+		case "sapling":
+			hasSapling = true
+		case "p2pkh":
+			hasP2PKH = true
+		default:
+			return &Error{Code: codeReceiversInvalid}
+		}
+	}
+	if len(receivers) == 1 && hasP2PKH {
+		if !k.transparent {
+			return &Error{Code: codeReceiversUnavailable}
+		}
+		if k.transparentErr != nil {
+			return k.transparentErr
+		}
+		return &Error{Code: codeReceiversUnsupported}
+	}
+	if len(receivers) > 0 && !hasOrchard {
+		return &Error{Code: codeReceiversInvalid}
+	}
+	if hasSapling {
+		if !k.sapling {
+			return &Error{Code: codeReceiversUnavailable}
+		}
+		if k.saplingErr != nil {
+			return k.saplingErr
+		}
+		if scope == "internal" {
+			return &Error{Code: codeScopeUnavailable}
+		}
+		return &Error{Code: codeReceiversUnsupported}
+	}
+	if hasP2PKH {
+		if !k.transparent {
+			return &Error{Code: codeReceiversUnavailable}
+		}
+		if k.transparentErr != nil {
+			return k.transparentErr
+		}
+		return &Error{Code: codeReceiversUnsupported}
+	}
+	return nil
+}
+
+// encodeAddress encodes an Orchard-only unified address on the key's
+// network.
+func (k *key) encodeAddress(raw [orchard.RawAddressLen]byte) (string, error) {
+	address, err := zip316.EncodeUnifiedContainer(k.network.address, typecodeOrchard, raw[:])
+	if err != nil {
+		return "", &Error{Code: codeInternal}
+	}
+	return address, nil
+}
+
+// fingerprint is the key's FVK fingerprint, or its IVK fingerprint if it was
+// decoded from a UIVK.
+func (k *key) fingerprint() [orchard.FingerprintLen]byte {
+	if k.fvk != nil {
+		return k.fvk.Fingerprint()
+	}
+	return k.ivk.Fingerprint()
+}
+
+// indexOf finds the scope and index at which k derives a parsed address (on
+// network n, with the given items), as the library's Key::index_of does: the
+// IVK's dk decrypts the diversifier straight back to its index. Addresses
+// with a Sapling or P2PKH receiver the key has no item for are not owned;
+// ones it has an item for cannot be checked here.
+func (k *key) indexOf(n *network, items []zip316.Item) (diversifier.Index, string, error) {
+	raw, err := orchardReceiver(items)
+	if err != nil {
+		return diversifier.Index{}, "", err
+	}
+	if n != k.network {
+		return diversifier.Index{}, "", &Error{Code: codeAddressNotOwned}
+	}
+
+	var index diversifier.Index
+	scope := ""
+	for _, s := range []string{"external", "internal"} {
+		ivk, err := k.scoped(s)
+		if err != nil {
+			continue
+		}
+		if i, ok := ivk.DiversifierIndex(raw); ok {
+			index, scope = i, s
+			break
+		}
+	}
+	if scope == "" {
+		return diversifier.Index{}, "", &Error{Code: codeAddressNotOwned}
+	}
+
+	if hasItem(items, typecodeSapling) {
+		if k.saplingErr != nil {
+			return diversifier.Index{}, "", k.saplingErr
+		}
+		if !k.sapling || scope != "external" {
+			return diversifier.Index{}, "", &Error{Code: codeAddressNotOwned}
+		}
+		return diversifier.Index{}, "", &Error{Code: codeReceiversUnsupported}
+	}
+	if hasItem(items, typecodeP2PKH) {
+		if k.transparentErr != nil {
+			return diversifier.Index{}, "", k.transparentErr
+		}
+		if i, ok := index.Uint32(); !k.transparent || !ok || i > maxTransparentIndex {
+			return diversifier.Index{}, "", &Error{Code: codeAddressNotOwned}
+		}
+		return diversifier.Index{}, "", &Error{Code: codeReceiversUnsupported}
+	}
+	return index, scope, nil
+}
+
+func hasItem(items []zip316.Item, typecode uint64) bool {
+	for _, item := range items {
+		if item.Typecode == typecode {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAddress decodes a Juno unified address with the library's rules and
+// error codes: see parse_address in the Rust library. Sapling receivers are
+// checked for length only; callers that vouch for one must refuse it.
+func parseAddress(s string) (*network, []zip316.Item, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil, &Error{Code: codeAddressEmpty}
+	}
+
+	hrp, err := zip316.DecodeHRP(s)
+	if err != nil {
+		return nil, nil, addressErr(err)
+	}
+	var n *network
+	for i := range networks {
+		if networks[i].address == hrp {
+			n = &networks[i]
+		}
+	}
+	if n == nil {
+		for _, z := range zcashAddressHRPs {
+			if hrp == z {
+				return nil, nil, &Error{Code: codeAddressZcash}
+			}
+		}
+		return nil, nil, &Error{Code: codeAddressHrpMismatch}
+	}
+
+	items, err := zip316.DecodeTLVContainer(hrp, s)
+	if err != nil {
+		return nil, nil, addressErr(err)
+	}
+	if len(items) == 0 {
+		return nil, nil, &Error{Code: codeAddressTlvInvalid}
+	}
+
+	known := false
+	transparent := 0
+	for i, item := range items {
+		for _, seen := range items[:i] {
+			if seen.Typecode == item.Typecode {
+				return nil, nil, &Error{Code: codeAddressTypecodeDuplicate}
+			}
+		}
+		var want int
+		switch item.Typecode {
+		case typecodeP2PKH, typecodeP2SH:
+			want = transparentRecvLen
+			transparent++
+		case typecodeSapling, typecodeOrchard:
+			want = shieldedReceiverLen
+		default:
+			continue
+		}
+		if len(item.Value) != want {
+			return nil, nil, &Error{Code: codeAddressValueLenInvalid}
+		}
+		if item.Typecode == typecodeOrchard {
+			var raw [orchard.RawAddressLen]byte
+			copy(raw[:], item.Value)
+			if orchard.CheckRawAddress(raw) != nil {
+				return nil, nil, &Error{Code: codeAddressReceiverInvalid}
+			}
+		}
+		known = true
+	}
+	if !known {
+		return nil, nil, &Error{Code: codeAddressReceiversUnknown}
+	}
+	// ZIP-316 forbids both transparent receiver types in one address, and an
+	// address of transparent receivers only.
+	if transparent == len(items) {
+		return nil, nil, &Error{Code: codeAddressTransparentOnly}
+	}
+	if transparent > 1 {
+		return nil, nil, &Error{Code: codeAddressP2PKHAndP2SH}
+	}
+	return n, items, nil
+}
+
+// orchardReceiver returns the Orchard receiver of a parsed address, checking
+// that it is a valid Orchard address.
+func orchardReceiver(items []zip316.Item) ([orchard.RawAddressLen]byte, error) {
+	var raw [orchard.RawAddressLen]byte
+	for _, item := range items {
+		if item.Typecode != typecodeOrchard {
+			continue
+		}
+		// parseAddress checked the length.
+		copy(raw[:], item.Value)
+		if err := orchard.CheckRawAddress(raw); err != nil {
+			return raw, &Error{Code: codeAddressReceiverInvalid}
+		}
+		return raw, nil
+	}
+	return raw, &Error{Code: codeAddressTypecodeUnsupported}
+}
+
+// keyErr maps a pkg/zip316 error decoding a key, as map_zip316_err does.
+func keyErr(err error) error {
+	switch {
+	case errors.Is(err, zip316.ErrHRPMismatch):
+		return &Error{Code: codeUFVKHrpMismatch}
+	case errors.Is(err, zip316.ErrTLVInvalid), errors.Is(err, zip316.ErrTLVTrailingBytes):
+		return &Error{Code: codeUFVKTlvInvalid}
+	case errors.Is(err, zip316.ErrHRPTooLong), errors.Is(err, zip316.ErrBech32EncodeFailed):
+		return &Error{Code: codeInternal}
+	default:
+		return &Error{Code: codeUFVKInvalidBech32m}
+	}
+}
+
+// addressErr maps a pkg/zip316 error decoding an address, as
+// map_address_zip316_err does.
+func addressErr(err error) error {
+	switch {
+	case errors.Is(err, zip316.ErrChecksumInvalid):
+		return &Error{Code: codeAddressChecksumInvalid}
+	case errors.Is(err, zip316.ErrPaddingInvalid):
+		return &Error{Code: codeAddressPaddingInvalid}
+	}
+	switch keyErr(err).(*Error).Code {
+	case codeUFVKHrpMismatch:
+		return &Error{Code: codeAddressHrpMismatch}
+	case codeUFVKTlvInvalid:
+		return &Error{Code: codeAddressTlvInvalid}
+	case codeInternal:
+		return &Error{Code: codeInternal}
+	default:
+		return &Error{Code: codeAddressInvalidBech32m}
+	}
+}
@@ -0,0 +1,355 @@
+// Package native implements the CLI's Deriver in pure Go, on pkg/orchard and
+// pkg/zip316, so that juno-addrgen can be built without the Rust library
+// (the addrgen_purego build tag of cmd/juno-addrgen).
+//
+// It derives and recognizes Orchard receivers only. Requests that need a
+// Sapling or P2PKH receiver derived or checked fail with
+// "receivers_unsupported"; everything else reports the same results and
+// error codes as the Rust library.
+package native
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Abdullah1738/juno-addrgen/internal/cli"
+	"github.com/Abdullah1738/juno-addrgen/pkg/diversifier"
+	"github.com/Abdullah1738/juno-addrgen/pkg/orchard"
+	"github.com/Abdullah1738/juno-addrgen/pkg/zip316"
+	"github.com/Abdullah1738/juno-addrgen/pkg/zip32"
+)
+
+// streamChunk is the number of addresses Stream derives between checks of
+// its context.
+const streamChunk = 256
+
+// junoCoinType is Juno Cash's SLIP-44 coin type, which Keygen derives under.
+const junoCoinType = 8133
+
+// Deriver is the pure-Go cli.Deriver.
+type Deriver struct{}
+
+var _ cli.Deriver = Deriver{}
+
+func (Deriver) Network(ufvk string) (string, error) {
+	s := strings.ToLower(strings.TrimSpace(ufvk))
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 1 {
+		return "", &Error{Code: codeNetworkUnknown}
+	}
+	hrp := s[:sep]
+	for _, n := range networks {
+		if hrp == n.ufvk || hrp == n.uivk || hrp == n.address {
+			return n.name, nil
+		}
+	}
+	return "", &Error{Code: codeNetworkUnknown}
+}
+
+func (Deriver) Derive(ufvk string, index diversifier.Index, opts cli.Options) (string, diversifier.Index, error) {
+	k, err := parseKey(ufvk)
+	if err != nil {
+		return "", diversifier.Index{}, err
+	}
+	ivk, err := k.derivingKey(opts)
+	if err != nil {
+		return "", diversifier.Index{}, err
+	}
+	address, err := k.encodeAddress(ivk.Address(index))
+	return address, index, err
+}
+
+// derivingKey checks opts against k and returns the IVK to derive with. The
+// checks run in the same order as with the Rust library: an invalid scope
+// name first, then the receivers, then whether the key has the scope.
+func (k *key) derivingKey(opts cli.Options) (*orchard.IncomingViewingKey, error) {
+	ivk, err := k.scoped(opts.Scope)
+	if errors.Is(err, &Error{Code: codeScopeInvalid}) {
+		return nil, err
+	}
+	if err := k.checkReceivers(opts.Scope, opts.Receivers); err != nil {
+		return nil, err
+	}
+	return ivk, err
+}
+
+func (Deriver) Stream(ctx context.Context, ufvk string, start diversifier.Index, count uint64, opts cli.Options, fn func(diversifier.Index, string) error) error {
+	if count == 0 {
+		return &Error{Code: codeCountZero}
+	}
+	if err := diversifier.CheckRange(start, count); err != nil {
+		return &Error{Code: codeRangeOverflow}
+	}
+	k, err := parseKey(ufvk)
+	if err != nil {
+		return err
+	}
+	ivk, err := k.derivingKey(opts)
+	if err != nil {
+		return err
+	}
+
+	jobs := opts.Jobs
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+	addresses := make([]string, streamChunk)
+	for done := uint64(0); done < count; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n := int(min(count-done, streamChunk))
+		// In range: CheckRange covered every index up to start+count-1.
+		first, _ := start.Add(done)
+		if err := deriveChunk(k, ivk, first, addresses[:n], jobs); err != nil {
+			return err
+		}
+		for i, address := range addresses[:n] {
+			index, _ := first.Add(uint64(i))
+			if err := fn(index, address); err != nil {
+				return err
+			}
+		}
+		done += uint64(n)
+	}
+	return nil
+}
+
+// deriveChunk fills out with the addresses at first, first+1, ..., split
+// between up to jobs goroutines.
+func deriveChunk(k *key, ivk *orchard.IncomingViewingKey, first diversifier.Index, out []string, jobs int) error {
+	jobs = min(jobs, len(out))
+	errs := make([]error, jobs)
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := w; i < len(out); i += jobs {
+				index, _ := first.Add(uint64(i))
+				address, err := k.encodeAddress(ivk.Address(index))
+				if err != nil {
+					errs[w] = err
+					return
+				}
+				out[i] = address
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (Deriver) IndexOf(ufvk, address string) (diversifier.Index, string, error) {
+	k, err := parseKey(ufvk)
+	if err != nil {
+		return diversifier.Index{}, "", err
+	}
+	n, items, err := parseAddress(address)
+	if err != nil {
+		return diversifier.Index{}, "", err
+	}
+	return k.indexOf(n, items)
+}
+
+// Owner checks keys as addrgen.KeyRing does: labels must be non-empty and
+// unique, so must keys (by network and external IVK), and only keys on the
+// address's network are tried, in label order.
+func (Deriver) Owner(keys []cli.KeyEntry, address string) (string, diversifier.Index, string, error) {
+	ring := make(map[string]*key, len(keys))
+	ids := make(map[string]bool, len(keys))
+	for _, e := range keys {
+		label := strings.TrimSpace(e.Label)
+		if label == "" {
+			return "", diversifier.Index{}, "", &KeyRingError{Label: label, Err: &Error{Code: codeKeyRingLabelEmpty}}
+		}
+		if _, ok := ring[label]; ok {
+			return "", diversifier.Index{}, "", &KeyRingError{Label: label, Err: &Error{Code: codeKeyRingLabelDuplicate}}
+		}
+		k, err := parseKey(e.UFVK)
+		if err != nil {
+			return "", diversifier.Index{}, "", &KeyRingError{Label: label, Err: err}
+		}
+		ivk := k.ivk.Bytes()
+		id := k.network.address + string(ivk[:])
+		if ids[id] {
+			return "", diversifier.Index{}, "", &KeyRingError{Label: label, Err: &Error{Code: codeKeyRingKeyDuplicate}}
+		}
+		ids[id] = true
+		ring[label] = k
+	}
+
+	n, items, err := parseAddress(address)
+	if err != nil {
+		return "", diversifier.Index{}, "", err
+	}
+	if _, err := orchardReceiver(items); err != nil {
+		return "", diversifier.Index{}, "", err
+	}
+
+	labels := make([]string, 0, len(ring))
+	for label, k := range ring {
+		if k.network == n {
+			labels = append(labels, label)
+		}
+	}
+	sort.Strings(labels)
+
+	for _, label := range labels {
+		index, scope, err := ring[label].indexOf(n, items)
+		if errors.Is(err, &Error{Code: codeAddressNotOwned}) {
+			continue
+		}
+		if err != nil {
+			return "", diversifier.Index{}, "", &KeyRingError{Label: label, Err: err}
+		}
+		return label, index, scope, nil
+	}
+	return "", diversifier.Index{}, "", &Error{Code: codeAddressNotOwned}
+}
+
+func (Deriver) ParseAddress(address string) (string, []string, error) {
+	n, items, err := parseAddress(address)
+	if err != nil {
+		return "", nil, err
+	}
+	// Without Jubjub arithmetic a Sapling receiver cannot be checked.
+	if hasItem(items, typecodeSapling) {
+		return "", nil, &Error{Code: codeReceiversUnsupported}
+	}
+	receivers := make([]string, len(items))
+	for i, item := range items {
+		receivers[i] = typecodeName(item.Typecode)
+	}
+	return n.name, receivers, nil
+}
+
+func (Deriver) ParseTransparentAddress(address string) (string, error) {
+	n, _, err := parseTransparentAddress(address)
+	if err != nil {
+		return "", err
+	}
+	return n.name, nil
+}
+
+// typecodeName names a receiver type as addrgen.Typecode.String does.
+func typecodeName(typecode uint64) string {
+	switch typecode {
+	case typecodeP2PKH:
+		return "p2pkh"
+	case typecodeP2SH:
+		return "p2sh"
+	case typecodeSapling:
+		return "sapling"
+	case typecodeOrchard:
+		return "orchard"
+	default:
+		return fmt.Sprintf("%#x", typecode)
+	}
+}
+
+func (Deriver) Inspect(ufvk string) (cli.UFVKInfo, error) {
+	s := strings.TrimSpace(ufvk)
+	if s == "" {
+		return cli.UFVKInfo{}, &Error{Code: codeUFVKEmpty}
+	}
+	n, items, err := decodeKeyContainer(s, func(n *network) string { return n.ufvk })
+	if err != nil {
+		return cli.UFVKInfo{}, err
+	}
+
+	info := cli.UFVKInfo{Network: n.name, Items: make([]cli.UFVKItem, len(items))}
+	for i, item := range items {
+		name := "unknown"
+		if item.Typecode <= typecodeOrchard {
+			name = typecodeName(item.Typecode)
+		}
+		info.Items[i] = cli.UFVKItem{Typecode: item.Typecode, Name: name, Len: len(item.Value)}
+	}
+
+	k, err := parseUFVK(s)
+	if err != nil {
+		var e *Error
+		if !errors.As(err, &e) {
+			return cli.UFVKInfo{}, err
+		}
+		info.FVKError = e.Code
+		return info, nil
+	}
+	fp := k.fingerprint()
+	info.Fingerprint = hex.EncodeToString(fp[:])
+	if info.Address, err = k.encodeAddress(k.ivk.Address(diversifier.Index{})); err != nil {
+		return cli.UFVKInfo{}, err
+	}
+	return info, nil
+}
+
+func (Deriver) KeyID(ufvk string) (string, error) {
+	k, err := parseKey(ufvk)
+	if err != nil {
+		return "", err
+	}
+	fp := k.fingerprint()
+	return hex.EncodeToString(fp[:]), nil
+}
+
+func (Deriver) ExportUIVK(ufvk string) (string, error) {
+	k, err := parseKey(ufvk)
+	if err != nil {
+		return "", err
+	}
+	if k.transparentErr != nil {
+		return "", k.transparentErr
+	}
+	if k.saplingErr != nil {
+		return "", k.saplingErr
+	}
+	if k.sapling || k.transparent {
+		return "", &Error{Code: codeReceiversUnsupported}
+	}
+	b := k.ivk.Bytes()
+	uivk, err := zip316.EncodeUnifiedContainer(k.network.uivk, typecodeOrchard, b[:])
+	if err != nil {
+		return "", &Error{Code: codeInternal}
+	}
+	return uivk, nil
+}
+
+// Keygen derives the keys with pkg/orchard, which computes the spending
+// key's scalar multiplication in variable time; the Rust library's keygen
+// does not.
+func (Deriver) Keygen(seed []byte, account uint32, networkName string) (string, []byte, error) {
+	var n *network
+	for i := range networks {
+		if networks[i].name == networkName {
+			n = &networks[i]
+		}
+	}
+	if n == nil {
+		return "", nil, &Error{Code: codeNetworkUnknown}
+	}
+
+	sk, err := orchard.SpendingKeyFromSeed(seed, junoCoinType, account)
+	switch {
+	case errors.Is(err, zip32.ErrSeedLength):
+		return "", nil, &Error{Code: codeSeedInvalid}
+	case errors.Is(err, zip32.ErrIndex):
+		return "", nil, &Error{Code: codeAccountInvalid}
+	case err != nil:
+		return "", nil, &Error{Code: codeSpendingKeyInvalid}
+	}
+	fvk := sk.FullViewingKey().Bytes()
+	ufvk, err := zip316.EncodeUnifiedContainer(n.ufvk, typecodeOrchard, fvk[:])
+	if err != nil {
+		return "", nil, &Error{Code: codeInternal}
+	}
+	b := sk.Bytes()
+	return ufvk, b[:], nil
+}
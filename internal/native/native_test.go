@@ -0,0 +1,480 @@
+package native
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Abdullah1738/juno-addrgen/internal/cli"
+	"github.com/Abdullah1738/juno-addrgen/pkg/diversifier"
+	"github.com/Abdullah1738/juno-addrgen/pkg/zip316"
+)
+
+const (
+	v1UIVK        = "jivk1ha7923zaevmrhxcr6h2lqcf6sk8kt3gz65ls74sqwk23aet7gfypxwslt44gyfuvdpj5qfmecm49pyw9n7hcldlt7ydldaw5n3rzf9a8ny80jq9akg29fdxrqamat8xxdzrslj74dq"
+	v1Fingerprint = "e37feab45ad86988f554c78392b95cd1ab94e1e0f407262f3c2477c772414738"
+)
+
+type vectors struct {
+	UFVK        string   `json:"ufvk"`
+	Addresses   []string `json:"addresses"`
+	Transparent []string `json:"transparent"`
+}
+
+func loadVectorsFile(t testing.TB, name string) vectors {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("..", "..", "vectors", name))
+	if err != nil {
+		t.Fatalf("read vectors: %v", err)
+	}
+	var v vectors
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatalf("parse vectors: %v", err)
+	}
+	if len(v.Addresses) == 0 {
+		t.Fatalf("no addresses")
+	}
+	return v
+}
+
+func checkCode(t *testing.T, what string, err error, code string) {
+	t.Helper()
+	if !errors.Is(err, &Error{Code: code}) {
+		t.Fatalf("%s: expected %q, got %v", what, code, err)
+	}
+}
+
+func TestDeriver_Vectors(t *testing.T) {
+	var d Deriver
+	for _, tc := range []struct {
+		file  string
+		scope string
+	}{
+		{"v1.json", "external"},
+		{"v1_internal.json", "internal"},
+	} {
+		v := loadVectorsFile(t, tc.file)
+		opts := cli.Options{Scope: tc.scope, Jobs: 3}
+
+		address, used, err := d.Derive(v.UFVK, diversifier.FromUint32(7), opts)
+		if err != nil || address != v.Addresses[7] || used != diversifier.FromUint32(7) {
+			t.Fatalf("%s: Derive(7) = %s, %s, %v", tc.file, address, used, err)
+		}
+
+		var streamed []string
+		err = d.Stream(context.Background(), v.UFVK, diversifier.FromUint32(0), uint64(len(v.Addresses)), opts, func(index diversifier.Index, address string) error {
+			if index != diversifier.FromUint32(uint32(len(streamed))) {
+				t.Fatalf("%s: Stream index %s out of order", tc.file, index)
+			}
+			streamed = append(streamed, address)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("%s: Stream error: %v", tc.file, err)
+		}
+		for i, want := range v.Addresses {
+			if streamed[i] != want {
+				t.Fatalf("%s: Stream[%d] = %s, want %s", tc.file, i, streamed[i], want)
+			}
+			if i%10 != 0 {
+				continue
+			}
+			index, scope, err := d.IndexOf(v.UFVK, want)
+			if err != nil || index != diversifier.FromUint32(uint32(i)) || scope != tc.scope {
+				t.Fatalf("%s: IndexOf(%d) = %s, %s, %v", tc.file, i, index, scope, err)
+			}
+		}
+	}
+}
+
+func TestDeriver_UIVK(t *testing.T) {
+	var d Deriver
+	v := loadVectorsFile(t, "v1.json")
+
+	uivk, err := d.ExportUIVK(v.UFVK)
+	if err != nil || uivk != v1UIVK {
+		t.Fatalf("ExportUIVK = %s, %v", uivk, err)
+	}
+	address, _, err := d.Derive(v1UIVK, diversifier.FromUint32(5), cli.Options{})
+	if err != nil || address != v.Addresses[5] {
+		t.Fatalf("Derive(uivk, 5) = %s, %v", address, err)
+	}
+	if again, err := d.ExportUIVK(v1UIVK); err != nil || again != v1UIVK {
+		t.Fatalf("ExportUIVK(uivk) = %s, %v", again, err)
+	}
+	_, err = d.ExportUIVK(loadVectorsFile(t, "v1_transparent.json").UFVK)
+	checkCode(t, "ExportUIVK(transparent ufvk)", err, codeReceiversUnsupported)
+	_, _, err = d.Derive(v1UIVK, diversifier.FromUint32(0), cli.Options{Scope: "internal"})
+	checkCode(t, "internal scope of a UIVK", err, codeScopeUnavailable)
+
+	// A UIVK's Sapling and P2PKH items are length-checked when needed.
+	orchardIVK, err := zip316.DecodeSingleTLVContainer("jivk", v1UIVK)
+	if err != nil {
+		t.Fatalf("decode uivk: %v", err)
+	}
+	withItems := func(sapling, transparent int) string {
+		s, err := zip316.EncodeTLVContainer("jivk", []zip316.Item{
+			{Typecode: typecodeP2PKH, Value: make([]byte, transparent)},
+			{Typecode: typecodeSapling, Value: make([]byte, sapling)},
+			orchardIVK,
+		})
+		if err != nil {
+			t.Fatalf("encode uivk: %v", err)
+		}
+		return s
+	}
+	full := withItems(saplingIVKLen, transparentKeyLen)
+	address, _, err = d.Derive(full, diversifier.FromUint32(5), cli.Options{})
+	if err != nil || address != v.Addresses[5] {
+		t.Fatalf("Derive(full uivk, 5) = %s, %v", address, err)
+	}
+	_, _, err = d.Derive(full, diversifier.FromUint32(5), cli.Options{Receivers: []string{"orchard", "sapling"}})
+	checkCode(t, "sapling receiver of a UIVK", err, codeReceiversUnsupported)
+	_, err = d.ExportUIVK(full)
+	checkCode(t, "ExportUIVK(full uivk)", err, codeReceiversUnsupported)
+	_, _, err = d.Derive(withItems(saplingIVKLen+1, transparentKeyLen), diversifier.FromUint32(5),
+		cli.Options{Receivers: []string{"orchard", "sapling"}})
+	checkCode(t, "short sapling item of a UIVK", err, codeUFVKValueLenInvalid)
+	_, err = d.ExportUIVK(withItems(saplingIVKLen, transparentKeyLen-1))
+	checkCode(t, "ExportUIVK(short p2pkh item)", err, codeUFVKValueLenInvalid)
+
+	internal := loadVectorsFile(t, "v1_internal.json")
+	_, _, err = d.IndexOf(v1UIVK, internal.Addresses[0])
+	checkCode(t, "IndexOf(uivk, change address)", err, codeAddressNotOwned)
+
+	if id, err := d.KeyID(v.UFVK); err != nil || id != v1Fingerprint {
+		t.Fatalf("KeyID = %s, %v", id, err)
+	}
+	if id, err := d.KeyID(v1UIVK); err != nil || id == v1Fingerprint {
+		t.Fatalf("KeyID(uivk) = %s, %v", id, err)
+	}
+}
+
+func TestDeriver_Inspect(t *testing.T) {
+	var d Deriver
+	v := loadVectorsFile(t, "v1.json")
+
+	info, err := d.Inspect(v.UFVK)
+	if err != nil {
+		t.Fatalf("Inspect error: %v", err)
+	}
+	want := cli.UFVKInfo{
+		Network:     "mainnet",
+		Items:       []cli.UFVKItem{{Typecode: 3, Name: "orchard", Len: 96}},
+		Fingerprint: v1Fingerprint,
+		Address:     v.Addresses[0],
+	}
+	if info.Network != want.Network || len(info.Items) != 1 || info.Items[0] != want.Items[0] ||
+		info.FVKError != "" || info.Fingerprint != want.Fingerprint || info.Address != want.Address {
+		t.Fatalf("Inspect = %+v, want %+v", info, want)
+	}
+
+	// An FVK that fails to parse is described, not an error.
+	fvk := make([]byte, 96)
+	fvk[31] = 0x80
+	ufvk, err := zip316.EncodeUnifiedContainer("jviewtest", typecodeOrchard, fvk)
+	if err != nil {
+		t.Fatalf("EncodeUnifiedContainer error: %v", err)
+	}
+	info, err = d.Inspect(ufvk)
+	if err != nil || info.Network != "testnet" || info.FVKError != codeUFVKFVKBytesInvalid || info.Address != "" {
+		t.Fatalf("Inspect(invalid fvk) = %+v, %v", info, err)
+	}
+}
+
+func TestDeriver_Errors(t *testing.T) {
+	var d Deriver
+	v := loadVectorsFile(t, "v1.json")
+	tv := loadVectorsFile(t, "v1_transparent.json")
+
+	keyCases := []struct {
+		name string
+		key  string
+		code string
+	}{
+		{"empty", "  ", codeUFVKEmpty},
+		{"address", v.Addresses[0], codeUFVKHrpMismatch},
+		{"bad checksum", v.UFVK[:len(v.UFVK)-1] + "q", codeUFVKInvalidBech32m},
+	}
+	for _, tc := range keyCases {
+		_, _, err := d.Derive(tc.key, diversifier.Index{}, cli.Options{})
+		checkCode(t, tc.name, err, tc.code)
+	}
+
+	optCases := []struct {
+		name string
+		key  string
+		opts cli.Options
+		code string
+	}{
+		{"scope", v.UFVK, cli.Options{Scope: "change"}, codeScopeInvalid},
+		{"no orchard", v.UFVK, cli.Options{Receivers: []string{"sapling"}}, codeReceiversInvalid},
+		{"no sapling key", v.UFVK, cli.Options{Receivers: []string{"orchard", "sapling"}}, codeReceiversUnavailable},
+		{"no transparent key", v.UFVK, cli.Options{Receivers: []string{"p2pkh"}}, codeReceiversUnavailable},
+		{"uivk sapling", v1UIVK, cli.Options{Scope: "internal", Receivers: []string{"orchard", "sapling"}}, codeReceiversUnavailable},
+		{"p2pkh", tv.UFVK, cli.Options{Receivers: []string{"orchard", "p2pkh"}}, codeReceiversUnsupported},
+		{"transparent only", tv.UFVK, cli.Options{Receivers: []string{"p2pkh"}}, codeReceiversUnsupported},
+	}
+	for _, tc := range optCases {
+		_, _, err := d.Derive(tc.key, diversifier.Index{}, tc.opts)
+		checkCode(t, tc.name, err, tc.code)
+		err = d.Stream(context.Background(), tc.key, diversifier.Index{}, 1, tc.opts, func(diversifier.Index, string) error { return nil })
+		checkCode(t, tc.name+" stream", err, tc.code)
+	}
+
+	err := d.Stream(context.Background(), v.UFVK, diversifier.Index{}, 0, cli.Options{}, nil)
+	checkCode(t, "count 0", err, codeCountZero)
+	err = d.Stream(context.Background(), v.UFVK, diversifier.Max, 2, cli.Options{}, nil)
+	checkCode(t, "past the last index", err, codeRangeOverflow)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = d.Stream(ctx, v.UFVK, diversifier.Index{}, 1, cli.Options{}, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled Stream: %v", err)
+	}
+
+	// The transparent vectors' addresses carry a P2PKH receiver, which this
+	// backend cannot check.
+	_, _, err = d.IndexOf(tv.UFVK, tv.Addresses[0])
+	checkCode(t, "IndexOf(p2pkh address)", err, codeReceiversUnsupported)
+	_, _, err = d.IndexOf(v.UFVK, tv.Addresses[0])
+	checkCode(t, "IndexOf(someone else's p2pkh address)", err, codeAddressNotOwned)
+}
+
+func TestDeriver_MalformedItems(t *testing.T) {
+	var d Deriver
+	v := loadVectorsFile(t, "v1.json")
+
+	items, err := zip316.DecodeTLVContainer("jview", v.UFVK)
+	if err != nil {
+		t.Fatalf("DecodeTLVContainer error: %v", err)
+	}
+	ufvk, err := zip316.EncodeTLVContainer("jview", append([]zip316.Item{
+		{Typecode: typecodeP2PKH, Value: make([]byte, transparentKeyLen-1)},
+		{Typecode: typecodeSapling, Value: make([]byte, saplingDFVKLen-1)},
+	}, items...))
+	if err != nil {
+		t.Fatalf("EncodeTLVContainer error: %v", err)
+	}
+
+	// Short Sapling and P2PKH items only fail the calls that need them.
+	address, _, err := d.Derive(ufvk, diversifier.Index{}, cli.Options{})
+	if err != nil {
+		t.Fatalf("Derive error: %v", err)
+	}
+	if address != v.Addresses[0] {
+		t.Fatalf("Derive = %q, want %q", address, v.Addresses[0])
+	}
+	for _, receivers := range [][]string{{"orchard", "sapling"}, {"orchard", "p2pkh"}, {"p2pkh"}} {
+		_, _, err := d.Derive(ufvk, diversifier.Index{}, cli.Options{Receivers: receivers})
+		checkCode(t, strings.Join(receivers, ","), err, codeUFVKValueLenInvalid)
+	}
+	info, err := d.Inspect(ufvk)
+	if err != nil {
+		t.Fatalf("Inspect error: %v", err)
+	}
+	if info.FVKError != "" || len(info.Items) != 3 {
+		t.Fatalf("Inspect = %+v", info)
+	}
+}
+
+func TestDeriver_ParseAddress(t *testing.T) {
+	var d Deriver
+	v := loadVectorsFile(t, "v1.json")
+	tv := loadVectorsFile(t, "v1_transparent.json")
+
+	network, receivers, err := d.ParseAddress(tv.Addresses[0])
+	if err != nil || network != "mainnet" || len(receivers) == 0 || receivers[len(receivers)-1] != "orchard" {
+		t.Fatalf("ParseAddress = %s, %v, %v", network, receivers, err)
+	}
+
+	zcash, err := zip316.EncodeUnifiedContainer("u", typecodeOrchard, make([]byte, 43))
+	if err != nil {
+		t.Fatalf("EncodeUnifiedContainer error: %v", err)
+	}
+	identity, err := zip316.EncodeUnifiedContainer("j", typecodeOrchard, make([]byte, 43))
+	if err != nil {
+		t.Fatalf("EncodeUnifiedContainer error: %v", err)
+	}
+	unknown, err := zip316.EncodeUnifiedContainer("j", 0x30, make([]byte, 43))
+	if err != nil {
+		t.Fatalf("EncodeUnifiedContainer error: %v", err)
+	}
+	for _, tc := range []struct {
+		address string
+		code    string
+	}{
+		{"", codeAddressEmpty},
+		{v.UFVK, codeAddressHrpMismatch},
+		{zcash, codeAddressZcash},
+		{v.Addresses[0][:len(v.Addresses[0])-1] + "q", codeAddressChecksumInvalid},
+		{"j1", codeAddressChecksumInvalid},
+		{"no-separator", codeAddressInvalidBech32m},
+		{unknown, codeAddressReceiversUnknown},
+		// A P2PKH and a P2SH receiver, then the same with an Orchard one.
+		{"j18yzxjy3ej5w0ac0n9avc8v7h0e5gpvfxg0elegdtv22ujt0vqc4yyk3a9wje4smzul2luppl6zaphjnaz76rztapv3m7dm4l75ag0s", codeAddressTransparentOnly},
+		{"j17t3w0r5y8alf2exeh2ypcs8g649ras9f48zeuhpk9x3zgpwmenf9n4zpkug7vscc0weu3x3p8r647lf3s02afmnty3fuuefewanqzqdf4xpskx0ns9gdfxjvsr95qpfhwkfep073dttf8qr27w5lchp27hc0j4s66rvdfxckl7c53k", codeAddressP2PKHAndP2SH},
+	} {
+		_, _, err := d.ParseAddress(tc.address)
+		checkCode(t, tc.address, err, tc.code)
+	}
+
+	// An identity pk_d is the right length but not a valid receiver.
+	_, _, err = d.ParseAddress(identity)
+	checkCode(t, "ParseAddress(identity pk_d)", err, codeAddressReceiverInvalid)
+	_, _, err = d.IndexOf(v.UFVK, identity)
+	checkCode(t, "IndexOf(identity pk_d)", err, codeAddressReceiverInvalid)
+	_, _, err = d.IndexOf(v.UFVK, unknown)
+	checkCode(t, "IndexOf(no orchard receiver)", err, codeAddressReceiversUnknown)
+
+	// Sapling receivers cannot be checked without Jubjub.
+	orchardItem, err := zip316.DecodeSingleTLVContainer("j", v.Addresses[0])
+	if err != nil {
+		t.Fatalf("DecodeSingleTLVContainer error: %v", err)
+	}
+	sapling, err := zip316.EncodeTLVContainer("j", []zip316.Item{
+		{Typecode: typecodeSapling, Value: make([]byte, 43)},
+		orchardItem,
+	})
+	if err != nil {
+		t.Fatalf("EncodeTLVContainer error: %v", err)
+	}
+	_, _, err = d.ParseAddress(sapling)
+	checkCode(t, "ParseAddress(sapling)", err, codeReceiversUnsupported)
+}
+
+func TestDeriver_ParseTransparentAddress(t *testing.T) {
+	var d Deriver
+	tv := loadVectorsFile(t, "v1_transparent.json")
+
+	for i, address := range tv.Transparent[:10] {
+		n, receiver, err := parseTransparentAddress(address)
+		if err != nil || n.name != "mainnet" {
+			t.Fatalf("parseTransparentAddress(%d) = %v, %v", i, n, err)
+		}
+		// The unified address at the same index carries the same receiver.
+		items, err := zip316.DecodeTLVContainer("j", tv.Addresses[i])
+		if err != nil {
+			t.Fatalf("DecodeTLVContainer(%d) error: %v", i, err)
+		}
+		if items[0].Typecode != typecodeP2PKH || !bytes.Equal(items[0].Value, receiver) {
+			t.Fatalf("receiver %d = %x, want %x", i, receiver, items[0].Value)
+		}
+	}
+
+	// The tm encoding of 20 0x11 bytes; below, its t1 encoding with one
+	// character changed, and its t3 (P2SH) encoding.
+	network, err := d.ParseTransparentAddress(" tmBGbGFCAGzzZKynp1TD1xVFnmLthXdapG6\n")
+	if err != nil || network != "testnet" {
+		t.Fatalf("ParseTransparentAddress(tm) = %q, %v", network, err)
+	}
+	for _, tc := range []struct {
+		address string
+		code    string
+	}{
+		{"", codeAddressEmpty},
+		{"t1KRq2QhktLV4BjbNLiuH6pb3AMoszZKcQB", codeAddressChecksumInvalid},
+		{"t3L7rrxCCSEoSGuHoTpZsXSwyJt6bcF2i5q", codeAddressTransparentInvalid},
+		{"t1-not-base58", codeAddressTransparentInvalid},
+		{tv.Addresses[0], codeAddressTransparentInvalid},
+	} {
+		_, err := d.ParseTransparentAddress(tc.address)
+		checkCode(t, tc.address, err, tc.code)
+	}
+}
+
+func TestDeriver_Owner(t *testing.T) {
+	var d Deriver
+	v := loadVectorsFile(t, "v1.json")
+	internal := loadVectorsFile(t, "v1_internal.json")
+	tv := loadVectorsFile(t, "v1_transparent.json")
+
+	keys := []cli.KeyEntry{{Label: "main", UFVK: v.UFVK}, {Label: "uivk", UFVK: v1UIVK}}
+	label, index, scope, err := d.Owner(keys[:1], internal.Addresses[9])
+	if err != nil || label != "main" || index != diversifier.FromUint32(9) || scope != "internal" {
+		t.Fatalf("Owner = %s, %s, %s, %v", label, index, scope, err)
+	}
+	if label, _, _, err := d.Owner(keys[1:], v.Addresses[9]); err != nil || label != "uivk" {
+		t.Fatalf("Owner(external) = %s, %v", label, err)
+	}
+
+	_, _, _, err = d.Owner(keys[1:], internal.Addresses[9])
+	checkCode(t, "unowned", err, codeAddressNotOwned)
+
+	// A key with a P2PKH item fails the lookup rather than guess.
+	_, _, _, err = d.Owner([]cli.KeyEntry{{Label: "t", UFVK: tv.UFVK}}, tv.Addresses[0])
+	var kre *KeyRingError
+	if !errors.As(err, &kre) || kre.Label != "t" {
+		t.Fatalf("expected a KeyRingError for t, got %v", err)
+	}
+	checkCode(t, "p2pkh", err, codeReceiversUnsupported)
+
+	for _, tc := range []struct {
+		keys []cli.KeyEntry
+		code string
+	}{
+		{[]cli.KeyEntry{{Label: " ", UFVK: v.UFVK}}, codeKeyRingLabelEmpty},
+		{[]cli.KeyEntry{{Label: "a", UFVK: v.UFVK}, {Label: "a ", UFVK: v1UIVK}}, codeKeyRingLabelDuplicate},
+		// A UFVK and its UIVK derive the same external addresses.
+		{keys, codeKeyRingKeyDuplicate},
+		{[]cli.KeyEntry{{Label: "a", UFVK: v.UFVK}, {Label: "b", UFVK: v.UFVK}}, codeKeyRingKeyDuplicate},
+		{[]cli.KeyEntry{{Label: "a", UFVK: "jview1"}}, codeUFVKInvalidBech32m},
+	} {
+		_, _, _, err := d.Owner(tc.keys, v.Addresses[0])
+		if !errors.As(err, &kre) {
+			t.Fatalf("expected a KeyRingError, got %v", err)
+		}
+		checkCode(t, tc.code, err, tc.code)
+	}
+}
+
+func TestDeriver_Network(t *testing.T) {
+	var d Deriver
+	for s, want := range map[string]string{
+		"jview1abc":       "mainnet",
+		"JIVKTEST1ABC":    "testnet",
+		" jregtest1abc ":  "regtest",
+		"jviewregtest1xy": "regtest",
+	} {
+		if got, err := d.Network(s); err != nil || got != want {
+			t.Fatalf("Network(%q) = %s, %v", s, got, err)
+		}
+	}
+	for _, s := range []string{"", "u1abc", "1abc", "jview"} {
+		_, err := d.Network(s)
+		checkCode(t, s, err, codeNetworkUnknown)
+	}
+}
+
+func TestDeriver_Keygen(t *testing.T) {
+	var d Deriver
+	// Every vectors file holds account 0 of the seed [7; 64].
+	seed := bytes.Repeat([]byte{7}, 64)
+	for file, network := range map[string]string{
+		"v1.json":         "mainnet",
+		"v1_testnet.json": "testnet",
+		"v1_regtest.json": "regtest",
+	} {
+		ufvk, sk, err := d.Keygen(seed, 0, network)
+		if err != nil || len(sk) != 32 {
+			t.Fatalf("%s: Keygen = %d-byte key, %v", network, len(sk), err)
+		}
+		if want := loadVectorsFile(t, file).UFVK; ufvk != want {
+			t.Fatalf("%s: want %s\ngot  %s", network, want, ufvk)
+		}
+	}
+
+	_, _, err := d.Keygen(seed[:31], 0, "mainnet")
+	checkCode(t, "short seed", err, codeSeedInvalid)
+	_, _, err = d.Keygen(seed, 1<<31, "mainnet")
+	checkCode(t, "hardened account", err, codeAccountInvalid)
+	_, _, err = d.Keygen(seed, 0, "signet")
+	checkCode(t, "unknown network", err, codeNetworkUnknown)
+}
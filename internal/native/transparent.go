@@ -0,0 +1,64 @@
+package native
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"math/big"
+	"strings"
+)
+
+// Base58Check version bytes of P2PKH addresses, as in the Rust library's
+// transparent module: base58Prefixes[PUBKEY_ADDRESS] in the node's
+// chainparams.cpp. Testnet and regtest share a prefix.
+var (
+	p2pkhPrefixMainnet = [2]byte{0x1c, 0xb8}
+	p2pkhPrefixTestnet = [2]byte{0x1d, 0x25}
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// decodeBase58 decodes s, keeping one zero byte for each leading '1'.
+func decodeBase58(s string) ([]byte, bool) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for i := 0; i < len(s); i++ {
+		d := strings.IndexByte(base58Alphabet, s[i])
+		if d < 0 {
+			return nil, false
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(d)))
+	}
+	zeros := len(s) - len(strings.TrimLeft(s, "1"))
+	return append(make([]byte, zeros), n.Bytes()...), true
+}
+
+// parseTransparentAddress decodes a standalone transparent P2PKH address with
+// the library's rules and error codes: see parse_transparent_address in the
+// Rust library. It returns the address's network and P2PKH receiver.
+func parseTransparentAddress(s string) (*network, []byte, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil, &Error{Code: codeAddressEmpty}
+	}
+
+	raw, ok := decodeBase58(s)
+	if !ok || len(raw) != 2+transparentRecvLen+4 {
+		return nil, nil, &Error{Code: codeAddressTransparentInvalid}
+	}
+	payload, checksum := raw[:len(raw)-4], raw[len(raw)-4:]
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], checksum) {
+		return nil, nil, &Error{Code: codeAddressChecksumInvalid}
+	}
+
+	switch [2]byte(payload[:2]) {
+	case p2pkhPrefixMainnet:
+		return &networks[0], payload[2:], nil
+	case p2pkhPrefixTestnet:
+		return &networks[1], payload[2:], nil
+	default:
+		return nil, nil, &Error{Code: codeAddressTransparentInvalid}
+	}
+}
@@ -0,0 +1,176 @@
+package addrgen
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Abdullah1738/juno-addrgen/internal/ffi"
+	"github.com/Abdullah1738/juno-addrgen/pkg/diversifier"
+)
+
+// ReceiverLen is the length of a raw Orchard receiver: the 11-byte
+// diversifier followed by the 32-byte pk_d.
+const ReceiverLen = ffi.ReceiverLen
+
+// Address is a derived unified address together with how it was derived.
+//
+// Only Encoded, Network and Receiver are recoverable from the encoded string;
+// Index and Scope are zero after UnmarshalText or Scan, since the address does
+// not carry them.
+type Address struct {
+	Encoded  string
+	Index    DiversifierIndex
+	Network  Network
+	Scope    Scope
+	Receiver [ReceiverLen]byte
+}
+
+// String returns the encoded address (j1... / jtest1... / jregtest1...).
+func (a Address) String() string {
+	return a.Encoded
+}
+
+// MarshalText returns the encoded address.
+func (a Address) MarshalText() ([]byte, error) {
+	return []byte(a.Encoded), nil
+}
+
+// UnmarshalText decodes an encoded address, filling Encoded, Network and
+// Receiver. Surrounding whitespace is ignored.
+func (a *Address) UnmarshalText(text []byte) error {
+	if err := SelfTest(); err != nil {
+		return err
+	}
+	encoded := strings.TrimSpace(string(text))
+	network, receiver, err := ffi.DecodeAddress(encoded)
+	if err != nil {
+		return wrapErr(err)
+	}
+	*a = Address{
+		Encoded:  encoded,
+		Network:  Network(network),
+		Receiver: receiver,
+	}
+	return nil
+}
+
+type addressJSON struct {
+	Address  string           `json:"address"`
+	Index    DiversifierIndex `json:"index"`
+	Network  Network          `json:"network"`
+	Scope    Scope            `json:"scope"`
+	Receiver string           `json:"receiver"`
+}
+
+// MarshalJSON encodes the address as an object with the receiver in hex:
+//
+//	{"address":"j1...","index":0,"network":"mainnet","scope":"external","receiver":"..."}
+func (a Address) MarshalJSON() ([]byte, error) {
+	return json.Marshal(addressJSON{
+		Address:  a.Encoded,
+		Index:    a.Index,
+		Network:  a.Network,
+		Scope:    a.Scope,
+		Receiver: hex.EncodeToString(a.Receiver[:]),
+	})
+}
+
+// UnmarshalJSON accepts either the object written by MarshalJSON or a bare
+// encoded address string. The network and receiver are always taken from the
+// decoded address.
+func (a *Address) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return a.UnmarshalText([]byte(s))
+	}
+
+	var v addressJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := a.UnmarshalText([]byte(v.Address)); err != nil {
+		return err
+	}
+	a.Index = v.Index
+	a.Scope = v.Scope
+	return nil
+}
+
+// Value implements driver.Valuer, storing the encoded address, or NULL for
+// the zero Address.
+func (a Address) Value() (driver.Value, error) {
+	if a.Encoded == "" {
+		return nil, nil
+	}
+	return a.Encoded, nil
+}
+
+// Scan implements sql.Scanner for string and []byte columns holding an
+// encoded address. NULL scans to the zero Address.
+func (a *Address) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*a = Address{}
+		return nil
+	case string:
+		return a.UnmarshalText([]byte(v))
+	case []byte:
+		return a.UnmarshalText(v)
+	default:
+		return fmt.Errorf("addrgen: cannot scan %T into Address", src)
+	}
+}
+
+// DeriveAddress is like Derive, returning a structured Address. Only
+// WithScope and WithReceivers apply.
+func DeriveAddress(ufvk string, index uint32, opts ...Option) (Address, error) {
+	k, err := ParseUFVK(ufvk)
+	if err != nil {
+		return Address{}, err
+	}
+	defer k.Close()
+
+	return k.DeriveAddress(index, opts...)
+}
+
+// BatchAddresses is like Batch, returning structured Addresses.
+func BatchAddresses(ufvk string, start uint32, count uint32, opts ...Option) ([]Address, error) {
+	if err := checkBatchRange(start, count); err != nil {
+		return nil, err
+	}
+
+	k, err := ParseUFVK(ufvk)
+	if err != nil {
+		return nil, err
+	}
+	defer k.Close()
+
+	return k.BatchAddresses(start, count, opts...)
+}
+
+// DeriveAddress is like Key.Derive, returning a structured Address. Only
+// WithScope and WithReceivers apply.
+func (k *Key) DeriveAddress(index uint32, opts ...Option) (Address, error) {
+	return k.DeriveAddressIndex(diversifier.FromUint32(index), opts...)
+}
+
+// BatchAddresses is like Key.Batch, returning structured Addresses.
+func (k *Key) BatchAddresses(start uint32, count uint32, opts ...Option) ([]Address, error) {
+	if err := checkBatchRange(start, count); err != nil {
+		return nil, err
+	}
+	return k.batch(diversifier.FromUint32(start), count, applyOptions(opts))
+}
+
+func (k *Key) address(encoded string, index DiversifierIndex, scope Scope, receiver [ReceiverLen]byte) Address {
+	return Address{
+		Encoded:  encoded,
+		Index:    index,
+		Network:  k.network,
+		Scope:    scope,
+		Receiver: receiver,
+	}
+}
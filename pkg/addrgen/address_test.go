@@ -0,0 +1,146 @@
+package addrgen
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Abdullah1738/juno-addrgen/pkg/diversifier"
+)
+
+func TestBatchAddresses_GoldenVectors(t *testing.T) {
+	v := loadVectors(t)
+
+	got, err := BatchAddresses(v.UFVK, 0, uint32(len(v.Addresses)), WithParallelism(0))
+	if err != nil {
+		t.Fatalf("BatchAddresses error: %v", err)
+	}
+	if len(got) != len(v.Addresses) {
+		t.Fatalf("unexpected address count: %d", len(got))
+	}
+	for i, a := range got {
+		if a.Encoded != v.Addresses[i] || a.String() != v.Addresses[i] {
+			t.Fatalf("mismatch at %d\nwant: %s\ngot:  %s", i, v.Addresses[i], a.Encoded)
+		}
+		if a.Index != diversifier.FromUint32(uint32(i)) || a.Network != NetworkMainnet || a.Scope != ScopeExternal {
+			t.Fatalf("unexpected metadata at %d: %+v", i, a)
+		}
+	}
+
+	single, err := DeriveAddress(v.UFVK, 42)
+	if err != nil {
+		t.Fatalf("DeriveAddress error: %v", err)
+	}
+	if single != got[42] {
+		t.Fatalf("DeriveAddress mismatch\nwant: %+v\ngot:  %+v", got[42], single)
+	}
+}
+
+func TestAddress_TextRoundTrip(t *testing.T) {
+	v := loadVectors(t)
+
+	want, err := DeriveAddress(v.UFVK, 7)
+	if err != nil {
+		t.Fatalf("DeriveAddress error: %v", err)
+	}
+
+	text, err := want.MarshalText()
+	if err != nil {
+		t.Fatalf("MarshalText error: %v", err)
+	}
+	var got Address
+	if err := got.UnmarshalText(text); err != nil {
+		t.Fatalf("UnmarshalText error: %v", err)
+	}
+	if got.Encoded != want.Encoded || got.Network != want.Network || got.Receiver != want.Receiver {
+		t.Fatalf("round trip mismatch\nwant: %+v\ngot:  %+v", want, got)
+	}
+}
+
+func TestAddress_JSONRoundTrip(t *testing.T) {
+	v := loadVectors(t)
+
+	want, err := DeriveAddress(v.UFVK, 3)
+	if err != nil {
+		t.Fatalf("DeriveAddress error: %v", err)
+	}
+
+	b, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+	var got Address
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+	if got != want {
+		t.Fatalf("round trip mismatch\nwant: %+v\ngot:  %+v", want, got)
+	}
+
+	var bare Address
+	if err := json.Unmarshal([]byte(`"`+v.Addresses[3]+`"`), &bare); err != nil {
+		t.Fatalf("unmarshal string error: %v", err)
+	}
+	if bare.Receiver != want.Receiver {
+		t.Fatalf("receiver mismatch for bare string")
+	}
+}
+
+func TestAddress_SQL(t *testing.T) {
+	v := loadVectors(t)
+
+	want, err := DeriveAddress(v.UFVK, 0)
+	if err != nil {
+		t.Fatalf("DeriveAddress error: %v", err)
+	}
+
+	val, err := want.Value()
+	if err != nil {
+		t.Fatalf("Value error: %v", err)
+	}
+	if val != v.Addresses[0] {
+		t.Fatalf("unexpected Value: %v", val)
+	}
+
+	for _, src := range []any{v.Addresses[0], []byte(v.Addresses[0]), " " + v.Addresses[0] + "\n"} {
+		var got Address
+		if err := got.Scan(src); err != nil {
+			t.Fatalf("Scan(%T) error: %v", src, err)
+		}
+		if got.Encoded != want.Encoded || got.Receiver != want.Receiver {
+			t.Fatalf("Scan(%T) mismatch: %+v", src, got)
+		}
+	}
+
+	// The zero Address round-trips through NULL.
+	if val, err := (Address{}).Value(); err != nil || val != nil {
+		t.Fatalf("zero Address Value: %v, %v", val, err)
+	}
+	got := want
+	if err := got.Scan(nil); err != nil {
+		t.Fatalf("Scan(nil) error: %v", err)
+	}
+	if got != (Address{}) {
+		t.Fatalf("Scan(nil) left %+v", got)
+	}
+}
+
+func TestAddress_UnmarshalErrors(t *testing.T) {
+	v := loadVectors(t)
+
+	tests := []struct {
+		in   string
+		want ErrorCode
+	}{
+		{in: "", want: ErrAddressEmpty},
+		{in: "not-an-address", want: ErrAddressInvalidBech32m},
+		{in: v.UFVK, want: ErrAddressHrpMismatch},
+	}
+	for _, tc := range tests {
+		var a Address
+		err := a.UnmarshalText([]byte(tc.in))
+		if !errors.Is(err, &Error{Code: tc.want}) {
+			t.Fatalf("UnmarshalText(%q): expected %q, got %v", tc.in, tc.want, err)
+		}
+	}
+}
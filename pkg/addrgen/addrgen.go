@@ -1,7 +1,6 @@
 package addrgen
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 
@@ -11,17 +10,52 @@ import (
 type ErrorCode string
 
 const (
-	ErrUFVKEmpty               ErrorCode = "ufvk_empty"
-	ErrUFVKInvalidBech32m      ErrorCode = "ufvk_invalid_bech32m"
-	ErrUFVKHrpMismatch         ErrorCode = "ufvk_hrp_mismatch"
-	ErrUFVKTlvInvalid          ErrorCode = "ufvk_tlv_invalid"
-	ErrUFVKTypecodeUnsupported ErrorCode = "ufvk_typecode_unsupported"
-	ErrUFVKValueLenInvalid     ErrorCode = "ufvk_value_len_invalid"
-	ErrUFVKFVKBytesInvalid     ErrorCode = "ufvk_fvk_bytes_invalid"
-	ErrCountZero               ErrorCode = "count_zero"
-	ErrCountTooLarge           ErrorCode = "count_too_large"
-	ErrRangeOverflow           ErrorCode = "range_overflow"
-	ErrInternal                ErrorCode = "internal"
+	ErrUFVKEmpty                  ErrorCode = "ufvk_empty"
+	ErrUFVKInvalidBech32m         ErrorCode = "ufvk_invalid_bech32m"
+	ErrUFVKHrpMismatch            ErrorCode = "ufvk_hrp_mismatch"
+	ErrUFVKTlvInvalid             ErrorCode = "ufvk_tlv_invalid"
+	ErrUFVKTypecodeUnsupported    ErrorCode = "ufvk_typecode_unsupported"
+	ErrUFVKValueLenInvalid        ErrorCode = "ufvk_value_len_invalid"
+	ErrUFVKFVKBytesInvalid        ErrorCode = "ufvk_fvk_bytes_invalid"
+	ErrCountZero                  ErrorCode = "count_zero"
+	ErrCountTooLarge              ErrorCode = "count_too_large"
+	ErrRangeOverflow              ErrorCode = "range_overflow"
+	ErrIndexInvalid               ErrorCode = "index_invalid"
+	ErrAddressEmpty               ErrorCode = "address_empty"
+	ErrAddressInvalidBech32m      ErrorCode = "address_invalid_bech32m"
+	ErrAddressHrpMismatch         ErrorCode = "address_hrp_mismatch"
+	ErrAddressTlvInvalid          ErrorCode = "address_tlv_invalid"
+	ErrAddressTypecodeUnsupported ErrorCode = "address_typecode_unsupported"
+	ErrAddressValueLenInvalid     ErrorCode = "address_value_len_invalid"
+	ErrAddressReceiverInvalid     ErrorCode = "address_receiver_invalid"
+	ErrAddressNotOwned            ErrorCode = "address_not_owned"
+	ErrAddressChecksumInvalid     ErrorCode = "address_checksum_invalid"
+	ErrAddressZcash               ErrorCode = "address_zcash"
+	ErrAddressPaddingInvalid      ErrorCode = "address_padding_invalid"
+	ErrAddressTypecodeDuplicate   ErrorCode = "address_typecode_duplicate"
+	ErrAddressReceiversUnknown    ErrorCode = "address_receivers_unknown"
+	ErrAddressTransparentOnly     ErrorCode = "address_transparent_only"
+	ErrAddressP2PKHAndP2SH        ErrorCode = "address_p2pkh_and_p2sh"
+	ErrAddressTransparentInvalid  ErrorCode = "address_transparent_invalid"
+	ErrScopeInvalid               ErrorCode = "scope_invalid"
+	ErrScopeUnavailable           ErrorCode = "scope_unavailable"
+	ErrUFVKSaplingInvalid         ErrorCode = "ufvk_sapling_invalid"
+	ErrUFVKTransparentInvalid     ErrorCode = "ufvk_transparent_invalid"
+	ErrUIVKExportUnsupported      ErrorCode = "uivk_export_unsupported" // no longer returned
+	ErrReceiversInvalid           ErrorCode = "receivers_invalid"
+	ErrReceiversUnavailable       ErrorCode = "receivers_unavailable"
+	ErrSeedInvalid                ErrorCode = "seed_invalid"
+	ErrAccountInvalid             ErrorCode = "account_invalid"
+	ErrSpendingKeyInvalid         ErrorCode = "spending_key_invalid"
+	ErrNetworkUnknown             ErrorCode = "network_unknown"
+	ErrKeyClosed                  ErrorCode = "key_closed"
+	ErrKeyRingLabelEmpty          ErrorCode = "keyring_label_empty"
+	ErrKeyRingLabelDuplicate      ErrorCode = "keyring_label_duplicate"
+	ErrKeyRingKeyDuplicate        ErrorCode = "keyring_key_duplicate"
+	ErrInternal                   ErrorCode = "internal"
+	// ErrSelfTestFailed is returned by every call into the library once it
+	// has failed its known-answer test; see SelfTest.
+	ErrSelfTestFailed ErrorCode = "selftest_failed"
 )
 
 type Error struct {
@@ -45,72 +79,70 @@ func (e *Error) Is(target error) bool {
 }
 
 func Derive(ufvk string, index uint32) (string, error) {
-	raw, err := ffi.DeriveJSON(ufvk, index)
-	if err != nil {
+	if err := SelfTest(); err != nil {
 		return "", err
 	}
+	address, err := ffi.Derive(ufvk, index)
+	if err != nil {
+		return "", wrapErr(err)
+	}
+	return address, nil
+}
 
-	var resp deriveResponse
-	if err := json.Unmarshal([]byte(raw), &resp); err != nil {
-		return "", errors.New("addrgen: invalid response")
+// DeriveScoped returns the address at index in the given scope.
+func DeriveScoped(ufvk string, index uint32, scope Scope) (string, error) {
+	if scope == ScopeExternal {
+		return Derive(ufvk, index)
+	}
+	if _, err := scope.id(); err != nil {
+		return "", err
 	}
 
-	switch resp.Status {
-	case "ok":
-		if resp.Address == "" {
-			return "", errors.New("addrgen: invalid response")
-		}
-		return resp.Address, nil
-	case "err":
-		if resp.Error == "" {
-			return "", errors.New("addrgen: invalid response")
-		}
-		return "", &Error{Code: ErrorCode(resp.Error)}
-	default:
-		return "", errors.New("addrgen: invalid response")
+	k, err := ParseUFVK(ufvk)
+	if err != nil {
+		return "", err
 	}
+	defer k.Close()
+
+	return k.DeriveScoped(index, scope)
 }
 
-func Batch(ufvk string, start uint32, count uint32) ([]string, error) {
-	raw, err := ffi.BatchJSON(ufvk, start, count)
-	if err != nil {
+func Batch(ufvk string, start uint32, count uint32, opts ...Option) ([]string, error) {
+	o := applyOptions(opts)
+	if o.scope != ScopeExternal || o.receivers != nil {
+		return batchWithKey(ufvk, start, count, opts)
+	}
+	if err := SelfTest(); err != nil {
 		return nil, err
 	}
+	addresses, err := ffi.Batch(ufvk, start, count, o.jobs)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	return addresses, nil
+}
 
-	var resp batchResponse
-	if err := json.Unmarshal([]byte(raw), &resp); err != nil {
-		return nil, errors.New("addrgen: invalid response")
+// batchWithKey derives through a parsed key, for the scopes and receiver types
+// the one-shot library call does not cover.
+func batchWithKey(ufvk string, start uint32, count uint32, opts []Option) ([]string, error) {
+	if err := checkBatchRange(start, count); err != nil {
+		return nil, err
 	}
 
-	switch resp.Status {
-	case "ok":
-		if len(resp.Addresses) == 0 {
-			return nil, errors.New("addrgen: invalid response")
-		}
-		if resp.Start != start || resp.Count != count {
-			return nil, errors.New("addrgen: invalid response")
-		}
-		return resp.Addresses, nil
-	case "err":
-		if resp.Error == "" {
-			return nil, errors.New("addrgen: invalid response")
-		}
-		return nil, &Error{Code: ErrorCode(resp.Error)}
-	default:
-		return nil, errors.New("addrgen: invalid response")
+	k, err := ParseUFVK(ufvk)
+	if err != nil {
+		return nil, err
 	}
-}
+	defer k.Close()
 
-type deriveResponse struct {
-	Status  string `json:"status"`
-	Address string `json:"address,omitempty"`
-	Error   string `json:"error,omitempty"`
+	return k.Batch(start, count, opts...)
 }
 
-type batchResponse struct {
-	Status    string   `json:"status"`
-	Start     uint32   `json:"start,omitempty"`
-	Count     uint32   `json:"count,omitempty"`
-	Addresses []string `json:"addresses,omitempty"`
-	Error     string   `json:"error,omitempty"`
+// wrapErr converts library status codes into *Error.
+func wrapErr(err error) error {
+	var ce ffi.CodeError
+	if errors.As(err, &ce) {
+		return &Error{Code: ErrorCode(ce)}
+	}
+	return err
 }
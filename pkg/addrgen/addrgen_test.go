@@ -10,14 +10,20 @@ import (
 
 type vectorsV1 struct {
 	Version   int      `json:"version"`
+	Scope     string   `json:"scope"`
 	UFVK      string   `json:"ufvk"`
 	Addresses []string `json:"addresses"`
 }
 
-func loadVectors(t *testing.T) vectorsV1 {
+func loadVectors(t testing.TB) vectorsV1 {
+	t.Helper()
+	return loadVectorsFile(t, "v1.json")
+}
+
+func loadVectorsFile(t testing.TB, name string) vectorsV1 {
 	t.Helper()
 
-	path := filepath.Join("..", "..", "vectors", "v1.json")
+	path := filepath.Join("..", "..", "vectors", name)
 	b, err := os.ReadFile(path)
 	if err != nil {
 		t.Fatalf("read vectors: %v", err)
@@ -83,6 +89,25 @@ func TestBatch_GoldenVectors(t *testing.T) {
 	}
 }
 
+func TestBatch_Parallel_GoldenVectors(t *testing.T) {
+	v := loadVectors(t)
+
+	for _, jobs := range []int{0, 2, 3, 8} {
+		got, err := Batch(v.UFVK, 0, uint32(len(v.Addresses)), WithParallelism(jobs))
+		if err != nil {
+			t.Fatalf("Batch(jobs=%d) error: %v", jobs, err)
+		}
+		if len(got) != len(v.Addresses) {
+			t.Fatalf("jobs=%d: unexpected len: %d", jobs, len(got))
+		}
+		for i := range got {
+			if got[i] != v.Addresses[i] {
+				t.Fatalf("jobs=%d: mismatch at %d\nwant: %s\ngot:  %s", jobs, i, v.Addresses[i], got[i])
+			}
+		}
+	}
+}
+
 func TestErrors(t *testing.T) {
 	_, err := Derive("", 0)
 	var ae *Error
@@ -101,3 +126,55 @@ func TestErrors(t *testing.T) {
 		t.Fatalf("expected %q, got %v", ErrCountZero, err)
 	}
 }
+
+var benchSizes = []struct {
+	name  string
+	count uint32
+}{
+	{"1k", 1_000},
+	{"10k", 10_000},
+	{"100k", 100_000},
+}
+
+// BenchmarkBatch derives the IVK once per call and reuses it for every index.
+func BenchmarkBatch(b *testing.B) {
+	ufvk := loadVectors(b).UFVK
+
+	for _, size := range benchSizes {
+		b.Run(size.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := Batch(ufvk, 0, size.count); err != nil {
+					b.Fatalf("Batch error: %v", err)
+				}
+			}
+			reportAddressRate(b, size.count)
+		})
+	}
+}
+
+// BenchmarkDeriveLoop is the per-index baseline: every call decodes the UFVK
+// and re-derives the IVK before diversifying.
+func BenchmarkDeriveLoop(b *testing.B) {
+	ufvk := loadVectors(b).UFVK
+
+	for _, size := range benchSizes {
+		b.Run(size.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for idx := uint32(0); idx < size.count; idx++ {
+					if _, err := Derive(ufvk, idx); err != nil {
+						b.Fatalf("Derive error: %v", err)
+					}
+				}
+			}
+			reportAddressRate(b, size.count)
+		})
+	}
+}
+
+func reportAddressRate(b *testing.B, count uint32) {
+	b.Helper()
+
+	if secs := b.Elapsed().Seconds(); secs > 0 {
+		b.ReportMetric(float64(b.N)*float64(count)/secs, "addrs/s")
+	}
+}
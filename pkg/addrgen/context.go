@@ -0,0 +1,67 @@
+package addrgen
+
+import "context"
+
+// maxBatchCount mirrors the library's per-call batch limit.
+const maxBatchCount = 100_000
+
+// DeriveContext is like Derive, but returns ctx.Err() if ctx is already done.
+func DeriveContext(ctx context.Context, ufvk string, index uint32) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return Derive(ufvk, index)
+}
+
+// BatchContext is like Batch, but derives in chunks and returns ctx.Err() as
+// soon as ctx is done, without waiting for the rest of the batch.
+func BatchContext(ctx context.Context, ufvk string, start uint32, count uint32, opts ...Option) ([]string, error) {
+	if err := checkBatchRange(start, count); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	k, err := ParseUFVK(ufvk)
+	if err != nil {
+		return nil, err
+	}
+	defer k.Close()
+
+	return k.BatchContext(ctx, start, count, opts...)
+}
+
+// DeriveContext is like Key.Derive, but returns ctx.Err() if ctx is already
+// done.
+func (k *Key) DeriveContext(ctx context.Context, index uint32) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return k.Derive(index)
+}
+
+// BatchContext is like Key.Batch, but derives in chunks and returns ctx.Err()
+// as soon as ctx is done.
+func (k *Key) BatchContext(ctx context.Context, start uint32, count uint32, opts ...Option) ([]string, error) {
+	if err := checkBatchRange(start, count); err != nil {
+		return nil, err
+	}
+
+	out := make([]string, 0, count)
+	err := k.StreamContext(ctx, start, uint64(count), func(_ uint32, address string) error {
+		out = append(out, address)
+		return nil
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func checkBatchRange(start uint32, count uint32) error {
+	if count > maxBatchCount {
+		return &Error{Code: ErrCountTooLarge}
+	}
+	return checkStreamRange(start, uint64(count))
+}
@@ -0,0 +1,86 @@
+package addrgen
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestContext_GoldenVectors(t *testing.T) {
+	v := loadVectors(t)
+	ctx := context.Background()
+
+	got, err := DeriveContext(ctx, v.UFVK, 10)
+	if err != nil {
+		t.Fatalf("DeriveContext error: %v", err)
+	}
+	if got != v.Addresses[10] {
+		t.Fatalf("unexpected address\nwant: %s\ngot:  %s", v.Addresses[10], got)
+	}
+
+	batch, err := BatchContext(ctx, v.UFVK, 0, uint32(len(v.Addresses)))
+	if err != nil {
+		t.Fatalf("BatchContext error: %v", err)
+	}
+	for i := range batch {
+		if batch[i] != v.Addresses[i] {
+			t.Fatalf("mismatch at %d\nwant: %s\ngot:  %s", i, v.Addresses[i], batch[i])
+		}
+	}
+}
+
+func TestContext_Canceled(t *testing.T) {
+	v := loadVectors(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := DeriveContext(ctx, v.UFVK, 0); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if _, err := BatchContext(ctx, v.UFVK, 0, 10); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestContext_CanceledMidStream(t *testing.T) {
+	v := loadVectors(t)
+
+	k, err := ParseUFVK(v.UFVK)
+	if err != nil {
+		t.Fatalf("ParseUFVK error: %v", err)
+	}
+	defer k.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var n int
+	err = k.StreamContext(ctx, 0, 3*streamChunk, func(uint32, string) error {
+		n++
+		if n == 1 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if n != streamChunk {
+		t.Fatalf("expected to stop after the first chunk, got %d addresses", n)
+	}
+}
+
+func TestBatchContext_RangeErrors(t *testing.T) {
+	var ae *Error
+
+	_, err := BatchContext(context.Background(), "j1not-a-ufvk", 0, 0)
+	if !errors.As(err, &ae) || ae.Code != ErrCountZero {
+		t.Fatalf("expected %q, got %v", ErrCountZero, err)
+	}
+
+	_, err = BatchContext(context.Background(), "j1not-a-ufvk", 0, maxBatchCount+1)
+	if !errors.As(err, &ae) || ae.Code != ErrCountTooLarge {
+		t.Fatalf("expected %q, got %v", ErrCountTooLarge, err)
+	}
+}
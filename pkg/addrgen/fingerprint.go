@@ -0,0 +1,68 @@
+package addrgen
+
+import (
+	"encoding/hex"
+
+	"github.com/Abdullah1738/juno-addrgen/internal/ffi"
+)
+
+// FingerprintLen is the length of a KeyFingerprint.
+const FingerprintLen = ffi.FingerprintLen
+
+// fingerprintTagLen is the length of the ZIP-32 key tag, the fingerprint
+// prefix used as its short form.
+const fingerprintTagLen = 4
+
+// KeyFingerprint is the ZIP-32 Orchard full viewing key fingerprint: the
+// BLAKE2b-256 hash of the FVK, personalized "ZcashOrchardFVFP". It identifies
+// a key without revealing it, and is the same whichever network the UFVK is
+// encoded for.
+type KeyFingerprint [FingerprintLen]byte
+
+// String returns the fingerprint in hex. This is the key_id in derive and
+// batch JSON output.
+func (f KeyFingerprint) String() string {
+	return hex.EncodeToString(f[:])
+}
+
+// Short returns the hex of the fingerprint's first 4 bytes (the ZIP-32 key
+// tag), for logs and tickets. It is too short to tell keys apart reliably; use
+// String to assert which key is in use.
+func (f KeyFingerprint) Short() string {
+	return hex.EncodeToString(f[:fingerprintTagLen])
+}
+
+// MarshalText returns the fingerprint in hex.
+func (f KeyFingerprint) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// Fingerprint returns the FVK fingerprint of ufvk. Given a UIVK it returns the
+// key's IVK fingerprint (see Key.Fingerprint).
+func Fingerprint(ufvk string) (KeyFingerprint, error) {
+	k, err := ParseUFVK(ufvk)
+	if err != nil {
+		return KeyFingerprint{}, err
+	}
+	defer k.Close()
+
+	return k.Fingerprint()
+}
+
+// Fingerprint returns the key's FVK fingerprint. A key parsed from a UIVK has
+// no FVK; its fingerprint is instead the BLAKE2b-256 hash of the IVK,
+// personalized "JunoOrchardIVKFP", and differs from the fingerprint of the
+// UFVK the UIVK was exported from.
+func (k *Key) Fingerprint() (KeyFingerprint, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if k.handle == nil {
+		return KeyFingerprint{}, &Error{Code: ErrKeyClosed}
+	}
+	fp, err := k.handle.Fingerprint()
+	if err != nil {
+		return KeyFingerprint{}, wrapErr(err)
+	}
+	return fp, nil
+}
@@ -0,0 +1,45 @@
+package addrgen
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	v := loadVectors(t)
+
+	fp, err := Fingerprint(v.UFVK)
+	if err != nil {
+		t.Fatalf("Fingerprint error: %v", err)
+	}
+	if fp.String() != v1Fingerprint || fp.Short() != v1Fingerprint[:8] {
+		t.Fatalf("unexpected fingerprint: %s (%s)", fp, fp.Short())
+	}
+
+	b, err := json.Marshal(map[string]KeyFingerprint{"key_id": fp})
+	if err != nil || string(b) != `{"key_id":"`+v1Fingerprint+`"}` {
+		t.Fatalf("unexpected json: %s, %v", b, err)
+	}
+
+	// The fingerprint names the FVK, not its encoding.
+	if testnet, err := Fingerprint(testnetUFVK); err != nil || testnet != fp {
+		t.Fatalf("testnet fingerprint = %s, %v", testnet, err)
+	}
+	if other, err := Fingerprint(otherMainnetUFVK); err != nil || other == fp {
+		t.Fatalf("other key fingerprint = %s, %v", other, err)
+	}
+
+	if _, err := Fingerprint("jview1qqqq"); !errors.Is(err, &Error{Code: ErrUFVKInvalidBech32m}) {
+		t.Fatalf("expected %q, got %v", ErrUFVKInvalidBech32m, err)
+	}
+
+	k, err := ParseUFVK(v.UFVK)
+	if err != nil {
+		t.Fatalf("ParseUFVK error: %v", err)
+	}
+	k.Close()
+	if _, err := k.Fingerprint(); !errors.Is(err, &Error{Code: ErrKeyClosed}) {
+		t.Fatalf("expected %q, got %v", ErrKeyClosed, err)
+	}
+}
@@ -0,0 +1,171 @@
+package addrgen
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Abdullah1738/juno-addrgen/pkg/diversifier"
+)
+
+// DiversifierIndex is a full 88-bit ZIP-32 diversifier index. The uint32
+// functions in this package cover its low 2^32 values; the *Index variants
+// accept any index up to MaxDiversifierIndex.
+type DiversifierIndex = diversifier.Index
+
+// MaxDiversifierIndex is the largest diversifier index, 2^88 - 1.
+var MaxDiversifierIndex = diversifier.Max
+
+// ParseDiversifierIndex parses a decimal or 0x-prefixed hexadecimal index,
+// returning ErrIndexInvalid if it is malformed or does not fit in 88 bits.
+func ParseDiversifierIndex(s string) (DiversifierIndex, error) {
+	index, err := diversifier.Parse(s)
+	if err != nil {
+		return DiversifierIndex{}, &Error{Code: ErrIndexInvalid}
+	}
+	return index, nil
+}
+
+// DeriveIndex returns the address at index, in the external scope unless
+// WithScope is given.
+func DeriveIndex(ufvk string, index DiversifierIndex, opts ...Option) (string, error) {
+	k, err := ParseUFVK(ufvk)
+	if err != nil {
+		return "", err
+	}
+	defer k.Close()
+
+	return k.DeriveIndex(index, opts...)
+}
+
+// StreamIndexContext is like StreamContext over the full index space: the
+// range may extend to MaxDiversifierIndex.
+func StreamIndexContext(ctx context.Context, ufvk string, start DiversifierIndex, count uint64, fn func(index DiversifierIndex, address string) error, opts ...Option) error {
+	if err := checkIndexRange(start, count); err != nil {
+		return err
+	}
+
+	k, err := ParseUFVK(ufvk)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+
+	return k.StreamIndexContext(ctx, start, count, fn, opts...)
+}
+
+// DeriveIndex is like the package-level DeriveIndex, using the parsed key.
+// With a Sapling receiver (see WithReceivers) the address may be at a later
+// index; DeriveAddressIndex reports it.
+func (k *Key) DeriveIndex(index DiversifierIndex, opts ...Option) (string, error) {
+	a, err := k.DeriveAddressIndex(index, opts...)
+	if err != nil {
+		return "", err
+	}
+	return a.Encoded, nil
+}
+
+// BatchIndex is like Batch, starting at a full diversifier index. The batch
+// may end at MaxDiversifierIndex.
+func (k *Key) BatchIndex(start DiversifierIndex, count uint32, opts ...Option) ([]string, error) {
+	addresses, err := k.batch(start, count, applyOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, len(addresses))
+	for i, a := range addresses {
+		out[i] = a.Encoded
+	}
+	return out, nil
+}
+
+// DeriveAddressIndex is like DeriveAddress, at a full diversifier index.
+func (k *Key) DeriveAddressIndex(index DiversifierIndex, opts ...Option) (Address, error) {
+	o := applyOptions(opts)
+	scope, receivers, err := o.ids()
+	if err != nil {
+		return Address{}, err
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if k.handle == nil {
+		return Address{}, &Error{Code: ErrKeyClosed}
+	}
+	encoded, used, receiver, err := k.handle.DeriveReceivers(scope, receivers, index)
+	if err != nil {
+		return Address{}, wrapErr(err)
+	}
+	return k.address(encoded, used, o.scope, receiver), nil
+}
+
+// batch derives the addresses in [start, start+count) that are valid for the
+// requested receiver types, in index order.
+func (k *Key) batch(start DiversifierIndex, count uint32, o options) ([]Address, error) {
+	scope, receivers, err := o.ids()
+	if err != nil {
+		return nil, err
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if k.handle == nil {
+		return nil, &Error{Code: ErrKeyClosed}
+	}
+	encoded, indices, raw, err := k.handle.BatchReceivers(scope, receivers, start, count, o.jobs)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+
+	out := make([]Address, len(encoded))
+	for i := range encoded {
+		out[i] = k.address(encoded[i], indices[i], o.scope, raw[i])
+	}
+	return out, nil
+}
+
+// StreamIndex is like Stream, over the full index space.
+func (k *Key) StreamIndex(start DiversifierIndex, count uint64, fn func(index DiversifierIndex, address string) error, opts ...Option) error {
+	return k.StreamIndexContext(context.Background(), start, count, fn, opts...)
+}
+
+// StreamIndexContext is like StreamContext, over the full index space.
+func (k *Key) StreamIndexContext(ctx context.Context, start DiversifierIndex, count uint64, fn func(index DiversifierIndex, address string) error, opts ...Option) error {
+	if err := checkIndexRange(start, count); err != nil {
+		return err
+	}
+
+	o := applyOptions(opts)
+	for done := uint64(0); done < count; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n := min(count-done, streamChunk)
+		// In range: checkIndexRange covered every index up to start+count-1.
+		first, _ := start.Add(done)
+
+		addresses, err := k.batch(first, uint32(n), o)
+		if err != nil {
+			return err
+		}
+		for _, a := range addresses {
+			if err := fn(a.Index, a.Encoded); err != nil {
+				return err
+			}
+		}
+		done += n
+	}
+	return nil
+}
+
+func checkIndexRange(start DiversifierIndex, count uint64) error {
+	if count == 0 {
+		return &Error{Code: ErrCountZero}
+	}
+	if err := diversifier.CheckRange(start, count); errors.Is(err, diversifier.ErrRange) {
+		return &Error{Code: ErrRangeOverflow}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package addrgen
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type wideVectorsV1 struct {
+	Version int    `json:"version"`
+	UFVK    string `json:"ufvk"`
+	Indexed []struct {
+		Index   DiversifierIndex `json:"index"`
+		Address string           `json:"address"`
+	} `json:"indexed"`
+}
+
+func loadWideVectors(t testing.TB) wideVectorsV1 {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("..", "..", "vectors", "v1_wide.json"))
+	if err != nil {
+		t.Fatalf("read vectors: %v", err)
+	}
+	var v wideVectorsV1
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatalf("parse vectors: %v", err)
+	}
+	if v.Version != 1 || v.UFVK == "" || len(v.Indexed) == 0 {
+		t.Fatalf("unexpected vectors: version=%d indexed=%d", v.Version, len(v.Indexed))
+	}
+	return v
+}
+
+func TestDeriveIndex_WideGoldenVectors(t *testing.T) {
+	v := loadWideVectors(t)
+
+	k, err := ParseUFVK(v.UFVK)
+	if err != nil {
+		t.Fatalf("ParseUFVK error: %v", err)
+	}
+	defer k.Close()
+
+	for _, tc := range v.Indexed {
+		got, err := k.DeriveIndex(tc.Index)
+		if err != nil {
+			t.Fatalf("DeriveIndex(%s) error: %v", tc.Index, err)
+		}
+		if got != tc.Address {
+			t.Fatalf("mismatch at %s\nwant: %s\ngot:  %s", tc.Index, tc.Address, got)
+		}
+
+		a, err := k.DeriveAddressIndex(tc.Index)
+		if err != nil {
+			t.Fatalf("DeriveAddressIndex(%s) error: %v", tc.Index, err)
+		}
+		if a.Encoded != tc.Address || a.Index != tc.Index {
+			t.Fatalf("unexpected address at %s: %+v", tc.Index, a)
+		}
+	}
+}
+
+func TestStreamIndex_CrossesBoundaries(t *testing.T) {
+	v := loadWideVectors(t)
+
+	k, err := ParseUFVK(v.UFVK)
+	if err != nil {
+		t.Fatalf("ParseUFVK error: %v", err)
+	}
+	defer k.Close()
+
+	// The vectors list 2^32-1, 2^32, 2^32+1 in order; stream across them.
+	start := v.Indexed[0].Index
+	var got []string
+	err = k.StreamIndex(start, 3, func(index DiversifierIndex, address string) error {
+		if want := v.Indexed[len(got)].Index; index != want {
+			t.Fatalf("unexpected index: %s (want %s)", index, want)
+		}
+		got = append(got, address)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamIndex error: %v", err)
+	}
+	for i, address := range got {
+		if address != v.Indexed[i].Address {
+			t.Fatalf("mismatch at %s", v.Indexed[i].Index)
+		}
+	}
+
+	// The last two indices of the space are reachable; one more is not.
+	last := v.Indexed[len(v.Indexed)-2:]
+	addresses, err := k.BatchIndex(last[0].Index, 2)
+	if err != nil {
+		t.Fatalf("BatchIndex error: %v", err)
+	}
+	if addresses[0] != last[0].Address || addresses[1] != last[1].Address {
+		t.Fatalf("BatchIndex mismatch at end of index space")
+	}
+
+	var ae *Error
+	if _, err := k.BatchIndex(last[0].Index, 3); !errors.As(err, &ae) || ae.Code != ErrRangeOverflow {
+		t.Fatalf("expected %q, got %v", ErrRangeOverflow, err)
+	}
+	err = k.StreamIndex(MaxDiversifierIndex, math.MaxUint64, func(DiversifierIndex, string) error { return nil })
+	if !errors.As(err, &ae) || ae.Code != ErrRangeOverflow {
+		t.Fatalf("expected %q, got %v", ErrRangeOverflow, err)
+	}
+}
+
+func TestDeriveIndex_MatchesUint32API(t *testing.T) {
+	v := loadVectors(t)
+
+	index, err := ParseDiversifierIndex("0x2a")
+	if err != nil {
+		t.Fatalf("ParseDiversifierIndex error: %v", err)
+	}
+	got, err := DeriveIndex(v.UFVK, index)
+	if err != nil {
+		t.Fatalf("DeriveIndex error: %v", err)
+	}
+	if got != v.Addresses[42] {
+		t.Fatalf("mismatch\nwant: %s\ngot:  %s", v.Addresses[42], got)
+	}
+
+	var ae *Error
+	if _, err := ParseDiversifierIndex("309485009821345068724781056"); !errors.As(err, &ae) || ae.Code != ErrIndexInvalid {
+		t.Fatalf("expected %q, got %v", ErrIndexInvalid, err)
+	}
+}
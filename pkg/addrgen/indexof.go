@@ -0,0 +1,31 @@
+package addrgen
+
+// IndexOf reports the diversifier index and scope at which ufvk derives
+// address. The index is recovered by decrypting the address's diversifier
+// with the viewing key, so the cost does not depend on how far out the index
+// is. It returns ErrAddressNotOwned if ufvk derives address in neither scope,
+// which includes addresses on another network.
+func IndexOf(ufvk string, address string) (DiversifierIndex, Scope, error) {
+	k, err := ParseUFVK(ufvk)
+	if err != nil {
+		return DiversifierIndex{}, "", err
+	}
+	defer k.Close()
+
+	return k.IndexOf(address)
+}
+
+// IndexOf is like the package-level IndexOf, using the parsed key.
+func (k *Key) IndexOf(address string) (DiversifierIndex, Scope, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if k.handle == nil {
+		return DiversifierIndex{}, "", &Error{Code: ErrKeyClosed}
+	}
+	scope, index, err := k.handle.IndexOf(address)
+	if err != nil {
+		return DiversifierIndex{}, "", wrapErr(err)
+	}
+	return index, scopeFromID(scope), nil
+}
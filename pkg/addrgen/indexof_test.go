@@ -0,0 +1,72 @@
+package addrgen
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Abdullah1738/juno-addrgen/pkg/diversifier"
+)
+
+func TestIndexOf_GoldenVectors(t *testing.T) {
+	v := loadVectors(t)
+
+	k, err := ParseUFVK(v.UFVK)
+	if err != nil {
+		t.Fatalf("ParseUFVK error: %v", err)
+	}
+	defer k.Close()
+
+	for i, address := range v.Addresses {
+		index, scope, err := k.IndexOf(address)
+		if err != nil {
+			t.Fatalf("IndexOf(%d) error: %v", i, err)
+		}
+		if index != diversifier.FromUint32(uint32(i)) || scope != ScopeExternal {
+			t.Fatalf("IndexOf(%d) = %s, %s", i, index, scope)
+		}
+	}
+
+	internal := loadInternalVectors(t)
+	for _, i := range []int{0, 42, 99} {
+		index, scope, err := IndexOf(v.UFVK, internal.Addresses[i])
+		if err != nil {
+			t.Fatalf("IndexOf internal %d error: %v", i, err)
+		}
+		if index != diversifier.FromUint32(uint32(i)) || scope != ScopeInternal {
+			t.Fatalf("IndexOf internal %d = %s, %s", i, index, scope)
+		}
+	}
+
+	for _, tc := range loadWideVectors(t).Indexed {
+		index, scope, err := k.IndexOf(tc.Address)
+		if err != nil || index != tc.Index || scope != ScopeExternal {
+			t.Fatalf("IndexOf(%s) = %s, %s, %v", tc.Index, index, scope, err)
+		}
+	}
+}
+
+func TestIndexOf_NotOwned(t *testing.T) {
+	v := loadVectors(t)
+
+	tests := []struct {
+		name    string
+		address string
+		want    ErrorCode
+	}{
+		// v1 address 0 with one diversifier bit flipped: a well-formed
+		// receiver whose pk_d does not match the decrypted index.
+		{"foreign", "j17qx3r0gf3sm28ltngthgzu3ke5453e5qnql3pxfkc0skkphqwrex6e6sfml4msnllfsk4z60jq9p2m5dxj4xhnpn0vehp9uhlqc2wdwf", ErrAddressNotOwned},
+		// v1 address 0's receiver, encoded for testnet.
+		{"other_network", "jtest158cgua0efdr2jweerrapng6uz48rjmsg2hy69t7peulekq4c6hrytspm0rvpr33kwcrvjgdvsjlkat3pv0gmsqsf3gs7est7ugc7003m", ErrAddressNotOwned},
+		{"ufvk", v.UFVK, ErrAddressHrpMismatch},
+		{"empty", " ", ErrAddressEmpty},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := IndexOf(v.UFVK, tc.address)
+			if !errors.Is(err, &Error{Code: tc.want}) {
+				t.Fatalf("expected %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package addrgen
+
+import "github.com/Abdullah1738/juno-addrgen/internal/ffi"
+
+// UFVKItem describes one item of a UFVK: its typecode and the length of its
+// value. The value itself is never exposed.
+type UFVKItem struct {
+	Typecode Typecode
+	Len      int
+}
+
+// UFVKInfo is what InspectUFVK reports about a UFVK. None of it reveals the
+// key.
+type UFVKInfo struct {
+	Network Network
+	// Items lists every item of the UFVK in encoding order, including ones this
+	// package does not use.
+	Items []UFVKItem
+	// FVKErr is the *Error ParseUFVK fails with, or nil if the UFVK holds a
+	// usable Orchard FVK. Fingerprint and Address are only set when it is nil.
+	// It is about the Orchard item only: a malformed Sapling or P2PKH item
+	// does not set it.
+	FVKErr      error
+	Fingerprint KeyFingerprint
+	// Address is the external address at index 0.
+	Address string
+}
+
+// InspectUFVK decodes ufvk and describes it. Unlike ParseUFVK, it succeeds as
+// long as the bech32m container decodes, so it can explain why a UFVK is not
+// usable: see UFVKInfo.FVKErr.
+func InspectUFVK(ufvk string) (UFVKInfo, error) {
+	if err := SelfTest(); err != nil {
+		return UFVKInfo{}, err
+	}
+	network, items, fvkErr, err := ffi.InspectUFVK(ufvk)
+	if err != nil {
+		return UFVKInfo{}, wrapErr(err)
+	}
+
+	info := UFVKInfo{Network: Network(network), Items: make([]UFVKItem, len(items))}
+	for i, item := range items {
+		info.Items[i] = UFVKItem{Typecode: Typecode(item.Typecode), Len: item.Len}
+	}
+	if fvkErr != nil {
+		info.FVKErr = wrapErr(fvkErr)
+		return info, nil
+	}
+
+	k, err := ParseUFVK(ufvk)
+	if err != nil {
+		return UFVKInfo{}, err
+	}
+	defer k.Close()
+
+	if info.Fingerprint, err = k.Fingerprint(); err != nil {
+		return UFVKInfo{}, err
+	}
+	if info.Address, err = k.Derive(0); err != nil {
+		return UFVKInfo{}, err
+	}
+	return info, nil
+}
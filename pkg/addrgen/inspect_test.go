@@ -0,0 +1,58 @@
+package addrgen
+
+import (
+	"errors"
+	"testing"
+)
+
+// The FVK fingerprint of the v1 vectors' UFVK.
+const v1Fingerprint = "e37feab45ad86988f554c78392b95cd1ab94e1e0f407262f3c2477c772414738"
+
+func TestInspectUFVK(t *testing.T) {
+	v := loadVectors(t)
+
+	info, err := InspectUFVK(v.UFVK)
+	if err != nil {
+		t.Fatalf("InspectUFVK error: %v", err)
+	}
+	if info.Network != NetworkMainnet || len(info.Items) != 1 || info.Items[0] != (UFVKItem{TypecodeOrchard, 96}) {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+	if info.FVKErr != nil || info.Fingerprint.String() != v1Fingerprint || info.Address != v.Addresses[0] {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+
+	// Same FVK, other network: same fingerprint.
+	info, err = InspectUFVK(testnetUFVK)
+	if err != nil || info.Network != NetworkTestnet || info.Fingerprint.String() != v1Fingerprint {
+		t.Fatalf("unexpected testnet info: %+v, %v", info, err)
+	}
+	info, err = InspectUFVK(otherMainnetUFVK)
+	if err != nil || info.Fingerprint.String() == v1Fingerprint {
+		t.Fatalf("unexpected info for other key: %+v, %v", info, err)
+	}
+}
+
+func TestInspectUFVK_UnusableFVK(t *testing.T) {
+	// Typecode 0x42 (3 bytes), then the v1 FVK cut to 95 bytes.
+	const short = "jview1vtlaeujwgzlw7e59kyhnylgt0gchugh9xk4rgz2gaym86fkd754wctfreyp9q8tdqrkjeu8cg4h874u2thua7k0qcecefkhxm6uurdukffk6wl507kzgwzxq24k9mlwmdhaxr39mfstz6zksxq3wsf6pkah0fjayvhwfy0uvgxwxgsslxk4xr3qh4qh9vyhhelv"
+
+	info, err := InspectUFVK(short)
+	if err != nil {
+		t.Fatalf("InspectUFVK error: %v", err)
+	}
+	want := []UFVKItem{{Typecode(0x42), 3}, {TypecodeOrchard, 95}}
+	if len(info.Items) != 2 || info.Items[0] != want[0] || info.Items[1] != want[1] {
+		t.Fatalf("unexpected items: %+v", info.Items)
+	}
+	if !errors.Is(info.FVKErr, &Error{Code: ErrUFVKValueLenInvalid}) {
+		t.Fatalf("expected %q, got %v", ErrUFVKValueLenInvalid, info.FVKErr)
+	}
+	if info.Fingerprint != (KeyFingerprint{}) || info.Address != "" {
+		t.Fatalf("unexpected key data for unusable FVK: %+v", info)
+	}
+
+	if _, err := InspectUFVK("jview1qqqq"); !errors.Is(err, &Error{Code: ErrUFVKInvalidBech32m}) {
+		t.Fatalf("expected %q, got %v", ErrUFVKInvalidBech32m, err)
+	}
+}
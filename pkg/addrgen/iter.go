@@ -0,0 +1,70 @@
+package addrgen
+
+import (
+	"iter"
+
+	"github.com/Abdullah1738/juno-addrgen/pkg/diversifier"
+)
+
+// firstIterChunk is the size of the first chunk derived by AddressSeq. Chunks
+// double up to streamChunk, so a loop that breaks early wastes little work
+// while long loops still amortize the per-call overhead.
+const firstIterChunk = 16
+
+// AddressSeq is a lazily derived range of addresses, created by
+// Key.Addresses. Range over All, then check Err:
+//
+//	seq := key.Addresses(0, 1000)
+//	for idx, addr := range seq.All() {
+//		...
+//	}
+//	if err := seq.Err(); err != nil {
+//		...
+//	}
+type AddressSeq struct {
+	key   *Key
+	start uint32
+	end   uint32
+	opts  []Option
+	err   error
+}
+
+// Addresses returns the addresses with indices in [start, end). Nothing is
+// derived until the sequence is iterated.
+func (k *Key) Addresses(start, end uint32, opts ...Option) *AddressSeq {
+	return &AddressSeq{key: k, start: start, end: end, opts: opts}
+}
+
+// All yields index/address pairs in index order. Addresses are derived in
+// chunks as the loop advances; breaking out of the loop stops derivation. If
+// derivation fails, iteration stops and the error is reported by Err.
+func (s *AddressSeq) All() iter.Seq2[uint32, string] {
+	return func(yield func(uint32, string) bool) {
+		s.err = nil
+
+		o := applyOptions(s.opts)
+		chunk := uint32(firstIterChunk)
+		for next := s.start; next < s.end; {
+			n := min(chunk, s.end-next)
+			addresses, err := s.key.batch(diversifier.FromUint32(next), n, o)
+			if err != nil {
+				s.err = err
+				return
+			}
+			for _, a := range addresses {
+				// In range: the chunk lies below s.end.
+				index, _ := a.Index.Uint32()
+				if !yield(index, a.Encoded) {
+					return
+				}
+			}
+			next += n
+			chunk = min(chunk*2, streamChunk)
+		}
+	}
+}
+
+// Err returns the error that stopped the most recent iteration, if any.
+func (s *AddressSeq) Err() error {
+	return s.err
+}
@@ -0,0 +1,79 @@
+package addrgen
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAddressSeq_GoldenVectors(t *testing.T) {
+	v := loadVectors(t)
+
+	k, err := ParseUFVK(v.UFVK)
+	if err != nil {
+		t.Fatalf("ParseUFVK error: %v", err)
+	}
+	defer k.Close()
+
+	seq := k.Addresses(3, uint32(len(v.Addresses)))
+	want := uint32(3)
+	for idx, addr := range seq.All() {
+		if idx != want {
+			t.Fatalf("unexpected index: %d (want %d)", idx, want)
+		}
+		if addr != v.Addresses[idx] {
+			t.Fatalf("mismatch at %d\nwant: %s\ngot:  %s", idx, v.Addresses[idx], addr)
+		}
+		want++
+	}
+	if err := seq.Err(); err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	if want != uint32(len(v.Addresses)) {
+		t.Fatalf("iteration ended early at %d", want)
+	}
+}
+
+func TestAddressSeq_Break(t *testing.T) {
+	v := loadVectors(t)
+
+	k, err := ParseUFVK(v.UFVK)
+	if err != nil {
+		t.Fatalf("ParseUFVK error: %v", err)
+	}
+	defer k.Close()
+
+	seq := k.Addresses(0, 1<<20)
+	var n int
+	for range seq.All() {
+		n++
+		if n == 5 {
+			break
+		}
+	}
+	if n != 5 || seq.Err() != nil {
+		t.Fatalf("unexpected result: n=%d err=%v", n, seq.Err())
+	}
+
+	for range k.Addresses(10, 10).All() {
+		t.Fatalf("expected empty range")
+	}
+}
+
+func TestAddressSeq_Error(t *testing.T) {
+	v := loadVectors(t)
+
+	k, err := ParseUFVK(v.UFVK)
+	if err != nil {
+		t.Fatalf("ParseUFVK error: %v", err)
+	}
+	k.Close()
+
+	seq := k.Addresses(0, 10)
+	for range seq.All() {
+		t.Fatalf("unexpected address from closed key")
+	}
+	var ae *Error
+	if !errors.As(seq.Err(), &ae) || ae.Code != ErrKeyClosed {
+		t.Fatalf("expected %q, got %v", ErrKeyClosed, seq.Err())
+	}
+}
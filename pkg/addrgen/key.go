@@ -0,0 +1,79 @@
+package addrgen
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/Abdullah1738/juno-addrgen/internal/ffi"
+	"github.com/Abdullah1738/juno-addrgen/pkg/diversifier"
+)
+
+// Key is a parsed UFVK or UIVK. Parsing once and deriving many addresses
+// avoids re-decoding the key on every call.
+//
+// A Key is safe for concurrent use by multiple goroutines. Call Close to
+// release the underlying handle; a finalizer releases it otherwise.
+type Key struct {
+	mu      sync.RWMutex
+	handle  *ffi.Key
+	network Network
+}
+
+// ParseUFVK decodes and validates ufvk (jview*1...). It also accepts a UIVK
+// (jivk*1...), reporting the same ErrUFVK* errors; a key parsed from a UIVK
+// derives external addresses only, and fails with ErrScopeUnavailable in the
+// internal scope. Only the Orchard item is validated here; a malformed Sapling
+// or P2PKH item is reported by the calls that request those receivers.
+func ParseUFVK(ufvk string) (*Key, error) {
+	if err := SelfTest(); err != nil {
+		return nil, err
+	}
+	handle, network, err := ffi.ParseKey(ufvk)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+
+	k := &Key{handle: handle, network: Network(network)}
+	runtime.SetFinalizer(k, (*Key).Close)
+	return k, nil
+}
+
+// Network reports the network encoded in the key's HRP.
+func (k *Key) Network() Network {
+	return k.network
+}
+
+// Derive returns the external address at the given diversifier index.
+func (k *Key) Derive(index uint32) (string, error) {
+	return k.DeriveScoped(index, ScopeExternal)
+}
+
+// DeriveScoped returns the address at the given diversifier index in scope.
+func (k *Key) DeriveScoped(index uint32, scope Scope) (string, error) {
+	return k.DeriveIndex(diversifier.FromUint32(index), WithScope(scope))
+}
+
+// Batch returns count consecutive addresses starting at start, in the
+// external scope unless WithScope is given. The batch must end within the
+// 32-bit index space; use BatchIndex beyond it.
+func (k *Key) Batch(start uint32, count uint32, opts ...Option) ([]string, error) {
+	if err := checkBatchRange(start, count); err != nil {
+		return nil, err
+	}
+	return k.BatchIndex(diversifier.FromUint32(start), count, opts...)
+}
+
+// Close releases the underlying handle. Calls after the first are no-ops;
+// using the Key after Close returns ErrKeyClosed.
+func (k *Key) Close() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.handle == nil {
+		return nil
+	}
+	k.handle.Free()
+	k.handle = nil
+	runtime.SetFinalizer(k, nil)
+	return nil
+}
@@ -0,0 +1,105 @@
+package addrgen
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestKey_GoldenVectors(t *testing.T) {
+	v := loadVectors(t)
+
+	k, err := ParseUFVK(v.UFVK)
+	if err != nil {
+		t.Fatalf("ParseUFVK error: %v", err)
+	}
+	defer k.Close()
+
+	if k.Network() != NetworkMainnet {
+		t.Fatalf("unexpected network: %q", k.Network())
+	}
+
+	for _, idx := range []uint32{0, 1, 10, 99} {
+		got, err := k.Derive(idx)
+		if err != nil {
+			t.Fatalf("Derive(%d) error: %v", idx, err)
+		}
+		if got != v.Addresses[idx] {
+			t.Fatalf("mismatch at %d\nwant: %s\ngot:  %s", idx, v.Addresses[idx], got)
+		}
+	}
+
+	got, err := k.Batch(90, 10)
+	if err != nil {
+		t.Fatalf("Batch error: %v", err)
+	}
+	for i, a := range got {
+		if a != v.Addresses[90+i] {
+			t.Fatalf("mismatch at %d\nwant: %s\ngot:  %s", 90+i, v.Addresses[90+i], a)
+		}
+	}
+}
+
+func TestKey_Concurrent(t *testing.T) {
+	v := loadVectors(t)
+
+	k, err := ParseUFVK(v.UFVK)
+	if err != nil {
+		t.Fatalf("ParseUFVK error: %v", err)
+	}
+	defer k.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(v.Addresses))
+	for i := range v.Addresses {
+		wg.Add(1)
+		go func(idx uint32) {
+			defer wg.Done()
+			got, err := k.Derive(idx)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if got != v.Addresses[idx] {
+				errs <- errors.New("mismatch at " + got)
+			}
+		}(uint32(i))
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
+
+func TestKey_Errors(t *testing.T) {
+	_, err := ParseUFVK("")
+	var ae *Error
+	if !errors.As(err, &ae) || ae.Code != ErrUFVKEmpty {
+		t.Fatalf("expected %q, got %v", ErrUFVKEmpty, err)
+	}
+
+	v := loadVectors(t)
+	k, err := ParseUFVK(v.UFVK)
+	if err != nil {
+		t.Fatalf("ParseUFVK error: %v", err)
+	}
+
+	_, err = k.Batch(0, 0)
+	if !errors.As(err, &ae) || ae.Code != ErrCountZero {
+		t.Fatalf("expected %q, got %v", ErrCountZero, err)
+	}
+
+	if err := k.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	if err := k.Close(); err != nil {
+		t.Fatalf("second Close error: %v", err)
+	}
+
+	_, err = k.Derive(0)
+	if !errors.As(err, &ae) || ae.Code != ErrKeyClosed {
+		t.Fatalf("expected %q, got %v", ErrKeyClosed, err)
+	}
+}
@@ -0,0 +1,34 @@
+package addrgen
+
+import "github.com/Abdullah1738/juno-addrgen/internal/ffi"
+
+// SpendingKeyLen is the length of an Orchard spending key.
+const SpendingKeyLen = ffi.SpendingKeyLen
+
+// AccountKeys are the keys of one ZIP-32 account.
+type AccountKeys struct {
+	// UFVK is the account's Orchard-only UFVK.
+	UFVK string
+	// SpendingKey is the account's Orchard spending key, which can spend
+	// its funds.
+	SpendingKey [SpendingKeyLen]byte
+}
+
+// DeriveAccountKeys derives the keys of ZIP-32 account account of seed, under
+// Juno Cash's coin type, with the orchard crate. The seed must be 32 to 252
+// bytes (ErrSeedInvalid) and the account below 2^31 (ErrAccountInvalid). A
+// seed whose account has no valid spending key, which is vanishingly
+// unlikely, fails with ErrSpendingKeyInvalid.
+func DeriveAccountKeys(seed []byte, account uint32, network Network) (AccountKeys, error) {
+	if !network.Valid() {
+		return AccountKeys{}, &Error{Code: ErrNetworkUnknown}
+	}
+	if err := SelfTest(); err != nil {
+		return AccountKeys{}, err
+	}
+	ufvk, sk, err := ffi.Keygen(seed, account, string(network))
+	if err != nil {
+		return AccountKeys{}, wrapErr(err)
+	}
+	return AccountKeys{UFVK: ufvk, SpendingKey: sk}, nil
+}
@@ -0,0 +1,63 @@
+package addrgen
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/Abdullah1738/juno-addrgen/pkg/orchard"
+)
+
+func TestDeriveAccountKeys(t *testing.T) {
+	// Every vectors file holds account 0 of the seed [7; 64].
+	seed := bytes.Repeat([]byte{7}, 64)
+	for _, tc := range []struct {
+		file    string
+		network Network
+	}{
+		{"v1.json", NetworkMainnet},
+		{"v1_testnet.json", NetworkTestnet},
+		{"v1_regtest.json", NetworkRegtest},
+	} {
+		keys, err := DeriveAccountKeys(seed, 0, tc.network)
+		if err != nil {
+			t.Fatalf("%s: DeriveAccountKeys error: %v", tc.network, err)
+		}
+		if want := loadVectorsFile(t, tc.file).UFVK; keys.UFVK != want {
+			t.Fatalf("%s: want %s\ngot  %s", tc.network, want, keys.UFVK)
+		}
+	}
+
+	// The orchard crate and pkg/orchard agree.
+	for _, account := range []uint32{0, 1, 7, 1<<31 - 1} {
+		keys, err := DeriveAccountKeys(seed, account, NetworkMainnet)
+		if err != nil {
+			t.Fatalf("account %d: DeriveAccountKeys error: %v", account, err)
+		}
+		sk, err := orchard.SpendingKeyFromSeed(seed, 8133, account)
+		if err != nil {
+			t.Fatalf("account %d: SpendingKeyFromSeed error: %v", account, err)
+		}
+		if keys.SpendingKey != sk.Bytes() {
+			t.Fatalf("account %d: spending keys differ", account)
+		}
+	}
+
+	for _, tc := range []struct {
+		name    string
+		seed    []byte
+		account uint32
+		network Network
+		want    ErrorCode
+	}{
+		{"no seed", nil, 0, NetworkMainnet, ErrSeedInvalid},
+		{"short seed", seed[:31], 0, NetworkMainnet, ErrSeedInvalid},
+		{"long seed", bytes.Repeat([]byte{7}, 253), 0, NetworkMainnet, ErrSeedInvalid},
+		{"hardened account", seed, 1 << 31, NetworkMainnet, ErrAccountInvalid},
+		{"unknown network", seed, 0, "signet", ErrNetworkUnknown},
+	} {
+		if _, err := DeriveAccountKeys(tc.seed, tc.account, tc.network); !errors.Is(err, &Error{Code: tc.want}) {
+			t.Fatalf("%s: expected %q, got %v", tc.name, tc.want, err)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package addrgen
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// KeyRing holds many parsed UFVKs under labels and routes an address to the
+// key that derives it.
+//
+// A KeyRing is safe for concurrent use by multiple goroutines. Close releases
+// every key it holds.
+type KeyRing struct {
+	mu   sync.RWMutex
+	keys map[string]*Key
+	// labels maps each key's external address at index 0, which identifies
+	// its Orchard incoming viewing key and network, to the key's label.
+	labels map[string]string
+}
+
+// KeyRingError reports which key ring entry an error is about.
+type KeyRingError struct {
+	Label string
+	Err   error
+}
+
+func (e *KeyRingError) Error() string {
+	return fmt.Sprintf("keyring entry %q: %v", e.Label, e.Err)
+}
+
+func (e *KeyRingError) Unwrap() error {
+	return e.Err
+}
+
+// NewKeyRing returns an empty key ring.
+func NewKeyRing() *KeyRing {
+	return &KeyRing{keys: make(map[string]*Key), labels: make(map[string]string)}
+}
+
+// Add parses ufvk and stores it under label. Labels must be non-empty and
+// unique within the ring, and so must keys: a key that derives the same
+// addresses as one already in the ring (the same UFVK, or a UIVK of it) fails
+// with ErrKeyRingKeyDuplicate, as Owner could only ever report one of them.
+// Errors are returned as *KeyRingError wrapping the underlying *Error.
+func (r *KeyRing) Add(label string, ufvk string) error {
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return &KeyRingError{Label: label, Err: &Error{Code: ErrKeyRingLabelEmpty}}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.keys[label]; ok {
+		return &KeyRingError{Label: label, Err: &Error{Code: ErrKeyRingLabelDuplicate}}
+	}
+	k, err := ParseUFVK(ufvk)
+	if err != nil {
+		return &KeyRingError{Label: label, Err: err}
+	}
+	id, err := k.Derive(0)
+	if err != nil {
+		k.Close()
+		return &KeyRingError{Label: label, Err: err}
+	}
+	if _, ok := r.labels[id]; ok {
+		k.Close()
+		return &KeyRingError{Label: label, Err: &Error{Code: ErrKeyRingKeyDuplicate}}
+	}
+	r.keys[label] = k
+	r.labels[id] = label
+	return nil
+}
+
+// Labels returns the labels in the ring, sorted.
+func (r *KeyRing) Labels() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	labels := make([]string, 0, len(r.keys))
+	for label := range r.keys {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// Owner returns the label of the key that derives address, with the index and
+// scope it was derived at. Only keys on the address's network are tried, in
+// label order. It returns ErrAddressNotOwned if no key derives address, and
+// the address decoding error if address is malformed.
+func (r *KeyRing) Owner(address string) (string, DiversifierIndex, Scope, error) {
+	var a Address
+	if err := a.UnmarshalText([]byte(strings.TrimSpace(address))); err != nil {
+		return "", DiversifierIndex{}, "", err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	labels := make([]string, 0, len(r.keys))
+	for label, k := range r.keys {
+		if k.Network() == a.Network {
+			labels = append(labels, label)
+		}
+	}
+	sort.Strings(labels)
+
+	for _, label := range labels {
+		index, scope, err := r.keys[label].IndexOf(a.Encoded)
+		if errors.Is(err, &Error{Code: ErrAddressNotOwned}) {
+			continue
+		}
+		if err != nil {
+			return "", DiversifierIndex{}, "", &KeyRingError{Label: label, Err: err}
+		}
+		return label, index, scope, nil
+	}
+	return "", DiversifierIndex{}, "", &Error{Code: ErrAddressNotOwned}
+}
+
+// Close releases every key in the ring and empties it.
+func (r *KeyRing) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for label, k := range r.keys {
+		k.Close()
+		delete(r.keys, label)
+	}
+	clear(r.labels)
+	return nil
+}
@@ -0,0 +1,106 @@
+package addrgen
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Abdullah1738/juno-addrgen/pkg/diversifier"
+)
+
+const (
+	// The v1 vectors' FVK, encoded for testnet.
+	testnetUFVK = "jviewtest1mxlgzwy7xh5ypgcsskhz647rgkkv0exvttkl2409zvsts9yx3sa6klwuelmgluwp8dju9lsc6tsr29kklcmswzzwe58chn54f99nhstzlqf4m9gkvarr9tvmm2grvg28ay3fmewjps2z4mmvcz5dmwwmtxpjj2h6qs477z6dsmnkrf3vt23qfpsz377gc"
+	// The v1 vectors' FVK with a different rivk: a second, unrelated mainnet key.
+	otherMainnetUFVK = "jview1rdqr9e0k6tleza5lnfzwxy9r8uqz3kkjxqejrhw8kvr3mq7typhmcz4hx0n9tnqx3tf0nep28jfuye0a8yrxpwqj466c7wplvzqgrrqdnaeww6jlwv79wjw42nwlysww86c5xjmacndzh57mwnvdf620zx93mfhss08h5hsqn42drzwfzz22xestn2hjv"
+)
+
+func newTestKeyRing(t *testing.T) *KeyRing {
+	t.Helper()
+
+	r := NewKeyRing()
+	t.Cleanup(func() { r.Close() })
+	for label, ufvk := range map[string]string{
+		"retail":   loadVectors(t).UFVK,
+		"treasury": otherMainnetUFVK,
+		"staging":  testnetUFVK,
+	} {
+		if err := r.Add(label, ufvk); err != nil {
+			t.Fatalf("Add(%s) error: %v", label, err)
+		}
+	}
+	return r
+}
+
+func TestKeyRing_Owner(t *testing.T) {
+	v := loadVectors(t)
+	r := newTestKeyRing(t)
+
+	if got := r.Labels(); !reflect.DeepEqual(got, []string{"retail", "staging", "treasury"}) {
+		t.Fatalf("unexpected labels: %v", got)
+	}
+
+	label, index, scope, err := r.Owner(v.Addresses[5])
+	if err != nil || label != "retail" || index != diversifier.FromUint32(5) || scope != ScopeExternal {
+		t.Fatalf("Owner = %q, %s, %s, %v", label, index, scope, err)
+	}
+
+	for _, tc := range []struct {
+		label string
+		ufvk  string
+		scope Scope
+	}{
+		{"treasury", otherMainnetUFVK, ScopeInternal},
+		{"staging", testnetUFVK, ScopeExternal},
+	} {
+		address, err := DeriveScoped(tc.ufvk, 9, tc.scope)
+		if err != nil {
+			t.Fatalf("DeriveScoped error: %v", err)
+		}
+		label, index, scope, err := r.Owner(address)
+		if err != nil || label != tc.label || index != diversifier.FromUint32(9) || scope != tc.scope {
+			t.Fatalf("Owner(%s address) = %q, %s, %s, %v", tc.label, label, index, scope, err)
+		}
+	}
+}
+
+func TestKeyRing_Errors(t *testing.T) {
+	v := loadVectors(t)
+	r := newTestKeyRing(t)
+
+	// v1 address 0 with one diversifier bit flipped.
+	const foreign = "j17qx3r0gf3sm28ltngthgzu3ke5453e5qnql3pxfkc0skkphqwrex6e6sfml4msnllfsk4z60jq9p2m5dxj4xhnpn0vehp9uhlqc2wdwf"
+	if _, _, _, err := r.Owner(foreign); !errors.Is(err, &Error{Code: ErrAddressNotOwned}) {
+		t.Fatalf("expected %q, got %v", ErrAddressNotOwned, err)
+	}
+	if _, _, _, err := r.Owner(v.UFVK); !errors.Is(err, &Error{Code: ErrAddressHrpMismatch}) {
+		t.Fatalf("expected %q, got %v", ErrAddressHrpMismatch, err)
+	}
+
+	var ke *KeyRingError
+	err := r.Add("retail", otherMainnetUFVK)
+	if !errors.As(err, &ke) || ke.Label != "retail" || !errors.Is(err, &Error{Code: ErrKeyRingLabelDuplicate}) {
+		t.Fatalf("expected duplicate label error, got %v", err)
+	}
+	// The same key under a second label, as a UFVK or as its UIVK.
+	uivk, err := ExportUIVK(v.UFVK)
+	if err != nil {
+		t.Fatalf("ExportUIVK error: %v", err)
+	}
+	for _, dup := range []string{v.UFVK, uivk} {
+		err = r.Add("retail-copy", dup)
+		if !errors.As(err, &ke) || ke.Label != "retail-copy" || !errors.Is(err, &Error{Code: ErrKeyRingKeyDuplicate}) {
+			t.Fatalf("expected duplicate key error, got %v", err)
+		}
+	}
+	if got := r.Labels(); len(got) != 3 {
+		t.Fatalf("unexpected labels: %v", got)
+	}
+	err = r.Add("broken", "jview1qqqq")
+	if !errors.As(err, &ke) || ke.Label != "broken" || !errors.Is(err, &Error{Code: ErrUFVKInvalidBech32m}) {
+		t.Fatalf("expected ufvk error for label, got %v", err)
+	}
+	if err := r.Add(" ", v.UFVK); !errors.Is(err, &Error{Code: ErrKeyRingLabelEmpty}) {
+		t.Fatalf("expected %q, got %v", ErrKeyRingLabelEmpty, err)
+	}
+}
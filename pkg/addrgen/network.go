@@ -0,0 +1,90 @@
+package addrgen
+
+import "strings"
+
+// Network identifies the Juno Cash network a key or address belongs to.
+type Network string
+
+const (
+	NetworkMainnet Network = "mainnet"
+	NetworkTestnet Network = "testnet"
+	NetworkRegtest Network = "regtest"
+)
+
+var networks = [...]Network{NetworkMainnet, NetworkTestnet, NetworkRegtest}
+
+// Networks returns the supported networks. The slice is a copy; callers may
+// modify it.
+func Networks() []Network {
+	return append([]Network(nil), networks[:]...)
+}
+
+type networkHRPs struct {
+	ufvk    string
+	uivk    string
+	address string
+}
+
+// Mirrors UFVK_HRP_TO_UA_HRP and UIVK_HRP_TO_UA_HRP in the Rust library.
+var hrpsByNetwork = map[Network]networkHRPs{
+	NetworkMainnet: {ufvk: "jview", uivk: "jivk", address: "j"},
+	NetworkTestnet: {ufvk: "jviewtest", uivk: "jivktest", address: "jtest"},
+	NetworkRegtest: {ufvk: "jviewregtest", uivk: "jivkregtest", address: "jregtest"},
+}
+
+func (n Network) String() string {
+	return string(n)
+}
+
+// Valid reports whether n is one of Networks().
+func (n Network) Valid() bool {
+	_, ok := hrpsByNetwork[n]
+	return ok
+}
+
+// UFVKHRP returns the HRP of UFVKs on n (e.g. "jview"), or "" if n is not
+// valid.
+func (n Network) UFVKHRP() string {
+	return hrpsByNetwork[n].ufvk
+}
+
+// UIVKHRP returns the HRP of UIVKs on n (e.g. "jivk"), or "" if n is not
+// valid.
+func (n Network) UIVKHRP() string {
+	return hrpsByNetwork[n].uivk
+}
+
+// AddressHRP returns the HRP of unified addresses on n (e.g. "j"), or "" if n
+// is not valid.
+func (n Network) AddressHRP() string {
+	return hrpsByNetwork[n].address
+}
+
+// ParseNetwork parses "mainnet", "testnet" or "regtest".
+func ParseNetwork(s string) (Network, error) {
+	n := Network(strings.ToLower(strings.TrimSpace(s)))
+	if !n.Valid() {
+		return "", &Error{Code: ErrNetworkUnknown}
+	}
+	return n, nil
+}
+
+// DetectNetwork returns the network of a UFVK, UIVK or unified address from
+// its HRP. It does not validate the rest of the string; use ParseUFVK or
+// Address.UnmarshalText for that.
+func DetectNetwork(s string) (Network, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 1 {
+		return "", &Error{Code: ErrNetworkUnknown}
+	}
+	hrp := s[:sep]
+
+	for _, n := range networks {
+		h := hrpsByNetwork[n]
+		if hrp == h.ufvk || hrp == h.uivk || hrp == h.address {
+			return n, nil
+		}
+	}
+	return "", &Error{Code: ErrNetworkUnknown}
+}
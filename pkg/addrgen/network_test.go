@@ -0,0 +1,73 @@
+package addrgen
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDetectNetwork(t *testing.T) {
+	v := loadVectors(t)
+
+	tests := []struct {
+		in   string
+		want Network
+	}{
+		{in: v.UFVK, want: NetworkMainnet},
+		{in: v.Addresses[0], want: NetworkMainnet},
+		{in: "jviewtest1qqqq", want: NetworkTestnet},
+		{in: "jtest1qqqq", want: NetworkTestnet},
+		{in: "jviewregtest1qqqq", want: NetworkRegtest},
+		{in: v1UIVK, want: NetworkMainnet},
+		{in: "jivkregtest1qqqq", want: NetworkRegtest},
+		{in: "  JREGTEST1QQQQ\n", want: NetworkRegtest},
+	}
+	for _, tc := range tests {
+		got, err := DetectNetwork(tc.in)
+		if err != nil {
+			t.Fatalf("DetectNetwork(%q) error: %v", tc.in, err)
+		}
+		if got != tc.want {
+			t.Fatalf("DetectNetwork(%q): want %q, got %q", tc.in, tc.want, got)
+		}
+	}
+
+	for _, in := range []string{"", "1qqqq", "u1qqqq", "uview1qqqq", "jviewmain1qqqq", "jview"} {
+		_, err := DetectNetwork(in)
+		if !errors.Is(err, &Error{Code: ErrNetworkUnknown}) {
+			t.Fatalf("DetectNetwork(%q): expected %q, got %v", in, ErrNetworkUnknown, err)
+		}
+	}
+}
+
+func TestNetwork_HRPs(t *testing.T) {
+	for _, n := range Networks() {
+		parsed, err := ParseNetwork(n.String())
+		if err != nil || parsed != n {
+			t.Fatalf("ParseNetwork(%q) = %q, %v", n, parsed, err)
+		}
+		if got, _ := DetectNetwork(n.UFVKHRP() + "1qqqq"); got != n {
+			t.Fatalf("UFVK HRP of %q detected as %q", n, got)
+		}
+		if got, _ := DetectNetwork(n.UIVKHRP() + "1qqqq"); got != n {
+			t.Fatalf("UIVK HRP of %q detected as %q", n, got)
+		}
+		if got, _ := DetectNetwork(n.AddressHRP() + "1qqqq"); got != n {
+			t.Fatalf("address HRP of %q detected as %q", n, got)
+		}
+	}
+
+	if _, err := ParseNetwork("signet"); !errors.Is(err, &Error{Code: ErrNetworkUnknown}) {
+		t.Fatalf("expected %q, got %v", ErrNetworkUnknown, err)
+	}
+	if Network("signet").Valid() {
+		t.Fatalf("unexpected valid network")
+	}
+}
+
+func TestNetworks_ReturnsCopy(t *testing.T) {
+	got := Networks()
+	got[0] = "bogus"
+	if Networks()[0] != NetworkMainnet {
+		t.Fatalf("Networks shares its backing array with callers")
+	}
+}
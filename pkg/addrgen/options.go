@@ -0,0 +1,99 @@
+package addrgen
+
+import (
+	"math"
+
+	"github.com/Abdullah1738/juno-addrgen/internal/ffi"
+)
+
+// Option configures derivation.
+type Option func(*options)
+
+type options struct {
+	jobs      uint32
+	scope     Scope
+	receivers []Typecode
+}
+
+func applyOptions(opts []Option) options {
+	o := options{jobs: 1, scope: ScopeExternal}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithParallelism splits a batch across up to n worker threads in the Rust
+// core. n <= 0 uses one worker per available core. Output order and content
+// are identical to sequential derivation. The default is 1 (sequential).
+func WithParallelism(n int) Option {
+	return func(o *options) {
+		switch {
+		case n <= 0:
+			o.jobs = 0
+		case uint64(n) > math.MaxUint32:
+			o.jobs = math.MaxUint32
+		default:
+			o.jobs = uint32(n)
+		}
+	}
+}
+
+// WithScope derives addresses in the given ZIP-32 scope instead of
+// ScopeExternal.
+func WithScope(scope Scope) Option {
+	return func(o *options) {
+		o.scope = scope
+	}
+}
+
+// WithReceivers derives addresses with the given receiver types instead of an
+// Orchard receiver alone. TypecodeOrchard is required; TypecodeSapling adds a
+// Sapling receiver, which needs a UFVK with a Sapling key (ErrReceiversUnavailable
+// otherwise) and ScopeExternal (ErrScopeUnavailable otherwise). TypecodeP2PKH
+// adds a transparent receiver for payers that only send to transparent
+// addresses; it needs a UFVK with a P2PKH item (ErrReceiversUnavailable
+// otherwise), works in both scopes, and uses the diversifier index as its
+// BIP-44 address index, so indices above MaxTransparentIndex fail with
+// ErrRangeOverflow. Other types fail with ErrReceiversInvalid. A malformed
+// Sapling or P2PKH item fails here, with ErrUFVKSaplingInvalid,
+// ErrUFVKTransparentInvalid or ErrUFVKValueLenInvalid, rather than in
+// ParseUFVK.
+//
+// About half of all Sapling diversifiers are invalid, and ZIP-316 has wallets
+// skip those indices. With a Sapling receiver, single derivations move on to
+// the next valid index and report it in Address.Index; batches, streams and
+// sequences leave the invalid indices out, so they may return fewer addresses
+// than the range holds.
+func WithReceivers(types ...Typecode) Option {
+	return func(o *options) {
+		o.receivers = types
+	}
+}
+
+// ids returns the library identifiers of the scope and receiver types.
+func (o options) ids() (scope uint32, receivers uint32, err error) {
+	scope, err = o.scope.id()
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(o.receivers) == 0 {
+		return scope, ffi.ReceiversOrchard, nil
+	}
+	for _, t := range o.receivers {
+		switch t {
+		case TypecodeOrchard:
+			receivers |= ffi.ReceiversOrchard
+		case TypecodeSapling:
+			receivers |= ffi.ReceiversSapling
+		case TypecodeP2PKH:
+			receivers |= ffi.ReceiversP2PKH
+		default:
+			return 0, 0, &Error{Code: ErrReceiversInvalid}
+		}
+	}
+	if receivers&ffi.ReceiversOrchard == 0 {
+		return 0, 0, &Error{Code: ErrReceiversInvalid}
+	}
+	return scope, receivers, nil
+}
@@ -0,0 +1,185 @@
+package addrgen
+
+import (
+	"errors"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/Abdullah1738/juno-addrgen/pkg/diversifier"
+	"github.com/Abdullah1738/juno-addrgen/pkg/orchard"
+	"github.com/Abdullah1738/juno-addrgen/pkg/zip316"
+)
+
+// These tests check pkg/orchard, the pure-Go derivation behind the
+// addrgen_purego build, against the Rust library on random keys.
+
+var (
+	pallasP, _ = new(big.Int).SetString("40000000000000000000000000000000224698fc094cf91b992d30ed00000001", 16)
+	pallasQ, _ = new(big.Int).SetString("40000000000000000000000000000000224698fc0994a8dd8c46eb2100000001", 16)
+)
+
+// putLE writes a, little-endian, to the 32 bytes of b.
+func putLE(b []byte, a *big.Int) {
+	a.FillBytes(b[:32])
+	for i, j := 0, 31; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
+
+// randomFVK returns a random FVK that is well-formed: ak is an encoded
+// Pallas point with an even y-coordinate, nk and rivk are canonical. It is
+// valid unless one of its IVKs is zero, which is vanishingly unlikely.
+func randomFVK(rng *rand.Rand) []byte {
+	fvk := make([]byte, orchard.FullViewingKeyLen)
+	for {
+		x := new(big.Int).Rand(rng, pallasP)
+		rhs := new(big.Int).Exp(x, big.NewInt(3), pallasP)
+		rhs.Add(rhs, big.NewInt(5))
+		if rhs.ModSqrt(rhs, pallasP) != nil && x.Sign() != 0 {
+			// Bit 255 holds the sign of y; leaving it clear selects the
+			// even root.
+			putLE(fvk[:32], x)
+			break
+		}
+	}
+	putLE(fvk[32:64], new(big.Int).Rand(rng, pallasP))
+	putLE(fvk[64:], new(big.Int).Rand(rng, pallasQ))
+	return fvk
+}
+
+// randomIndex returns a random diversifier index, below 2^32 half the time.
+func randomIndex(rng *rand.Rand) diversifier.Index {
+	var i diversifier.Index
+	n := len(i)
+	if rng.Intn(2) == 0 {
+		n = 4
+	}
+	rng.Read(i[:n])
+	return i
+}
+
+func TestOrchard_Differential(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	keys, indices := 16, 8
+	if testing.Short() {
+		keys, indices = 4, 2
+	}
+
+	for n := 0; n < keys; n++ {
+		fvkBytes := randomFVK(rng)
+		network := networks[n%len(networks)]
+		ufvk, err := zip316.EncodeUnifiedContainer(network.UFVKHRP(), uint64(TypecodeOrchard), fvkBytes)
+		if err != nil {
+			t.Fatalf("EncodeUnifiedContainer error: %v", err)
+		}
+
+		k, err := ParseUFVK(ufvk)
+		if err != nil {
+			t.Fatalf("key %d: ParseUFVK error: %v", n, err)
+		}
+		fvk, err := orchard.NewFullViewingKey(fvkBytes)
+		if err != nil {
+			k.Close()
+			t.Fatalf("key %d: NewFullViewingKey error: %v", n, err)
+		}
+
+		fp, err := k.Fingerprint()
+		if err != nil || fp != KeyFingerprint(fvk.Fingerprint()) {
+			t.Fatalf("key %d: fingerprint differs: %v", n, err)
+		}
+		uivk, err := k.UIVK()
+		if err != nil {
+			t.Fatalf("key %d: UIVK error: %v", n, err)
+		}
+		item, err := zip316.DecodeSingleTLVContainer(network.UIVKHRP(), uivk)
+		if b := fvk.IncomingViewingKey(orchard.ScopeExternal).Bytes(); err != nil || string(item.Value) != string(b[:]) {
+			t.Fatalf("key %d: IVK differs: %v", n, err)
+		}
+
+		for j := 0; j < indices; j++ {
+			index := randomIndex(rng)
+			for _, scope := range []struct {
+				rust Scope
+				pure orchard.Scope
+			}{
+				{ScopeExternal, orchard.ScopeExternal},
+				{ScopeInternal, orchard.ScopeInternal},
+			} {
+				a, err := k.DeriveAddressIndex(index, WithScope(scope.rust))
+				if err != nil {
+					t.Fatalf("key %d: DeriveAddressIndex(%s) error: %v", n, index, err)
+				}
+				ivk := fvk.IncomingViewingKey(scope.pure)
+				if ivk.Address(index) != a.Receiver {
+					t.Fatalf("key %d, %s index %s: receivers differ", n, scope.rust, index)
+				}
+				got, ok := ivk.DiversifierIndex(a.Receiver)
+				wantIndex, wantScope, err := k.IndexOf(a.Encoded)
+				if !ok || err != nil || got != wantIndex || wantScope != scope.rust {
+					t.Fatalf("key %d: IndexOf differs: %s %v / %s %s %v", n, got, ok, wantIndex, wantScope, err)
+				}
+			}
+		}
+		k.Close()
+	}
+}
+
+// TestOrchard_DifferentialInvalid checks that both implementations reject
+// the same malformed FVKs and IVKs, and the same receivers.
+func TestOrchard_DifferentialInvalid(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for n := 0; n < 200; n++ {
+		fvk := randomFVK(rng)
+		switch n % 4 {
+		case 0:
+			// Random bytes: ak is a valid point about half the time, and
+			// nk and rivk are out of range about three quarters of it.
+			rng.Read(fvk)
+		case 1:
+			fvk[31] |= 0x80
+		case 2:
+			// nk or rivk out of range: at least 2^254.
+			fvk[32+32*rng.Intn(2)+31] |= 0x40
+		case 3:
+			rng.Read(fvk[:32])
+		}
+		ufvk, err := zip316.EncodeUnifiedContainer(NetworkMainnet.UFVKHRP(), uint64(TypecodeOrchard), fvk)
+		if err != nil {
+			t.Fatalf("EncodeUnifiedContainer error: %v", err)
+		}
+		_, rustErr := Fingerprint(ufvk)
+		_, goErr := orchard.NewFullViewingKey(fvk)
+		if (rustErr == nil) != (goErr == nil) {
+			t.Fatalf("fvk %x: library says %v, pkg/orchard says %v", fvk, rustErr, goErr)
+		}
+		if rustErr != nil && !errors.Is(rustErr, &Error{Code: ErrUFVKFVKBytesInvalid}) {
+			t.Fatalf("fvk %x: unexpected error %v", fvk, rustErr)
+		}
+
+		// The same bytes as an IVK (dk || ivk, with rivk ignored), and as
+		// d || pk_d.
+		uivk, err := zip316.EncodeUnifiedContainer(NetworkMainnet.UIVKHRP(), uint64(TypecodeOrchard), fvk[:64])
+		if err != nil {
+			t.Fatalf("EncodeUnifiedContainer error: %v", err)
+		}
+		_, rustErr = Fingerprint(uivk)
+		_, goErr = orchard.NewIncomingViewingKey(fvk[:64])
+		if (rustErr == nil) != (goErr == nil) {
+			t.Fatalf("ivk %x: library says %v, pkg/orchard says %v", fvk[:64], rustErr, goErr)
+		}
+
+		var raw [orchard.RawAddressLen]byte
+		copy(raw[:], fvk[21:64])
+		address, err := zip316.EncodeUnifiedContainer(NetworkMainnet.AddressHRP(), uint64(TypecodeOrchard), raw[:])
+		if err != nil {
+			t.Fatalf("EncodeUnifiedContainer error: %v", err)
+		}
+		var a Address
+		rustErr = a.UnmarshalText([]byte(address))
+		goErr = orchard.CheckRawAddress(raw)
+		if (rustErr == nil) != (goErr == nil) {
+			t.Fatalf("receiver %x: library says %v, pkg/orchard says %v", raw, rustErr, goErr)
+		}
+	}
+}
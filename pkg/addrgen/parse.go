@@ -0,0 +1,109 @@
+package addrgen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Abdullah1738/juno-addrgen/internal/ffi"
+)
+
+// Typecode identifies the type of a unified address item (ZIP-316).
+type Typecode uint64
+
+const (
+	TypecodeP2PKH   Typecode = 0x00
+	TypecodeP2SH    Typecode = 0x01
+	TypecodeSapling Typecode = 0x02
+	TypecodeOrchard Typecode = 0x03
+)
+
+// String returns "p2pkh", "p2sh", "sapling" or "orchard", and the hex
+// typecode (e.g. "0x42") for unknown types.
+func (t Typecode) String() string {
+	switch t {
+	case TypecodeP2PKH:
+		return "p2pkh"
+	case TypecodeP2SH:
+		return "p2sh"
+	case TypecodeSapling:
+		return "sapling"
+	case TypecodeOrchard:
+		return "orchard"
+	default:
+		return fmt.Sprintf("%#x", uint64(t))
+	}
+}
+
+// Known reports whether t is one of the receiver types defined by ZIP-316.
+func (t Typecode) Known() bool {
+	return t <= TypecodeOrchard
+}
+
+// Receiver is one item of a unified address: its typecode and raw value.
+type Receiver struct {
+	Typecode Typecode
+	Data     []byte
+}
+
+// ParsedAddress is a decoded unified address, or a transparent address
+// decoded by ParseTransparentAddress.
+type ParsedAddress struct {
+	Encoded   string
+	Network   Network
+	Receivers []Receiver
+}
+
+// Receiver returns the value of the receiver with typecode t.
+func (p ParsedAddress) Receiver(t Typecode) ([]byte, bool) {
+	for _, r := range p.Receivers {
+		if r.Typecode == t {
+			return r.Data, true
+		}
+	}
+	return nil, false
+}
+
+// ParseAddress decodes a Juno unified address into its network and receivers,
+// in encoding order. Items with unknown typecodes are kept, as ZIP-316
+// requires, but at least one receiver must be of a known type, and the item
+// set must be one ZIP-316 allows.
+//
+// Errors are *Error with one of:
+//
+//   - ErrAddressEmpty
+//   - ErrAddressInvalidBech32m: not a bech32m string, or not an F4Jumble payload
+//   - ErrAddressChecksumInvalid: well-formed bech32m with a bad checksum (a typo)
+//   - ErrAddressZcash: a Zcash unified address (u1... / utest1... / uregtest1...)
+//   - ErrAddressHrpMismatch: any other HRP, including Juno UFVKs
+//   - ErrAddressPaddingInvalid: the ZIP-316 padding does not match the HRP
+//   - ErrAddressTlvInvalid: malformed or empty item list
+//   - ErrAddressTypecodeDuplicate: a typecode appears more than once
+//   - ErrAddressValueLenInvalid: a known receiver has the wrong length
+//   - ErrAddressReceiverInvalid: a Sapling or Orchard receiver is not a valid
+//     address (its diversifier or pk_d does not decode to a point)
+//   - ErrAddressReceiversUnknown: no receiver is of a known type
+//   - ErrAddressTransparentOnly: every item is a transparent receiver
+//   - ErrAddressP2PKHAndP2SH: both a P2PKH and a P2SH receiver
+//
+// Unlike Address.UnmarshalText, ParseAddress does not require an Orchard
+// receiver. Transparent receivers are hashes and cannot be checked.
+func ParseAddress(s string) (ParsedAddress, error) {
+	s = strings.TrimSpace(s)
+	if err := SelfTest(); err != nil {
+		return ParsedAddress{}, err
+	}
+	network, items, err := ffi.ParseAddress(s)
+	if err != nil {
+		return ParsedAddress{}, wrapErr(err)
+	}
+
+	receivers := make([]Receiver, len(items))
+	for i, item := range items {
+		receivers[i] = Receiver{Typecode: Typecode(item.Typecode), Data: item.Value}
+	}
+	return ParsedAddress{
+		Encoded:   s,
+		Network:   Network(network),
+		Receivers: receivers,
+	}, nil
+}
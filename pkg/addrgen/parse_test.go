@@ -0,0 +1,99 @@
+package addrgen
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestParseAddress(t *testing.T) {
+	v := loadVectors(t)
+
+	var want Address
+	if err := want.UnmarshalText([]byte(v.Addresses[0])); err != nil {
+		t.Fatalf("UnmarshalText error: %v", err)
+	}
+	p, err := ParseAddress(" " + v.Addresses[0] + "\n")
+	if err != nil {
+		t.Fatalf("ParseAddress error: %v", err)
+	}
+	if p.Encoded != v.Addresses[0] || p.Network != NetworkMainnet || len(p.Receivers) != 1 {
+		t.Fatalf("unexpected parse: %+v", p)
+	}
+	if data, ok := p.Receiver(TypecodeOrchard); !ok || !bytes.Equal(data, want.Receiver[:]) {
+		t.Fatalf("orchard receiver mismatch: %x", data)
+	}
+
+	// P2PKH (0x11 x 20), v1 address 0's Orchard receiver, and typecode 0x42
+	// (0x42 x 32), in that order.
+	const mixed = "j1vu7uca6s8ye232fj666jxfnn7gnrxcfqj6727qhy8sephdcv3gdf9dhn58tf5rd7frja5m90flcelmplwv39gwtd66l02352sju0ysvzma07g7kw824fsx3qckct5rvs8cv4dd92hclwddw5c7vl6zsx0w656lqe8de2ck69rghld4l6kzjpc8vhc4uqq0yy9j"
+	p, err = ParseAddress(mixed)
+	if err != nil {
+		t.Fatalf("ParseAddress(mixed) error: %v", err)
+	}
+	wantReceivers := []Receiver{
+		{TypecodeP2PKH, bytes.Repeat([]byte{0x11}, 20)},
+		{TypecodeOrchard, want.Receiver[:]},
+		{Typecode(0x42), bytes.Repeat([]byte{0x42}, 32)},
+	}
+	if len(p.Receivers) != len(wantReceivers) {
+		t.Fatalf("unexpected receivers: %+v", p.Receivers)
+	}
+	for i, r := range p.Receivers {
+		if r.Typecode != wantReceivers[i].Typecode || !bytes.Equal(r.Data, wantReceivers[i].Data) {
+			t.Fatalf("receiver %d = %s %x", i, r.Typecode, r.Data)
+		}
+	}
+	if _, ok := p.Receiver(TypecodeSapling); ok {
+		t.Fatalf("unexpected sapling receiver")
+	}
+	if got := p.Receivers[2].Typecode.String(); got != "0x42" || p.Receivers[2].Typecode.Known() {
+		t.Fatalf("unknown typecode = %s", got)
+	}
+}
+
+func TestParseAddress_Errors(t *testing.T) {
+	v := loadVectors(t)
+
+	flipped := []byte(v.Addresses[0])
+	if flipped[5] == 'q' {
+		flipped[5] = 'p'
+	} else {
+		flipped[5] = 'q'
+	}
+
+	cases := []struct {
+		name    string
+		address string
+		want    ErrorCode
+	}{
+		{"empty", " ", ErrAddressEmpty},
+		{"not bech32m", "not-an-address", ErrAddressInvalidBech32m},
+		{"typo", string(flipped), ErrAddressChecksumInvalid},
+		{"ufvk", v.UFVK, ErrAddressHrpMismatch},
+		// v1 address 0's receiver, encoded for Zcash mainnet.
+		{"zcash", "u1g5cetvll76pmqsfhdlgvwn6e6lnp45ldntkufejcz3r6wu0xk9xerpprxq36wwr2kzggyp8vt6ankx76d68xmvczwld8zjsjsvsfcrtw", ErrAddressZcash},
+		// The testnet encoding of v1 address 0 relabelled as mainnet: the
+		// padding still names jtest.
+		{"padding", "j158cgua0efdr2jweerrapng6uz48rjmsg2hy69t7peulekq4c6hrytspm0rvpr33kwcrvjgdvsjlkat3pv0gmsqsf3gs7est7ugc9lle2", ErrAddressPaddingInvalid},
+		// v1 address 0's Orchard receiver, twice.
+		{"duplicate", "j1ent93p3ked0ncl2fe5m8m3tahxvw5rxsny6fuvaj4qdvg87rm88488dfj3nu2zhklq9vq66gx33jrpqy4ujzvg6rjwdsux89cvrea4fa5yvuhvt9xgxd7qgcdvteyetnn5sgj7tlr23a5x2zqrmkmss8jmm0vurcwj2dprrcgvzvhyfc", ErrAddressTypecodeDuplicate},
+		// A single item with typecode 0x42.
+		{"unknown only", "j12f30cpzqdkfx55kk4scs53krpgdvasdq9fncqjs5smeqgjkea9wg37y0mw7d2t98gf35x9v640jhcrtjhdewq8", ErrAddressReceiversUnknown},
+		// An Orchard receiver of 43 zero bytes: its pk_d is the identity.
+		{"orchard identity", "j16ujr02l8qxle7k8gujpeyfs935tufgaacf3jax4zf32g58lr38ps5zvkz280ef7fyknqck0lvfqmwqv3vktvg89k5m07e3nv8qsaqpze", ErrAddressReceiverInvalid},
+		// A Sapling receiver of 43 0xff bytes (a non-canonical pk_d) and v1
+		// address 0's Orchard receiver.
+		{"sapling invalid", "j18wgy2ddudywprjhkw7dx4tzxd2nr5t4kertucs4twk4lk6u9t9al8hwdrmte3fu4fy5hw3wyl5kq5t4rmn2zqxnh4zt0z6g89l5nw6ycme0vn4jzzvsxjwujthampdyasgsllhd24r9ru0q07jh90w5f7ym98escy6we8nlmu592rluy", ErrAddressReceiverInvalid},
+		// A P2PKH and a P2SH receiver, nothing shielded.
+		{"transparent only", "j18yzxjy3ej5w0ac0n9avc8v7h0e5gpvfxg0elegdtv22ujt0vqc4yyk3a9wje4smzul2luppl6zaphjnaz76rztapv3m7dm4l75ag0s", ErrAddressTransparentOnly},
+		// The same two receivers and v1 address 0's Orchard receiver.
+		{"p2pkh and p2sh", "j17t3w0r5y8alf2exeh2ypcs8g649ras9f48zeuhpk9x3zgpwmenf9n4zpkug7vscc0weu3x3p8r647lf3s02afmnty3fuuefewanqzqdf4xpskx0ns9gdfxjvsr95qpfhwkfep073dttf8qr27w5lchp27hc0j4s66rvdfxckl7c53k", ErrAddressP2PKHAndP2SH},
+	}
+	for _, tc := range cases {
+		_, err := ParseAddress(tc.address)
+		if !errors.Is(err, &Error{Code: tc.want}) {
+			t.Fatalf("%s: expected %q, got %v", tc.name, tc.want, err)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package addrgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Abdullah1738/juno-addrgen/pkg/diversifier"
+)
+
+var withSapling = WithReceivers(TypecodeOrchard, TypecodeSapling)
+
+// saplingVectorsV1 is vectors/v1_sapling.json: the v1 UFVK with the same
+// account's Sapling key, and its Sapling + Orchard addresses at the indices
+// whose Sapling diversifier is valid.
+type saplingVectorsV1 struct {
+	Version   int      `json:"version"`
+	Receivers []string `json:"receivers"`
+	UFVK      string   `json:"ufvk"`
+	Indexed   []struct {
+		Index   DiversifierIndex `json:"index"`
+		Address string           `json:"address"`
+	} `json:"indexed"`
+}
+
+func loadSaplingVectors(t testing.TB) saplingVectorsV1 {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("..", "..", "vectors", "v1_sapling.json"))
+	if err != nil {
+		t.Fatalf("read vectors: %v", err)
+	}
+	var v saplingVectorsV1
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatalf("parse vectors: %v", err)
+	}
+	if v.Version != 1 || v.UFVK == "" || len(v.Indexed) == 0 {
+		t.Fatalf("unexpected vectors: version=%d indexed=%d", v.Version, len(v.Indexed))
+	}
+	return v
+}
+
+func TestWithReceivers_SaplingGoldenVectors(t *testing.T) {
+	sv := loadSaplingVectors(t)
+	v := loadVectors(t)
+
+	k, err := ParseUFVK(sv.UFVK)
+	if err != nil {
+		t.Fatalf("ParseUFVK error: %v", err)
+	}
+	defer k.Close()
+
+	// Without WithReceivers the Sapling key is ignored.
+	if got, err := k.Derive(3); err != nil || got != v.Addresses[3] {
+		t.Fatalf("Derive(3) = %s, %v", got, err)
+	}
+
+	// The vectors cover every index up to the last one, so the indices
+	// between two vectors are the skipped ones.
+	last, _ := sv.Indexed[len(sv.Indexed)-1].Index.Uint32()
+	count := last + 1
+	batch, err := k.BatchAddresses(0, count, withSapling)
+	if err != nil {
+		t.Fatalf("BatchAddresses error: %v", err)
+	}
+	if len(batch) != len(sv.Indexed) {
+		t.Fatalf("batch has %d addresses, want %d", len(batch), len(sv.Indexed))
+	}
+
+	next := diversifier.FromUint32(0)
+	for i, want := range sv.Indexed {
+		if batch[i].Encoded != want.Address || batch[i].Index != want.Index {
+			t.Fatalf("batch %d = %s at %s, want %s at %s", i, batch[i].Encoded, batch[i].Index, want.Address, want.Index)
+		}
+		// A single derivation lands on the next valid index.
+		single, err := k.DeriveAddressIndex(next, withSapling)
+		if err != nil {
+			t.Fatalf("DeriveAddressIndex error: %v", err)
+		}
+		if single != batch[i] {
+			t.Fatalf("DeriveAddressIndex(%s) = %+v, batch has %+v", next, single, batch[i])
+		}
+
+		j, _ := want.Index.Uint32()
+		p, err := ParseAddress(want.Address)
+		if err != nil {
+			t.Fatalf("ParseAddress error: %v", err)
+		}
+		if len(p.Receivers) != 2 || p.Receivers[0].Typecode != TypecodeSapling || p.Receivers[1].Typecode != TypecodeOrchard {
+			t.Fatalf("unexpected receivers: %+v", p.Receivers)
+		}
+		var orchard Address
+		if err := orchard.UnmarshalText([]byte(v.Addresses[j])); err != nil {
+			t.Fatalf("UnmarshalText error: %v", err)
+		}
+		if !bytes.Equal(p.Receivers[1].Data, orchard.Receiver[:]) || batch[i].Receiver != orchard.Receiver {
+			t.Fatalf("index %d: orchard receiver differs from the v1 vector", j)
+		}
+
+		index, scope, err := k.IndexOf(want.Address)
+		if err != nil || index != want.Index || scope != ScopeExternal {
+			t.Fatalf("IndexOf = %s, %s, %v", index, scope, err)
+		}
+		next, _ = want.Index.Add(1)
+	}
+
+	var streamed []Address
+	err = k.StreamIndex(diversifier.FromUint32(0), uint64(count), func(index DiversifierIndex, address string) error {
+		streamed = append(streamed, Address{Encoded: address, Index: index})
+		return nil
+	}, withSapling)
+	if err != nil {
+		t.Fatalf("StreamIndex error: %v", err)
+	}
+	if len(streamed) != len(batch) {
+		t.Fatalf("streamed %d addresses, batch has %d", len(streamed), len(batch))
+	}
+	for i, a := range streamed {
+		if a.Encoded != batch[i].Encoded || a.Index != batch[i].Index {
+			t.Fatalf("stream %d = %+v, batch has %+v", i, a, batch[i])
+		}
+	}
+	for i, address := range k.Addresses(0, count, withSapling).All() {
+		if address != batch[0].Encoded || diversifier.FromUint32(i) != batch[0].Index {
+			t.Fatalf("first sequence address = %d %s", i, address)
+		}
+		break
+	}
+}
+
+func TestWithReceivers_Errors(t *testing.T) {
+	v := loadVectors(t)
+	saplingUFVK := loadSaplingVectors(t).UFVK
+
+	cases := []struct {
+		name string
+		ufvk string
+		opts []Option
+		want ErrorCode
+	}{
+		{"no sapling key", v.UFVK, []Option{withSapling}, ErrReceiversUnavailable},
+		{"uivk", v1UIVK, []Option{withSapling}, ErrReceiversUnavailable},
+		{"internal", saplingUFVK, []Option{withSapling, WithScope(ScopeInternal)}, ErrScopeUnavailable},
+		{"no orchard", saplingUFVK, []Option{WithReceivers(TypecodeSapling)}, ErrReceiversInvalid},
+		{"no transparent key", saplingUFVK, []Option{WithReceivers(TypecodeOrchard, TypecodeP2PKH)}, ErrReceiversUnavailable},
+		{"p2sh", saplingUFVK, []Option{WithReceivers(TypecodeOrchard, TypecodeP2SH)}, ErrReceiversInvalid},
+	}
+	for _, tc := range cases {
+		if _, err := DeriveAddress(tc.ufvk, 0, tc.opts...); !errors.Is(err, &Error{Code: tc.want}) {
+			t.Fatalf("%s: DeriveAddress: expected %q, got %v", tc.name, tc.want, err)
+		}
+		if _, err := Batch(tc.ufvk, 0, 4, tc.opts...); !errors.Is(err, &Error{Code: tc.want}) {
+			t.Fatalf("%s: Batch: expected %q, got %v", tc.name, tc.want, err)
+		}
+	}
+
+	// The Sapling item must be a valid 128-byte DFVK: 0xff... is not a point,
+	// and the second key's item is one byte short. Such a key still parses and
+	// derives Orchard-only addresses; asking for a Sapling receiver fails.
+	for _, tc := range []struct {
+		ufvk string
+		want ErrorCode
+	}{
+		{"jview1yv5z28utgp72ac7kpfccpd7t4pj09tmmr32hjp669v9xpgr0k8vx7ap323scmrutmzhpnm4nwyrhw0g6m8m30cm3ws24n08czh89gjxpfwanurksvuu5uxezskv8k8lq9j3fg22wc9dy6n2khkngs5yktmfxu0p8lvs23wa5pjamtmuxttae60vym5ppaf652jmng0q64y2k44czpy0nqg2evjjrsp3ry3z8a75qfy5gdx69h9c7ncy9wvk8nc8jctx3vfexy9pylfjql5us882ds3llhhhc5vkzesahw9apdtv9mfhakx3kp8s3yhclvk2nkl0967p08lmrt4f5q0uj2pdk7rvp5m2vv8xg0scyey0kcjd6smycs0p968ctu0qkp4gyrq3mt", ErrUFVKSaplingInvalid},
+		{"jview1f77fz3p2eqgg7nk8l09z6qy3p9pwykz7c7nf3m0f6qe9zkryc5w22z4e9gwnzg43dfagl6cgcfsnek8wc5t8hjedcrmn8gl3z8j67j0s9t2vfa06pszqflfnjev2247vst833sgmxujxctxd7qn2pmwy3xkc8ptchpqguvmawgvu0a7mtxpgxv5kw00h9v5mz6qgvamvzxurjtcgrwapens0m4thpxlqlzaud0vh3umh0m79p9qpctyjl2g0s94387gvvd527nsram3aaaguxafhsaag0v4qmhd5szvfhm4csyntqgfj6zvutc7lgdwwdmgu2mptrca5zylt5pgjtkcp02cd5jxk30zz3hhpydw46zy9a0nql4sqcrpyzjmxhlcrszumx50", ErrUFVKValueLenInvalid},
+	} {
+		k, err := ParseUFVK(tc.ufvk)
+		if err != nil {
+			t.Fatalf("ParseUFVK: %v", err)
+		}
+		defer k.Close()
+		if _, err := k.DeriveAddress(0, withSapling); !errors.Is(err, &Error{Code: tc.want}) {
+			t.Fatalf("expected %q, got %v", tc.want, err)
+		}
+		if _, err := k.DeriveAddress(0); err != nil {
+			t.Fatalf("orchard only: %v", err)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package addrgen
+
+import (
+	"strings"
+
+	"github.com/Abdullah1738/juno-addrgen/internal/ffi"
+)
+
+// Scope is the ZIP-32 key scope an address is derived under.
+type Scope string
+
+const (
+	// ScopeExternal addresses are the ones handed out to payers.
+	ScopeExternal Scope = "external"
+	// ScopeInternal addresses are change addresses used by the wallet itself.
+	ScopeInternal Scope = "internal"
+)
+
+func (s Scope) String() string {
+	return string(s)
+}
+
+// ParseScope parses "external" or "internal".
+func ParseScope(s string) (Scope, error) {
+	scope := Scope(strings.ToLower(strings.TrimSpace(s)))
+	if _, err := scope.id(); err != nil {
+		return "", err
+	}
+	return scope, nil
+}
+
+func (s Scope) id() (uint32, error) {
+	switch s {
+	case ScopeExternal:
+		return ffi.ScopeExternal, nil
+	case ScopeInternal:
+		return ffi.ScopeInternal, nil
+	default:
+		return 0, &Error{Code: ErrScopeInvalid}
+	}
+}
+
+func scopeFromID(id uint32) Scope {
+	if id == ffi.ScopeInternal {
+		return ScopeInternal
+	}
+	return ScopeExternal
+}
@@ -0,0 +1,79 @@
+package addrgen
+
+import (
+	"errors"
+	"testing"
+)
+
+func loadInternalVectors(t testing.TB) vectorsV1 {
+	t.Helper()
+
+	v := loadVectorsFile(t, "v1_internal.json")
+	if v.Scope != string(ScopeInternal) {
+		t.Fatalf("unexpected vectors scope: %q", v.Scope)
+	}
+	return v
+}
+
+func TestDeriveScoped_InternalGoldenVectors(t *testing.T) {
+	v := loadInternalVectors(t)
+
+	for _, idx := range []uint32{0, 1, 2, 10, 99} {
+		got, err := DeriveScoped(v.UFVK, idx, ScopeInternal)
+		if err != nil {
+			t.Fatalf("DeriveScoped(%d) error: %v", idx, err)
+		}
+		if got != v.Addresses[idx] {
+			t.Fatalf("mismatch at %d\nwant: %s\ngot:  %s", idx, v.Addresses[idx], got)
+		}
+	}
+
+	external, err := DeriveScoped(v.UFVK, 0, ScopeExternal)
+	if err != nil {
+		t.Fatalf("DeriveScoped external error: %v", err)
+	}
+	if want := loadVectors(t).Addresses[0]; external != want {
+		t.Fatalf("external mismatch\nwant: %s\ngot:  %s", want, external)
+	}
+}
+
+func TestBatch_InternalGoldenVectors(t *testing.T) {
+	v := loadInternalVectors(t)
+
+	got, err := Batch(v.UFVK, 0, uint32(len(v.Addresses)), WithScope(ScopeInternal), WithParallelism(0))
+	if err != nil {
+		t.Fatalf("Batch error: %v", err)
+	}
+	for i := range got {
+		if got[i] != v.Addresses[i] {
+			t.Fatalf("mismatch at %d\nwant: %s\ngot:  %s", i, v.Addresses[i], got[i])
+		}
+	}
+
+	addrs, err := BatchAddresses(v.UFVK, 40, 5, WithScope(ScopeInternal))
+	if err != nil {
+		t.Fatalf("BatchAddresses error: %v", err)
+	}
+	for i, a := range addrs {
+		if a.Encoded != v.Addresses[40+i] || a.Scope != ScopeInternal {
+			t.Fatalf("unexpected address at %d: %+v", 40+i, a)
+		}
+	}
+}
+
+func TestScope_Invalid(t *testing.T) {
+	v := loadVectors(t)
+
+	if _, err := DeriveScoped(v.UFVK, 0, Scope("change")); !errors.Is(err, &Error{Code: ErrScopeInvalid}) {
+		t.Fatalf("expected %q, got %v", ErrScopeInvalid, err)
+	}
+	if _, err := Batch(v.UFVK, 0, 1, WithScope("change")); !errors.Is(err, &Error{Code: ErrScopeInvalid}) {
+		t.Fatalf("expected %q, got %v", ErrScopeInvalid, err)
+	}
+	if _, err := ParseScope("change"); !errors.Is(err, &Error{Code: ErrScopeInvalid}) {
+		t.Fatalf("expected %q, got %v", ErrScopeInvalid, err)
+	}
+	if s, err := ParseScope(" Internal "); err != nil || s != ScopeInternal {
+		t.Fatalf("ParseScope = %q, %v", s, err)
+	}
+}
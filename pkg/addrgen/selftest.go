@@ -0,0 +1,212 @@
+package addrgen
+
+import (
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/Abdullah1738/juno-addrgen/internal/ffi"
+	"github.com/Abdullah1738/juno-addrgen/pkg/diversifier"
+	"github.com/Abdullah1738/juno-addrgen/vectors"
+)
+
+// selfTestFingerprint is the FVK fingerprint of the v1 vectors' UFVK.
+const selfTestFingerprint = "e37feab45ad86988f554c78392b95cd1ab94e1e0f407262f3c2477c772414738"
+
+var selfTest struct {
+	once sync.Once
+	err  error
+}
+
+// SelfTest runs a known-answer test of the library against the golden
+// vectors embedded in the binary (package vectors), once per process, and
+// returns its result. Every function that calls into the library runs it
+// first and, if it failed, fails with ErrSelfTestFailed: a mis-built or
+// tampered library must not hand out addresses. Call SelfTest at startup to
+// fail there instead of on first use.
+//
+// The test takes a few milliseconds. The selftest command checks every
+// vector.
+func SelfTest() error {
+	selfTest.once.Do(func() {
+		if err := knownAnswerTest(); err != nil {
+			selfTest.err = fmt.Errorf("%w: %v", &Error{Code: ErrSelfTestFailed}, err)
+		}
+	})
+	return selfTest.err
+}
+
+// knownAnswerTest checks a few vectors along each of the library's code
+// paths: one-shot and batch derivation, parsed keys in both scopes, every
+// network, indices past 2^32, Sapling and transparent receivers, fingerprints
+// and index recovery. It calls internal/ffi directly because the exported API
+// itself waits on SelfTest.
+func knownAnswerTest() error {
+	load := func(name string) (vectors.File, error) {
+		f, err := vectors.Load(name)
+		if err == nil && f.UFVK == "" {
+			err = fmt.Errorf("%s: no UFVK", name)
+		}
+		return f, err
+	}
+
+	v1, err := load("v1.json")
+	if err != nil {
+		return err
+	}
+	if got, err := ffi.Derive(v1.UFVK, 0); err != nil || got != v1.Addresses[0] {
+		return mismatch("v1.json", "Derive(0)", got, v1.Addresses[0], err)
+	}
+	if got, err := ffi.Batch(v1.UFVK, 96, 4, 1); err != nil || !slices.Equal(got, v1.Addresses[96:100]) {
+		return fmt.Errorf("v1.json: Batch(96, 4) mismatch (err %v)", err)
+	}
+
+	k, _, err := ffi.ParseKey(v1.UFVK)
+	if err != nil {
+		return fmt.Errorf("v1.json: ParseKey: %w", err)
+	}
+	defer k.Free()
+
+	fp, err := k.Fingerprint()
+	if got := hex.EncodeToString(fp[:]); err != nil || got != selfTestFingerprint {
+		return mismatch("v1.json", "Fingerprint", got, selfTestFingerprint, err)
+	}
+	if err := checkKAT(k, "v1.json", ffi.ScopeExternal, 42, v1.Addresses[42]); err != nil {
+		return err
+	}
+
+	wide, err := load("v1_wide.json")
+	if err != nil {
+		return err
+	}
+	if len(wide.Indexed) == 0 {
+		return fmt.Errorf("v1_wide.json: no vectors")
+	}
+	last := wide.Indexed[len(wide.Indexed)-1]
+	index, err := diversifier.Parse(last.Index)
+	if err != nil {
+		return fmt.Errorf("v1_wide.json: %w", err)
+	}
+	if got, used, _, err := k.DeriveReceivers(ffi.ScopeExternal, ffi.ReceiversOrchard, index); err != nil || got != last.Address || used != index {
+		return mismatch("v1_wide.json", "index "+last.Index, got, last.Address, err)
+	}
+
+	for _, name := range []string{"v1_internal.json", "v1_testnet.json", "v1_testnet_internal.json", "v1_regtest.json", "v1_regtest_internal.json"} {
+		if err := checkKATFile(name, load); err != nil {
+			return err
+		}
+	}
+
+	if err := checkSaplingKAT(load); err != nil {
+		return err
+	}
+
+	tv, err := load("v1_transparent.json")
+	if err != nil {
+		return err
+	}
+	if len(tv.Addresses) == 0 || len(tv.Transparent) == 0 || len(tv.TransparentInternal) == 0 {
+		return fmt.Errorf("v1_transparent.json: no vectors")
+	}
+	tk, _, err := ffi.ParseKey(tv.UFVK)
+	if err != nil {
+		return fmt.Errorf("v1_transparent.json: ParseKey: %w", err)
+	}
+	defer tk.Free()
+
+	zero := diversifier.Index{}
+	if got, _, _, err := tk.DeriveReceivers(ffi.ScopeExternal, ffi.ReceiversOrchard|ffi.ReceiversP2PKH, zero); err != nil || got != tv.Addresses[0] {
+		return mismatch("v1_transparent.json", "unified address 0", got, tv.Addresses[0], err)
+	}
+	if got, _, err := tk.DeriveTransparent(ffi.ScopeExternal, zero); err != nil || got != tv.Transparent[0] {
+		return mismatch("v1_transparent.json", "transparent address 0", got, tv.Transparent[0], err)
+	}
+	if got, _, err := tk.DeriveTransparent(ffi.ScopeInternal, zero); err != nil || got != tv.TransparentInternal[0] {
+		return mismatch("v1_transparent.json", "internal transparent address 0", got, tv.TransparentInternal[0], err)
+	}
+	return nil
+}
+
+// checkSaplingKAT checks the first Sapling vector, which derivation from index
+// 0 must land on past the indices whose Sapling diversifier is invalid, and
+// finds it there.
+func checkSaplingKAT(load func(string) (vectors.File, error)) error {
+	const name = "v1_sapling.json"
+	sv, err := load(name)
+	if err != nil {
+		return err
+	}
+	if len(sv.Indexed) == 0 {
+		return fmt.Errorf("%s: no vectors", name)
+	}
+	first := sv.Indexed[0]
+	index, err := diversifier.Parse(first.Index)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	k, _, err := ffi.ParseKey(sv.UFVK)
+	if err != nil {
+		return fmt.Errorf("%s: ParseKey: %w", name, err)
+	}
+	defer k.Free()
+
+	got, used, _, err := k.DeriveReceivers(ffi.ScopeExternal, ffi.ReceiversOrchard|ffi.ReceiversSapling, diversifier.Index{})
+	if err != nil || got != first.Address || used != index {
+		return mismatch(name, "first address", got, first.Address, err)
+	}
+	gotScope, gotIndex, err := k.IndexOf(first.Address)
+	if err != nil || gotScope != ffi.ScopeExternal || gotIndex != index {
+		return fmt.Errorf("%s: IndexOf(first address) = %d, %s (err %v)", name, gotScope, diversifier.Index(gotIndex), err)
+	}
+	return nil
+}
+
+// checkKATFile checks the first address of a vectors file in its scope.
+func checkKATFile(name string, load func(string) (vectors.File, error)) error {
+	f, err := load(name)
+	if err != nil {
+		return err
+	}
+	if len(f.Addresses) == 0 {
+		return fmt.Errorf("%s: no vectors", name)
+	}
+	scope := ffi.ScopeExternal
+	if f.Scope == string(ScopeInternal) {
+		scope = ffi.ScopeInternal
+	}
+
+	k, network, err := ffi.ParseKey(f.UFVK)
+	if err != nil {
+		return fmt.Errorf("%s: ParseKey: %w", name, err)
+	}
+	defer k.Free()
+
+	if network != f.Network {
+		return mismatch(name, "network", network, f.Network, nil)
+	}
+	return checkKAT(k, name, scope, 0, f.Addresses[0])
+}
+
+// checkKAT checks that k derives want at index in scope, and finds it there.
+func checkKAT(k *ffi.Key, name string, scope uint32, index uint32, want string) error {
+	i := diversifier.FromUint32(index)
+	what := fmt.Sprintf("address %d", index)
+	got, used, _, err := k.DeriveReceivers(scope, ffi.ReceiversOrchard, i)
+	if err != nil || got != want || used != i {
+		return mismatch(name, what, got, want, err)
+	}
+	gotScope, gotIndex, err := k.IndexOf(want)
+	if err != nil || gotScope != scope || gotIndex != i {
+		return fmt.Errorf("%s: IndexOf(%s) = %d, %s (err %v)", name, what, gotScope, diversifier.Index(gotIndex), err)
+	}
+	return nil
+}
+
+func mismatch(name, what, got, want string, err error) error {
+	if err != nil {
+		return fmt.Errorf("%s: %s: %w", name, what, err)
+	}
+	return fmt.Errorf("%s: %s is %q, want %q", name, what, got, want)
+}
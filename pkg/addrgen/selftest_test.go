@@ -0,0 +1,97 @@
+package addrgen
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestSelfTest(t *testing.T) {
+	if err := SelfTest(); err != nil {
+		t.Fatalf("SelfTest error: %v", err)
+	}
+	if err := knownAnswerTest(); err != nil {
+		t.Fatalf("knownAnswerTest error: %v", err)
+	}
+}
+
+func TestSelfTest_FailsClosed(t *testing.T) {
+	v := loadVectors(t)
+	if err := SelfTest(); err != nil {
+		t.Fatalf("SelfTest error: %v", err)
+	}
+
+	// Not parallel: no other test runs while the result is replaced.
+	selfTest.err = fmt.Errorf("%w: injected", &Error{Code: ErrSelfTestFailed})
+	defer func() { selfTest.err = nil }()
+
+	calls := map[string]func() error{
+		"Derive": func() error {
+			_, err := Derive(v.UFVK, 0)
+			return err
+		},
+		"Batch": func() error {
+			_, err := Batch(v.UFVK, 0, 2)
+			return err
+		},
+		"ParseUFVK": func() error {
+			_, err := ParseUFVK(v.UFVK)
+			return err
+		},
+		"InspectUFVK": func() error {
+			_, err := InspectUFVK(v.UFVK)
+			return err
+		},
+		"ParseAddress": func() error {
+			_, err := ParseAddress(v.Addresses[0])
+			return err
+		},
+		"Address.UnmarshalText": func() error {
+			var a Address
+			return a.UnmarshalText([]byte(v.Addresses[0]))
+		},
+	}
+	for name, call := range calls {
+		err := call()
+		var e *Error
+		if !errors.As(err, &e) || e.Code != ErrSelfTestFailed {
+			t.Fatalf("%s error = %v, want %s", name, err, ErrSelfTestFailed)
+		}
+	}
+}
+
+func TestBatch_NetworkVectors(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		network Network
+	}{
+		{"v1_testnet.json", NetworkTestnet},
+		{"v1_testnet_internal.json", NetworkTestnet},
+		{"v1_regtest.json", NetworkRegtest},
+		{"v1_regtest_internal.json", NetworkRegtest},
+	} {
+		v := loadVectorsFile(t, tc.name)
+		scope := ScopeExternal
+		if v.Scope != "" {
+			scope = Scope(v.Scope)
+		}
+
+		k, err := ParseUFVK(v.UFVK)
+		if err != nil {
+			t.Fatalf("%s: ParseUFVK error: %v", tc.name, err)
+		}
+		if k.Network() != tc.network {
+			t.Fatalf("%s: network = %s", tc.name, k.Network())
+		}
+		got, err := k.Batch(0, uint32(len(v.Addresses)), WithScope(scope))
+		k.Close()
+		if err != nil || len(got) != len(v.Addresses) {
+			t.Fatalf("%s: Batch = %d addresses, %v", tc.name, len(got), err)
+		}
+		for i := range v.Addresses {
+			if got[i] != v.Addresses[i] {
+				t.Fatalf("%s: address %d = %s, want %s", tc.name, i, got[i], v.Addresses[i])
+			}
+		}
+	}
+}
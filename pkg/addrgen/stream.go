@@ -0,0 +1,68 @@
+package addrgen
+
+import (
+	"context"
+
+	"github.com/Abdullah1738/juno-addrgen/pkg/diversifier"
+)
+
+// streamChunk is the number of addresses derived per library call while
+// streaming. It bounds memory use independently of the requested count.
+const streamChunk = 8192
+
+// indexSpace is the number of 32-bit diversifier indices.
+const indexSpace = uint64(1) << 32
+
+// Stream derives count consecutive external addresses starting at start and
+// calls fn with each index and address, in index order. Unlike Batch, count is
+// not capped: the range may extend to the last 32-bit index, and addresses are
+// derived in fixed-size chunks so memory use stays constant.
+//
+// If fn returns an error, Stream stops and returns that error unchanged.
+func Stream(ufvk string, start uint32, count uint64, fn func(index uint32, address string) error, opts ...Option) error {
+	return StreamContext(context.Background(), ufvk, start, count, fn, opts...)
+}
+
+// StreamContext is like Stream, but stops between chunks once ctx is done and
+// returns ctx.Err().
+func StreamContext(ctx context.Context, ufvk string, start uint32, count uint64, fn func(index uint32, address string) error, opts ...Option) error {
+	if err := checkStreamRange(start, count); err != nil {
+		return err
+	}
+
+	k, err := ParseUFVK(ufvk)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+
+	return k.StreamContext(ctx, start, count, fn, opts...)
+}
+
+// Stream is like the package-level Stream, using the parsed key.
+func (k *Key) Stream(start uint32, count uint64, fn func(index uint32, address string) error, opts ...Option) error {
+	return k.StreamContext(context.Background(), start, count, fn, opts...)
+}
+
+// StreamContext is like the package-level StreamContext, using the parsed key.
+func (k *Key) StreamContext(ctx context.Context, start uint32, count uint64, fn func(index uint32, address string) error, opts ...Option) error {
+	if err := checkStreamRange(start, count); err != nil {
+		return err
+	}
+
+	return k.StreamIndexContext(ctx, diversifier.FromUint32(start), count, func(index DiversifierIndex, address string) error {
+		// In range: checkStreamRange keeps the whole stream below 2^32.
+		i, _ := index.Uint32()
+		return fn(i, address)
+	}, opts...)
+}
+
+func checkStreamRange(start uint32, count uint64) error {
+	if count == 0 {
+		return &Error{Code: ErrCountZero}
+	}
+	if count > indexSpace-uint64(start) {
+		return &Error{Code: ErrRangeOverflow}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package addrgen
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestStream_GoldenVectors(t *testing.T) {
+	v := loadVectors(t)
+
+	var got []string
+	err := Stream(v.UFVK, 0, uint64(len(v.Addresses)), func(index uint32, address string) error {
+		if index != uint32(len(got)) {
+			t.Fatalf("unexpected index: %d (want %d)", index, len(got))
+		}
+		got = append(got, address)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Stream error: %v", err)
+	}
+	if len(got) != len(v.Addresses) {
+		t.Fatalf("unexpected len: %d", len(got))
+	}
+	for i := range got {
+		if got[i] != v.Addresses[i] {
+			t.Fatalf("mismatch at %d\nwant: %s\ngot:  %s", i, v.Addresses[i], got[i])
+		}
+	}
+}
+
+func TestStream_AcrossChunksMatchesBatch(t *testing.T) {
+	v := loadVectors(t)
+
+	k, err := ParseUFVK(v.UFVK)
+	if err != nil {
+		t.Fatalf("ParseUFVK error: %v", err)
+	}
+	defer k.Close()
+
+	const start, count = 5, streamChunk + 7
+	want, err := k.Batch(start, count, WithParallelism(0))
+	if err != nil {
+		t.Fatalf("Batch error: %v", err)
+	}
+
+	var n int
+	err = k.Stream(start, count, func(index uint32, address string) error {
+		if index != start+uint32(n) || address != want[n] {
+			t.Fatalf("mismatch at %d: index=%d", n, index)
+		}
+		n++
+		return nil
+	}, WithParallelism(0))
+	if err != nil {
+		t.Fatalf("Stream error: %v", err)
+	}
+	if n != count {
+		t.Fatalf("unexpected count: %d", n)
+	}
+}
+
+func TestStream_Ranges(t *testing.T) {
+	v := loadVectors(t)
+
+	var last uint32
+	err := Stream(v.UFVK, math.MaxUint32-1, 2, func(index uint32, _ string) error {
+		last = index
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Stream error: %v", err)
+	}
+	if last != math.MaxUint32 {
+		t.Fatalf("unexpected last index: %d", last)
+	}
+
+	// The one-shot library batch may end at the last index too.
+	batch, err := Batch(v.UFVK, math.MaxUint32-1, 2)
+	if err != nil || len(batch) != 2 {
+		t.Fatalf("Batch at the end of the index space = %d addresses, %v", len(batch), err)
+	}
+	if want, err := Derive(v.UFVK, math.MaxUint32); err != nil || batch[1] != want {
+		t.Fatalf("Batch last address = %s, want %s (%v)", batch[1], want, err)
+	}
+
+	var ae *Error
+	if _, err := Batch(v.UFVK, math.MaxUint32, 2); !errors.As(err, &ae) || ae.Code != ErrRangeOverflow {
+		t.Fatalf("expected %q, got %v", ErrRangeOverflow, err)
+	}
+	err = Stream(v.UFVK, 1, uint64(1)<<32, func(uint32, string) error { return nil })
+	if !errors.As(err, &ae) || ae.Code != ErrRangeOverflow {
+		t.Fatalf("expected %q, got %v", ErrRangeOverflow, err)
+	}
+
+	err = Stream(v.UFVK, 0, 0, func(uint32, string) error { return nil })
+	if !errors.As(err, &ae) || ae.Code != ErrCountZero {
+		t.Fatalf("expected %q, got %v", ErrCountZero, err)
+	}
+}
+
+func TestStream_CallbackErrorStops(t *testing.T) {
+	v := loadVectors(t)
+
+	stop := errors.New("stop")
+	var calls int
+	err := Stream(v.UFVK, 0, 10, func(uint32, string) error {
+		calls++
+		if calls == 3 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop {
+		t.Fatalf("expected callback error, got %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("unexpected calls: %d", calls)
+	}
+}
@@ -0,0 +1,162 @@
+package addrgen
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Abdullah1738/juno-addrgen/internal/ffi"
+	"github.com/Abdullah1738/juno-addrgen/pkg/diversifier"
+)
+
+// MaxTransparentIndex is the largest transparent address index. BIP-44 address
+// indices are non-hardened BIP-32 indices, which stop below 2^31.
+const MaxTransparentIndex = 1<<31 - 1
+
+// TransparentAddress is a standalone transparent P2PKH address (t1... on
+// mainnet, tm... on testnet and regtest), derived from the account-level
+// transparent key in a UFVK's P2PKH item at BIP-44 path
+// m/44'/8133'/account'/change/index.
+type TransparentAddress struct {
+	Encoded string
+	Index   uint32
+	Network Network
+	Scope   Scope
+}
+
+// String returns the encoded address.
+func (a TransparentAddress) String() string {
+	return a.Encoded
+}
+
+// ParseTransparentAddress decodes a standalone transparent P2PKH address
+// (t1... / tm...) into its network and its single P2PKH receiver. Testnet and
+// regtest share the tm prefix, so a tm address reports NetworkTestnet even if
+// it is meant for regtest.
+//
+// Errors are *Error with one of:
+//
+//   - ErrAddressEmpty
+//   - ErrAddressChecksumInvalid: well-formed Base58 with a bad checksum (a typo)
+//   - ErrAddressTransparentInvalid: anything else that is not a Juno P2PKH
+//     address, including P2SH and unified addresses
+func ParseTransparentAddress(s string) (ParsedAddress, error) {
+	s = strings.TrimSpace(s)
+	if err := SelfTest(); err != nil {
+		return ParsedAddress{}, err
+	}
+	network, receiver, err := ffi.DecodeTransparentAddress(s)
+	if err != nil {
+		return ParsedAddress{}, wrapErr(err)
+	}
+	return ParsedAddress{
+		Encoded:   s,
+		Network:   Network(network),
+		Receivers: []Receiver{{Typecode: TypecodeP2PKH, Data: receiver[:]}},
+	}, nil
+}
+
+// DeriveTransparent returns the transparent address at index on the external
+// chain, or the change chain with WithScope(ScopeInternal). The UFVK must have
+// a valid P2PKH item (ErrReceiversUnavailable if it has none,
+// ErrUFVKTransparentInvalid or ErrUFVKValueLenInvalid if it is malformed) and
+// index must not exceed MaxTransparentIndex (ErrRangeOverflow otherwise). Only
+// WithScope applies.
+//
+// BIP-32 cannot derive about 2^-127 of all indices; those are skipped, and
+// Index reports the index actually used.
+func DeriveTransparent(ufvk string, index uint32, opts ...Option) (TransparentAddress, error) {
+	k, err := ParseUFVK(ufvk)
+	if err != nil {
+		return TransparentAddress{}, err
+	}
+	defer k.Close()
+
+	return k.DeriveTransparent(index, opts...)
+}
+
+// DeriveTransparent is like the package-level DeriveTransparent, using the
+// parsed key.
+func (k *Key) DeriveTransparent(index uint32, opts ...Option) (TransparentAddress, error) {
+	o := applyOptions(opts)
+	scope, err := o.scope.id()
+	if err != nil {
+		return TransparentAddress{}, err
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if k.handle == nil {
+		return TransparentAddress{}, &Error{Code: ErrKeyClosed}
+	}
+	encoded, used, err := k.handle.DeriveTransparent(scope, diversifier.FromUint32(index))
+	if err != nil {
+		return TransparentAddress{}, wrapErr(err)
+	}
+	// At most MaxTransparentIndex: the library rejects anything larger.
+	i, _ := diversifier.Index(used).Uint32()
+	return TransparentAddress{Encoded: encoded, Index: i, Network: k.network, Scope: o.scope}, nil
+}
+
+// StreamTransparentContext derives the transparent addresses at indices
+// [start, start+count) and calls fn with each index and address, in index
+// order, deriving streamChunk addresses per library call and checking ctx
+// between chunks. The range must end at or below MaxTransparentIndex. Indices
+// BIP-32 cannot derive are skipped. WithScope and WithParallelism apply.
+//
+// If fn returns an error, StreamTransparentContext stops and returns that
+// error unchanged.
+func (k *Key) StreamTransparentContext(ctx context.Context, start uint32, count uint64, fn func(index uint32, address string) error, opts ...Option) error {
+	if count == 0 {
+		return &Error{Code: ErrCountZero}
+	}
+	if start > MaxTransparentIndex || count > MaxTransparentIndex+1-uint64(start) {
+		return &Error{Code: ErrRangeOverflow}
+	}
+
+	o := applyOptions(opts)
+	for done := uint64(0); done < count; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n := min(count-done, streamChunk)
+		// In range: the whole stream ends at or below MaxTransparentIndex.
+		first := start + uint32(done)
+
+		encoded, indices, err := k.batchTransparent(first, uint32(n), o)
+		if err != nil {
+			return err
+		}
+		for i, a := range encoded {
+			// At most MaxTransparentIndex: the library rejects anything larger.
+			index, _ := diversifier.Index(indices[i]).Uint32()
+			if err := fn(index, a); err != nil {
+				return err
+			}
+		}
+		done += n
+	}
+	return nil
+}
+
+// batchTransparent derives the transparent addresses in [start, start+count)
+// that BIP-32 can derive, in index order.
+func (k *Key) batchTransparent(start, count uint32, o options) ([]string, [][ffi.IndexLen]byte, error) {
+	scope, err := o.scope.id()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if k.handle == nil {
+		return nil, nil, &Error{Code: ErrKeyClosed}
+	}
+	encoded, indices, err := k.handle.BatchTransparent(scope, diversifier.FromUint32(start), count, o.jobs)
+	if err != nil {
+		return nil, nil, wrapErr(err)
+	}
+	return encoded, indices, nil
+}
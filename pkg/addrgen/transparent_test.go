@@ -0,0 +1,262 @@
+package addrgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Abdullah1738/juno-addrgen/pkg/diversifier"
+)
+
+// transparentTestnetUFVK holds the same items as the transparent vectors'
+// UFVK, encoded for testnet.
+const transparentTestnetUFVK = "jviewtest1h72u7kgejmchfujuw06cnz5lsgntup94vqs423z6xl0dxty4g5qyfkwn8s8hna98pkv20nxqc9vvq75ed4usm7ml028uwwqrh8e8uqaljyg67u6kmlrvzz2q03uww0m5hfkpre2qg2e4xgdv2reh3wvnxf2mhfdpq8hhrnemf903eehk8zw49w5xzeyrw7slvnc89c8pyzukdxkay0gc7pc49rk4nk70sr9x69dr7pl6qe8uqxq8h9dqcj4jr6lv29um8pmej37r7mydvywv605f3u0wnfufjuqaylyr"
+
+var withP2PKH = WithReceivers(TypecodeOrchard, TypecodeP2PKH)
+
+type transparentVectorsV1 struct {
+	Version             int      `json:"version"`
+	UFVK                string   `json:"ufvk"`
+	Addresses           []string `json:"addresses"`
+	Transparent         []string `json:"transparent"`
+	TransparentInternal []string `json:"transparent_internal"`
+}
+
+func loadTransparentVectors(t testing.TB) transparentVectorsV1 {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("..", "..", "vectors", "v1_transparent.json"))
+	if err != nil {
+		t.Fatalf("read vectors: %v", err)
+	}
+	var v transparentVectorsV1
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatalf("parse vectors: %v", err)
+	}
+	if v.Version != 1 || v.UFVK == "" || len(v.Addresses) != 100 || len(v.Transparent) != 100 || len(v.TransparentInternal) != 100 {
+		t.Fatalf("unexpected vectors: version=%d addresses=%d", v.Version, len(v.Addresses))
+	}
+	return v
+}
+
+func TestDeriveTransparent_GoldenVectors(t *testing.T) {
+	v := loadTransparentVectors(t)
+	orchard := loadVectors(t)
+
+	k, err := ParseUFVK(v.UFVK)
+	if err != nil {
+		t.Fatalf("ParseUFVK error: %v", err)
+	}
+	defer k.Close()
+
+	for i := range uint32(100) {
+		got, err := k.DeriveTransparent(i)
+		if err != nil || got.Encoded != v.Transparent[i] || got.Index != i || got.Scope != ScopeExternal || got.Network != NetworkMainnet {
+			t.Fatalf("DeriveTransparent(%d) = %+v, %v", i, got, err)
+		}
+		got, err = k.DeriveTransparent(i, WithScope(ScopeInternal))
+		if err != nil || got.Encoded != v.TransparentInternal[i] || got.Scope != ScopeInternal {
+			t.Fatalf("DeriveTransparent(%d, internal) = %+v, %v", i, got, err)
+		}
+	}
+
+	// Without WithReceivers the transparent key is ignored.
+	if got, err := k.Derive(7); err != nil || got != orchard.Addresses[7] {
+		t.Fatalf("Derive(7) = %s, %v", got, err)
+	}
+
+	batch, err := k.BatchAddresses(0, 100, withP2PKH)
+	if err != nil {
+		t.Fatalf("BatchAddresses error: %v", err)
+	}
+	if len(batch) != len(v.Addresses) {
+		t.Fatalf("BatchAddresses returned %d addresses", len(batch))
+	}
+	for i, a := range batch {
+		if a.Encoded != v.Addresses[i] || a.Index != diversifier.FromUint32(uint32(i)) {
+			t.Fatalf("batch %d = %+v, want %s", i, a, v.Addresses[i])
+		}
+		p, err := ParseAddress(a.Encoded)
+		if err != nil {
+			t.Fatalf("ParseAddress error: %v", err)
+		}
+		if len(p.Receivers) != 2 || p.Receivers[0].Typecode != TypecodeP2PKH || p.Receivers[1].Typecode != TypecodeOrchard {
+			t.Fatalf("unexpected receivers: %+v", p.Receivers)
+		}
+		var want Address
+		if err := want.UnmarshalText([]byte(orchard.Addresses[i])); err != nil {
+			t.Fatalf("UnmarshalText error: %v", err)
+		}
+		if !bytes.Equal(p.Receivers[1].Data, want.Receiver[:]) {
+			t.Fatalf("index %d: orchard receiver differs from the v1 vector", i)
+		}
+	}
+
+	single, err := k.DeriveAddress(42, withP2PKH)
+	if err != nil || single.Encoded != v.Addresses[42] {
+		t.Fatalf("DeriveAddress(42) = %+v, %v", single, err)
+	}
+	index, scope, err := k.IndexOf(v.Addresses[42])
+	if err != nil || index != diversifier.FromUint32(42) || scope != ScopeExternal {
+		t.Fatalf("IndexOf = %s, %s, %v", index, scope, err)
+	}
+
+	change, err := k.DeriveAddress(3, withP2PKH, WithScope(ScopeInternal))
+	if err != nil {
+		t.Fatalf("DeriveAddress(internal) error: %v", err)
+	}
+	if index, scope, err := k.IndexOf(change.Encoded); err != nil || index != diversifier.FromUint32(3) || scope != ScopeInternal {
+		t.Fatalf("IndexOf(change) = %s, %s, %v", index, scope, err)
+	}
+
+	var streamed []string
+	err = k.StreamTransparentContext(context.Background(), 0, 100, func(index uint32, address string) error {
+		if index != uint32(len(streamed)) {
+			t.Fatalf("stream index %d after %d addresses", index, len(streamed))
+		}
+		streamed = append(streamed, address)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamTransparentContext error: %v", err)
+	}
+	if strings.Join(streamed, "\n") != strings.Join(v.Transparent, "\n") {
+		t.Fatalf("streamed addresses differ from the vectors")
+	}
+
+	// In parallel, on the change chain.
+	const start = 1000
+	n := 0
+	err = k.StreamTransparentContext(context.Background(), start, 6, func(index uint32, address string) error {
+		want, err := k.DeriveTransparent(index, WithScope(ScopeInternal))
+		if err != nil || index != start+uint32(n) || address != want.Encoded {
+			t.Fatalf("stream %d: %d %s, want %+v, %v", n, index, address, want, err)
+		}
+		n++
+		return nil
+	}, WithScope(ScopeInternal), WithParallelism(4))
+	if err != nil || n != 6 {
+		t.Fatalf("StreamTransparentContext(internal) = %d addresses, %v", n, err)
+	}
+
+	testnet, err := DeriveTransparent(transparentTestnetUFVK, 0)
+	if err != nil || testnet.Encoded != "tmRAk4ediioeaVW8zj5nRKjooWswTZWCBKA" || testnet.Network != NetworkTestnet {
+		t.Fatalf("testnet DeriveTransparent = %+v, %v", testnet, err)
+	}
+}
+
+func TestDeriveTransparent_Errors(t *testing.T) {
+	v := loadTransparentVectors(t)
+	orchard := loadVectors(t)
+
+	k, err := ParseUFVK(v.UFVK)
+	if err != nil {
+		t.Fatalf("ParseUFVK error: %v", err)
+	}
+	defer k.Close()
+
+	if _, err := DeriveTransparent(orchard.UFVK, 0); !errors.Is(err, &Error{Code: ErrReceiversUnavailable}) {
+		t.Fatalf("expected %q, got %v", ErrReceiversUnavailable, err)
+	}
+	if _, err := DeriveTransparent(v1UIVK, 0); !errors.Is(err, &Error{Code: ErrReceiversUnavailable}) {
+		t.Fatalf("uivk: expected %q, got %v", ErrReceiversUnavailable, err)
+	}
+	if _, err := k.DeriveTransparent(MaxTransparentIndex + 1); !errors.Is(err, &Error{Code: ErrRangeOverflow}) {
+		t.Fatalf("expected %q, got %v", ErrRangeOverflow, err)
+	}
+	if a, err := k.DeriveTransparent(MaxTransparentIndex); err != nil || a.Index != MaxTransparentIndex {
+		t.Fatalf("DeriveTransparent(max) = %+v, %v", a, err)
+	}
+	if _, err := k.DeriveAddressIndex(diversifier.FromUint32(MaxTransparentIndex+1), withP2PKH); !errors.Is(err, &Error{Code: ErrRangeOverflow}) {
+		t.Fatalf("expected %q, got %v", ErrRangeOverflow, err)
+	}
+	if _, err := k.BatchAddresses(MaxTransparentIndex-1, 4, withP2PKH); !errors.Is(err, &Error{Code: ErrRangeOverflow}) {
+		t.Fatalf("batch across 2^31: expected %q, got %v", ErrRangeOverflow, err)
+	}
+
+	// A P2PKH item whose public key (0xff...) is not a curve point fails only
+	// the calls that need it.
+	const badTransparent = "jview1hts5e08ka2mw2pllfpptymn33apkfwtq9p846hdh2z5nh7cal6e5rrxpyzhq9sfnm6a2l0ay6qp34698pdl87c7688javu6tpafegufvxyg5aru0v0m42tmxmqplptu9udfsmylhaugcm28769jln9gtev72eflvg3hct9fpu87c08ejffuq4fzql4muhusrcm0kn82d9k2q8fqncag6cy8d40s7md46h8uehvqmf5mf07lzp2dx48jq043ed0vk9khcx30t6kfcqu42qvtlgvnq2wusw7a07u5l7nmw"
+	bad, err := ParseUFVK(badTransparent)
+	if err != nil {
+		t.Fatalf("ParseUFVK: %v", err)
+	}
+	defer bad.Close()
+	if _, err := bad.DeriveTransparent(0); !errors.Is(err, &Error{Code: ErrUFVKTransparentInvalid}) {
+		t.Fatalf("expected %q, got %v", ErrUFVKTransparentInvalid, err)
+	}
+	if _, err := bad.DeriveAddress(0, withP2PKH); !errors.Is(err, &Error{Code: ErrUFVKTransparentInvalid}) {
+		t.Fatalf("expected %q, got %v", ErrUFVKTransparentInvalid, err)
+	}
+	if _, err := bad.DeriveAddress(0); err != nil {
+		t.Fatalf("orchard only: %v", err)
+	}
+
+	noop := func(uint32, string) error { return nil }
+	ctx := context.Background()
+	if err := k.StreamTransparentContext(ctx, MaxTransparentIndex, 2, noop); !errors.Is(err, &Error{Code: ErrRangeOverflow}) {
+		t.Fatalf("stream: expected %q, got %v", ErrRangeOverflow, err)
+	}
+	if err := k.StreamTransparentContext(ctx, 0, 0, noop); !errors.Is(err, &Error{Code: ErrCountZero}) {
+		t.Fatalf("stream: expected %q, got %v", ErrCountZero, err)
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := k.StreamTransparentContext(canceled, 0, 1, noop); !errors.Is(err, context.Canceled) {
+		t.Fatalf("stream: expected context.Canceled, got %v", err)
+	}
+}
+
+func TestParseTransparentAddress(t *testing.T) {
+	v := loadTransparentVectors(t)
+
+	for i := range 10 {
+		p, err := ParseTransparentAddress(" " + v.Transparent[i] + "\n")
+		if err != nil || p.Encoded != v.Transparent[i] || p.Network != NetworkMainnet || len(p.Receivers) != 1 {
+			t.Fatalf("ParseTransparentAddress(%d) = %+v, %v", i, p, err)
+		}
+		// The vectors' unified address at the same index carries the same
+		// P2PKH receiver.
+		ua, err := ParseAddress(v.Addresses[i])
+		if err != nil {
+			t.Fatalf("ParseAddress(%d) error: %v", i, err)
+		}
+		want, _ := ua.Receiver(TypecodeP2PKH)
+		if got, ok := p.Receiver(TypecodeP2PKH); !ok || !bytes.Equal(got, want) {
+			t.Fatalf("receiver %d = %x, want %x", i, got, want)
+		}
+	}
+
+	testnet, err := DeriveTransparent(transparentTestnetUFVK, 0)
+	if err != nil {
+		t.Fatalf("DeriveTransparent(testnet) error: %v", err)
+	}
+	if p, err := ParseTransparentAddress(testnet.Encoded); err != nil || p.Network != NetworkTestnet {
+		t.Fatalf("ParseTransparentAddress(testnet) = %+v, %v", p, err)
+	}
+
+	typo := []byte(v.Transparent[0])
+	if typo[5] == '2' {
+		typo[5] = '3'
+	} else {
+		typo[5] = '2'
+	}
+	for _, tc := range []struct {
+		address string
+		want    ErrorCode
+	}{
+		{" ", ErrAddressEmpty},
+		{string(typo), ErrAddressChecksumInvalid},
+		{v.Addresses[0], ErrAddressTransparentInvalid},
+		{"t1-not-base58", ErrAddressTransparentInvalid},
+	} {
+		if _, err := ParseTransparentAddress(tc.address); !errors.Is(err, &Error{Code: tc.want}) {
+			t.Fatalf("%q: expected %q, got %v", tc.address, tc.want, err)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package addrgen
+
+// ExportUIVK converts ufvk to a UIVK (jivk*1...) on the same network. The
+// UIVK derives the same external addresses and finds their indices with
+// IndexOf, but cannot derive internal (change) addresses or see outgoing
+// transactions, so it can be handed to services that only issue deposit
+// addresses.
+//
+// A UFVK's Sapling item carries over as its Sapling IVK, and its P2PKH item
+// as the external chain key m/44'/8133'/account'/0, so the UIVK also derives
+// external Sapling and P2PKH receivers and standalone transparent addresses.
+// A malformed Sapling or P2PKH item fails with its error (e.g.
+// ErrUFVKSaplingInvalid) rather than being left out.
+func ExportUIVK(ufvk string) (string, error) {
+	k, err := ParseUFVK(ufvk)
+	if err != nil {
+		return "", err
+	}
+	defer k.Close()
+
+	return k.UIVK()
+}
+
+// UIVK returns the key's external-scope incoming viewing keys, encoded as a
+// UIVK for the key's network. See ExportUIVK for what it carries.
+func (k *Key) UIVK() (string, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if k.handle == nil {
+		return "", &Error{Code: ErrKeyClosed}
+	}
+	uivk, err := k.handle.ExportUIVK()
+	if err != nil {
+		return "", wrapErr(err)
+	}
+	return uivk, nil
+}
@@ -0,0 +1,162 @@
+package addrgen
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Abdullah1738/juno-addrgen/pkg/diversifier"
+)
+
+const (
+	// The v1 vectors' external IVK.
+	v1UIVK            = "jivk1ha7923zaevmrhxcr6h2lqcf6sk8kt3gz65ls74sqwk23aet7gfypxwslt44gyfuvdpj5qfmecm49pyw9n7hcldlt7ydldaw5n3rzf9a8ny80jq9akg29fdxrqamat8xxdzrslj74dq"
+	testnetUIVK       = "jivktest1ypue2ewntfwph7jvuel5tvqnvmrt5fgjfgwp9vyfrq6spzn0uvyler2jrjav7qeftmkwssvxulh2p6msq87hk6c5d9vsvyyhg8g2tzmv3kedr5h9cs0ppz5rp5cjya8km68sv823qw"
+	v1UIVKFingerprint = "d61b45fa8c5834e6d0d8df5a7610a22506558f540f3b072fc3e79d053aa2109e"
+)
+
+func TestExportUIVK(t *testing.T) {
+	v := loadVectors(t)
+
+	for _, tc := range []struct{ ufvk, want string }{
+		{v.UFVK, v1UIVK},
+		{testnetUFVK, testnetUIVK},
+	} {
+		got, err := ExportUIVK(tc.ufvk)
+		if err != nil {
+			t.Fatalf("ExportUIVK error: %v", err)
+		}
+		if got != tc.want {
+			t.Fatalf("want %s\ngot  %s", tc.want, got)
+		}
+	}
+
+	// A UIVK exports to itself.
+	if got, err := ExportUIVK(v1UIVK); err != nil || got != v1UIVK {
+		t.Fatalf("ExportUIVK(uivk) = %s, %v", got, err)
+	}
+
+	k, err := ParseUFVK(v.UFVK)
+	if err != nil {
+		t.Fatalf("ParseUFVK error: %v", err)
+	}
+	k.Close()
+	if _, err := k.UIVK(); !errors.Is(err, &Error{Code: ErrKeyClosed}) {
+		t.Fatalf("expected %q, got %v", ErrKeyClosed, err)
+	}
+}
+
+func TestExportUIVK_SaplingAndTransparent(t *testing.T) {
+	tv := loadTransparentVectors(t)
+	sv := loadSaplingVectors(t)
+
+	// The UIVK of a UFVK with a P2PKH item derives its external P2PKH
+	// receivers and standalone transparent addresses.
+	uivk, err := ExportUIVK(tv.UFVK)
+	if err != nil {
+		t.Fatalf("ExportUIVK error: %v", err)
+	}
+	k, err := ParseUFVK(uivk)
+	if err != nil {
+		t.Fatalf("ParseUFVK(uivk) error: %v", err)
+	}
+	defer k.Close()
+	if got, err := k.UIVK(); err != nil || got != uivk {
+		t.Fatalf("UIVK round trip = %s, %v", got, err)
+	}
+	for i := range uint32(10) {
+		a, err := k.DeriveAddress(i, withP2PKH)
+		if err != nil || a.Encoded != tv.Addresses[i] {
+			t.Fatalf("DeriveAddress(%d) = %s, %v", i, a.Encoded, err)
+		}
+		ta, err := k.DeriveTransparent(i)
+		if err != nil || ta.Encoded != tv.Transparent[i] {
+			t.Fatalf("DeriveTransparent(%d) = %s, %v", i, ta.Encoded, err)
+		}
+	}
+	if _, err := k.DeriveTransparent(0, WithScope(ScopeInternal)); !errors.Is(err, &Error{Code: ErrScopeUnavailable}) {
+		t.Fatalf("expected %q, got %v", ErrScopeUnavailable, err)
+	}
+
+	// The UIVK of a UFVK with a Sapling item derives its Sapling receivers.
+	uivk, err = ExportUIVK(sv.UFVK)
+	if err != nil {
+		t.Fatalf("ExportUIVK error: %v", err)
+	}
+	k, err = ParseUFVK(uivk)
+	if err != nil {
+		t.Fatalf("ParseUFVK(uivk) error: %v", err)
+	}
+	defer k.Close()
+	for _, want := range sv.Indexed {
+		a, err := k.DeriveAddressIndex(want.Index, withSapling)
+		if err != nil || a.Encoded != want.Address {
+			t.Fatalf("DeriveAddressIndex(%s) = %s, %v", want.Index, a.Encoded, err)
+		}
+	}
+}
+
+func TestUIVK_DerivesExternalAddresses(t *testing.T) {
+	v := loadVectors(t)
+
+	if got, err := Derive(v1UIVK, 7); err != nil || got != v.Addresses[7] {
+		t.Fatalf("Derive(uivk, 7) = %s, %v", got, err)
+	}
+
+	k, err := ParseUFVK(v1UIVK)
+	if err != nil {
+		t.Fatalf("ParseUFVK(uivk) error: %v", err)
+	}
+	defer k.Close()
+
+	if k.Network() != NetworkMainnet {
+		t.Fatalf("unexpected network: %q", k.Network())
+	}
+	got, err := k.Batch(90, 10)
+	if err != nil {
+		t.Fatalf("Batch error: %v", err)
+	}
+	for i, a := range got {
+		if a != v.Addresses[90+i] {
+			t.Fatalf("mismatch at %d\nwant: %s\ngot:  %s", 90+i, v.Addresses[90+i], a)
+		}
+	}
+
+	index, scope, err := k.IndexOf(v.Addresses[42])
+	if err != nil || index != diversifier.FromUint32(42) || scope != ScopeExternal {
+		t.Fatalf("IndexOf = %s, %s, %v", index, scope, err)
+	}
+
+	// A UIVK holds no internal-scope key.
+	if _, err := k.DeriveScoped(0, ScopeInternal); !errors.Is(err, &Error{Code: ErrScopeUnavailable}) {
+		t.Fatalf("expected %q, got %v", ErrScopeUnavailable, err)
+	}
+	if _, _, err := k.IndexOf(loadInternalVectors(t).Addresses[0]); !errors.Is(err, &Error{Code: ErrAddressNotOwned}) {
+		t.Fatalf("expected %q, got %v", ErrAddressNotOwned, err)
+	}
+
+	fp, err := k.Fingerprint()
+	if err != nil || fp.String() != v1UIVKFingerprint {
+		t.Fatalf("Fingerprint = %s, %v", fp, err)
+	}
+}
+
+func TestUIVK_Errors(t *testing.T) {
+	v := loadVectors(t)
+
+	cases := []struct {
+		name string
+		uivk string
+		want ErrorCode
+	}{
+		{"not bech32m", "jivk1qqqq", ErrUFVKInvalidBech32m},
+		{"address", v.Addresses[0], ErrUFVKHrpMismatch},
+		// ivk = 0, which is not a valid key.
+		{"zero ivk", "jivk16d59yn95yqwkq8fvdfmtaya7zw3543xk848hl55gg3vt3f9msqaeegmx9pa62806eaq5yg2qfl4xezuvgzxv2905f3ppvujjqqznc70ley8l0yuwfw4vhv9qqfp56vj2dr9sdez270", ErrUFVKFVKBytesInvalid},
+	}
+	for _, tc := range cases {
+		_, err := ParseUFVK(tc.uivk)
+		if !errors.Is(err, &Error{Code: tc.want}) {
+			t.Fatalf("%s: expected %q, got %v", tc.name, tc.want, err)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package addrgen
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/Abdullah1738/juno-addrgen/pkg/zip316"
+)
+
+// These tests check pkg/zip316 against the Rust library's zip316 module, as
+// ParseAddress and InspectUFVK expose it.
+
+// zip316AddressCode is what ParseAddress fails with for s when the container
+// itself is at fault, computed with pkg/zip316 alone, mirroring
+// map_address_zip316_err in the Rust library. It returns "" and the items if
+// the container decodes.
+func zip316AddressCode(s string) (ErrorCode, []zip316.Item) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ErrAddressEmpty, nil
+	}
+	hrp, err := zip316.DecodeHRP(s)
+	if err == nil {
+		switch hrp {
+		case NetworkMainnet.AddressHRP(), NetworkTestnet.AddressHRP(), NetworkRegtest.AddressHRP():
+		case "u", "utest", "uregtest":
+			return ErrAddressZcash, nil
+		default:
+			return ErrAddressHrpMismatch, nil
+		}
+		var items []zip316.Item
+		if items, err = zip316.DecodeTLVContainer(hrp, s); err == nil {
+			return "", items
+		}
+	}
+
+	switch {
+	case errors.Is(err, zip316.ErrChecksumInvalid):
+		return ErrAddressChecksumInvalid, nil
+	case errors.Is(err, zip316.ErrPaddingInvalid):
+		return ErrAddressPaddingInvalid, nil
+	case errors.Is(err, zip316.ErrHRPMismatch):
+		return ErrAddressHrpMismatch, nil
+	case errors.Is(err, zip316.ErrTLVInvalid), errors.Is(err, zip316.ErrTLVTrailingBytes):
+		return ErrAddressTlvInvalid, nil
+	default:
+		return ErrAddressInvalidBech32m, nil
+	}
+}
+
+// checkAgainstRust fails t unless ParseAddress agrees with pkg/zip316 on s.
+func checkAgainstRust(t *testing.T, s string) {
+	t.Helper()
+
+	want, items := zip316AddressCode(s)
+	p, err := ParseAddress(s)
+	if want != "" {
+		if !errors.Is(err, &Error{Code: want}) {
+			t.Fatalf("%q: pkg/zip316 says %q, ParseAddress says %v", s, want, err)
+		}
+		return
+	}
+
+	// The container decodes; ParseAddress may still reject its items.
+	var e *Error
+	if errors.As(err, &e) {
+		switch e.Code {
+		case ErrAddressTlvInvalid, ErrAddressTypecodeDuplicate, ErrAddressValueLenInvalid, ErrAddressReceiversUnknown,
+			ErrAddressTransparentOnly, ErrAddressP2PKHAndP2SH:
+			return
+		}
+		t.Fatalf("%q: pkg/zip316 decodes it, ParseAddress says %v", s, err)
+	}
+	if err != nil {
+		t.Fatalf("%q: ParseAddress error: %v", s, err)
+	}
+	if len(p.Receivers) != len(items) {
+		t.Fatalf("%q: ParseAddress found %d items, pkg/zip316 %d", s, len(p.Receivers), len(items))
+	}
+	for i, r := range p.Receivers {
+		if uint64(r.Typecode) != items[i].Typecode || !bytes.Equal(r.Data, items[i].Value) {
+			t.Fatalf("%q: item %d differs", s, i)
+		}
+	}
+}
+
+func TestZIP316_Vectors(t *testing.T) {
+	for _, name := range []string{"v1.json", "v1_internal.json", "v1_transparent.json"} {
+		v := loadVectorsFile(t, name)
+		for _, address := range v.Addresses {
+			checkAgainstRust(t, address)
+		}
+
+		info, err := InspectUFVK(v.UFVK)
+		if err != nil {
+			t.Fatalf("%s: InspectUFVK error: %v", name, err)
+		}
+		items, err := zip316.DecodeTLVContainer(info.Network.UFVKHRP(), v.UFVK)
+		if err != nil {
+			t.Fatalf("%s: DecodeTLVContainer error: %v", name, err)
+		}
+		if len(items) != len(info.Items) {
+			t.Fatalf("%s: InspectUFVK found %d items, pkg/zip316 %d", name, len(info.Items), len(items))
+		}
+		for i, item := range info.Items {
+			if uint64(item.Typecode) != items[i].Typecode || item.Len != len(items[i].Value) {
+				t.Fatalf("%s: item %d differs", name, i)
+			}
+		}
+	}
+}
+
+func TestZIP316_Mutations(t *testing.T) {
+	v := loadVectors(t)
+	rng := rand.New(rand.NewSource(316))
+	const chars = "qpzry9x8gf2tvdw0s3jn54khce6mua7l1QPZ-é "
+
+	mutate := func(s string) string {
+		i := rng.Intn(len(s))
+		c := string(chars[rng.Intn(len(chars))])
+		switch rng.Intn(5) {
+		case 0:
+			return s[:i] + c + s[i+1:]
+		case 1:
+			return s[:i] + s[i+1:]
+		case 2:
+			return s[:i] + c + s[i:]
+		case 3:
+			return s[:i]
+		default:
+			return strings.ToUpper(s[:i]) + s[i:]
+		}
+	}
+
+	for n := 0; n < 2000; n++ {
+		s := v.Addresses[rng.Intn(len(v.Addresses))]
+		for range 1 + rng.Intn(2) {
+			s = mutate(s)
+		}
+		checkAgainstRust(t, s)
+	}
+	for _, s := range []string{"", "1", "j1", "j1qqqqqq", "J1QQQQQQ", strings.ToUpper(v.Addresses[0]), v.UFVK} {
+		checkAgainstRust(t, s)
+	}
+}
+
+func TestZIP316_RandomContainers(t *testing.T) {
+	rng := rand.New(rand.NewSource(316))
+	hrps := []string{"j", "jtest", "jregtest", "u", "jview", "juno"}
+	typecodes := []uint64{0, 1, 2, 3, 0x42, 0xfffe}
+	sizes := []int{0, 20, 32, 43, 44, 300}
+
+	for n := 0; n < 500; n++ {
+		items := make([]zip316.Item, 1+rng.Intn(3))
+		for i := range items {
+			items[i].Typecode = typecodes[rng.Intn(len(typecodes))]
+			items[i].Value = make([]byte, sizes[rng.Intn(len(sizes))])
+			rng.Read(items[i].Value)
+		}
+		s, err := zip316.EncodeTLVContainer(hrps[rng.Intn(len(hrps))], items)
+		if errors.Is(err, zip316.ErrPayloadTooShort) {
+			continue
+		}
+		if err != nil {
+			t.Fatalf("EncodeTLVContainer error: %v", err)
+		}
+		checkAgainstRust(t, s)
+	}
+}
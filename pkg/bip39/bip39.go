@@ -0,0 +1,115 @@
+// Package bip39 turns BIP-39 mnemonics into seeds.
+//
+// Only the English wordlist is supported. Mnemonics are checked against it
+// and their checksum before use, so a mistyped word is reported rather than
+// silently yielding the seed of a different wallet.
+package bip39
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	_ "embed"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// SeedLen is the length of a seed in bytes.
+const SeedLen = 64
+
+var (
+	// ErrLength is returned for a mnemonic that is not 12, 15, 18, 21 or 24
+	// words long.
+	ErrLength = errors.New("bip39: mnemonic must have 12, 15, 18, 21 or 24 words")
+	// ErrWord is returned (wrapped, with the word's position) for a word
+	// that is not in the wordlist.
+	ErrWord = errors.New("bip39: word not in the English wordlist")
+	// ErrChecksum is returned for a mnemonic whose words are all valid but
+	// whose checksum does not match.
+	ErrChecksum = errors.New("bip39: mnemonic checksum mismatch")
+)
+
+// english is the BIP-39 English wordlist, one word per line. Its SHA-256 is
+// 2f5eed53a4727b4bf8880d8f3f199efc90e58503646d9ff8eff3a2ed3b24dbda.
+//
+//go:embed english.txt
+var english string
+
+var wordlist = strings.Split(strings.TrimSuffix(english, "\n"), "\n")
+
+// Normalize returns mnemonic as BIP-39 hashes it: NFKD, lower case, with
+// words separated by single spaces.
+func Normalize(mnemonic string) string {
+	return strings.Join(strings.Fields(strings.ToLower(norm.NFKD.String(mnemonic))), " ")
+}
+
+// Entropy checks mnemonic's words and checksum and returns the entropy it
+// encodes: 16 to 32 bytes.
+func Entropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(Normalize(mnemonic))
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return nil, ErrLength
+	}
+
+	// Each word holds 11 bits: the entropy, then a checksum of one bit per
+	// 32 bits of entropy.
+	bits := make([]byte, 0, len(words)*11)
+	for i, w := range words {
+		n, ok := slices.BinarySearch(wordlist, w)
+		if !ok {
+			return nil, fmt.Errorf("%w: word %d", ErrWord, i+1)
+		}
+		for j := 10; j >= 0; j-- {
+			bits = append(bits, byte(n>>j)&1)
+		}
+	}
+	csLen := len(bits) / 33
+	entropy := make([]byte, (len(bits)-csLen)/8)
+	for i := range entropy {
+		for _, b := range bits[i*8 : i*8+8] {
+			entropy[i] = entropy[i]<<1 | b
+		}
+	}
+
+	sum := sha256.Sum256(entropy)
+	for i, b := range bits[len(entropy)*8:] {
+		if sum[i/8]>>(7-i%8)&1 != b {
+			return nil, ErrChecksum
+		}
+	}
+	return entropy, nil
+}
+
+// Seed checks mnemonic and returns its seed: PBKDF2-HMAC-SHA512 of the
+// normalized mnemonic, salted with "mnemonic" and the NFKD passphrase, over
+// 2048 iterations. The passphrase is used as given; an empty one is the
+// BIP-39 default.
+func Seed(mnemonic, passphrase string) ([]byte, error) {
+	if _, err := Entropy(mnemonic); err != nil {
+		return nil, err
+	}
+	return pbkdf2SHA512([]byte(Normalize(mnemonic)), []byte("mnemonic"+norm.NFKD.String(passphrase)), 2048), nil
+}
+
+// pbkdf2SHA512 is PBKDF2 (RFC 8018) with HMAC-SHA512, for one block of output.
+func pbkdf2SHA512(password, salt []byte, iterations int) []byte {
+	mac := hmac.New(sha512.New, password)
+	mac.Write(salt)
+	mac.Write(binary.BigEndian.AppendUint32(nil, 1))
+	u := mac.Sum(nil)
+	out := slices.Clone(u)
+	for i := 1; i < iterations; i++ {
+		mac.Reset()
+		mac.Write(u)
+		u = mac.Sum(u[:0])
+		for j := range out {
+			out[j] ^= u[j]
+		}
+	}
+	return out
+}
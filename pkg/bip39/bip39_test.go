@@ -0,0 +1,99 @@
+package bip39
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestWordlist(t *testing.T) {
+	sum := sha256.Sum256([]byte(english))
+	if got := hex.EncodeToString(sum[:]); got != "2f5eed53a4727b4bf8880d8f3f199efc90e58503646d9ff8eff3a2ed3b24dbda" {
+		t.Fatalf("wordlist SHA-256 = %s", got)
+	}
+	if len(wordlist) != 2048 {
+		t.Fatalf("wordlist has %d words", len(wordlist))
+	}
+}
+
+// From the BIP-39 reference vectors (passphrase "TREZOR").
+var vectors = []struct {
+	entropy, mnemonic, seed string
+}{
+	{
+		"00000000000000000000000000000000",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+	},
+	{
+		"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+		"legal winner thank year wave sausage worth useful legal winner thank yellow",
+		"2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+	},
+	{
+		"ffffffffffffffffffffffffffffffff",
+		"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong",
+		"ac27495480225222079d7be181583751e86f571027b0497b5b5d11218e0a8a13332572917f0f8e5a589620c6f15b11c61dee327651a14c34e18231052e48c069",
+	},
+	{
+		"808080808080808080808080808080808080808080808080",
+		"letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic avoid letter always",
+		"107d7c02a5aa6f38c58083ff74f04c607c2d2c0ecc55501dadd72d025b751bc27fe913ffb796f841c49b1d33b610cf0e91d3aa239027f5e99fe4ce9e5088cd65",
+	},
+	{
+		"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo vote",
+		"dd48c104698c30cfe2b6142103248622fb7bb0ff692eebb00089b32d22484e1613912f0a5b694407be899ffd31ed3992c456cdf60f5d4564b8ba3f05a69890ad",
+	},
+}
+
+func TestSeed(t *testing.T) {
+	for _, v := range vectors {
+		entropy, err := Entropy(v.mnemonic)
+		if err != nil || hex.EncodeToString(entropy) != v.entropy {
+			t.Fatalf("Entropy(%q) = %x, %v", v.mnemonic, entropy, err)
+		}
+		seed, err := Seed(v.mnemonic, "TREZOR")
+		if err != nil || hex.EncodeToString(seed) != v.seed {
+			t.Fatalf("Seed(%q) = %x, %v", v.mnemonic, seed, err)
+		}
+	}
+}
+
+func TestSeed_Normalization(t *testing.T) {
+	want, _ := Seed(vectors[0].mnemonic, "")
+	got, err := Seed("  Abandon abandon\tabandon abandon abandon abandon\nabandon abandon abandon abandon abandon ABOUT\n", "")
+	if err != nil || hex.EncodeToString(got) != hex.EncodeToString(want) {
+		t.Fatalf("Seed with extra whitespace and capitals = %x, %v", got, err)
+	}
+
+	// U+00E9 and "e" + U+0301 are the same passphrase after NFKD.
+	a, _ := Seed(vectors[0].mnemonic, "caf\u00e9")
+	b, _ := Seed(vectors[0].mnemonic, "cafe\u0301")
+	if hex.EncodeToString(a) != hex.EncodeToString(b) {
+		t.Fatalf("passphrase is not NFKD-normalized")
+	}
+}
+
+func TestEntropy_Invalid(t *testing.T) {
+	for _, tc := range []struct {
+		mnemonic string
+		want     error
+	}{
+		{"", ErrLength},
+		{"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon", ErrLength},
+		{"legal winner thank year wave sausage worth useful legal winner thank yellow yellow", ErrLength},
+		{"letter advice cage absurd amount doctor acoustic avoid letter advice caged above", ErrWord},
+		{"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo, wrong", ErrWord},
+		{"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo why", ErrWord},
+		{"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon yellow", ErrChecksum},
+	} {
+		if _, err := Entropy(tc.mnemonic); !errors.Is(err, tc.want) {
+			t.Fatalf("Entropy(%q) error = %v, want %v", tc.mnemonic, err, tc.want)
+		}
+		if _, err := Seed(tc.mnemonic, ""); !errors.Is(err, tc.want) {
+			t.Fatalf("Seed(%q) error = %v, want %v", tc.mnemonic, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+// Package diversifier implements ZIP-32 diversifier indices: the 88-bit
+// unsigned integers that select one of a viewing key's addresses.
+//
+// It is pure Go so that callers which only parse and validate indices (such as
+// the CLI) don't need the cgo library.
+package diversifier
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Len is the length of an encoded index in bytes.
+const Len = 11
+
+// Bits is the width of the index space.
+const Bits = 88
+
+var (
+	// ErrSyntax is returned when a string is not a decimal or 0x-prefixed
+	// hexadecimal integer.
+	ErrSyntax = errors.New("diversifier: invalid index syntax")
+	// ErrRange is returned when a value does not fit in 88 bits, or a range of
+	// indices extends past Max.
+	ErrRange = errors.New("diversifier: index out of range")
+)
+
+// Index is a diversifier index, stored little-endian as in ZIP-32. The zero
+// value is index 0.
+type Index [Len]byte
+
+// Max is the largest diversifier index, 2^88 - 1.
+var Max = Index{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+// hiLimit bounds the high part of an index (bits 64..87).
+const hiLimit = 1 << (Bits - 64)
+
+// FromUint32 returns the index n.
+func FromUint32(n uint32) Index {
+	return FromUint64(uint64(n))
+}
+
+// FromUint64 returns the index n.
+func FromUint64(n uint64) Index {
+	return join(n, 0)
+}
+
+// FromBig returns the index n, or ErrRange if n is negative or at least 2^88.
+func FromBig(n *big.Int) (Index, error) {
+	if n.Sign() < 0 || n.BitLen() > Bits {
+		return Index{}, ErrRange
+	}
+	var be [Len]byte
+	n.FillBytes(be[:])
+
+	var i Index
+	for j := range be {
+		i[j] = be[Len-1-j]
+	}
+	return i, nil
+}
+
+// Parse parses a decimal or 0x-prefixed hexadecimal index. Surrounding
+// whitespace is ignored; signs and digit separators are not accepted.
+func Parse(s string) (Index, error) {
+	s = strings.TrimSpace(s)
+
+	base, digits := 10, s
+	if rest, ok := strings.CutPrefix(strings.ToLower(s), "0x"); ok {
+		base, digits = 16, rest
+	}
+	if digits == "" {
+		return Index{}, ErrSyntax
+	}
+	for _, c := range strings.ToLower(digits) {
+		switch {
+		case c >= '0' && c <= '9':
+		case base == 16 && c >= 'a' && c <= 'f':
+		default:
+			return Index{}, ErrSyntax
+		}
+	}
+
+	n, ok := new(big.Int).SetString(digits, base)
+	if !ok {
+		return Index{}, ErrSyntax
+	}
+	return FromBig(n)
+}
+
+// Big returns the index as a big.Int.
+func (i Index) Big() *big.Int {
+	var be [Len]byte
+	for j := range i {
+		be[j] = i[Len-1-j]
+	}
+	return new(big.Int).SetBytes(be[:])
+}
+
+// Uint64 returns the index and true if it fits in a uint64.
+func (i Index) Uint64() (uint64, bool) {
+	lo, hi := i.split()
+	return lo, hi == 0
+}
+
+// Uint32 returns the index and true if it fits in a uint32.
+func (i Index) Uint32() (uint32, bool) {
+	n, ok := i.Uint64()
+	if !ok || n > 1<<32-1 {
+		return 0, false
+	}
+	return uint32(n), true
+}
+
+// String returns the index in decimal.
+func (i Index) String() string {
+	if n, ok := i.Uint64(); ok {
+		return strconv.FormatUint(n, 10)
+	}
+	return i.Big().String()
+}
+
+// Cmp compares i and j, returning -1, 0 or +1.
+func (i Index) Cmp(j Index) int {
+	ilo, ihi := i.split()
+	jlo, jhi := j.split()
+	switch {
+	case ihi < jhi, ihi == jhi && ilo < jlo:
+		return -1
+	case ihi > jhi, ihi == jhi && ilo > jlo:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Add returns i + n, and false if the sum exceeds Max.
+func (i Index) Add(n uint64) (Index, bool) {
+	lo, hi := i.split()
+	sum := lo + n
+	if sum < lo {
+		hi++
+	}
+	if hi >= hiLimit {
+		return Index{}, false
+	}
+	return join(sum, hi), true
+}
+
+// CheckRange reports whether the count indices starting at start all fit in
+// the index space. It returns ErrRange if the range extends past Max; a zero
+// count is always in range.
+func CheckRange(start Index, count uint64) error {
+	if count == 0 {
+		return nil
+	}
+	if _, ok := start.Add(count - 1); !ok {
+		return ErrRange
+	}
+	return nil
+}
+
+// MarshalText returns the index in decimal.
+func (i Index) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+
+// UnmarshalText parses an index as Parse does.
+func (i *Index) UnmarshalText(text []byte) error {
+	v, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*i = v
+	return nil
+}
+
+// MarshalJSON encodes the index as a JSON number, in decimal. Indices above
+// 2^53 - 1 are exact in the JSON text but not in float64 decoders such as
+// JavaScript's or jq's; those should read the index from its decimal string
+// (String) instead.
+func (i Index) MarshalJSON() ([]byte, error) {
+	return i.MarshalText()
+}
+
+// UnmarshalJSON accepts a JSON number or a JSON string holding a decimal or
+// hexadecimal index.
+func (i *Index) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		return i.UnmarshalText([]byte(s))
+	}
+	return i.UnmarshalText(data)
+}
+
+func (i Index) split() (lo uint64, hi uint32) {
+	lo = binary.LittleEndian.Uint64(i[:8])
+	hi = uint32(i[8]) | uint32(i[9])<<8 | uint32(i[10])<<16
+	return lo, hi
+}
+
+func join(lo uint64, hi uint32) Index {
+	var i Index
+	binary.LittleEndian.PutUint64(i[:8], lo)
+	i[8], i[9], i[10] = byte(hi), byte(hi>>8), byte(hi>>16)
+	return i
+}
@@ -0,0 +1,148 @@
+package diversifier
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	max := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), Bits), big.NewInt(1))
+
+	cases := []struct {
+		in   string
+		want *big.Int
+	}{
+		{"0", big.NewInt(0)},
+		{" 42\n", big.NewInt(42)},
+		{"4294967296", big.NewInt(1 << 32)},
+		{"0x100000000", big.NewInt(1 << 32)},
+		{"0XfF", big.NewInt(255)},
+		{"18446744073709551616", new(big.Int).Lsh(big.NewInt(1), 64)},
+		{max.String(), max},
+		{"0xffffffffffffffffffffff", max},
+	}
+	for _, tc := range cases {
+		got, err := Parse(tc.in)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", tc.in, err)
+		}
+		if got.Big().Cmp(tc.want) != 0 {
+			t.Fatalf("Parse(%q) = %s, want %s", tc.in, got, tc.want)
+		}
+		if got.String() != tc.want.String() {
+			t.Fatalf("String() = %s, want %s", got, tc.want)
+		}
+	}
+	if got, _ := Parse(max.String()); got != Max {
+		t.Fatalf("Parse(max) = %x, want Max", got)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, in := range []string{"", "0x", "-1", "+1", "1_000", "1e3", "0x1g", "ten"} {
+		if _, err := Parse(in); !errors.Is(err, ErrSyntax) {
+			t.Fatalf("Parse(%q): expected ErrSyntax, got %v", in, err)
+		}
+	}
+	for _, in := range []string{"309485009821345068724781056", "0x10000000000000000000000"} {
+		if _, err := Parse(in); !errors.Is(err, ErrRange) {
+			t.Fatalf("Parse(%q): expected ErrRange, got %v", in, err)
+		}
+	}
+}
+
+func TestUintConversions(t *testing.T) {
+	i := FromUint32(math.MaxUint32)
+	if n, ok := i.Uint32(); !ok || n != math.MaxUint32 {
+		t.Fatalf("Uint32() = %d, %v", n, ok)
+	}
+
+	i, _ = i.Add(1)
+	if _, ok := i.Uint32(); ok {
+		t.Fatalf("Uint32() ok above 2^32-1")
+	}
+	if n, ok := i.Uint64(); !ok || n != 1<<32 {
+		t.Fatalf("Uint64() = %d, %v", n, ok)
+	}
+
+	i = FromUint64(math.MaxUint64)
+	i, _ = i.Add(1)
+	if _, ok := i.Uint64(); ok {
+		t.Fatalf("Uint64() ok above 2^64-1")
+	}
+	if i.String() != "18446744073709551616" {
+		t.Fatalf("String() = %s", i)
+	}
+}
+
+func TestAddAndCheckRange(t *testing.T) {
+	beforeMax, _ := Parse("0xfffffffffffffffffffffe")
+	if got, ok := beforeMax.Add(1); !ok || got != Max {
+		t.Fatalf("Add to Max = %s, %v", got, ok)
+	}
+	if _, ok := Max.Add(1); ok {
+		t.Fatalf("Add past Max succeeded")
+	}
+
+	if err := CheckRange(beforeMax, 2); err != nil {
+		t.Fatalf("CheckRange to Max: %v", err)
+	}
+	if err := CheckRange(beforeMax, 3); !errors.Is(err, ErrRange) {
+		t.Fatalf("CheckRange past Max: %v", err)
+	}
+	if err := CheckRange(Max, math.MaxUint64); !errors.Is(err, ErrRange) {
+		t.Fatalf("CheckRange with huge count: %v", err)
+	}
+	if err := CheckRange(Index{}, math.MaxUint64); err != nil {
+		t.Fatalf("CheckRange from zero: %v", err)
+	}
+}
+
+func TestCmp(t *testing.T) {
+	lo := FromUint64(math.MaxUint64)
+	hi, _ := lo.Add(1)
+	if lo.Cmp(hi) != -1 || hi.Cmp(lo) != 1 || hi.Cmp(hi) != 0 {
+		t.Fatalf("unexpected ordering")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	i, _ := Parse("0x123456789abcdef0123456")
+
+	b, err := json.Marshal(i)
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	if string(b) != i.String() {
+		t.Fatalf("Marshal = %s, want %s", b, i)
+	}
+	for n, want := range map[uint64]string{
+		0:         `0`,
+		1 << 32:   `4294967296`,
+		1<<53 + 1: `9007199254740993`,
+	} {
+		if b, err := json.Marshal(FromUint64(n)); err != nil || string(b) != want {
+			t.Fatalf("Marshal(%d) = %s, %v; want %s", n, b, err, want)
+		}
+	}
+
+	var got Index
+	if err := json.Unmarshal(b, &got); err != nil || got != i {
+		t.Fatalf("Unmarshal = %s, %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(`"0x123456789abcdef0123456"`), &got); err != nil || got != i {
+		t.Fatalf("Unmarshal string = %s, %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(`"\u0031\u0032"`), &got); err != nil || got != FromUint32(12) {
+		t.Fatalf("Unmarshal escaped string = %s, %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(`1234`), &got); err != nil || got != FromUint32(1234) {
+		t.Fatalf("Unmarshal number = %s, %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(`-1`), &got); err == nil {
+		t.Fatalf("expected error for negative index")
+	}
+}
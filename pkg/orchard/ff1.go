@@ -0,0 +1,98 @@
+package orchard
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+)
+
+// Orchard encrypts diversifier indices into diversifiers with FF1-AES-256
+// (NIST SP 800-38G) over radix 2 with an empty tweak. A diversifier is an
+// 88-numeral binary string, numeral i being bit i of the little-endian
+// index; FF1 splits it into two 44-numeral halves, each held here as the
+// integer NUM(half), most significant numeral first.
+const (
+	ff1N      = 88
+	ff1Half   = ff1N / 2
+	ff1Rounds = 10
+	ff1Mask   = 1<<ff1Half - 1
+)
+
+// ff1 is FF1 keyed with a diversifier key.
+type ff1 struct {
+	block cipher.Block
+	// mac is the CBC-MAC state after the fixed first block P.
+	mac [aes.BlockSize]byte
+}
+
+func newFF1(key []byte) ff1 {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		panic("orchard: " + err.Error())
+	}
+	f := ff1{block: block}
+	// P = [1, 2, 1] || [radix]^3 || [10] || [u mod 256]^1 || [n]^4 || [t]^4.
+	p := [aes.BlockSize]byte{1, 2, 1, 0, 0, 2, ff1Rounds, ff1Half, 0, 0, 0, ff1N}
+	block.Encrypt(f.mac[:], p[:])
+	return f
+}
+
+// round returns round i's y, reduced mod 2^44, for the half b. With b = 6
+// and d = 12, Q is nine zero bytes, i, and NUM(b) in six bytes, and y is the
+// first 12 bytes of PRF(P || Q).
+func (f ff1) round(i int, b uint64) uint64 {
+	var q [aes.BlockSize]byte
+	q[9] = byte(i)
+	var num [8]byte
+	binary.BigEndian.PutUint64(num[:], b)
+	copy(q[10:], num[2:])
+	for j := range q {
+		q[j] ^= f.mac[j]
+	}
+	f.block.Encrypt(q[:], q[:])
+	// Only the low 44 bits of the 96-bit y matter.
+	return binary.BigEndian.Uint64(q[4:12]) & ff1Mask
+}
+
+func (f ff1) encrypt(in [11]byte) [11]byte {
+	a, b := ff1Split(in)
+	for i := 0; i < ff1Rounds; i++ {
+		a, b = b, (a+f.round(i, b))&ff1Mask
+	}
+	return ff1Join(a, b)
+}
+
+func (f ff1) decrypt(in [11]byte) [11]byte {
+	a, b := ff1Split(in)
+	for i := ff1Rounds - 1; i >= 0; i-- {
+		a, b = (b-f.round(i, a))&ff1Mask, a
+	}
+	return ff1Join(a, b)
+}
+
+// ff1Split reads the two halves of a diversifier-sized bit string.
+func ff1Split(in [11]byte) (a, b uint64) {
+	for i := 0; i < ff1N; i++ {
+		bit := uint64(in[i/8]>>(i%8)) & 1
+		if i < ff1Half {
+			a = a<<1 | bit
+		} else {
+			b = b<<1 | bit
+		}
+	}
+	return a, b
+}
+
+func ff1Join(a, b uint64) [11]byte {
+	var out [11]byte
+	for i := 0; i < ff1N; i++ {
+		var bit uint64
+		if i < ff1Half {
+			bit = a >> (ff1Half - 1 - i) & 1
+		} else {
+			bit = b >> (ff1N - 1 - i) & 1
+		}
+		out[i/8] |= byte(bit) << (i % 8)
+	}
+	return out
+}
@@ -0,0 +1,63 @@
+package orchard
+
+import "math/big"
+
+// The Pallas base field modulus p and scalar field modulus q. They are
+// swapped for Vesta, whose base field is Fq: scalars here are Vesta base
+// field elements.
+var (
+	fieldP = mustHex("40000000000000000000000000000000224698fc094cf91b992d30ed00000001")
+	fieldQ = mustHex("40000000000000000000000000000000224698fc0994a8dd8c46eb2100000001")
+)
+
+func mustHex(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("orchard: bad constant " + s)
+	}
+	return n
+}
+
+// fpReduce reduces a modulo p in place.
+func fpReduce(a *big.Int) *big.Int { return a.Mod(a, fieldP) }
+
+// Arithmetic in Fp. Every result is reduced and freshly allocated, so
+// arguments are never modified.
+
+func fpInt(v int64) *big.Int       { return fpReduce(big.NewInt(v)) }
+func fpAdd(a, b *big.Int) *big.Int { return fpReduce(new(big.Int).Add(a, b)) }
+func fpSub(a, b *big.Int) *big.Int { return fpReduce(new(big.Int).Sub(a, b)) }
+func fpMul(a, b *big.Int) *big.Int { return fpReduce(new(big.Int).Mul(a, b)) }
+func fpNeg(a *big.Int) *big.Int    { return fpReduce(new(big.Int).Neg(a)) }
+func fpInv(a *big.Int) *big.Int    { return new(big.Int).ModInverse(a, fieldP) }
+
+// fpSqrt returns a square root of a, or nil if a is not a square.
+func fpSqrt(a *big.Int) *big.Int { return new(big.Int).ModSqrt(a, fieldP) }
+
+// fpOdd is the "sign" of a field element: its least significant bit.
+func fpOdd(a *big.Int) bool { return a.Bit(0) == 1 }
+
+// fromLE decodes a little-endian integer.
+func fromLE(b []byte) *big.Int {
+	be := make([]byte, len(b))
+	for i, c := range b {
+		be[len(b)-1-i] = c
+	}
+	return new(big.Int).SetBytes(be)
+}
+
+// putLE encodes a, which must be non-negative and fit, little-endian into b.
+func putLE(b []byte, a *big.Int) {
+	a.FillBytes(b)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
+
+// le32 returns a as 32 little-endian bytes, the encoding of field elements
+// and scalars.
+func le32(a *big.Int) []byte {
+	b := make([]byte, 32)
+	putLE(b, a)
+	return b
+}
@@ -0,0 +1,129 @@
+package orchard
+
+import (
+	"math/big"
+
+	"github.com/Abdullah1738/juno-addrgen/internal/blake2b"
+)
+
+// isoPallas is the curve isogenous to Pallas that the simplified SWU map
+// targets, since Pallas itself has a = 0.
+var isoPallas = curve{
+	a: mustHex("18354a2eb0ea8c9c49be2d7258370742b74134581a27a59f92bb4b0b657a014b"),
+	b: big.NewInt(1265),
+}
+
+// sswuZ is the simplified SWU constant Z = -13.
+var sswuZ = fpInt(-13)
+
+// The 3-isogeny from iso-Pallas to Pallas, as the rational map
+//
+//	x' = xNum(x) / xDen(x),  y' = y · yNum(x) / yDen(x)
+//
+// with coefficients from the constant term up. The denominators are monic;
+// their leading coefficient is left out.
+var (
+	isoXNum = []*big.Int{
+		mustHex("1c71c71c71c71c71c71c71c71c71c71c8102eea8e7b06eb6eebec06955555580"),
+		mustHex("17329b9ec525375398c7d7ac3d98fd13380af066cfeb6d690eb64faef37ea4f7"),
+		mustHex("3509afd51872d88e267c7ffa51cf412a0f93b82ee4b994958cf863b02814fb76"),
+		mustHex("0e38e38e38e38e38e38e38e38e38e38e4081775473d8375b775f6034aaaaaaab"),
+	}
+	isoXDen = []*big.Int{
+		mustHex("325669becaecd5d11d13bf2a7f22b105b4abf9fb9a1fc81c2aa3af1eae5b6604"),
+		mustHex("1d572e7ddc099cff5a607fcce0494a799c434ac1c96b6980c47f2ab668bcd71f"),
+	}
+	isoYNum = []*big.Int{
+		mustHex("025ed097b425ed097b425ed097b425ed0ac03e8e134eb3e493e53ab371c71c4f"),
+		mustHex("3fb98ff0d2ddcadd303216cce1db9ff11765e924f745937802e2be87d225b234"),
+		mustHex("1a84d7ea8c396c47133e3ffd28e7a09507c9dc17725cca4ac67c31d8140a7dbb"),
+		mustHex("1a12f684bda12f684bda12f684bda12f7642b01ad461bad25ad985b5e38e38e4"),
+	}
+	isoYDen = []*big.Int{
+		mustHex("40000000000000000000000000000000224698fc094cf91b992d30ecfffffde5"),
+		mustHex("17033d3c60c68173573b3d7f7d681310d976bbfabbc5661d4d90ab820b12320a"),
+		mustHex("0c02c5bcca0e6b7f0790bfb3506defb65941a3a4a97aa1b35a28279b1d1b42ae"),
+	}
+)
+
+// horner evaluates the polynomial with coefficients c (constant term first)
+// at x, plus x^len(c) if monic.
+func horner(c []*big.Int, x *big.Int, monic bool) *big.Int {
+	acc := new(big.Int)
+	if monic {
+		acc.SetInt64(1)
+	}
+	for i := len(c) - 1; i >= 0; i-- {
+		acc = fpAdd(fpMul(acc, x), c[i])
+	}
+	return acc
+}
+
+// isoMap maps an iso-Pallas point to Pallas.
+func isoMap(p point) point {
+	if p.inf {
+		return p
+	}
+	xDen := horner(isoXDen, p.x, true)
+	yDen := horner(isoYDen, p.x, true)
+	// The denominators vanish together, at the kernel of the isogeny.
+	if xDen.Sign() == 0 || yDen.Sign() == 0 {
+		return point{inf: true}
+	}
+	x := fpMul(horner(isoXNum, p.x, false), fpInv(xDen))
+	y := fpMul(fpMul(p.y, horner(isoYNum, p.x, false)), fpInv(yDen))
+	return point{x: x, y: y}
+}
+
+// hashToField is hash_to_field for Pallas with expand_message_xmd over
+// BLAKE2b-512, producing two field elements from 64 bytes each.
+func hashToField(domain string, msg []byte) [2]*big.Int {
+	const outLen = 128
+	dst := []byte(domain + "-pallas_XMD:BLAKE2b_SSWU_RO_")
+	dstPrime := append(dst, byte(len(dst)))
+
+	var zPad [blake2b.BlockSize]byte
+	b0 := blake2b.Sum(64, nil, zPad[:], msg, []byte{0, outLen, 0}, dstPrime)
+	b1 := blake2b.Sum(64, nil, b0, []byte{1}, dstPrime)
+	x := make([]byte, 64)
+	for i := range x {
+		x[i] = b0[i] ^ b1[i]
+	}
+	b2 := blake2b.Sum(64, nil, x, []byte{2}, dstPrime)
+	return [2]*big.Int{
+		fpReduce(new(big.Int).SetBytes(b1)),
+		fpReduce(new(big.Int).SetBytes(b2)),
+	}
+}
+
+// sswu is the simplified SWU map from Fp to iso-Pallas.
+func sswu(u *big.Int) point {
+	a, b := isoPallas.a, isoPallas.b
+	zu2 := fpMul(sswuZ, fpMul(u, u))
+	tv := fpAdd(fpMul(zu2, zu2), zu2)
+
+	var x1 *big.Int
+	if tv.Sign() == 0 {
+		x1 = fpMul(b, fpInv(fpMul(sswuZ, a)))
+	} else {
+		x1 = fpMul(fpMul(fpNeg(b), fpInv(a)), fpAdd(big.NewInt(1), fpInv(tv)))
+	}
+	g := func(x *big.Int) *big.Int {
+		return fpAdd(fpAdd(fpMul(fpMul(x, x), x), fpMul(a, x)), b)
+	}
+	x, y := x1, fpSqrt(g(x1))
+	if y == nil {
+		x = fpMul(zu2, x1)
+		y = fpSqrt(g(x))
+	}
+	if fpOdd(u) != fpOdd(y) {
+		y = fpNeg(y)
+	}
+	return point{x: x, y: y}
+}
+
+// groupHash is GroupHash^P: Pallas's hash_to_curve("domain", msg).
+func groupHash(domain string, msg []byte) point {
+	u := hashToField(domain, msg)
+	return isoMap(isoPallas.add(sswu(u[0]), sswu(u[1])))
+}
@@ -0,0 +1,218 @@
+// Package orchard derives Orchard addresses from viewing keys in pure Go: the
+// Pallas arithmetic, Sinsemilla CommitIvk, DiversifyHash and FF1-AES
+// diversifier encryption that the Rust library takes from the orchard crate.
+// It also derives spending keys from a seed with ZIP-32, and their viewing
+// keys, for key generation.
+//
+// It exists so that juno-addrgen can be built and audited without a Rust
+// toolchain, and is tested against the same vectors as the library and
+// differentially against it. It is written for clarity over speed, on
+// math/big, and is not constant time: its timing depends on the keys.
+// Viewing keys cannot spend, but they do reveal a wallet's incoming funds;
+// prefer the Rust library where that matters, and only derive spending keys
+// on a machine no one else can observe.
+package orchard
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/Abdullah1738/juno-addrgen/internal/blake2b"
+	"github.com/Abdullah1738/juno-addrgen/pkg/diversifier"
+)
+
+const (
+	// FullViewingKeyLen is the length of an encoded FVK: ak || nk || rivk.
+	FullViewingKeyLen = 96
+	// IncomingViewingKeyLen is the length of an encoded IVK: dk || ivk.
+	IncomingViewingKeyLen = 64
+	// RawAddressLen is the length of a raw address (an Orchard receiver):
+	// d || pk_d.
+	RawAddressLen = 43
+	// FingerprintLen is the length of a key fingerprint.
+	FingerprintLen = 32
+)
+
+var (
+	// ErrFullViewingKeyInvalid is returned for FVK bytes the orchard crate
+	// would not accept.
+	ErrFullViewingKeyInvalid = errors.New("orchard: invalid full viewing key")
+	// ErrIncomingViewingKeyInvalid is returned for IVK bytes the orchard
+	// crate would not accept.
+	ErrIncomingViewingKeyInvalid = errors.New("orchard: invalid incoming viewing key")
+	// ErrRawAddressInvalid is returned for a receiver whose pk_d is not a
+	// valid non-identity point.
+	ErrRawAddressInvalid = errors.New("orchard: invalid raw address")
+)
+
+// Scope is a ZIP-32 key scope.
+type Scope int
+
+const (
+	// ScopeExternal is for addresses given out to payers.
+	ScopeExternal Scope = iota
+	// ScopeInternal is for change.
+	ScopeInternal
+)
+
+// FullViewingKey is a decoded Orchard full viewing key. It is immutable, so
+// it may be shared between goroutines.
+type FullViewingKey struct {
+	raw [FullViewingKeyLen]byte
+	ivk [2]*IncomingViewingKey
+}
+
+// NewFullViewingKey decodes an FVK. Like the orchard crate it requires a
+// non-identity ak with an even y-coordinate, canonical nk and rivk, and a
+// non-zero IVK in both scopes, which it derives up front.
+func NewFullViewingKey(b []byte) (*FullViewingKey, error) {
+	if len(b) != FullViewingKeyLen {
+		return nil, ErrFullViewingKeyInvalid
+	}
+	ak, ok := decodePallas(b[:32])
+	if !ok || ak.inf || b[31]&0x80 != 0 {
+		return nil, ErrFullViewingKeyInvalid
+	}
+	nk := fromLE(b[32:64])
+	rivk := fromLE(b[64:96])
+	if nk.Cmp(fieldP) >= 0 || rivk.Cmp(fieldQ) >= 0 {
+		return nil, ErrFullViewingKeyInvalid
+	}
+
+	k := &FullViewingKey{raw: [FullViewingKeyLen]byte(b)}
+	external, err := deriveIVK(ak, nk, rivk)
+	if err != nil {
+		return nil, err
+	}
+	// ZIP-32: the internal FVK has rivk' = ToScalar(PRF^expand_rivk([0x83] ||
+	// I2LEOSP(ak) || I2LEOSP(nk))).
+	wide := prfExpand(b[64:96], []byte{0x83}, b[:32], b[32:64])
+	internalRivk := new(big.Int).Mod(fromLE(wide), fieldQ)
+	internal, err := deriveIVK(ak, nk, internalRivk)
+	if err != nil {
+		return nil, err
+	}
+	k.ivk = [2]*IncomingViewingKey{external, internal}
+	return k, nil
+}
+
+// deriveIVK derives the IVK of the FVK (ak, nk, rivk): ivk = CommitIvk(ak,
+// nk) with randomness rivk, and dk from PRF^expand(rivk).
+func deriveIVK(ak point, nk, rivk *big.Int) (*IncomingViewingKey, error) {
+	bits := make([]byte, 0, 2*255)
+	bits = appendBits(bits, ak.x, 255)
+	bits = appendBits(bits, nk, 255)
+	ivk := sinsemillaShortCommit("z.cash:Orchard-CommitIvk", bits, rivk)
+	if ivk == nil || ivk.Sign() == 0 {
+		return nil, ErrFullViewingKeyInvalid
+	}
+	encAk := ak.encode()
+	wide := prfExpand(le32(rivk), []byte{0x82}, encAk[:], le32(nk))
+	k := &IncomingViewingKey{ivk: ivk}
+	copy(k.dk[:], wide[:32])
+	return k, nil
+}
+
+// prfExpand is PRF^expand: BLAKE2b-512 personalized "Zcash_ExpandSeed".
+func prfExpand(sk []byte, t ...[]byte) []byte {
+	return blake2b.Sum(64, []byte("Zcash_ExpandSeed"), append([][]byte{sk}, t...)...)
+}
+
+// Bytes returns the encoded FVK.
+func (k *FullViewingKey) Bytes() [FullViewingKeyLen]byte {
+	return k.raw
+}
+
+// Fingerprint returns the ZIP-32 FVK fingerprint: BLAKE2b-256 of the encoded
+// FVK, personalized "ZcashOrchardFVFP".
+func (k *FullViewingKey) Fingerprint() [FingerprintLen]byte {
+	return [FingerprintLen]byte(blake2b.Sum(FingerprintLen, []byte("ZcashOrchardFVFP"), k.raw[:]))
+}
+
+// IncomingViewingKey returns the key's IVK in scope.
+func (k *FullViewingKey) IncomingViewingKey(scope Scope) *IncomingViewingKey {
+	if scope == ScopeInternal {
+		return k.ivk[1]
+	}
+	return k.ivk[0]
+}
+
+// IncomingViewingKey is a decoded Orchard IVK: a diversifier key dk and the
+// scalar ivk. It is immutable, so it may be shared between goroutines.
+type IncomingViewingKey struct {
+	dk  [32]byte
+	ivk *big.Int
+}
+
+// NewIncomingViewingKey decodes an IVK. Like the orchard crate it requires a
+// canonical, non-zero ivk; any dk is accepted.
+func NewIncomingViewingKey(b []byte) (*IncomingViewingKey, error) {
+	if len(b) != IncomingViewingKeyLen {
+		return nil, ErrIncomingViewingKeyInvalid
+	}
+	ivk := fromLE(b[32:])
+	if ivk.Sign() == 0 || ivk.Cmp(fieldP) >= 0 {
+		return nil, ErrIncomingViewingKeyInvalid
+	}
+	return &IncomingViewingKey{dk: [32]byte(b[:32]), ivk: ivk}, nil
+}
+
+// Bytes returns the encoded IVK.
+func (k *IncomingViewingKey) Bytes() [IncomingViewingKeyLen]byte {
+	var out [IncomingViewingKeyLen]byte
+	copy(out[:32], k.dk[:])
+	putLE(out[32:], k.ivk)
+	return out
+}
+
+// Fingerprint returns juno-addrgen's fingerprint of a key known only by its
+// IVK: BLAKE2b-256 of the encoded IVK, personalized "JunoOrchardIVKFP".
+func (k *IncomingViewingKey) Fingerprint() [FingerprintLen]byte {
+	b := k.Bytes()
+	return [FingerprintLen]byte(blake2b.Sum(FingerprintLen, []byte("JunoOrchardIVKFP"), b[:]))
+}
+
+// Address returns the raw address at index: the diversifier d = FF1(dk,
+// index) followed by pk_d = [ivk] DiversifyHash(d).
+func (k *IncomingViewingKey) Address(index diversifier.Index) [RawAddressLen]byte {
+	d := newFF1(k.dk[:]).encrypt(index)
+	pkd := mul(k.ivk, diversifyHash(d))
+
+	var out [RawAddressLen]byte
+	copy(out[:diversifier.Len], d[:])
+	enc := pkd.encode()
+	copy(out[diversifier.Len:], enc[:])
+	return out
+}
+
+// DiversifierIndex returns the index at which k derives raw, decrypting its
+// diversifier and re-deriving the address to confirm it. It reports false if
+// k does not derive raw.
+func (k *IncomingViewingKey) DiversifierIndex(raw [RawAddressLen]byte) (diversifier.Index, bool) {
+	index := diversifier.Index(newFF1(k.dk[:]).decrypt([diversifier.Len]byte(raw[:diversifier.Len])))
+	if k.Address(index) != raw {
+		return diversifier.Index{}, false
+	}
+	return index, true
+}
+
+// diversifyHash is DiversifyHash^Orchard: GroupHash of d, or of the empty
+// string if that is the identity.
+func diversifyHash(d [diversifier.Len]byte) point {
+	g := groupHash("z.cash:Orchard-gd", d[:])
+	if g.inf {
+		g = groupHash("z.cash:Orchard-gd", nil)
+	}
+	return g
+}
+
+// CheckRawAddress reports whether raw is a valid Orchard receiver, as the
+// orchard crate decodes it: any diversifier, and a pk_d that is a canonical
+// encoding of a non-identity point.
+func CheckRawAddress(raw [RawAddressLen]byte) error {
+	pkd, ok := decodePallas(raw[diversifier.Len:])
+	if !ok || pkd.inf {
+		return ErrRawAddressInvalid
+	}
+	return nil
+}
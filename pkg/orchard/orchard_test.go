@@ -0,0 +1,343 @@
+package orchard
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Abdullah1738/juno-addrgen/pkg/diversifier"
+	"github.com/Abdullah1738/juno-addrgen/pkg/zip316"
+	"github.com/Abdullah1738/juno-addrgen/pkg/zip32"
+)
+
+const (
+	typecodeOrchard = 3
+
+	// The v1 vectors' UIVK and the fingerprints of their UFVK and UIVK, as
+	// the Rust library computes them.
+	v1UIVK            = "jivk1ha7923zaevmrhxcr6h2lqcf6sk8kt3gz65ls74sqwk23aet7gfypxwslt44gyfuvdpj5qfmecm49pyw9n7hcldlt7ydldaw5n3rzf9a8ny80jq9akg29fdxrqamat8xxdzrslj74dq"
+	v1Fingerprint     = "e37feab45ad86988f554c78392b95cd1ab94e1e0f407262f3c2477c772414738"
+	v1UIVKFingerprint = "d61b45fa8c5834e6d0d8df5a7610a22506558f540f3b072fc3e79d053aa2109e"
+)
+
+type vectors struct {
+	UFVK      string   `json:"ufvk"`
+	Addresses []string `json:"addresses"`
+	Indexed   []struct {
+		Index   diversifier.Index `json:"index"`
+		Address string            `json:"address"`
+	} `json:"indexed"`
+}
+
+func loadVectorsFile(t testing.TB, name string) vectors {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("..", "..", "vectors", name))
+	if err != nil {
+		t.Fatalf("read vectors: %v", err)
+	}
+	var v vectors
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatalf("parse vectors: %v", err)
+	}
+	return v
+}
+
+// vectorKey decodes the FVK of the vectors' UFVK.
+func vectorKey(t testing.TB, ufvk string) *FullViewingKey {
+	t.Helper()
+
+	items, err := zip316.DecodeTLVContainer("jview", ufvk)
+	if err != nil {
+		t.Fatalf("DecodeTLVContainer error: %v", err)
+	}
+	for _, item := range items {
+		if item.Typecode == typecodeOrchard {
+			k, err := NewFullViewingKey(item.Value)
+			if err != nil {
+				t.Fatalf("NewFullViewingKey error: %v", err)
+			}
+			return k
+		}
+	}
+	t.Fatalf("no orchard item")
+	return nil
+}
+
+// checkAddress fails t unless ivk derives want at index, and finds index
+// again from it.
+func checkAddress(t *testing.T, ivk *IncomingViewingKey, index diversifier.Index, want string) {
+	t.Helper()
+
+	raw := ivk.Address(index)
+	got, err := zip316.EncodeUnifiedContainer("j", typecodeOrchard, raw[:])
+	if err != nil {
+		t.Fatalf("EncodeUnifiedContainer error: %v", err)
+	}
+	if got != want {
+		t.Fatalf("index %s: got %s, want %s", index, got, want)
+	}
+	if i, ok := ivk.DiversifierIndex(raw); !ok || i != index {
+		t.Fatalf("DiversifierIndex = %s, %v, want %s", i, ok, index)
+	}
+	if err := CheckRawAddress(raw); err != nil {
+		t.Fatalf("CheckRawAddress error: %v", err)
+	}
+}
+
+func TestVectors(t *testing.T) {
+	for _, tc := range []struct {
+		file  string
+		scope Scope
+	}{
+		{"v1.json", ScopeExternal},
+		{"v1_internal.json", ScopeInternal},
+	} {
+		v := loadVectorsFile(t, tc.file)
+		ivk := vectorKey(t, v.UFVK).IncomingViewingKey(tc.scope)
+		if len(v.Addresses) != 100 {
+			t.Fatalf("%s: unexpected address count: %d", tc.file, len(v.Addresses))
+		}
+		for i, want := range v.Addresses {
+			checkAddress(t, ivk, diversifier.FromUint32(uint32(i)), want)
+		}
+	}
+}
+
+func TestVectors_Wide(t *testing.T) {
+	v := loadVectorsFile(t, "v1_wide.json")
+	ivk := vectorKey(t, v.UFVK).IncomingViewingKey(ScopeExternal)
+	if len(v.Indexed) == 0 {
+		t.Fatalf("no indexed vectors")
+	}
+	for _, a := range v.Indexed {
+		checkAddress(t, ivk, a.Index, a.Address)
+	}
+}
+
+func TestKeyEncodings(t *testing.T) {
+	v := loadVectorsFile(t, "v1.json")
+	k := vectorKey(t, v.UFVK)
+
+	fp := k.Fingerprint()
+	if got := hex.EncodeToString(fp[:]); got != v1Fingerprint {
+		t.Fatalf("FVK fingerprint = %s, want %s", got, v1Fingerprint)
+	}
+
+	item, err := zip316.DecodeSingleTLVContainer("jivk", v1UIVK)
+	if err != nil {
+		t.Fatalf("DecodeSingleTLVContainer error: %v", err)
+	}
+	ivk := k.IncomingViewingKey(ScopeExternal)
+	if b := ivk.Bytes(); string(b[:]) != string(item.Value) {
+		t.Fatalf("IVK bytes differ from the v1 UIVK")
+	}
+	parsed, err := NewIncomingViewingKey(item.Value)
+	if err != nil {
+		t.Fatalf("NewIncomingViewingKey error: %v", err)
+	}
+	fp = parsed.Fingerprint()
+	if got := hex.EncodeToString(fp[:]); got != v1UIVKFingerprint {
+		t.Fatalf("IVK fingerprint = %s, want %s", got, v1UIVKFingerprint)
+	}
+	if parsed.Address(diversifier.FromUint32(7)) != ivk.Address(diversifier.FromUint32(7)) {
+		t.Fatalf("parsed IVK derives a different address")
+	}
+
+	b := k.Bytes()
+	again, err := NewFullViewingKey(b[:])
+	if err != nil || again.Bytes() != b {
+		t.Fatalf("FVK round trip: %v", err)
+	}
+}
+
+func TestNewFullViewingKey_Invalid(t *testing.T) {
+	v := loadVectorsFile(t, "v1.json")
+	valid := vectorKey(t, v.UFVK).Bytes()
+
+	mutate := func(f func(b []byte)) []byte {
+		b := valid
+		f(b[:])
+		return b[:]
+	}
+	p, q := le32(fieldP), le32(fieldQ)
+	cases := map[string][]byte{
+		"short":          valid[:95],
+		"ak sign":        mutate(func(b []byte) { b[31] |= 0x80 }),
+		"ak identity":    mutate(func(b []byte) { clear(b[:32]) }),
+		"ak x too big":   mutate(func(b []byte) { copy(b[:32], p) }),
+		"nk not in Fp":   mutate(func(b []byte) { copy(b[32:64], p) }),
+		"rivk not in Fq": mutate(func(b []byte) { copy(b[64:], q) }),
+	}
+	for name, b := range cases {
+		if _, err := NewFullViewingKey(b); !errors.Is(err, ErrFullViewingKeyInvalid) {
+			t.Fatalf("%s: expected ErrFullViewingKeyInvalid, got %v", name, err)
+		}
+	}
+
+	// nk = p - 1 and rivk = q - 1 are canonical.
+	one := big.NewInt(1)
+	b := mutate(func(b []byte) {
+		copy(b[32:64], le32(new(big.Int).Sub(fieldP, one)))
+		copy(b[64:], le32(new(big.Int).Sub(fieldQ, one)))
+	})
+	if _, err := NewFullViewingKey(b); err != nil {
+		t.Fatalf("NewFullViewingKey(nk = p-1, rivk = q-1) error: %v", err)
+	}
+}
+
+func TestNewIncomingViewingKey_Invalid(t *testing.T) {
+	var b [IncomingViewingKeyLen]byte
+	for name, ivk := range map[string][]byte{
+		"zero":     make([]byte, 32),
+		"p":        le32(fieldP),
+		"above p":  le32(new(big.Int).Add(fieldP, big.NewInt(1))),
+		"all ones": []byte("\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff"),
+	} {
+		copy(b[32:], ivk)
+		if _, err := NewIncomingViewingKey(b[:]); !errors.Is(err, ErrIncomingViewingKeyInvalid) {
+			t.Fatalf("%s: expected ErrIncomingViewingKeyInvalid, got %v", name, err)
+		}
+	}
+	if _, err := NewIncomingViewingKey(b[:63]); !errors.Is(err, ErrIncomingViewingKeyInvalid) {
+		t.Fatalf("short: expected ErrIncomingViewingKeyInvalid, got %v", err)
+	}
+	copy(b[32:], le32(big.NewInt(1)))
+	if _, err := NewIncomingViewingKey(b[:]); err != nil {
+		t.Fatalf("ivk = 1: %v", err)
+	}
+}
+
+func TestDiversifierIndex_NotOwned(t *testing.T) {
+	v := loadVectorsFile(t, "v1.json")
+	k := vectorKey(t, v.UFVK)
+	external := k.IncomingViewingKey(ScopeExternal)
+	internal := k.IncomingViewingKey(ScopeInternal)
+
+	raw := external.Address(diversifier.FromUint32(5))
+	if _, ok := internal.DiversifierIndex(raw); ok {
+		t.Fatalf("internal IVK claims an external address")
+	}
+	raw[diversifier.Len] ^= 1
+	if _, ok := external.DiversifierIndex(raw); ok {
+		t.Fatalf("tampered pk_d was accepted")
+	}
+}
+
+func TestCheckRawAddress(t *testing.T) {
+	var raw [RawAddressLen]byte
+	if err := CheckRawAddress(raw); !errors.Is(err, ErrRawAddressInvalid) {
+		t.Fatalf("identity pk_d: expected ErrRawAddressInvalid, got %v", err)
+	}
+	copy(raw[diversifier.Len:], le32(fieldP))
+	if err := CheckRawAddress(raw); !errors.Is(err, ErrRawAddressInvalid) {
+		t.Fatalf("non-canonical pk_d: expected ErrRawAddressInvalid, got %v", err)
+	}
+}
+
+func TestFF1_RoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var key [32]byte
+	rng.Read(key[:])
+	f := newFF1(key[:])
+	for i := 0; i < 1000; i++ {
+		var in [11]byte
+		rng.Read(in[:])
+		out := f.encrypt(in)
+		if out == in {
+			t.Fatalf("encrypt(%x) is the identity", in)
+		}
+		if back := f.decrypt(out); back != in {
+			t.Fatalf("decrypt(encrypt(%x)) = %x", in, back)
+		}
+	}
+}
+
+// TestIsoMap checks the isogeny constants: SWU outputs lie on iso-Pallas,
+// and their images on Pallas.
+func TestIsoMap(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		u := new(big.Int).Rand(rng, fieldP)
+		p := sswu(u)
+		if !isoPallas.contains(p) {
+			t.Fatalf("sswu(%x) is not on iso-Pallas", u)
+		}
+		if q := isoMap(p); !pallas.contains(q) {
+			t.Fatalf("isoMap(sswu(%x)) is not on Pallas", u)
+		}
+	}
+}
+
+func TestMul(t *testing.T) {
+	g := groupHash("z.cash:test", []byte("mul"))
+	acc := point{inf: true}
+	for k := int64(0); k < 20; k++ {
+		if got := mul(big.NewInt(k), g); got.encode() != acc.encode() {
+			t.Fatalf("mul(%d) differs from repeated addition", k)
+		}
+		acc = pallas.add(acc, g)
+	}
+	// Pallas has prime order q.
+	if !mul(fieldQ, g).inf {
+		t.Fatalf("q·G is not the identity")
+	}
+}
+
+// TestSpendingKeyFromSeed checks ZIP-32 derivation against the v1 vectors,
+// whose UFVK is account 0 of the seed [7; 64] at coin type 8133.
+func TestSpendingKeyFromSeed(t *testing.T) {
+	v := loadVectorsFile(t, "v1.json")
+	want := vectorKey(t, v.UFVK).Bytes()
+
+	seed := bytes.Repeat([]byte{7}, 64)
+	sk, err := SpendingKeyFromSeed(seed, 8133, 0)
+	if err != nil {
+		t.Fatalf("SpendingKeyFromSeed error: %v", err)
+	}
+	if sk.FullViewingKey().Bytes() != want {
+		t.Fatalf("account 0 FVK differs from the v1 vectors")
+	}
+	b := sk.Bytes()
+	again, err := NewSpendingKey(b[:])
+	if err != nil || again.FullViewingKey().Bytes() != want {
+		t.Fatalf("spending key round trip: %v", err)
+	}
+
+	other, err := SpendingKeyFromSeed(seed, 8133, 1)
+	if err != nil || other.FullViewingKey().Bytes() == want {
+		t.Fatalf("account 1: %v", err)
+	}
+
+	// The Rust library's spending_keys_match_pkg_orchard test pins the same
+	// keys from the orchard crate.
+	for account, hexKey := range map[uint32]string{
+		0:                  "42124a673ea453515e9e595539acd51987d2c4456bae9aec60e4b22962644ad0",
+		1:                  "d6a6c5ffb369e3bcb5afddfd94bc8de24e8d5f7e66989934ec490c34e641ec95",
+		zip32.Hardened - 1: "b67135bf1b4ceb98075736d93d83e930d62af5cea3ffc080cd8e204c44615776",
+	} {
+		sk, err := SpendingKeyFromSeed(seed, 8133, account)
+		if err != nil {
+			t.Fatalf("account %d: %v", account, err)
+		}
+		if b := sk.Bytes(); hex.EncodeToString(b[:]) != hexKey {
+			t.Fatalf("account %d: spending key %x, want %s", account, b, hexKey)
+		}
+	}
+
+	if _, err := SpendingKeyFromSeed(seed[:31], 8133, 0); !errors.Is(err, zip32.ErrSeedLength) {
+		t.Fatalf("short seed: expected ErrSeedLength, got %v", err)
+	}
+	if _, err := SpendingKeyFromSeed(seed, 8133, zip32.Hardened); !errors.Is(err, zip32.ErrIndex) {
+		t.Fatalf("account 2^31: expected ErrIndex, got %v", err)
+	}
+	if _, err := NewSpendingKey(b[:31]); !errors.Is(err, ErrSpendingKeyInvalid) {
+		t.Fatalf("short spending key: expected ErrSpendingKeyInvalid, got %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package orchard
+
+import "math/big"
+
+// point is an affine point on a short Weierstrass curve y² = x³ + ax + b;
+// inf marks the point at infinity, the identity.
+type point struct {
+	x, y *big.Int
+	inf  bool
+}
+
+// curve is a short Weierstrass curve over Fp.
+type curve struct {
+	a, b *big.Int
+}
+
+// pallas is the Pallas curve, y² = x³ + 5.
+var pallas = curve{a: new(big.Int), b: big.NewInt(5)}
+
+func (c curve) contains(p point) bool {
+	if p.inf {
+		return true
+	}
+	rhs := fpAdd(fpAdd(fpMul(fpMul(p.x, p.x), p.x), fpMul(c.a, p.x)), c.b)
+	return fpMul(p.y, p.y).Cmp(rhs) == 0
+}
+
+// add is complete affine addition. It costs a field inversion, which is fine
+// for the handful of additions in a hash to the curve or a Sinsemilla hash;
+// scalar multiplication uses mul instead.
+func (c curve) add(p, q point) point {
+	switch {
+	case p.inf:
+		return q
+	case q.inf:
+		return p
+	}
+	var l *big.Int
+	if p.x.Cmp(q.x) == 0 {
+		if fpAdd(p.y, q.y).Sign() == 0 {
+			return point{inf: true}
+		}
+		l = fpMul(fpAdd(fpMul(fpInt(3), fpMul(p.x, p.x)), c.a), fpInv(fpAdd(p.y, p.y)))
+	} else {
+		l = fpMul(fpSub(q.y, p.y), fpInv(fpSub(q.x, p.x)))
+	}
+	x := fpSub(fpSub(fpMul(l, l), p.x), q.x)
+	y := fpSub(fpMul(l, fpSub(p.x, x)), p.y)
+	return point{x: x, y: y}
+}
+
+// jacobian is a Pallas point in Jacobian coordinates, (X/Z², Y/Z³); Z = 0 is
+// the identity.
+type jacobian struct {
+	x, y, z *big.Int
+}
+
+// double is dbl-2009-l, for curves with a = 0.
+func (p jacobian) double() jacobian {
+	if p.z.Sign() == 0 {
+		return p
+	}
+	a := fpMul(p.x, p.x)
+	b := fpMul(p.y, p.y)
+	c := fpMul(b, b)
+	d := fpSub(fpSub(fpMul(fpAdd(p.x, b), fpAdd(p.x, b)), a), c)
+	d = fpAdd(d, d)
+	e := fpMul(fpInt(3), a)
+	x := fpSub(fpMul(e, e), fpAdd(d, d))
+	y := fpSub(fpMul(e, fpSub(d, x)), fpMul(fpInt(8), c))
+	z := fpMul(fpAdd(p.y, p.y), p.z)
+	return jacobian{x, y, z}
+}
+
+// addAffine is madd-2007-bl: p + q for an affine q.
+func (p jacobian) addAffine(q point) jacobian {
+	switch {
+	case q.inf:
+		return p
+	case p.z.Sign() == 0:
+		return jacobian{q.x, q.y, big.NewInt(1)}
+	}
+	z1z1 := fpMul(p.z, p.z)
+	u2 := fpMul(q.x, z1z1)
+	s2 := fpMul(fpMul(q.y, p.z), z1z1)
+	h := fpSub(u2, p.x)
+	r := fpSub(s2, p.y)
+	if h.Sign() == 0 {
+		if r.Sign() == 0 {
+			return p.double()
+		}
+		return jacobian{new(big.Int), big.NewInt(1), new(big.Int)}
+	}
+	hh := fpMul(h, h)
+	i := fpMul(fpInt(4), hh)
+	j := fpMul(h, i)
+	r = fpAdd(r, r)
+	v := fpMul(p.x, i)
+	x := fpSub(fpSub(fpMul(r, r), j), fpAdd(v, v))
+	y := fpSub(fpMul(r, fpSub(v, x)), fpMul(fpInt(2), fpMul(p.y, j)))
+	z := fpSub(fpSub(fpMul(fpAdd(p.z, h), fpAdd(p.z, h)), z1z1), hh)
+	return jacobian{x, y, z}
+}
+
+func (p jacobian) affine() point {
+	if p.z.Sign() == 0 {
+		return point{inf: true}
+	}
+	zi := fpInv(p.z)
+	zi2 := fpMul(zi, zi)
+	return point{x: fpMul(p.x, zi2), y: fpMul(p.y, fpMul(zi2, zi))}
+}
+
+// mul returns k·p on Pallas, for a non-negative k. It is a plain
+// double-and-add: its running time depends on k.
+func mul(k *big.Int, p point) point {
+	acc := jacobian{new(big.Int), big.NewInt(1), new(big.Int)}
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		acc = acc.double()
+		if k.Bit(i) == 1 {
+			acc = acc.addAffine(p)
+		}
+	}
+	return acc.affine()
+}
+
+// encode is repr_P: x little-endian, with the sign of y in bit 255. The
+// identity encodes as all zeros.
+func (p point) encode() [32]byte {
+	var out [32]byte
+	if p.inf {
+		return out
+	}
+	putLE(out[:], p.x)
+	if fpOdd(p.y) {
+		out[31] |= 0x80
+	}
+	return out
+}
+
+// decodePallas is the inverse of encode. It rejects non-canonical x and x
+// that are not on the curve.
+func decodePallas(b []byte) (point, bool) {
+	buf := [32]byte(b)
+	sign := buf[31]>>7 == 1
+	buf[31] &= 0x7f
+	x := fromLE(buf[:])
+	if x.Cmp(fieldP) >= 0 {
+		return point{}, false
+	}
+	if x.Sign() == 0 && !sign {
+		return point{inf: true}, true
+	}
+	y := fpSqrt(fpAdd(fpMul(fpMul(x, x), x), pallas.b))
+	if y == nil {
+		return point{}, false
+	}
+	if fpOdd(y) != sign {
+		y = fpNeg(y)
+	}
+	return point{x: x, y: y}, true
+}
@@ -0,0 +1,60 @@
+package orchard
+
+import (
+	"encoding/binary"
+	"math/big"
+	"sync"
+)
+
+// sinsemillaK is the number of message bits Sinsemilla absorbs per step.
+const sinsemillaK = 10
+
+// sinsemillaS is the table of generators S(j) = GroupHash("z.cash:SinsemillaS",
+// LE32(j)) for 0 ≤ j < 2^k, each computed the first time it is needed.
+var sinsemillaS [1 << sinsemillaK]struct {
+	once sync.Once
+	p    point
+}
+
+func sinsemillaGenerator(j uint32) point {
+	s := &sinsemillaS[j]
+	s.once.Do(func() {
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], j)
+		s.p = groupHash("z.cash:SinsemillaS", b[:])
+	})
+	return s.p
+}
+
+// sinsemillaHashToPoint hashes a bit string (one bit per byte, in order),
+// zero-padded to a multiple of k bits, under domain.
+func sinsemillaHashToPoint(domain string, bits []byte) point {
+	acc := groupHash("z.cash:SinsemillaQ", []byte(domain))
+	for i := 0; i < len(bits); i += sinsemillaK {
+		var m uint32
+		for j := 0; j < sinsemillaK && i+j < len(bits); j++ {
+			m |= uint32(bits[i+j]) << j
+		}
+		acc = pallas.add(pallas.add(acc, sinsemillaGenerator(m)), acc)
+	}
+	return acc
+}
+
+// sinsemillaShortCommit is SinsemillaShortCommit: the x-coordinate of the
+// commitment to bits with randomness r, or nil for the identity.
+func sinsemillaShortCommit(domain string, bits []byte, r *big.Int) *big.Int {
+	h := sinsemillaHashToPoint(domain+"-M", bits)
+	c := pallas.add(h, mul(r, groupHash(domain+"-r", nil)))
+	if c.inf {
+		return nil
+	}
+	return c.x
+}
+
+// appendBits appends the low n bits of a, least significant first.
+func appendBits(bits []byte, a *big.Int, n int) []byte {
+	for i := 0; i < n; i++ {
+		bits = append(bits, byte(a.Bit(i)))
+	}
+	return bits
+}
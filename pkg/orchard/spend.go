@@ -0,0 +1,90 @@
+package orchard
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/Abdullah1738/juno-addrgen/internal/blake2b"
+	"github.com/Abdullah1738/juno-addrgen/pkg/zip32"
+)
+
+// SpendingKeyLen is the length of an encoded spending key.
+const SpendingKeyLen = 32
+
+// ErrSpendingKeyInvalid is returned for spending key bytes the orchard crate
+// would not accept, and when ZIP-32 derivation lands on such a key (with
+// negligible probability).
+var ErrSpendingKeyInvalid = errors.New("orchard: invalid spending key")
+
+// SpendingKey is an Orchard spending key, sk. Unlike a viewing key it can
+// spend every note its addresses receive; the key-generation commands of
+// this module only ever reveal it when asked to.
+type SpendingKey struct {
+	raw [SpendingKeyLen]byte
+	fvk *FullViewingKey
+}
+
+// NewSpendingKey decodes a spending key. Like the orchard crate it requires
+// that ask is non-zero and the FVK it derives is valid.
+func NewSpendingKey(b []byte) (*SpendingKey, error) {
+	if len(b) != SpendingKeyLen {
+		return nil, ErrSpendingKeyInvalid
+	}
+	// ask = ToScalar(PRF^expand(sk, [0x06])), ak = [ask] G; ask is negated
+	// when ak's y-coordinate is odd, which leaves the encoding of ak with a
+	// clear sign bit.
+	ask := new(big.Int).Mod(fromLE(prfExpand(b, []byte{0x06})), fieldQ)
+	if ask.Sign() == 0 {
+		return nil, ErrSpendingKeyInvalid
+	}
+	ak := mul(ask, groupHash("z.cash:Orchard", []byte("G"))).encode()
+	ak[31] &^= 0x80
+	nk := new(big.Int).Mod(fromLE(prfExpand(b, []byte{0x07})), fieldP)
+	rivk := new(big.Int).Mod(fromLE(prfExpand(b, []byte{0x08})), fieldQ)
+
+	fvk := make([]byte, 0, FullViewingKeyLen)
+	fvk = append(fvk, ak[:]...)
+	fvk = append(fvk, le32(nk)...)
+	fvk = append(fvk, le32(rivk)...)
+	k, err := NewFullViewingKey(fvk)
+	if err != nil {
+		return nil, ErrSpendingKeyInvalid
+	}
+	return &SpendingKey{raw: [SpendingKeyLen]byte(b), fvk: k}, nil
+}
+
+// SpendingKeyFromSeed derives the spending key of an account with ZIP-32,
+// at m_Orchard/32'/coinType'/account', as the orchard crate's
+// SpendingKey::from_zip32_seed does.
+func SpendingKeyFromSeed(seed []byte, coinType, account uint32) (*SpendingKey, error) {
+	if err := zip32.CheckSeed(seed); err != nil {
+		return nil, err
+	}
+	if coinType >= zip32.Hardened || account >= zip32.Hardened {
+		return nil, zip32.ErrIndex
+	}
+
+	// The master key is I = BLAKE2b-512("ZcashIP32Orchard", seed), and each
+	// hardened child I = PRF^expand(c, [0x81] || sk || I2LEOSP32(i)); sk is
+	// the left half of I and the chain code c the right. Like the orchard
+	// crate, fail if any key on the path is invalid.
+	i := blake2b.Sum(64, []byte("ZcashIP32Orchard"), seed)
+	for _, child := range []uint32{32, coinType, account} {
+		if _, err := NewSpendingKey(i[:32]); err != nil {
+			return nil, err
+		}
+		i = prfExpand(i[32:], []byte{0x81}, i[:32], binary.LittleEndian.AppendUint32(nil, child+zip32.Hardened))
+	}
+	return NewSpendingKey(i[:32])
+}
+
+// Bytes returns the encoded spending key.
+func (k *SpendingKey) Bytes() [SpendingKeyLen]byte {
+	return k.raw
+}
+
+// FullViewingKey returns the spending key's FVK.
+func (k *SpendingKey) FullViewingKey() *FullViewingKey {
+	return k.fvk
+}
@@ -0,0 +1,152 @@
+package zip316
+
+import "strings"
+
+const charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// checksumLen is the number of checksum characters.
+const checksumLen = 6
+
+// bech32mConst is the target residue of a bech32m checksum (BIP-350).
+const bech32mConst = 0x2bc830a3
+
+// maxHRPLen is the longest HRP BIP-173 allows.
+const maxHRPLen = 83
+
+var generator = [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+func polymod(chk uint32, values ...byte) uint32 {
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i, g := range generator {
+			if top>>i&1 == 1 {
+				chk ^= g
+			}
+		}
+	}
+	return chk
+}
+
+// hrpChecksum starts a checksum over the lowercase form of hrp.
+func hrpChecksum(hrp string) uint32 {
+	hrp = strings.ToLower(hrp)
+	chk := uint32(1)
+	for i := 0; i < len(hrp); i++ {
+		chk = polymod(chk, hrp[i]>>5)
+	}
+	chk = polymod(chk, 0)
+	for i := 0; i < len(hrp); i++ {
+		chk = polymod(chk, hrp[i]&31)
+	}
+	return chk
+}
+
+// validHRP reports whether hrp is 1 to 83 printable ASCII characters, not of
+// mixed case.
+func validHRP(hrp string) bool {
+	if len(hrp) == 0 || len(hrp) > maxHRPLen {
+		return false
+	}
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return false
+		}
+	}
+	return hrp == strings.ToLower(hrp) || hrp == strings.ToUpper(hrp)
+}
+
+// encodeBech32m encodes data under hrp with a bech32m checksum. Unlike BIP-350
+// it has no length limit, as ZIP-316 requires. The output is lowercase.
+func encodeBech32m(hrp string, data []byte) string {
+	hrp = strings.ToLower(hrp)
+
+	var sb strings.Builder
+	sb.Grow(len(hrp) + 1 + (len(data)*8+4)/5 + checksumLen)
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+
+	chk := hrpChecksum(hrp)
+	var acc uint32
+	var bits uint
+	emit := func(v byte) {
+		chk = polymod(chk, v)
+		sb.WriteByte(charset[v])
+	}
+	for _, b := range data {
+		acc = acc<<8 | uint32(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			emit(byte(acc >> bits & 31))
+		}
+	}
+	if bits > 0 {
+		emit(byte(acc << (5 - bits) & 31))
+	}
+
+	chk = polymod(chk, 0, 0, 0, 0, 0, 0) ^ bech32mConst
+	for i := range checksumLen {
+		sb.WriteByte(charset[chk>>(5*(checksumLen-1-i))&31])
+	}
+	return sb.String()
+}
+
+// decodeBech32m parses s, verifies its bech32m checksum, and returns its HRP
+// as written and its data as bytes. Trailing bits that do not fill a byte are
+// dropped unchecked, as the Rust bech32 crate does.
+func decodeBech32m(s string) (string, []byte, error) {
+	var lower, upper bool
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 0x80:
+			return "", nil, ErrBech32DecodeFailed
+		case 'a' <= c && c <= 'z':
+			lower = true
+		case 'A' <= c && c <= 'Z':
+			upper = true
+		}
+	}
+	if lower && upper {
+		return "", nil, ErrBech32DecodeFailed
+	}
+
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 0 {
+		return "", nil, ErrBech32DecodeFailed
+	}
+	hrp, chars := s[:sep], strings.ToLower(s[sep+1:])
+	if !validHRP(hrp) {
+		return "", nil, ErrBech32DecodeFailed
+	}
+
+	values := make([]byte, len(chars))
+	for i := 0; i < len(chars); i++ {
+		v := strings.IndexByte(charset, chars[i])
+		if v < 0 {
+			return "", nil, ErrBech32DecodeFailed
+		}
+		values[i] = byte(v)
+	}
+	if len(values) < checksumLen {
+		return "", nil, ErrChecksumInvalid
+	}
+	if polymod(hrpChecksum(hrp), values...) != bech32mConst {
+		return "", nil, ErrChecksumInvalid
+	}
+
+	values = values[:len(values)-checksumLen]
+	data := make([]byte, 0, len(values)*5/8)
+	var acc uint32
+	var bits uint
+	for _, v := range values {
+		acc = acc<<5 | uint32(v)
+		bits += 5
+		if bits >= 8 {
+			bits -= 8
+			data = append(data, byte(acc>>bits))
+		}
+	}
+	return hrp, data, nil
+}
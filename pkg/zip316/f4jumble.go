@@ -0,0 +1,68 @@
+package zip316
+
+import "github.com/Abdullah1738/juno-addrgen/internal/blake2b"
+
+// F4Jumble's message length range, in bytes (ZIP-316).
+const (
+	f4jumbleMinLen = 48
+	f4jumbleMaxLen = 4194368
+)
+
+// f4jumble applies ZIP-316's F4Jumble permutation to a copy of m.
+func f4jumble(m []byte) ([]byte, error) {
+	if len(m) < f4jumbleMinLen || len(m) > f4jumbleMaxLen {
+		return nil, ErrF4JumbleFailed
+	}
+	out := append([]byte(nil), m...)
+	a, b := split(out)
+	xorG(b, 0, a)
+	xorH(a, 0, b)
+	xorG(b, 1, a)
+	xorH(a, 1, b)
+	return out, nil
+}
+
+// f4jumbleInv inverts f4jumble, in place.
+func f4jumbleInv(m []byte) ([]byte, error) {
+	if len(m) < f4jumbleMinLen || len(m) > f4jumbleMaxLen {
+		return nil, ErrF4JumbleFailed
+	}
+	a, b := split(m)
+	xorH(a, 1, b)
+	xorG(b, 1, a)
+	xorH(a, 0, b)
+	xorG(b, 0, a)
+	return m, nil
+}
+
+// split returns the left part, of min(64, len/2) bytes, and the right part.
+func split(m []byte) (a, b []byte) {
+	n := min(blake2b.MaxSize, len(m)/2)
+	return m[:n], m[n:]
+}
+
+// xorH XORs dst with H_i(u): a len(dst)-byte BLAKE2b personalized with
+// "UA_F4Jumble_H" || i || 0 || 0.
+func xorH(dst []byte, i byte, u []byte) {
+	personal := []byte{'U', 'A', '_', 'F', '4', 'J', 'u', 'm', 'b', 'l', 'e', '_', 'H', i, 0, 0}
+	xor(dst, blake2b.Sum(len(dst), personal, u))
+}
+
+// xorG XORs dst with G_i(u): the concatenation of BLAKE2b-512 blocks
+// personalized with "UA_F4Jumble_G" || i || j as 16-bit little-endian.
+func xorG(dst []byte, i byte, u []byte) {
+	personal := []byte{'U', 'A', '_', 'F', '4', 'J', 'u', 'm', 'b', 'l', 'e', '_', 'G', i, 0, 0}
+	for j := 0; len(dst) > 0; j++ {
+		personal[14], personal[15] = byte(j), byte(j>>8)
+		dst = dst[xor(dst, blake2b.Sum(blake2b.MaxSize, personal, u)):]
+	}
+}
+
+// xor XORs src into dst and returns the number of bytes it covered.
+func xor(dst, src []byte) int {
+	n := min(len(dst), len(src))
+	for k := range n {
+		dst[k] ^= src[k]
+	}
+	return n
+}
@@ -0,0 +1,222 @@
+// Package zip316 encodes and decodes ZIP-316 TLV containers: the bech32m,
+// F4Jumble'd, HRP-padded item lists that unified addresses and viewing keys
+// are made of.
+//
+// It is pure Go so that CGO_ENABLED=0 builds can validate and inspect
+// addresses and keys without the Rust library. Its behaviour, including which
+// error each malformed input yields, matches the library's zip316 module.
+package zip316
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// The errors returned by this package. They match the Rust library's
+// Zip316Error variants one for one.
+var (
+	// ErrHRPTooLong is returned when an HRP does not fit in the 16-byte
+	// padding.
+	ErrHRPTooLong = errors.New("zip316: hrp_too_long")
+	// ErrInvalidHRP is returned when encoding with an HRP that is not a valid
+	// bech32 HRP.
+	ErrInvalidHRP = errors.New("zip316: invalid_hrp")
+	// ErrPayloadTooShort is returned when encoding fewer than 32 bytes of
+	// items, which F4Jumble cannot carry once padded.
+	ErrPayloadTooShort = errors.New("zip316: payload_too_short")
+	// ErrF4JumbleFailed is returned when a payload is outside F4Jumble's
+	// length range.
+	ErrF4JumbleFailed = errors.New("zip316: f4jumble_failed")
+	// ErrBech32EncodeFailed is the Rust library's bech32 encoding failure.
+	// Unlimited-length bech32m encoding cannot fail, so this package never
+	// returns it.
+	ErrBech32EncodeFailed = errors.New("zip316: bech32_encode_failed")
+	// ErrBech32DecodeFailed is returned when a string is not bech32: no
+	// separator, an invalid HRP or data character, or mixed case.
+	ErrBech32DecodeFailed = errors.New("zip316: bech32_decode_failed")
+	// ErrChecksumInvalid is returned when a well-formed bech32 string fails
+	// its bech32m checksum, or is too short to hold one.
+	ErrChecksumInvalid = errors.New("zip316: checksum_invalid")
+	// ErrHRPMismatch is returned when a string's HRP is not the expected one.
+	ErrHRPMismatch = errors.New("zip316: hrp_mismatch")
+	// ErrPaddingInvalid is returned when the decoded padding does not spell
+	// the HRP followed by zeros.
+	ErrPaddingInvalid = errors.New("zip316: padding_invalid")
+	// ErrTLVInvalid is returned when the item list is truncated.
+	ErrTLVInvalid = errors.New("zip316: tlv_invalid")
+	// ErrTLVTrailingBytes is returned by DecodeSingleTLVContainer when the
+	// container does not hold exactly one item.
+	ErrTLVTrailingBytes = errors.New("zip316: tlv_trailing_bytes")
+)
+
+// paddingLen is the length of the HRP padding appended before F4Jumble.
+const paddingLen = 16
+
+// Item is one typecode-value pair of a container.
+type Item struct {
+	Typecode uint64
+	Value    []byte
+}
+
+// DecodeHRP returns the HRP of a bech32m string, as written, after verifying
+// its checksum but without decoding the payload. It lets callers pick the
+// expected HRP, or reject a foreign one, before decoding.
+func DecodeHRP(s string) (string, error) {
+	hrp, _, err := decodeBech32m(s)
+	return hrp, err
+}
+
+// EncodeTLVContainer encodes items, in order, as a container with the given
+// HRP. Typecodes and lengths are written as compact sizes.
+func EncodeTLVContainer(hrp string, items []Item) (string, error) {
+	var payload []byte
+	for _, item := range items {
+		payload = appendCompactSize(payload, item.Typecode)
+		payload = appendCompactSize(payload, uint64(len(item.Value)))
+		payload = append(payload, item.Value...)
+	}
+	return encode(hrp, payload)
+}
+
+// EncodeUnifiedContainer encodes a container holding the single item
+// (typecode, value).
+func EncodeUnifiedContainer(hrp string, typecode uint64, value []byte) (string, error) {
+	return EncodeTLVContainer(hrp, []Item{{Typecode: typecode, Value: value}})
+}
+
+// DecodeTLVContainer decodes a container whose HRP must be hrpExpected, and
+// returns its items in encoding order. It does not interpret typecodes, so
+// duplicates and unknown types are returned as they are.
+func DecodeTLVContainer(hrpExpected, s string) ([]Item, error) {
+	rest, err := decode(hrpExpected, s)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Item
+	for len(rest) > 0 {
+		typecode, err := readCompactSize(&rest)
+		if err != nil {
+			return nil, err
+		}
+		n, err := readCompactSize(&rest)
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(rest)) < n {
+			return nil, ErrTLVInvalid
+		}
+		items = append(items, Item{Typecode: typecode, Value: rest[:n:n]})
+		rest = rest[n:]
+	}
+	return items, nil
+}
+
+// DecodeSingleTLVContainer is DecodeTLVContainer for containers that must
+// hold exactly one item.
+func DecodeSingleTLVContainer(hrpExpected, s string) (Item, error) {
+	items, err := DecodeTLVContainer(hrpExpected, s)
+	if err != nil {
+		return Item{}, err
+	}
+	if len(items) != 1 {
+		return Item{}, ErrTLVTrailingBytes
+	}
+	return items[0], nil
+}
+
+func encode(hrp string, payload []byte) (string, error) {
+	if len(hrp) > paddingLen {
+		return "", ErrHRPTooLong
+	}
+	if len(payload)+paddingLen < f4jumbleMinLen {
+		return "", ErrPayloadTooShort
+	}
+
+	var padding [paddingLen]byte
+	copy(padding[:], hrp)
+	jumbled, err := f4jumble(append(payload[:len(payload):len(payload)], padding[:]...))
+	if err != nil {
+		return "", err
+	}
+
+	if !validHRP(hrp) {
+		return "", ErrInvalidHRP
+	}
+	return encodeBech32m(hrp, jumbled), nil
+}
+
+func decode(hrpExpected, s string) ([]byte, error) {
+	hrp, data, err := decodeBech32m(s)
+	if err != nil {
+		return nil, err
+	}
+	if hrp != hrpExpected {
+		return nil, ErrHRPMismatch
+	}
+	// No encoding can carry an HRP longer than the padding.
+	if len(hrpExpected) > paddingLen {
+		return nil, ErrPaddingInvalid
+	}
+
+	data, err = f4jumbleInv(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < paddingLen {
+		return nil, ErrPaddingInvalid
+	}
+
+	padding := data[len(data)-paddingLen:]
+	for i, b := range padding {
+		var want byte
+		if i < len(hrpExpected) {
+			want = hrpExpected[i]
+		}
+		if b != want {
+			return nil, ErrPaddingInvalid
+		}
+	}
+	return data[:len(data)-paddingLen], nil
+}
+
+// appendCompactSize appends n in Bitcoin's CompactSize encoding.
+func appendCompactSize(b []byte, n uint64) []byte {
+	switch {
+	case n <= 252:
+		return append(b, byte(n))
+	case n <= math.MaxUint16:
+		return binary.LittleEndian.AppendUint16(append(b, 253), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.LittleEndian.AppendUint32(append(b, 254), uint32(n))
+	default:
+		return binary.LittleEndian.AppendUint64(append(b, 255), n)
+	}
+}
+
+// readCompactSize consumes a CompactSize from the front of *b. Like the Rust
+// library, it accepts non-minimal encodings.
+func readCompactSize(b *[]byte) (uint64, error) {
+	in := *b
+	if len(in) == 0 {
+		return 0, ErrTLVInvalid
+	}
+	first, in := in[0], in[1:]
+
+	var n uint64
+	switch {
+	case first <= 252:
+		n = uint64(first)
+	case first == 253 && len(in) >= 2:
+		n, in = uint64(binary.LittleEndian.Uint16(in)), in[2:]
+	case first == 254 && len(in) >= 4:
+		n, in = uint64(binary.LittleEndian.Uint32(in)), in[4:]
+	case first == 255 && len(in) >= 8:
+		n, in = binary.LittleEndian.Uint64(in), in[8:]
+	default:
+		return 0, ErrTLVInvalid
+	}
+	*b = in
+	return n, nil
+}
@@ -0,0 +1,281 @@
+package zip316
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	typecodeP2PKH   = 0x00
+	typecodeOrchard = 0x03
+)
+
+type vectorsV1 struct {
+	UFVK      string   `json:"ufvk"`
+	Addresses []string `json:"addresses"`
+}
+
+func loadVectors(t *testing.T, name string) vectorsV1 {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("..", "..", "vectors", name))
+	if err != nil {
+		t.Fatalf("read vectors: %v", err)
+	}
+	var v vectorsV1
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatalf("parse vectors: %v", err)
+	}
+	return v
+}
+
+func TestDecode_Vectors(t *testing.T) {
+	v := loadVectors(t, "v1.json")
+
+	if hrp, err := DecodeHRP(v.UFVK); err != nil || hrp != "jview" {
+		t.Fatalf("DecodeHRP(ufvk) = %q, %v", hrp, err)
+	}
+	ufvk, err := DecodeSingleTLVContainer("jview", v.UFVK)
+	if err != nil {
+		t.Fatalf("DecodeSingleTLVContainer(ufvk) error: %v", err)
+	}
+	if ufvk.Typecode != typecodeOrchard || len(ufvk.Value) != 96 {
+		t.Fatalf("ufvk item = %#x, %d bytes", ufvk.Typecode, len(ufvk.Value))
+	}
+	if s, err := EncodeUnifiedContainer("jview", ufvk.Typecode, ufvk.Value); err != nil || s != v.UFVK {
+		t.Fatalf("re-encoded ufvk = %s, %v", s, err)
+	}
+
+	for i, address := range v.Addresses {
+		item, err := DecodeSingleTLVContainer("j", address)
+		if err != nil {
+			t.Fatalf("address %d: %v", i, err)
+		}
+		if item.Typecode != typecodeOrchard || len(item.Value) != 43 {
+			t.Fatalf("address %d: item = %#x, %d bytes", i, item.Typecode, len(item.Value))
+		}
+		if s, err := EncodeUnifiedContainer("j", item.Typecode, item.Value); err != nil || s != address {
+			t.Fatalf("address %d: re-encoded = %s, %v", i, s, err)
+		}
+	}
+}
+
+func TestRoundTrip_Vectors(t *testing.T) {
+	for _, name := range []string{"v1.json", "v1_internal.json", "v1_transparent.json"} {
+		v := loadVectors(t, name)
+		for _, s := range append([]string{v.UFVK}, v.Addresses...) {
+			hrp, err := DecodeHRP(s)
+			if err != nil {
+				t.Fatalf("%s: DecodeHRP(%s) error: %v", name, s, err)
+			}
+			items, err := DecodeTLVContainer(hrp, s)
+			if err != nil {
+				t.Fatalf("%s: DecodeTLVContainer(%s) error: %v", name, s, err)
+			}
+			if got, err := EncodeTLVContainer(hrp, items); err != nil || got != s {
+				t.Fatalf("%s: re-encoded %s as %s, %v", name, s, got, err)
+			}
+		}
+	}
+
+	// The transparent vectors' UFVK and addresses lead with a P2PKH item.
+	v := loadVectors(t, "v1_transparent.json")
+	items, err := DecodeTLVContainer("j", v.Addresses[0])
+	if err != nil {
+		t.Fatalf("DecodeTLVContainer error: %v", err)
+	}
+	if len(items) < 2 || items[0].Typecode != typecodeP2PKH || len(items[0].Value) != 20 {
+		t.Fatalf("unexpected items: %+v", items)
+	}
+}
+
+func TestRoundTrip_Random(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	typecodes := []uint64{0, 3, 252, 253, 0xffff, 0x10000, 1 << 32}
+	sizes := []int{0, 1, 31, 252, 253, 300, 70000}
+
+	for n := 0; n < 50; n++ {
+		items := make([]Item, 1+rng.Intn(4))
+		for i := range items {
+			items[i].Typecode = typecodes[rng.Intn(len(typecodes))]
+			items[i].Value = make([]byte, sizes[rng.Intn(len(sizes))])
+			rng.Read(items[i].Value)
+		}
+		s, err := EncodeTLVContainer("jtest", items)
+		if errors.Is(err, ErrPayloadTooShort) {
+			continue
+		}
+		if err != nil {
+			t.Fatalf("EncodeTLVContainer error: %v", err)
+		}
+		got, err := DecodeTLVContainer("jtest", s)
+		if err != nil {
+			t.Fatalf("DecodeTLVContainer error: %v", err)
+		}
+		if len(got) != len(items) {
+			t.Fatalf("decoded %d items, want %d", len(got), len(items))
+		}
+		for i := range items {
+			if got[i].Typecode != items[i].Typecode || !bytes.Equal(got[i].Value, items[i].Value) {
+				t.Fatalf("item %d differs", i)
+			}
+		}
+	}
+}
+
+func TestF4Jumble(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, n := range []int{48, 49, 127, 128, 129, 200, 4096 + 65} {
+		m := make([]byte, n)
+		rng.Read(m)
+		j, err := f4jumble(m)
+		if err != nil {
+			t.Fatalf("f4jumble(%d bytes) error: %v", n, err)
+		}
+		if bytes.Equal(j, m) {
+			t.Fatalf("f4jumble(%d bytes) is the identity", n)
+		}
+		inv, err := f4jumbleInv(j)
+		if err != nil || !bytes.Equal(inv, m) {
+			t.Fatalf("f4jumbleInv(%d bytes) did not invert: %v", n, err)
+		}
+	}
+	for _, n := range []int{0, 47, f4jumbleMaxLen + 1} {
+		if _, err := f4jumble(make([]byte, n)); !errors.Is(err, ErrF4JumbleFailed) {
+			t.Fatalf("f4jumble(%d bytes): expected ErrF4JumbleFailed, got %v", n, err)
+		}
+	}
+}
+
+func TestEncode_Errors(t *testing.T) {
+	value := make([]byte, 43)
+	cases := []struct {
+		name string
+		hrp  string
+		data []byte
+		want error
+	}{
+		{"hrp too long", strings.Repeat("j", 17), value, ErrHRPTooLong},
+		// 29 bytes of value plus the two-byte item header is one short of 32.
+		{"short", "j", make([]byte, 29), ErrPayloadTooShort},
+		{"empty hrp", "", value, ErrInvalidHRP},
+		{"space", "j j", value, ErrInvalidHRP},
+		{"mixed case", "jTest", value, ErrInvalidHRP},
+	}
+	for _, tc := range cases {
+		if _, err := EncodeUnifiedContainer(tc.hrp, typecodeOrchard, tc.data); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+
+	// 30 bytes of value plus the two-byte item header is the shortest payload.
+	if _, err := EncodeUnifiedContainer("j", typecodeOrchard, make([]byte, 30)); err != nil {
+		t.Fatalf("32-byte payload: %v", err)
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	v := loadVectors(t, "v1.json")
+
+	flipped := []byte(v.Addresses[0])
+	if flipped[5] == 'q' {
+		flipped[5] = 'p'
+	} else {
+		flipped[5] = 'q'
+	}
+	mixedCase := "J" + v.Addresses[0][1:]
+
+	// Truncated items: an Orchard header claiming 43 bytes over 40.
+	truncated, err := encode("j", append([]byte{typecodeOrchard, 43}, make([]byte, 40)...))
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	shortLen, err := encode("j", append(make([]byte, 40), 253, 0))
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+
+	cases := []struct {
+		name string
+		hrp  string
+		s    string
+		want error
+	}{
+		{"empty", "j", "", ErrBech32DecodeFailed},
+		{"not bech32m", "j", "not-an-address", ErrBech32DecodeFailed},
+		{"no hrp", "j", "1qqqqqqqq", ErrBech32DecodeFailed},
+		{"bad char", "j", "j1bqqqqqq", ErrBech32DecodeFailed},
+		{"mixed case", "j", mixedCase, ErrBech32DecodeFailed},
+		{"non-ascii", "j", v.Addresses[0] + "é", ErrBech32DecodeFailed},
+		{"no checksum", "j", "j1qqqqq", ErrChecksumInvalid},
+		{"typo", "j", string(flipped), ErrChecksumInvalid},
+		{"ufvk", "j", v.UFVK, ErrHRPMismatch},
+		// Rust compares the HRP as written.
+		{"uppercase", "j", strings.ToUpper(v.Addresses[0]), ErrHRPMismatch},
+		{"too short", "j", encodeBech32m("j", make([]byte, 47)), ErrF4JumbleFailed},
+		// The testnet encoding of v1 address 0 relabelled as mainnet: the
+		// padding still names jtest.
+		{"padding", "j", "j158cgua0efdr2jweerrapng6uz48rjmsg2hy69t7peulekq4c6hrytspm0rvpr33kwcrvjgdvsjlkat3pv0gmsqsf3gs7est7ugc9lle2", ErrPaddingInvalid},
+		{"truncated value", "j", truncated, ErrTLVInvalid},
+		{"truncated length", "j", shortLen, ErrTLVInvalid},
+	}
+	for _, tc := range cases {
+		if _, err := DecodeTLVContainer(tc.hrp, tc.s); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+
+	// v1 address 0's receiver, encoded for Zcash mainnet.
+	const zcash = "u1g5cetvll76pmqsfhdlgvwn6e6lnp45ldntkufejcz3r6wu0xk9xerpprxq36wwr2kzggyp8vt6ankx76d68xmvczwld8zjsjsvsfcrtw"
+	if hrp, err := DecodeHRP(zcash); err != nil || hrp != "u" {
+		t.Fatalf("DecodeHRP(zcash) = %q, %v", hrp, err)
+	}
+	if hrp, err := DecodeHRP(strings.ToUpper(zcash)); err != nil || hrp != "U" {
+		t.Fatalf("DecodeHRP(upper) = %q, %v", hrp, err)
+	}
+
+	// v1 address 0's Orchard receiver, twice.
+	const duplicate = "j1ent93p3ked0ncl2fe5m8m3tahxvw5rxsny6fuvaj4qdvg87rm88488dfj3nu2zhklq9vq66gx33jrpqy4ujzvg6rjwdsux89cvrea4fa5yvuhvt9xgxd7qgcdvteyetnn5sgj7tlr23a5x2zqrmkmss8jmm0vurcwj2dprrcgvzvhyfc"
+	items, err := DecodeTLVContainer("j", duplicate)
+	if err != nil || len(items) != 2 || items[0].Typecode != items[1].Typecode {
+		t.Fatalf("DecodeTLVContainer(duplicate) = %+v, %v", items, err)
+	}
+	if _, err := DecodeSingleTLVContainer("j", duplicate); !errors.Is(err, ErrTLVTrailingBytes) {
+		t.Fatalf("DecodeSingleTLVContainer(duplicate): expected ErrTLVTrailingBytes, got %v", err)
+	}
+	empty, err := encode("j", make([]byte, 32))
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	// 32 zero bytes are 16 empty items with typecode 0.
+	if _, err := DecodeSingleTLVContainer("j", empty); !errors.Is(err, ErrTLVTrailingBytes) {
+		t.Fatalf("DecodeSingleTLVContainer(empty items): expected ErrTLVTrailingBytes, got %v", err)
+	}
+}
+
+func TestCompactSize(t *testing.T) {
+	for _, n := range []uint64{0, 252, 253, 0xffff, 0x10000, 0xffffffff, 1 << 32, 1<<64 - 1} {
+		b := appendCompactSize(nil, n)
+		got, err := readCompactSize(&b)
+		if err != nil || got != n || len(b) != 0 {
+			t.Fatalf("compact size %d round-tripped as %d, %v", n, got, err)
+		}
+	}
+
+	// Non-minimal encodings are accepted.
+	b := []byte{253, 1, 0}
+	if n, err := readCompactSize(&b); err != nil || n != 1 {
+		t.Fatalf("non-minimal compact size = %d, %v", n, err)
+	}
+	for _, b := range [][]byte{{}, {253, 1}, {254, 1, 2, 3}, {255, 1, 2, 3, 4, 5, 6, 7}} {
+		if _, err := readCompactSize(&b); !errors.Is(err, ErrTLVInvalid) {
+			t.Fatalf("readCompactSize(%x): expected ErrTLVInvalid, got %v", b, err)
+		}
+	}
+}
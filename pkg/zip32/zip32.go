@@ -0,0 +1,47 @@
+// Package zip32 holds the parts of ZIP-32 shared by every key tree: seed
+// checks, seed fingerprints and hardened child indices. The Orchard key
+// derivation itself is in pkg/orchard.
+package zip32
+
+import (
+	"errors"
+
+	"github.com/Abdullah1738/juno-addrgen/internal/blake2b"
+)
+
+const (
+	// MinSeedLen and MaxSeedLen bound the length of a ZIP-32 seed in bytes.
+	MinSeedLen = 32
+	MaxSeedLen = 252
+	// FingerprintLen is the length of a seed fingerprint.
+	FingerprintLen = 32
+	// Hardened is added to an index to select a hardened child.
+	Hardened = 1 << 31
+)
+
+var (
+	// ErrSeedLength is returned for a seed shorter than MinSeedLen or longer
+	// than MaxSeedLen bytes.
+	ErrSeedLength = errors.New("zip32: seed must be 32 to 252 bytes")
+	// ErrIndex is returned for a child index, such as an account number, of
+	// 2^31 or more: hardened indices are formed by adding Hardened.
+	ErrIndex = errors.New("zip32: index must be below 2^31")
+)
+
+// CheckSeed returns ErrSeedLength unless seed has a valid length.
+func CheckSeed(seed []byte) error {
+	if len(seed) < MinSeedLen || len(seed) > MaxSeedLen {
+		return ErrSeedLength
+	}
+	return nil
+}
+
+// SeedFingerprint returns the ZIP-32 seed fingerprint, which identifies a
+// seed without revealing it: BLAKE2b-256 of the seed's length byte followed
+// by the seed, personalized "Zcash_HD_Seed_FP".
+func SeedFingerprint(seed []byte) ([FingerprintLen]byte, error) {
+	if err := CheckSeed(seed); err != nil {
+		return [FingerprintLen]byte{}, err
+	}
+	return [FingerprintLen]byte(blake2b.Sum(FingerprintLen, []byte("Zcash_HD_Seed_FP"), []byte{byte(len(seed))}, seed)), nil
+}
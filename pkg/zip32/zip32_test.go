@@ -0,0 +1,34 @@
+package zip32
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestSeedFingerprint(t *testing.T) {
+	// ZIP-32's seed fingerprint test vector.
+	seed := make([]byte, 32)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	fp, err := SeedFingerprint(seed)
+	if err != nil {
+		t.Fatalf("SeedFingerprint error: %v", err)
+	}
+	if got := hex.EncodeToString(fp[:]); got != "deff604c246710f7176dead02aa746f2fd8d5389f7072556dcb555fdbe5e3ae3" {
+		t.Fatalf("SeedFingerprint = %s", got)
+	}
+}
+
+func TestCheckSeed(t *testing.T) {
+	for n, valid := range map[int]bool{0: false, 31: false, 32: true, 64: true, 252: true, 253: false} {
+		err := CheckSeed(make([]byte, n))
+		if valid != (err == nil) {
+			t.Fatalf("CheckSeed(%d bytes) = %v", n, err)
+		}
+		if _, fpErr := SeedFingerprint(make([]byte, n)); !errors.Is(fpErr, err) {
+			t.Fatalf("SeedFingerprint(%d bytes) error = %v, want %v", n, fpErr, err)
+		}
+	}
+}
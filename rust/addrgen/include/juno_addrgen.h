@@ -1,5 +1,6 @@
 #pragma once
 
+#include <stddef.h>
 #include <stdint.h>
 
 #ifdef __cplusplus
@@ -25,9 +26,306 @@ char *juno_addrgen_derive_json(const char *ufvk_utf8, uint32_t index);
 // The returned pointer must be freed with `juno_addrgen_string_free`.
 char *juno_addrgen_batch_json(const char *ufvk_utf8, uint32_t start, uint32_t count);
 
-// Frees a string returned by `juno_addrgen_derive_json` / `juno_addrgen_batch_json`.
+// Same as `juno_addrgen_batch_json`, but splits the range across up to `jobs` threads
+// (0 = one per available core). Addresses are returned in index order and are identical to the
+// sequential output.
+char *juno_addrgen_batch_parallel_json(const char *ufvk_utf8, uint32_t start, uint32_t count,
+                                       uint32_t jobs);
+
+// Opaque handle to a parsed Juno UFVK. A handle is immutable once created and may be used from
+// multiple threads concurrently; it must not be used after `juno_addrgen_key_free`.
+typedef struct juno_addrgen_key juno_addrgen_key;
+
+// Parses a Juno UFVK (`jview*1...`) into a reusable key handle.
+//
+// Returns a newly-allocated UTF-8 JSON string with one of:
+//   - {"status":"ok","network":"mainnet"|"testnet"|"regtest"}
+//   - {"status":"err","error":"..."}
+//
+// On success `*key_out` receives a handle that must be freed with `juno_addrgen_key_free`; on
+// error it is set to NULL. The returned string must be freed with `juno_addrgen_string_free`.
+char *juno_addrgen_key_parse_json(const char *ufvk_utf8, juno_addrgen_key **key_out);
+
+// Same as `juno_addrgen_derive_json`, using a parsed key handle.
+char *juno_addrgen_key_derive_json(const juno_addrgen_key *key, uint32_t index);
+
+// Same as `juno_addrgen_batch_json`, using a parsed key handle.
+char *juno_addrgen_key_batch_json(const juno_addrgen_key *key, uint32_t start, uint32_t count);
+
+// Same as `juno_addrgen_batch_parallel_json`, using a parsed key handle.
+char *juno_addrgen_key_batch_parallel_json(const juno_addrgen_key *key, uint32_t start,
+                                           uint32_t count, uint32_t jobs);
+
+// Frees a key handle returned by `juno_addrgen_key_parse_json` or `juno_addrgen_key_parse`. NULL
+// is ignored.
+void juno_addrgen_key_free(juno_addrgen_key *key);
+
+// Frees a string returned by any of the `*_json` functions.
 void juno_addrgen_string_free(char *s);
 
+// ---------------------------------------------------------------------------------------------
+// Binary API
+//
+// The functions below return 0 on success or one of the JUNO_ADDRGEN_ERR_* codes, and write
+// their results into caller-provided buffers. Nothing they return needs to be freed except key
+// handles. Error codes are stable; `juno_addrgen_error_name` maps them to the same strings used
+// in the JSON API's "error" field.
+// ---------------------------------------------------------------------------------------------
+
+#define JUNO_ADDRGEN_OK 0
+#define JUNO_ADDRGEN_ERR_UFVK_EMPTY 1
+#define JUNO_ADDRGEN_ERR_UFVK_INVALID_BECH32M 2
+#define JUNO_ADDRGEN_ERR_UFVK_HRP_MISMATCH 3
+#define JUNO_ADDRGEN_ERR_UFVK_TLV_INVALID 4
+#define JUNO_ADDRGEN_ERR_UFVK_TYPECODE_UNSUPPORTED 5
+#define JUNO_ADDRGEN_ERR_UFVK_VALUE_LEN_INVALID 6
+#define JUNO_ADDRGEN_ERR_UFVK_FVK_BYTES_INVALID 7
+#define JUNO_ADDRGEN_ERR_COUNT_ZERO 8
+#define JUNO_ADDRGEN_ERR_COUNT_TOO_LARGE 9
+#define JUNO_ADDRGEN_ERR_RANGE_OVERFLOW 10
+#define JUNO_ADDRGEN_ERR_INTERNAL 11
+#define JUNO_ADDRGEN_ERR_BUFFER_TOO_SMALL 12
+#define JUNO_ADDRGEN_ERR_ADDRESS_EMPTY 13
+#define JUNO_ADDRGEN_ERR_ADDRESS_INVALID_BECH32M 14
+#define JUNO_ADDRGEN_ERR_ADDRESS_HRP_MISMATCH 15
+#define JUNO_ADDRGEN_ERR_ADDRESS_TLV_INVALID 16
+#define JUNO_ADDRGEN_ERR_ADDRESS_TYPECODE_UNSUPPORTED 17
+#define JUNO_ADDRGEN_ERR_ADDRESS_VALUE_LEN_INVALID 18
+#define JUNO_ADDRGEN_ERR_ADDRESS_RECEIVER_INVALID 19
+#define JUNO_ADDRGEN_ERR_SCOPE_INVALID 20
+#define JUNO_ADDRGEN_ERR_ADDRESS_NOT_OWNED 21
+#define JUNO_ADDRGEN_ERR_ADDRESS_CHECKSUM_INVALID 22
+#define JUNO_ADDRGEN_ERR_ADDRESS_ZCASH 23
+#define JUNO_ADDRGEN_ERR_ADDRESS_PADDING_INVALID 24
+#define JUNO_ADDRGEN_ERR_ADDRESS_TYPECODE_DUPLICATE 25
+#define JUNO_ADDRGEN_ERR_ADDRESS_RECEIVERS_UNKNOWN 26
+#define JUNO_ADDRGEN_ERR_SCOPE_UNAVAILABLE 27
+#define JUNO_ADDRGEN_ERR_UFVK_SAPLING_INVALID 28
+#define JUNO_ADDRGEN_ERR_RECEIVERS_INVALID 29
+#define JUNO_ADDRGEN_ERR_RECEIVERS_UNAVAILABLE 30
+#define JUNO_ADDRGEN_ERR_UFVK_TRANSPARENT_INVALID 31
+#define JUNO_ADDRGEN_ERR_ADDRESS_TRANSPARENT_ONLY 32
+#define JUNO_ADDRGEN_ERR_ADDRESS_P2PKH_AND_P2SH 33
+#define JUNO_ADDRGEN_ERR_ADDRESS_TRANSPARENT_INVALID 34
+#define JUNO_ADDRGEN_ERR_UIVK_EXPORT_UNSUPPORTED 35
+#define JUNO_ADDRGEN_ERR_SEED_INVALID 36
+#define JUNO_ADDRGEN_ERR_ACCOUNT_INVALID 37
+#define JUNO_ADDRGEN_ERR_SPENDING_KEY_INVALID 38
+
+#define JUNO_ADDRGEN_NETWORK_MAINNET 1
+#define JUNO_ADDRGEN_NETWORK_TESTNET 2
+#define JUNO_ADDRGEN_NETWORK_REGTEST 3
+
+// ZIP-32 key scopes. External addresses are handed out to payers; internal (change) addresses
+// are used by the wallet itself.
+#define JUNO_ADDRGEN_SCOPE_EXTERNAL 0
+#define JUNO_ADDRGEN_SCOPE_INTERNAL 1
+
+// Receiver-type bits for `juno_addrgen_key_derive_receivers` / `_batch_receivers`: bit `t`
+// requests the receiver with ZIP-316 typecode `t`. Every address has an Orchard receiver.
+#define JUNO_ADDRGEN_RECEIVERS_P2PKH 0x01
+#define JUNO_ADDRGEN_RECEIVERS_SAPLING 0x04
+#define JUNO_ADDRGEN_RECEIVERS_ORCHARD 0x08
+
+// Size in bytes of one address slot. Every address fits in a slot; addresses are written
+// without a NUL terminator.
+#define JUNO_ADDRGEN_ADDRESS_MAX_LEN 256
+
+// Size in bytes of a buffer that holds any UIVK `juno_addrgen_key_export_uivk` writes. An
+// Orchard-only UIVK fits in an address slot; one with Sapling and transparent items does not.
+#define JUNO_ADDRGEN_UIVK_MAX_LEN 512
+
+// Size in bytes of a raw Orchard receiver (11-byte diversifier || 32-byte pk_d).
+#define JUNO_ADDRGEN_ORCHARD_RECEIVER_LEN 43
+
+// Size in bytes of a raw P2PKH receiver (HASH160 of a compressed public key).
+#define JUNO_ADDRGEN_P2PKH_RECEIVER_LEN 20
+
+// Most items `juno_addrgen_address_parse` reports for one address.
+#define JUNO_ADDRGEN_ADDRESS_MAX_RECEIVERS 16
+
+// Size in bytes of a ZIP-32 Orchard full viewing key fingerprint.
+#define JUNO_ADDRGEN_FVK_FINGERPRINT_LEN 32
+
+// Size in bytes of an Orchard spending key.
+#define JUNO_ADDRGEN_ORCHARD_SPENDING_KEY_LEN 32
+
+// Size in bytes of a ZIP-32 diversifier index (88 bits, little-endian).
+#define JUNO_ADDRGEN_DIVERSIFIER_INDEX_LEN 11
+
+// Returns the static, NUL-terminated name of an error code (e.g. "ufvk_empty"). Unknown codes
+// map to "internal". The result must not be freed.
+const char *juno_addrgen_error_name(int32_t code);
+
+// Parses a Juno UFVK into a key handle. On success `*key_out` receives a handle that must be
+// freed with `juno_addrgen_key_free` and `*network_out` one of JUNO_ADDRGEN_NETWORK_*; on error
+// `*key_out` is set to NULL.
+//
+// A Juno UIVK (`jivk*1...`) is accepted too, with the same JUNO_ADDRGEN_ERR_UFVK_* errors. Such
+// a key derives external addresses only: internal-scope calls fail with
+// JUNO_ADDRGEN_ERR_SCOPE_UNAVAILABLE.
+//
+// Only the Orchard item must be valid. Sapling and transparent items are checked when their
+// receivers are requested.
+int32_t juno_addrgen_key_parse(const char *ufvk_utf8, juno_addrgen_key **key_out,
+                               uint32_t *network_out);
+
+// Derives the address with the receivers in `receiver_types` (JUNO_ADDRGEN_RECEIVERS_* bits,
+// including _ORCHARD) at the first diversifier index at or after `*index` that is valid for all
+// of them, and writes that index to `index_out` (JUNO_ADDRGEN_DIVERSIFIER_INDEX_LEN bytes).
+// Indices whose Sapling diversifier is invalid are skipped, as ZIP-316 requires. `receiver_out`
+// may be NULL; otherwise it receives the Orchard receiver.
+//
+// Sapling receivers need a UFVK with a Sapling item (else JUNO_ADDRGEN_ERR_RECEIVERS_UNAVAILABLE)
+// and are only derived in the external scope (else JUNO_ADDRGEN_ERR_SCOPE_UNAVAILABLE). P2PKH
+// receivers need a UFVK with a transparent item (else _RECEIVERS_UNAVAILABLE) and use the
+// diversifier index as their BIP-44 address index, so it must be below 2^31 (else
+// JUNO_ADDRGEN_ERR_RANGE_OVERFLOW). Other bit patterns fail with
+// JUNO_ADDRGEN_ERR_RECEIVERS_INVALID. A malformed Sapling or transparent item does not stop
+// `juno_addrgen_key_parse`; asking for its receivers fails with _UFVK_SAPLING_INVALID,
+// _UFVK_TRANSPARENT_INVALID or _UFVK_VALUE_LEN_INVALID.
+int32_t juno_addrgen_key_derive_receivers(const juno_addrgen_key *key, uint32_t scope,
+                                          uint32_t receiver_types, const uint8_t *index,
+                                          char *addr_out, size_t addr_cap, size_t *addr_len_out,
+                                          uint8_t *index_out, uint8_t *receiver_out);
+
+// Like `juno_addrgen_key_derive_receivers`, for the `count` indices from `*start`, using up to
+// `jobs` threads (0 = one per available core). A batch may end at index 2^88 - 1; ranges past it
+// fail with JUNO_ADDRGEN_ERR_RANGE_OVERFLOW. Indices in the range that are not valid for all
+// the receivers are skipped. The `*len_out` addresses derived are packed at the front of the
+// buffers, in index order: address `i` is written at
+// `addrs_out + i * JUNO_ADDRGEN_ADDRESS_MAX_LEN` and its length to `lens_out[i]`, so `addrs_cap`
+// must be at least `count * JUNO_ADDRGEN_ADDRESS_MAX_LEN` and `lens_out` must hold `count`
+// entries. Their indices are written to `indices_out`, which must hold
+// `count * JUNO_ADDRGEN_DIVERSIFIER_INDEX_LEN` bytes, and, unless `receivers_out` is NULL, their
+// Orchard receivers to `receivers_out`, which must hold
+// `count * JUNO_ADDRGEN_ORCHARD_RECEIVER_LEN` bytes.
+int32_t juno_addrgen_key_batch_receivers(const juno_addrgen_key *key, uint32_t scope,
+                                         uint32_t receiver_types, const uint8_t *start,
+                                         uint32_t count, uint32_t jobs, char *addrs_out,
+                                         size_t addrs_cap, size_t *lens_out, uint8_t *indices_out,
+                                         uint8_t *receivers_out, size_t receivers_cap,
+                                         size_t *len_out);
+
+// Derives the standalone transparent P2PKH address (Base58Check, `t1...` on mainnet, `tm...` on
+// testnet and regtest) at BIP-44 address index `*index` (JUNO_ADDRGEN_DIVERSIFIER_INDEX_LEN
+// bytes) of the UFVK's transparent account key, on `scope`'s chain, and writes the index used
+// to `index_out`. Indices BIP-32 cannot derive (about 2^-127 of them) are skipped; indices from
+// 2^31 on fail with JUNO_ADDRGEN_ERR_RANGE_OVERFLOW. Keys without a transparent item fail with
+// JUNO_ADDRGEN_ERR_RECEIVERS_UNAVAILABLE.
+int32_t juno_addrgen_key_derive_transparent(const juno_addrgen_key *key, uint32_t scope,
+                                            const uint8_t *index, char *addr_out,
+                                            size_t addr_cap, size_t *addr_len_out,
+                                            uint8_t *index_out);
+
+// Like `juno_addrgen_key_derive_transparent`, for the `count` indices from `*start`, using up to
+// `jobs` threads (0 = one per available core). The last index must be below 2^31 (else
+// JUNO_ADDRGEN_ERR_RANGE_OVERFLOW). Indices BIP-32 cannot derive are skipped. The `*len_out`
+// addresses derived are packed at the front of the buffers, in index order, laid out as for
+// `juno_addrgen_key_batch_receivers`: `addrs_cap` must be at least
+// `count * JUNO_ADDRGEN_ADDRESS_MAX_LEN`, `lens_out` must hold `count` entries and `indices_out`
+// `count * JUNO_ADDRGEN_DIVERSIFIER_INDEX_LEN` bytes.
+int32_t juno_addrgen_key_batch_transparent(const juno_addrgen_key *key, uint32_t scope,
+                                           const uint8_t *start, uint32_t count, uint32_t jobs,
+                                           char *addrs_out, size_t addrs_cap, size_t *lens_out,
+                                           uint8_t *indices_out, size_t *len_out);
+
+// Looks up the scope (one of JUNO_ADDRGEN_SCOPE_*) and diversifier index
+// (JUNO_ADDRGEN_DIVERSIFIER_INDEX_LEN little-endian bytes) at which `key` derives
+// `address_utf8`. The index is recovered by decrypting the receiver's diversifier, not by
+// scanning. Fails with JUNO_ADDRGEN_ERR_ADDRESS_NOT_OWNED if the key does not derive the address
+// in either scope, including addresses on another network, or if the address has a Sapling or
+// P2PKH receiver the key does not derive at the same scope and index.
+int32_t juno_addrgen_key_index_of(const juno_addrgen_key *key, const char *address_utf8,
+                                  uint32_t *scope_out, uint8_t *index_out);
+
+// Writes the ZIP-32 Orchard FVK fingerprint of `key` (BLAKE2b-256 personalized
+// "ZcashOrchardFVFP" over the 96-byte FVK) to `out`, which must hold
+// JUNO_ADDRGEN_FVK_FINGERPRINT_LEN bytes. The fingerprint does not reveal the key and is the
+// same on every network.
+//
+// A key parsed from a UIVK has no FVK; its fingerprint is BLAKE2b-256 personalized
+// "JunoOrchardIVKFP" over the 64-byte IVK instead, which differs from the fingerprint of the
+// UFVK the UIVK was exported from.
+int32_t juno_addrgen_key_fingerprint(const juno_addrgen_key *key, uint8_t *out);
+
+// Encodes the external-scope incoming viewing keys of `key` as a Juno UIVK (`jivk1...` /
+// `jivktest1...` / `jivkregtest1...`, matching the key's network): its Orchard IVK, plus, if the
+// key has those items, its Sapling IVK and the external chain key `m/44'/8133'/account'/0` of its
+// transparent account key, as ZIP-316 encodes them. `out` must hold at least
+// JUNO_ADDRGEN_ADDRESS_MAX_LEN bytes, and JUNO_ADDRGEN_UIVK_MAX_LEN hold any UIVK; a longer UIVK
+// fails with JUNO_ADDRGEN_ERR_BUFFER_TOO_SMALL. The length is written to `*len_out`, without a
+// NUL terminator. A UIVK derives the same external addresses as its UFVK, with any of its
+// receiver types, but cannot derive change addresses. A key with a malformed Sapling or
+// transparent item fails with that item's error. JUNO_ADDRGEN_ERR_UIVK_EXPORT_UNSUPPORTED is no
+// longer returned.
+int32_t juno_addrgen_key_export_uivk(const juno_addrgen_key *key, char *out, size_t cap,
+                                     size_t *len_out);
+
+// Decodes the container of a Juno UFVK without requiring a usable Orchard FVK. On success
+// `*network_out` receives one of JUNO_ADDRGEN_NETWORK_*, `*items_len_out` the item count, and
+// item `i` has typecode `typecodes_out[i]` and a value of `lens_out[i]` bytes (both hold
+// `items_cap` entries). `*fvk_status_out` is JUNO_ADDRGEN_OK if `juno_addrgen_key_parse` would
+// accept the UFVK, and otherwise the error it would fail with. It reports on the Orchard item
+// only: malformed Sapling or transparent items do not change it.
+int32_t juno_addrgen_ufvk_inspect(const char *ufvk_utf8, uint32_t *network_out,
+                                  uint64_t *typecodes_out, size_t *lens_out, size_t items_cap,
+                                  size_t *items_len_out, int32_t *fvk_status_out);
+
+// Decodes a Juno unified address (j1... / jtest1... / jregtest1...). On success
+// `*network_out` receives one of JUNO_ADDRGEN_NETWORK_* and `receiver_out`
+// (JUNO_ADDRGEN_ORCHARD_RECEIVER_LEN bytes) the raw Orchard receiver.
+int32_t juno_addrgen_address_decode(const char *address_utf8, uint32_t *network_out,
+                                    uint8_t *receiver_out);
+
+// Decodes every item of a Juno unified address, in encoding order, including typecodes this
+// library does not know. `*network_out` receives one of JUNO_ADDRGEN_NETWORK_* and
+// `*items_len_out` the item count; item `i` has typecode `typecodes_out[i]` and its
+// `lens_out[i]` bytes follow those of the items before it in `data_out`. `typecodes_out` and
+// `lens_out` hold `items_cap` entries (JUNO_ADDRGEN_ADDRESS_MAX_RECEIVERS is enough for any
+// address this accepts); a `data_cap` of strlen(address_utf8) always suffices. Fails with
+// JUNO_ADDRGEN_ERR_ADDRESS_ZCASH for Zcash addresses, _TYPECODE_DUPLICATE for repeated
+// typecodes, _RECEIVER_INVALID for a Sapling or Orchard receiver that does not decode to a valid
+// address, _RECEIVERS_UNKNOWN when no item is a known receiver type, and, as ZIP-316
+// requires, _P2PKH_AND_P2SH for both transparent receiver types and _TRANSPARENT_ONLY when
+// every item is a transparent receiver.
+int32_t juno_addrgen_address_parse(const char *address_utf8, uint32_t *network_out,
+                                   uint64_t *typecodes_out, size_t *lens_out, size_t items_cap,
+                                   size_t *items_len_out, uint8_t *data_out, size_t data_cap);
+
+// Decodes a standalone transparent P2PKH address (t1... / tm...). On success `*network_out`
+// receives JUNO_ADDRGEN_NETWORK_MAINNET or, for tm... addresses, which testnet and regtest share,
+// JUNO_ADDRGEN_NETWORK_TESTNET; `receiver_out` (JUNO_ADDRGEN_P2PKH_RECEIVER_LEN bytes) receives
+// the public key hash. Fails with JUNO_ADDRGEN_ERR_ADDRESS_CHECKSUM_INVALID for a Base58Check
+// checksum mismatch and _TRANSPARENT_INVALID for anything else that is not a Juno P2PKH
+// address, including P2SH addresses and unified addresses.
+int32_t juno_addrgen_transparent_address_decode(const char *address_utf8, uint32_t *network_out,
+                                                uint8_t *receiver_out);
+
+// One-shot Orchard-only derivation of the external address at `index`, or of `count` external
+// addresses from `start` using up to `jobs` threads (0 = one per available core), parsing
+// `ufvk_utf8` for the single call. `addr_out` must hold at least JUNO_ADDRGEN_ADDRESS_MAX_LEN
+// bytes. Batch address `i` is written at `addrs_out + i * JUNO_ADDRGEN_ADDRESS_MAX_LEN` and its
+// length to `lens_out[i]`; `addrs_cap` must be at least `count * JUNO_ADDRGEN_ADDRESS_MAX_LEN`.
+// The last index of a batch must fit in 32 bits.
+int32_t juno_addrgen_derive(const char *ufvk_utf8, uint32_t index, char *addr_out,
+                            size_t addr_cap, size_t *addr_len_out);
+int32_t juno_addrgen_batch(const char *ufvk_utf8, uint32_t start, uint32_t count, uint32_t jobs,
+                           char *addrs_out, size_t addrs_cap, size_t *lens_out);
+
+// Derives the Orchard spending key of ZIP-32 account `account` (below 2^31, else
+// JUNO_ADDRGEN_ERR_ACCOUNT_INVALID) of the `seed_len`-byte `seed` (32 to 252 bytes, else
+// _SEED_INVALID) at m_Orchard/32'/8133'/account', as the orchard crate's
+// `SpendingKey::from_zip32_seed` does, and writes its Orchard-only UFVK for `network` (one of
+// JUNO_ADDRGEN_NETWORK_*) to `ufvk_out`, which must hold JUNO_ADDRGEN_ADDRESS_MAX_LEN bytes, and
+// its length to `*ufvk_len_out`. Unless `sk_out` is NULL, it receives the spending key
+// (JUNO_ADDRGEN_ORCHARD_SPENDING_KEY_LEN bytes), which can spend the account's funds. Fails with
+// _SPENDING_KEY_INVALID if the derivation lands on an invalid key, which happens with negligible
+// probability.
+int32_t juno_addrgen_keygen(const uint8_t *seed, size_t seed_len, uint32_t account,
+                            uint32_t network, char *ufvk_out, size_t ufvk_cap,
+                            size_t *ufvk_len_out, uint8_t *sk_out);
+
 #ifdef __cplusplus
 } // extern "C"
 #endif
@@ -0,0 +1,1493 @@
+//! Binary (non-JSON) C ABI.
+//!
+//! Every function returns `0` on success or an `ErrorCode` value; results are written to
+//! caller-provided buffers so the caller never has to parse JSON or free library strings.
+
+use core::ffi::c_char;
+use std::panic::UnwindSafe;
+
+use orchard::keys::Scope;
+
+use super::{
+    check_batch_range, check_index_range, decode_orchard_receiver, derive_parallel,
+    index_from_le_bytes, inspect_ufvk, keygen, network_id_for_ua_hrp, parse_address,
+    parse_transparent_address, scope_from_u32, transparent, transparent_index, ErrorCode, Key,
+    DIVERSIFIER_INDEX_LEN, FVK_FINGERPRINT_LEN, ORCHARD_RECEIVER_LEN, ORCHARD_SPENDING_KEY_LEN,
+};
+
+const STATUS_OK: i32 = 0;
+
+/// Most items `juno_addrgen_address_parse` reports. ZIP-316 defines far fewer receiver types; a
+/// longer address is rejected with `BufferTooSmall` rather than truncated.
+pub const ADDRESS_MAX_RECEIVERS: usize = 16;
+
+/// Size of one address slot in the output buffers. Large enough for any unified address this
+/// library produces.
+pub const ADDRESS_MAX_LEN: usize = 256;
+
+/// Size of a buffer that holds any UIVK this library exports. An Orchard-only UIVK fits in an
+/// address slot; one with Sapling and transparent items as well does not.
+pub const UIVK_MAX_LEN: usize = 512;
+
+fn status(f: impl FnOnce() -> Result<(), ErrorCode> + UnwindSafe) -> i32 {
+    match std::panic::catch_unwind(f) {
+        Ok(Ok(())) => STATUS_OK,
+        Ok(Err(code)) => code as i32,
+        Err(_) => ErrorCode::Internal as i32,
+    }
+}
+
+fn write_address(address: &str, slot: &mut [u8], len_out: &mut usize) -> Result<(), ErrorCode> {
+    let bytes = address.as_bytes();
+    if bytes.len() > slot.len() {
+        return Err(ErrorCode::Internal);
+    }
+    slot[..bytes.len()].copy_from_slice(bytes);
+    *len_out = bytes.len();
+    Ok(())
+}
+
+unsafe fn parse_key(ufvk_utf8: *const c_char) -> Result<Key, ErrorCode> {
+    if ufvk_utf8.is_null() {
+        return Err(ErrorCode::UfvkEmpty);
+    }
+    let ufvk = std::ffi::CStr::from_ptr(ufvk_utf8).to_string_lossy();
+    Key::parse(&ufvk)
+}
+
+unsafe fn read_index(index: *const u8) -> Result<u128, ErrorCode> {
+    if index.is_null() {
+        return Err(ErrorCode::Internal);
+    }
+    let bytes = &*index.cast::<[u8; DIVERSIFIER_INDEX_LEN]>();
+    Ok(index_from_le_bytes(bytes))
+}
+
+unsafe fn write_index(index: u128, out: *mut u8) {
+    std::slice::from_raw_parts_mut(out, DIVERSIFIER_INDEX_LEN)
+        .copy_from_slice(&index.to_le_bytes()[..DIVERSIFIER_INDEX_LEN]);
+}
+
+// Writes the external-scope Orchard-only address at `index` to `addr_out`.
+unsafe fn derive_into(
+    key: &Key,
+    index: u128,
+    addr_out: *mut c_char,
+    addr_cap: usize,
+    addr_len_out: *mut usize,
+) -> Result<(), ErrorCode> {
+    if addr_out.is_null() || addr_len_out.is_null() {
+        return Err(ErrorCode::Internal);
+    }
+    if addr_cap < ADDRESS_MAX_LEN {
+        return Err(ErrorCode::BufferTooSmall);
+    }
+    let slot = std::slice::from_raw_parts_mut(addr_out.cast::<u8>(), addr_cap);
+
+    let address = key.derive_scoped(Scope::External, index)?;
+    write_address(&address, slot, &mut *addr_len_out)
+}
+
+// Writes the `count` external-scope Orchard-only addresses from `start`, one per
+// `ADDRESS_MAX_LEN` slot of `addrs_out`.
+unsafe fn batch_into(
+    key: &Key,
+    start: u128,
+    count: u32,
+    jobs: u32,
+    addrs_out: *mut c_char,
+    addrs_cap: usize,
+    lens_out: *mut usize,
+) -> Result<(), ErrorCode> {
+    check_index_range(start, count)?;
+    if addrs_out.is_null() || lens_out.is_null() {
+        return Err(ErrorCode::Internal);
+    }
+    let needed = (count as usize)
+        .checked_mul(ADDRESS_MAX_LEN)
+        .ok_or(ErrorCode::CountTooLarge)?;
+    if addrs_cap < needed {
+        return Err(ErrorCode::BufferTooSmall);
+    }
+
+    let buf = std::slice::from_raw_parts_mut(addrs_out.cast::<u8>(), needed);
+    let lens = std::slice::from_raw_parts_mut(lens_out, count as usize);
+    let mut slots = buf
+        .chunks_mut(ADDRESS_MAX_LEN)
+        .zip(lens.iter_mut())
+        .collect::<Vec<_>>();
+
+    derive_parallel(start, jobs, &mut slots, |index, slot| {
+        let address = key.derive_scoped(Scope::External, index)?;
+        write_address(&address, slot.0, slot.1)
+    })
+}
+
+#[no_mangle]
+pub extern "C" fn juno_addrgen_error_name(code: i32) -> *const c_char {
+    if code == STATUS_OK {
+        return c"ok".as_ptr();
+    }
+    ErrorCode::from_i32(code)
+        .unwrap_or(ErrorCode::Internal)
+        .as_c_str()
+        .as_ptr()
+}
+
+#[no_mangle]
+pub extern "C" fn juno_addrgen_key_parse(
+    ufvk_utf8: *const c_char,
+    key_out: *mut *mut Key,
+    network_out: *mut u32,
+) -> i32 {
+    status(|| {
+        if key_out.is_null() || network_out.is_null() {
+            return Err(ErrorCode::Internal);
+        }
+        unsafe { *key_out = std::ptr::null_mut() };
+
+        let key = unsafe { parse_key(ufvk_utf8) }?;
+        unsafe {
+            *network_out = key.network_id();
+            *key_out = Box::into_raw(Box::new(key));
+        }
+        Ok(())
+    })
+}
+
+/// Derives the address with the receivers in `receiver_types` (a `RECEIVERS_*` bitmask, which
+/// must include Orchard) at the first diversifier index at or after `*index` that is valid for all
+/// of them, and writes that index to `index_out`. Without Sapling that is in practice always
+/// `*index`; with it, indices whose Sapling diversifier is invalid are skipped, as ZIP-316
+/// requires. With P2PKH, the index is also the BIP-44 address index and must stay below 2^31.
+/// `receiver_out` may be null; otherwise it receives the Orchard receiver.
+#[no_mangle]
+#[allow(clippy::too_many_arguments)]
+pub extern "C" fn juno_addrgen_key_derive_receivers(
+    key: *const Key,
+    scope: u32,
+    receiver_types: u32,
+    index: *const u8,
+    addr_out: *mut c_char,
+    addr_cap: usize,
+    addr_len_out: *mut usize,
+    index_out: *mut u8,
+    receiver_out: *mut u8,
+) -> i32 {
+    status(|| {
+        let key = unsafe { key.as_ref() }.ok_or(ErrorCode::Internal)?;
+        let scope = scope_from_u32(scope)?;
+        let index = unsafe { read_index(index) }?;
+        if addr_out.is_null() || addr_len_out.is_null() || index_out.is_null() {
+            return Err(ErrorCode::Internal);
+        }
+        if addr_cap < ADDRESS_MAX_LEN {
+            return Err(ErrorCode::BufferTooSmall);
+        }
+
+        let (index, address, receiver) = key.derive_receivers(scope, receiver_types, index)?;
+        let slot = unsafe { std::slice::from_raw_parts_mut(addr_out.cast::<u8>(), addr_cap) };
+        write_address(&address, slot, unsafe { &mut *addr_len_out })?;
+        unsafe { write_index(index, index_out) };
+        if !receiver_out.is_null() {
+            unsafe { std::slice::from_raw_parts_mut(receiver_out, ORCHARD_RECEIVER_LEN) }
+                .copy_from_slice(&receiver);
+        }
+        Ok(())
+    })
+}
+
+/// Derives the standalone transparent P2PKH address (`t1...` on mainnet, `tm...` elsewhere) at
+/// BIP-44 address index `*index` of the UFVK's transparent account key, in `scope`'s chain, and
+/// writes the index used to `index_out`. Indices must be below 2^31; the ones BIP-32 cannot
+/// derive (about 2^-127 of them) are skipped. Keys without a transparent item fail with
+/// `RECEIVERS_UNAVAILABLE`.
+#[no_mangle]
+pub extern "C" fn juno_addrgen_key_derive_transparent(
+    key: *const Key,
+    scope: u32,
+    index: *const u8,
+    addr_out: *mut c_char,
+    addr_cap: usize,
+    addr_len_out: *mut usize,
+    index_out: *mut u8,
+) -> i32 {
+    status(|| {
+        let key = unsafe { key.as_ref() }.ok_or(ErrorCode::Internal)?;
+        let scope = scope_from_u32(scope)?;
+        let index = unsafe { read_index(index) }?;
+        if addr_out.is_null() || addr_len_out.is_null() || index_out.is_null() {
+            return Err(ErrorCode::Internal);
+        }
+        if addr_cap < ADDRESS_MAX_LEN {
+            return Err(ErrorCode::BufferTooSmall);
+        }
+
+        let (index, address) = key.derive_transparent(scope, index)?;
+        let slot = unsafe { std::slice::from_raw_parts_mut(addr_out.cast::<u8>(), addr_cap) };
+        write_address(&address, slot, unsafe { &mut *addr_len_out })?;
+        unsafe { write_index(index, index_out) };
+        Ok(())
+    })
+}
+
+/// Like `juno_addrgen_key_derive_receivers`, for each index in `[*start, *start + count)`,
+/// skipping the ones that are not valid for all the requested receivers. The addresses
+/// that were derived are packed at the front of the output buffers, with their indices in
+/// `indices_out` (`count * DIVERSIFIER_INDEX_LEN` bytes); `*len_out` is how many there are.
+#[no_mangle]
+#[allow(clippy::too_many_arguments)]
+pub extern "C" fn juno_addrgen_key_batch_receivers(
+    key: *const Key,
+    scope: u32,
+    receiver_types: u32,
+    start: *const u8,
+    count: u32,
+    jobs: u32,
+    addrs_out: *mut c_char,
+    addrs_cap: usize,
+    lens_out: *mut usize,
+    indices_out: *mut u8,
+    receivers_out: *mut u8,
+    receivers_cap: usize,
+    len_out: *mut usize,
+) -> i32 {
+    status(|| {
+        let start = unsafe { read_index(start) }?;
+        check_index_range(start, count)?;
+        let key = unsafe { key.as_ref() }.ok_or(ErrorCode::Internal)?;
+        let scope = scope_from_u32(scope)?;
+        if addrs_out.is_null() || lens_out.is_null() || indices_out.is_null() || len_out.is_null()
+        {
+            return Err(ErrorCode::Internal);
+        }
+        let needed = (count as usize)
+            .checked_mul(ADDRESS_MAX_LEN)
+            .ok_or(ErrorCode::CountTooLarge)?;
+        if addrs_cap < needed {
+            return Err(ErrorCode::BufferTooSmall);
+        }
+        let receivers_needed = count as usize * ORCHARD_RECEIVER_LEN;
+        if !receivers_out.is_null() && receivers_cap < receivers_needed {
+            return Err(ErrorCode::BufferTooSmall);
+        }
+
+        let mut slots: Vec<Option<(String, [u8; ORCHARD_RECEIVER_LEN])>> =
+            vec![None; count as usize];
+        derive_parallel(start, jobs, &mut slots, |index, slot| {
+            *slot = key.derive_receivers_at(scope, receiver_types, index)?;
+            Ok(())
+        })?;
+
+        let buf = unsafe { std::slice::from_raw_parts_mut(addrs_out.cast::<u8>(), needed) };
+        let lens = unsafe { std::slice::from_raw_parts_mut(lens_out, count as usize) };
+        let derived = (start..).zip(slots).filter_map(|(index, slot)| Some((index, slot?)));
+        let mut n = 0;
+        for (index, (address, receiver)) in derived {
+            let slot = &mut buf[n * ADDRESS_MAX_LEN..(n + 1) * ADDRESS_MAX_LEN];
+            write_address(&address, slot, &mut lens[n])?;
+            unsafe { write_index(index, indices_out.add(n * DIVERSIFIER_INDEX_LEN)) };
+            if !receivers_out.is_null() {
+                let out = unsafe { receivers_out.add(n * ORCHARD_RECEIVER_LEN) };
+                unsafe { std::slice::from_raw_parts_mut(out, ORCHARD_RECEIVER_LEN) }
+                    .copy_from_slice(&receiver);
+            }
+            n += 1;
+        }
+        unsafe { *len_out = n };
+        Ok(())
+    })
+}
+
+/// Like `juno_addrgen_key_derive_transparent`, for each index in `[*start, *start + count)`,
+/// which must end below 2^31, skipping the ones BIP-32 cannot derive. The addresses that were
+/// derived are packed at the front of the output buffers, with their indices in `indices_out`
+/// (`count * DIVERSIFIER_INDEX_LEN` bytes); `*len_out` is how many there are.
+#[no_mangle]
+#[allow(clippy::too_many_arguments)]
+pub extern "C" fn juno_addrgen_key_batch_transparent(
+    key: *const Key,
+    scope: u32,
+    start: *const u8,
+    count: u32,
+    jobs: u32,
+    addrs_out: *mut c_char,
+    addrs_cap: usize,
+    lens_out: *mut usize,
+    indices_out: *mut u8,
+    len_out: *mut usize,
+) -> i32 {
+    status(|| {
+        let start = unsafe { read_index(start) }?;
+        check_index_range(start, count)?;
+        transparent_index(start + (count as u128 - 1))?;
+        let key = unsafe { key.as_ref() }.ok_or(ErrorCode::Internal)?;
+        let scope = scope_from_u32(scope)?;
+        if addrs_out.is_null() || lens_out.is_null() || indices_out.is_null() || len_out.is_null()
+        {
+            return Err(ErrorCode::Internal);
+        }
+        let needed = (count as usize)
+            .checked_mul(ADDRESS_MAX_LEN)
+            .ok_or(ErrorCode::CountTooLarge)?;
+        if addrs_cap < needed {
+            return Err(ErrorCode::BufferTooSmall);
+        }
+
+        let mut slots: Vec<Option<String>> = vec![None; count as usize];
+        derive_parallel(start, jobs, &mut slots, |index, slot| {
+            *slot = key.transparent_at(scope, index)?;
+            Ok(())
+        })?;
+
+        let buf = unsafe { std::slice::from_raw_parts_mut(addrs_out.cast::<u8>(), needed) };
+        let lens = unsafe { std::slice::from_raw_parts_mut(lens_out, count as usize) };
+        let derived = (start..).zip(slots).filter_map(|(index, slot)| Some((index, slot?)));
+        let mut n = 0;
+        for (index, address) in derived {
+            let slot = &mut buf[n * ADDRESS_MAX_LEN..(n + 1) * ADDRESS_MAX_LEN];
+            write_address(&address, slot, &mut lens[n])?;
+            unsafe { write_index(index, indices_out.add(n * DIVERSIFIER_INDEX_LEN)) };
+            n += 1;
+        }
+        unsafe { *len_out = n };
+        Ok(())
+    })
+}
+
+/// Looks up the scope and diversifier index at which `key` derives `address_utf8`. Fails with
+/// `AddressNotOwned` if the key does not derive it in either scope.
+#[no_mangle]
+pub extern "C" fn juno_addrgen_key_index_of(
+    key: *const Key,
+    address_utf8: *const c_char,
+    scope_out: *mut u32,
+    index_out: *mut u8,
+) -> i32 {
+    status(|| {
+        let key = unsafe { key.as_ref() }.ok_or(ErrorCode::Internal)?;
+        if scope_out.is_null() || index_out.is_null() {
+            return Err(ErrorCode::Internal);
+        }
+        if address_utf8.is_null() {
+            return Err(ErrorCode::AddressEmpty);
+        }
+        let address = unsafe { std::ffi::CStr::from_ptr(address_utf8) }.to_string_lossy();
+
+        let (scope, index) = key.index_of(&address)?;
+        unsafe {
+            *scope_out = match scope {
+                Scope::External => 0,
+                Scope::Internal => 1,
+            };
+            write_index(index, index_out);
+        }
+        Ok(())
+    })
+}
+
+/// Writes the key's ZIP-32 Orchard FVK fingerprint (`FVK_FINGERPRINT_LEN` bytes) to `out`.
+#[no_mangle]
+pub extern "C" fn juno_addrgen_key_fingerprint(key: *const Key, out: *mut u8) -> i32 {
+    status(|| {
+        let key = unsafe { key.as_ref() }.ok_or(ErrorCode::Internal)?;
+        if out.is_null() {
+            return Err(ErrorCode::Internal);
+        }
+        unsafe { std::slice::from_raw_parts_mut(out, FVK_FINGERPRINT_LEN) }
+            .copy_from_slice(&key.fingerprint());
+        Ok(())
+    })
+}
+
+/// Writes the key's external-scope IVKs, encoded as a UIVK for the key's network, to `out`.
+/// `cap` must be at least `ADDRESS_MAX_LEN`, and a UIVK longer than `cap` is `BufferTooSmall`;
+/// `UIVK_MAX_LEN` bytes hold any UIVK. The output is not NUL-terminated.
+#[no_mangle]
+pub extern "C" fn juno_addrgen_key_export_uivk(
+    key: *const Key,
+    out: *mut c_char,
+    cap: usize,
+    len_out: *mut usize,
+) -> i32 {
+    status(|| {
+        let key = unsafe { key.as_ref() }.ok_or(ErrorCode::Internal)?;
+        if out.is_null() || len_out.is_null() {
+            return Err(ErrorCode::Internal);
+        }
+        if cap < ADDRESS_MAX_LEN {
+            return Err(ErrorCode::BufferTooSmall);
+        }
+        let uivk = key.uivk()?;
+        if uivk.len() > cap {
+            return Err(ErrorCode::BufferTooSmall);
+        }
+        let slot = unsafe { std::slice::from_raw_parts_mut(out.cast::<u8>(), cap) };
+        write_address(&uivk, slot, unsafe { &mut *len_out })
+    })
+}
+
+/// Decodes a UFVK's container, reporting the typecode and length of every item. Succeeds even if
+/// the Orchard FVK is missing or malformed; `*fvk_status_out` then holds the error `Key::parse`
+/// would fail with, and `0` otherwise.
+#[no_mangle]
+pub extern "C" fn juno_addrgen_ufvk_inspect(
+    ufvk_utf8: *const c_char,
+    network_out: *mut u32,
+    typecodes_out: *mut u64,
+    lens_out: *mut usize,
+    items_cap: usize,
+    items_len_out: *mut usize,
+    fvk_status_out: *mut i32,
+) -> i32 {
+    status(|| {
+        if network_out.is_null()
+            || typecodes_out.is_null()
+            || lens_out.is_null()
+            || items_len_out.is_null()
+            || fvk_status_out.is_null()
+        {
+            return Err(ErrorCode::Internal);
+        }
+        if ufvk_utf8.is_null() {
+            return Err(ErrorCode::UfvkEmpty);
+        }
+        let ufvk = unsafe { std::ffi::CStr::from_ptr(ufvk_utf8) }.to_string_lossy();
+
+        let (ua_hrp, items, fvk_err) = inspect_ufvk(&ufvk)?;
+        if items.len() > items_cap {
+            return Err(ErrorCode::BufferTooSmall);
+        }
+        let typecodes = unsafe { std::slice::from_raw_parts_mut(typecodes_out, items_cap) };
+        let lens = unsafe { std::slice::from_raw_parts_mut(lens_out, items_cap) };
+        for (i, (typecode, len)) in items.iter().enumerate() {
+            typecodes[i] = *typecode;
+            lens[i] = *len;
+        }
+        unsafe {
+            *network_out = network_id_for_ua_hrp(ua_hrp);
+            *items_len_out = items.len();
+            *fvk_status_out = fvk_err.map_or(STATUS_OK, |code| code as i32);
+        }
+        Ok(())
+    })
+}
+
+#[no_mangle]
+pub extern "C" fn juno_addrgen_derive(
+    ufvk_utf8: *const c_char,
+    index: u32,
+    addr_out: *mut c_char,
+    addr_cap: usize,
+    addr_len_out: *mut usize,
+) -> i32 {
+    status(|| {
+        let key = unsafe { parse_key(ufvk_utf8) }?;
+        unsafe { derive_into(&key, index.into(), addr_out, addr_cap, addr_len_out) }
+    })
+}
+
+#[no_mangle]
+pub extern "C" fn juno_addrgen_batch(
+    ufvk_utf8: *const c_char,
+    start: u32,
+    count: u32,
+    jobs: u32,
+    addrs_out: *mut c_char,
+    addrs_cap: usize,
+    lens_out: *mut usize,
+) -> i32 {
+    status(|| {
+        // Range errors take precedence over UFVK errors.
+        check_batch_range(start, count)?;
+        let key = unsafe { parse_key(ufvk_utf8) }?;
+        unsafe { batch_into(&key, start.into(), count, jobs, addrs_out, addrs_cap, lens_out) }
+    })
+}
+
+/// Derives the Orchard spending key of ZIP-32 account `account` of the `seed_len`-byte `seed`
+/// with the orchard crate, and writes its Orchard-only UFVK for `network` to `ufvk_out` and,
+/// unless `sk_out` is null, the spending key (`ORCHARD_SPENDING_KEY_LEN` bytes) to `sk_out`.
+#[no_mangle]
+#[allow(clippy::too_many_arguments)]
+pub extern "C" fn juno_addrgen_keygen(
+    seed: *const u8,
+    seed_len: usize,
+    account: u32,
+    network: u32,
+    ufvk_out: *mut c_char,
+    ufvk_cap: usize,
+    ufvk_len_out: *mut usize,
+    sk_out: *mut u8,
+) -> i32 {
+    status(|| {
+        if seed.is_null() {
+            return Err(ErrorCode::SeedInvalid);
+        }
+        if ufvk_out.is_null() || ufvk_len_out.is_null() {
+            return Err(ErrorCode::Internal);
+        }
+        if ufvk_cap < ADDRESS_MAX_LEN {
+            return Err(ErrorCode::BufferTooSmall);
+        }
+        let seed = unsafe { std::slice::from_raw_parts(seed, seed_len) };
+
+        let (ufvk, sk) = keygen(seed, account, network)?;
+        let slot = unsafe { std::slice::from_raw_parts_mut(ufvk_out.cast::<u8>(), ufvk_cap) };
+        write_address(&ufvk, slot, unsafe { &mut *ufvk_len_out })?;
+        if !sk_out.is_null() {
+            unsafe { std::slice::from_raw_parts_mut(sk_out, ORCHARD_SPENDING_KEY_LEN) }
+                .copy_from_slice(&sk);
+        }
+        Ok(())
+    })
+}
+
+#[no_mangle]
+pub extern "C" fn juno_addrgen_address_decode(
+    address_utf8: *const c_char,
+    network_out: *mut u32,
+    receiver_out: *mut u8,
+) -> i32 {
+    status(|| {
+        if network_out.is_null() || receiver_out.is_null() {
+            return Err(ErrorCode::Internal);
+        }
+        if address_utf8.is_null() {
+            return Err(ErrorCode::AddressEmpty);
+        }
+        let address = unsafe { std::ffi::CStr::from_ptr(address_utf8) }.to_string_lossy();
+
+        let (ua_hrp, receiver) = decode_orchard_receiver(&address)?;
+        unsafe {
+            *network_out = network_id_for_ua_hrp(ua_hrp);
+            std::slice::from_raw_parts_mut(receiver_out, ORCHARD_RECEIVER_LEN)
+                .copy_from_slice(&receiver);
+        }
+        Ok(())
+    })
+}
+
+#[no_mangle]
+pub extern "C" fn juno_addrgen_transparent_address_decode(
+    address_utf8: *const c_char,
+    network_out: *mut u32,
+    receiver_out: *mut u8,
+) -> i32 {
+    status(|| {
+        if network_out.is_null() || receiver_out.is_null() {
+            return Err(ErrorCode::Internal);
+        }
+        if address_utf8.is_null() {
+            return Err(ErrorCode::AddressEmpty);
+        }
+        let address = unsafe { std::ffi::CStr::from_ptr(address_utf8) }.to_string_lossy();
+
+        let (ua_hrp, receiver) = parse_transparent_address(&address)?;
+        unsafe {
+            *network_out = network_id_for_ua_hrp(ua_hrp);
+            std::slice::from_raw_parts_mut(receiver_out, transparent::P2PKH_RECEIVER_LEN)
+                .copy_from_slice(&receiver);
+        }
+        Ok(())
+    })
+}
+
+/// Decodes every item of a Juno unified address. Item `i` has typecode `typecodes_out[i]` and
+/// its `lens_out[i]` bytes follow those of the items before it in `data_out`. `data_cap` may be
+/// the address length: the decoded items are always shorter than their encoding.
+#[no_mangle]
+pub extern "C" fn juno_addrgen_address_parse(
+    address_utf8: *const c_char,
+    network_out: *mut u32,
+    typecodes_out: *mut u64,
+    lens_out: *mut usize,
+    items_cap: usize,
+    items_len_out: *mut usize,
+    data_out: *mut u8,
+    data_cap: usize,
+) -> i32 {
+    status(|| {
+        if network_out.is_null()
+            || typecodes_out.is_null()
+            || lens_out.is_null()
+            || items_len_out.is_null()
+            || data_out.is_null()
+        {
+            return Err(ErrorCode::Internal);
+        }
+        if address_utf8.is_null() {
+            return Err(ErrorCode::AddressEmpty);
+        }
+        let address = unsafe { std::ffi::CStr::from_ptr(address_utf8) }.to_string_lossy();
+
+        let (ua_hrp, items) = parse_address(&address)?;
+        let data_len: usize = items.iter().map(|(_, value)| value.len()).sum();
+        if items.len() > items_cap || data_len > data_cap {
+            return Err(ErrorCode::BufferTooSmall);
+        }
+
+        let typecodes = unsafe { std::slice::from_raw_parts_mut(typecodes_out, items_cap) };
+        let lens = unsafe { std::slice::from_raw_parts_mut(lens_out, items_cap) };
+        let data = unsafe { std::slice::from_raw_parts_mut(data_out, data_cap) };
+        let mut offset = 0;
+        for (i, (typecode, value)) in items.iter().enumerate() {
+            typecodes[i] = *typecode;
+            lens[i] = value.len();
+            data[offset..][..value.len()].copy_from_slice(value);
+            offset += value.len();
+        }
+        unsafe {
+            *network_out = network_id_for_ua_hrp(ua_hrp);
+            *items_len_out = items.len();
+        }
+        Ok(())
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    use orchard::keys::{FullViewingKey, SpendingKey};
+    use zip32::AccountId;
+
+    use crate::{
+        transparent, zip316, HRP_JUNO_UFVK_REGTEST, JUNO_COIN_TYPE, MAX_DIVERSIFIER_INDEX,
+        RECEIVERS_ORCHARD, RECEIVERS_P2PKH, RECEIVERS_SAPLING, TYPECODE_ORCHARD, TYPECODE_P2PKH,
+        TYPECODE_SAPLING,
+    };
+
+    fn regtest_ufvk() -> std::ffi::CString {
+        let seed = [3u8; 64];
+        let account = AccountId::try_from(0).expect("account");
+        let sk = SpendingKey::from_zip32_seed(&seed, JUNO_COIN_TYPE, account).expect("sk");
+        let fvk = FullViewingKey::from(&sk);
+        let ufvk = zip316::encode_unified_container(
+            HRP_JUNO_UFVK_REGTEST,
+            TYPECODE_ORCHARD,
+            &fvk.to_bytes(),
+        )
+        .expect("ufvk");
+        std::ffi::CString::new(ufvk).expect("cstring")
+    }
+
+    fn index_bytes(index: u128) -> [u8; DIVERSIFIER_INDEX_LEN] {
+        let mut out = [0u8; DIVERSIFIER_INDEX_LEN];
+        out.copy_from_slice(&index.to_le_bytes()[..DIVERSIFIER_INDEX_LEN]);
+        out
+    }
+
+    // Calls `juno_addrgen_key_derive_receivers`, returning the address, the index used and the
+    // Orchard receiver.
+    fn derive_receivers(
+        key: *const Key,
+        scope: u32,
+        receivers: u32,
+        index: u128,
+    ) -> Result<(String, u128, [u8; ORCHARD_RECEIVER_LEN]), i32> {
+        let index = index_bytes(index);
+        let mut addr = [0u8; ADDRESS_MAX_LEN];
+        let mut addr_len = 0usize;
+        let mut used = [0u8; DIVERSIFIER_INDEX_LEN];
+        let mut receiver = [0u8; ORCHARD_RECEIVER_LEN];
+        let rc = juno_addrgen_key_derive_receivers(
+            key,
+            scope,
+            receivers,
+            index.as_ptr(),
+            addr.as_mut_ptr().cast(),
+            addr.len(),
+            &mut addr_len,
+            used.as_mut_ptr(),
+            receiver.as_mut_ptr(),
+        );
+        if rc != STATUS_OK {
+            return Err(rc);
+        }
+        let address = String::from_utf8(addr[..addr_len].to_vec()).expect("utf8");
+        Ok((address, index_from_le_bytes(&used), receiver))
+    }
+
+    // Calls `juno_addrgen_key_batch_receivers`, returning what `derive_receivers` returns for
+    // every address derived.
+    #[allow(clippy::type_complexity)]
+    fn batch_receivers(
+        key: *const Key,
+        scope: u32,
+        receivers: u32,
+        start: u128,
+        count: u32,
+        jobs: u32,
+    ) -> Result<Vec<(String, u128, [u8; ORCHARD_RECEIVER_LEN])>, i32> {
+        let start = index_bytes(start);
+        let count = count as usize;
+        let mut addrs = vec![0u8; count * ADDRESS_MAX_LEN];
+        let mut lens = vec![0usize; count];
+        let mut indices = vec![0u8; count * DIVERSIFIER_INDEX_LEN];
+        let mut raw = vec![0u8; count * ORCHARD_RECEIVER_LEN];
+        let mut n = 0usize;
+        let rc = juno_addrgen_key_batch_receivers(
+            key,
+            scope,
+            receivers,
+            start.as_ptr(),
+            count as u32,
+            jobs,
+            addrs.as_mut_ptr().cast(),
+            addrs.len(),
+            lens.as_mut_ptr(),
+            indices.as_mut_ptr(),
+            raw.as_mut_ptr(),
+            raw.len(),
+            &mut n,
+        );
+        if rc != STATUS_OK {
+            return Err(rc);
+        }
+        Ok((0..n)
+            .map(|i| {
+                let address = &addrs[i * ADDRESS_MAX_LEN..][..lens[i]];
+                let address = String::from_utf8(address.to_vec()).expect("utf8");
+                let index = indices[i * DIVERSIFIER_INDEX_LEN..][..DIVERSIFIER_INDEX_LEN]
+                    .try_into()
+                    .expect("index");
+                let receiver = raw[i * ORCHARD_RECEIVER_LEN..][..ORCHARD_RECEIVER_LEN]
+                    .try_into()
+                    .expect("receiver");
+                (address, index_from_le_bytes(index), receiver)
+            })
+            .collect())
+    }
+
+    #[test]
+    fn binary_batch_matches_key_derivation() {
+        let ufvk = regtest_ufvk();
+
+        let mut key = std::ptr::null_mut();
+        let mut network = 0u32;
+        assert_eq!(juno_addrgen_key_parse(ufvk.as_ptr(), &mut key, &mut network), STATUS_OK);
+        assert_eq!(network, 3);
+
+        let count = 130u32;
+        let mut buf = vec![0u8; count as usize * ADDRESS_MAX_LEN];
+        let mut lens = vec![0usize; count as usize];
+        let rc = juno_addrgen_batch(
+            ufvk.as_ptr(),
+            40,
+            count,
+            3,
+            buf.as_mut_ptr().cast(),
+            buf.len(),
+            lens.as_mut_ptr(),
+        );
+        assert_eq!(rc, STATUS_OK);
+
+        let key_ref = unsafe { &*key };
+        for i in 0..count as usize {
+            let got = std::str::from_utf8(&buf[i * ADDRESS_MAX_LEN..][..lens[i]]).expect("utf8");
+            let want = key_ref.derive_scoped(Scope::External, 40 + i as u128).expect("derive");
+            assert_eq!(got, want);
+        }
+
+        let mut single = [0u8; ADDRESS_MAX_LEN];
+        let mut single_len = 0usize;
+        let rc = juno_addrgen_derive(
+            ufvk.as_ptr(),
+            40,
+            single.as_mut_ptr().cast(),
+            single.len(),
+            &mut single_len,
+        );
+        assert_eq!(rc, STATUS_OK);
+        assert_eq!(&single[..single_len], &buf[..lens[0]]);
+
+        crate::juno_addrgen_key_free(key);
+    }
+
+    #[test]
+    fn binary_receivers_round_trip_through_address_decode() {
+        let ufvk = regtest_ufvk();
+
+        let mut key = std::ptr::null_mut();
+        let mut network = 0u32;
+        assert_eq!(juno_addrgen_key_parse(ufvk.as_ptr(), &mut key, &mut network), STATUS_OK);
+
+        let batch = batch_receivers(key, 0, RECEIVERS_ORCHARD, 5, 70, 2).expect("batch");
+        assert_eq!(batch.len(), 70);
+        for (i, (address, index, receiver)) in batch.iter().enumerate() {
+            assert_eq!(*index, 5 + i as u128);
+            let address = std::ffi::CString::new(address.as_str()).expect("cstring");
+
+            let mut decoded_network = 0u32;
+            let mut decoded = [0u8; ORCHARD_RECEIVER_LEN];
+            let rc = juno_addrgen_address_decode(
+                address.as_ptr(),
+                &mut decoded_network,
+                decoded.as_mut_ptr(),
+            );
+            assert_eq!(rc, STATUS_OK);
+            assert_eq!(decoded_network, network);
+            assert_eq!(&decoded, receiver);
+        }
+
+        let single = derive_receivers(key, 0, RECEIVERS_ORCHARD, 5).expect("derive");
+        assert_eq!(single, batch[0]);
+
+        crate::juno_addrgen_key_free(key);
+    }
+
+    #[test]
+    fn scoped_calls_validate_scope() {
+        let ufvk = regtest_ufvk();
+
+        let mut key = std::ptr::null_mut();
+        let mut network = 0u32;
+        assert_eq!(juno_addrgen_key_parse(ufvk.as_ptr(), &mut key, &mut network), STATUS_OK);
+
+        let mut external = [0u8; ADDRESS_MAX_LEN];
+        let mut external_len = 0usize;
+        let rc = juno_addrgen_derive(
+            ufvk.as_ptr(),
+            3,
+            external.as_mut_ptr().cast(),
+            external.len(),
+            &mut external_len,
+        );
+        assert_eq!(rc, STATUS_OK);
+
+        for (scope, same_as_external) in [(0u32, true), (1u32, false)] {
+            let (scoped, _, _) = derive_receivers(key, scope, RECEIVERS_ORCHARD, 3).expect("derive");
+            assert_eq!(scoped.as_bytes() == &external[..external_len], same_as_external);
+        }
+
+        assert_eq!(
+            derive_receivers(key, 2, RECEIVERS_ORCHARD, 3),
+            Err(ErrorCode::ScopeInvalid as i32)
+        );
+
+        crate::juno_addrgen_key_free(key);
+    }
+
+    #[test]
+    fn index_calls_cover_88_bit_range() {
+        let seed = [3u8; 64];
+        let account = AccountId::try_from(0).expect("account");
+        let sk = SpendingKey::from_zip32_seed(&seed, JUNO_COIN_TYPE, account).expect("sk");
+        let fvk = FullViewingKey::from(&sk);
+        let ufvk = regtest_ufvk();
+
+        let mut key = std::ptr::null_mut();
+        let mut network = 0u32;
+        assert_eq!(juno_addrgen_key_parse(ufvk.as_ptr(), &mut key, &mut network), STATUS_OK);
+
+        let index: [u8; DIVERSIFIER_INDEX_LEN] = [7, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0x80];
+        let (_, used, receiver) =
+            derive_receivers(key, 1, RECEIVERS_ORCHARD, index_from_le_bytes(&index))
+                .expect("derive");
+        assert_eq!(index_bytes(used), index);
+        let want = fvk.address_at(orchard::keys::DiversifierIndex::from(index), Scope::Internal);
+        assert_eq!(receiver, want.to_raw_address_bytes());
+
+        // The last two indices of the space are reachable; one more is not.
+        let last_two = MAX_DIVERSIFIER_INDEX - 1;
+        let batch = batch_receivers(key, 0, RECEIVERS_ORCHARD, last_two, 2, 1).expect("batch");
+        assert_eq!(batch[1].1, MAX_DIVERSIFIER_INDEX);
+        assert_eq!(
+            batch_receivers(key, 0, RECEIVERS_ORCHARD, last_two, 3, 1),
+            Err(ErrorCode::RangeOverflow as i32)
+        );
+
+        crate::juno_addrgen_key_free(key);
+    }
+
+    #[test]
+    fn index_of_round_trips_derive_receivers() {
+        let ufvk = regtest_ufvk();
+
+        let mut key = std::ptr::null_mut();
+        let mut network = 0u32;
+        assert_eq!(juno_addrgen_key_parse(ufvk.as_ptr(), &mut key, &mut network), STATUS_OK);
+
+        let index: [u8; DIVERSIFIER_INDEX_LEN] = [0x34, 0x12, 0, 0, 0, 0, 0, 0, 0, 0x01, 0];
+        let (address, _, _) =
+            derive_receivers(key, 1, RECEIVERS_ORCHARD, index_from_le_bytes(&index))
+                .expect("derive");
+        let address = std::ffi::CString::new(address).expect("cstring");
+
+        let mut scope = 0u32;
+        let mut found = [0u8; DIVERSIFIER_INDEX_LEN];
+        let rc = juno_addrgen_key_index_of(key, address.as_ptr(), &mut scope, found.as_mut_ptr());
+        assert_eq!(rc, STATUS_OK);
+        assert_eq!(scope, 1);
+        assert_eq!(found, index);
+
+        let rc = juno_addrgen_key_index_of(key, ufvk.as_ptr(), &mut scope, found.as_mut_ptr());
+        assert_eq!(rc, ErrorCode::AddressHrpMismatch as i32);
+
+        crate::juno_addrgen_key_free(key);
+    }
+
+    #[test]
+    fn batch_receivers_packs_the_valid_indices() {
+        let seed = [3u8; 64];
+        let account = AccountId::try_from(0).expect("account");
+        let sk = SpendingKey::from_zip32_seed(&seed, JUNO_COIN_TYPE, account).expect("sk");
+        let fvk = FullViewingKey::from(&sk);
+        let dfvk = sapling::zip32::ExtendedSpendingKey::master(&seed)
+            .to_diversifiable_full_viewing_key();
+        let ufvk = zip316::encode_tlv_container(
+            HRP_JUNO_UFVK_REGTEST,
+            &[
+                zip316::Tlv {
+                    typecode: TYPECODE_SAPLING,
+                    value: &dfvk.to_bytes(),
+                },
+                zip316::Tlv {
+                    typecode: TYPECODE_ORCHARD,
+                    value: &fvk.to_bytes(),
+                },
+            ],
+        )
+        .expect("ufvk");
+        let ufvk = std::ffi::CString::new(ufvk).expect("cstring");
+
+        let mut key = std::ptr::null_mut();
+        let mut network = 0u32;
+        assert_eq!(juno_addrgen_key_parse(ufvk.as_ptr(), &mut key, &mut network), STATUS_OK);
+
+        let both = RECEIVERS_ORCHARD | RECEIVERS_SAPLING;
+        let count = 32usize;
+        let start = [0u8; DIVERSIFIER_INDEX_LEN];
+        let mut addrs = vec![0u8; count * ADDRESS_MAX_LEN];
+        let mut lens = vec![0usize; count];
+        let mut indices = vec![0u8; count * DIVERSIFIER_INDEX_LEN];
+        let mut receivers = vec![0u8; count * ORCHARD_RECEIVER_LEN];
+        let mut n = 0usize;
+        let rc = juno_addrgen_key_batch_receivers(
+            key,
+            0,
+            both,
+            start.as_ptr(),
+            count as u32,
+            4,
+            addrs.as_mut_ptr().cast(),
+            addrs.len(),
+            lens.as_mut_ptr(),
+            indices.as_mut_ptr(),
+            receivers.as_mut_ptr(),
+            receivers.len(),
+            &mut n,
+        );
+        assert_eq!(rc, STATUS_OK);
+        assert!(n > 0 && n < count, "expected some of {count} indices to be skipped, got {n}");
+
+        // Each packed address is the one derive_receivers finds from the index after the
+        // previous one.
+        let mut next = [0u8; DIVERSIFIER_INDEX_LEN];
+        for i in 0..n {
+            let mut addr = [0u8; ADDRESS_MAX_LEN];
+            let mut addr_len = 0usize;
+            let mut found = [0u8; DIVERSIFIER_INDEX_LEN];
+            let mut receiver = [0u8; ORCHARD_RECEIVER_LEN];
+            let rc = juno_addrgen_key_derive_receivers(
+                key,
+                0,
+                both,
+                next.as_ptr(),
+                addr.as_mut_ptr().cast(),
+                addr.len(),
+                &mut addr_len,
+                found.as_mut_ptr(),
+                receiver.as_mut_ptr(),
+            );
+            assert_eq!(rc, STATUS_OK);
+
+            let off = i * ADDRESS_MAX_LEN;
+            assert_eq!(&addrs[off..off + lens[i]], &addr[..addr_len]);
+            let idx = &indices[i * DIVERSIFIER_INDEX_LEN..(i + 1) * DIVERSIFIER_INDEX_LEN];
+            assert_eq!(idx, &found[..]);
+            let off = i * ORCHARD_RECEIVER_LEN;
+            assert_eq!(&receivers[off..off + ORCHARD_RECEIVER_LEN], &receiver[..]);
+
+            let index = index_from_le_bytes(&found) + 1;
+            next.copy_from_slice(&index.to_le_bytes()[..DIVERSIFIER_INDEX_LEN]);
+        }
+
+        let rc = juno_addrgen_key_batch_receivers(
+            key,
+            0,
+            RECEIVERS_SAPLING,
+            start.as_ptr(),
+            count as u32,
+            1,
+            addrs.as_mut_ptr().cast(),
+            addrs.len(),
+            lens.as_mut_ptr(),
+            indices.as_mut_ptr(),
+            std::ptr::null_mut(),
+            0,
+            &mut n,
+        );
+        assert_eq!(rc, ErrorCode::ReceiversInvalid as i32);
+
+        crate::juno_addrgen_key_free(key);
+    }
+
+    fn regtest_transparent_ufvk() -> std::ffi::CString {
+        let seed = [3u8; 64];
+        let account = AccountId::try_from(0).expect("account");
+        let sk = SpendingKey::from_zip32_seed(&seed, JUNO_COIN_TYPE, account).expect("sk");
+        let fvk = FullViewingKey::from(&sk);
+        let account_key =
+            transparent::account_pubkey_from_seed(&seed, JUNO_COIN_TYPE, 0).expect("account key");
+        let ufvk = zip316::encode_tlv_container(
+            HRP_JUNO_UFVK_REGTEST,
+            &[
+                zip316::Tlv {
+                    typecode: TYPECODE_P2PKH,
+                    value: &account_key,
+                },
+                zip316::Tlv {
+                    typecode: TYPECODE_ORCHARD,
+                    value: &fvk.to_bytes(),
+                },
+            ],
+        )
+        .expect("ufvk");
+        std::ffi::CString::new(ufvk).expect("cstring")
+    }
+
+    #[test]
+    fn derive_transparent_uses_the_network_prefix() {
+        let ufvks = [
+            (regtest_transparent_ufvk(), STATUS_OK),
+            (regtest_ufvk(), ErrorCode::ReceiversUnavailable as i32),
+        ];
+        for (ufvk, want) in ufvks {
+            let mut key = std::ptr::null_mut();
+            let mut network = 0u32;
+            assert_eq!(juno_addrgen_key_parse(ufvk.as_ptr(), &mut key, &mut network), STATUS_OK);
+
+            let mut index = [0u8; DIVERSIFIER_INDEX_LEN];
+            index[0] = 7;
+            let mut addr = [0u8; ADDRESS_MAX_LEN];
+            let mut addr_len = 0usize;
+            let mut used = [0u8; DIVERSIFIER_INDEX_LEN];
+            let rc = juno_addrgen_key_derive_transparent(
+                key,
+                1,
+                index.as_ptr(),
+                addr.as_mut_ptr().cast(),
+                addr.len(),
+                &mut addr_len,
+                used.as_mut_ptr(),
+            );
+            assert_eq!(rc, want);
+            if rc == STATUS_OK {
+                assert!(addr[..addr_len].starts_with(b"tm"));
+                assert_eq!(used, index);
+            }
+            crate::juno_addrgen_key_free(key);
+        }
+    }
+
+    // Calls `juno_addrgen_key_batch_transparent`, returning the addresses and their indices.
+    fn batch_transparent(
+        key: *const Key,
+        scope: u32,
+        start: u128,
+        count: u32,
+        jobs: u32,
+    ) -> Result<Vec<(String, u128)>, i32> {
+        let start = index_bytes(start);
+        let mut addrs = vec![0u8; count as usize * ADDRESS_MAX_LEN];
+        let mut lens = vec![0usize; count as usize];
+        let mut indices = vec![0u8; count as usize * DIVERSIFIER_INDEX_LEN];
+        let mut n = 0usize;
+        let rc = juno_addrgen_key_batch_transparent(
+            key,
+            scope,
+            start.as_ptr(),
+            count,
+            jobs,
+            addrs.as_mut_ptr().cast(),
+            addrs.len(),
+            lens.as_mut_ptr(),
+            indices.as_mut_ptr(),
+            &mut n,
+        );
+        if rc != STATUS_OK {
+            return Err(rc);
+        }
+        Ok((0..n)
+            .map(|i| {
+                let off = i * ADDRESS_MAX_LEN;
+                let address = String::from_utf8(addrs[off..off + lens[i]].to_vec()).expect("utf8");
+                let idx = &indices[i * DIVERSIFIER_INDEX_LEN..(i + 1) * DIVERSIFIER_INDEX_LEN];
+                (address, index_from_le_bytes(idx.try_into().expect("index")))
+            })
+            .collect())
+    }
+
+    #[test]
+    fn batch_transparent_matches_single_derivation() {
+        let ufvk = regtest_transparent_ufvk();
+        let mut key = std::ptr::null_mut();
+        let mut network = 0u32;
+        assert_eq!(juno_addrgen_key_parse(ufvk.as_ptr(), &mut key, &mut network), STATUS_OK);
+        let key_ref = unsafe { &*key };
+
+        for scope in [0, 1] {
+            let batch = batch_transparent(key, scope, 5, 300, 4).expect("batch");
+            assert_eq!(batch.len(), 300);
+            let s = scope_from_u32(scope).expect("scope");
+            for (i, (address, index)) in batch.into_iter().enumerate() {
+                assert_eq!(index, 5 + i as u128);
+                assert_eq!(key_ref.derive_transparent(s, index), Ok((index, address)));
+            }
+        }
+
+        // The batch may end at the last non-hardened index, but not past it.
+        let last = u128::from(transparent::MAX_ADDRESS_INDEX);
+        assert_eq!(batch_transparent(key, 0, last - 1, 2, 1).expect("batch").len(), 2);
+        assert_eq!(
+            batch_transparent(key, 0, last - 1, 3, 1),
+            Err(ErrorCode::RangeOverflow as i32)
+        );
+        crate::juno_addrgen_key_free(key);
+
+        let ufvk = regtest_ufvk();
+        let mut key = std::ptr::null_mut();
+        assert_eq!(juno_addrgen_key_parse(ufvk.as_ptr(), &mut key, &mut network), STATUS_OK);
+        assert_eq!(
+            batch_transparent(key, 0, 0, 1, 1),
+            Err(ErrorCode::ReceiversUnavailable as i32)
+        );
+        crate::juno_addrgen_key_free(key);
+    }
+
+    #[test]
+    fn keygen_writes_the_ufvk_and_spending_key() {
+        let seed = [3u8; 64];
+        let mut ufvk = [0u8; ADDRESS_MAX_LEN];
+        let mut ufvk_len = 0usize;
+        let mut sk = [0u8; ORCHARD_SPENDING_KEY_LEN];
+        let rc = juno_addrgen_keygen(
+            seed.as_ptr(),
+            seed.len(),
+            0,
+            3,
+            ufvk.as_mut_ptr().cast(),
+            ufvk.len(),
+            &mut ufvk_len,
+            sk.as_mut_ptr(),
+        );
+        assert_eq!(rc, STATUS_OK);
+        assert_eq!(&ufvk[..ufvk_len], regtest_ufvk().as_bytes());
+        let account = AccountId::try_from(0).expect("account");
+        let want = SpendingKey::from_zip32_seed(&seed, JUNO_COIN_TYPE, account).expect("sk");
+        assert_eq!(&sk, want.to_bytes());
+
+        let rc = juno_addrgen_keygen(
+            seed.as_ptr(),
+            31,
+            0,
+            3,
+            ufvk.as_mut_ptr().cast(),
+            ufvk.len(),
+            &mut ufvk_len,
+            std::ptr::null_mut(),
+        );
+        assert_eq!(rc, ErrorCode::SeedInvalid as i32);
+        let rc = juno_addrgen_keygen(
+            std::ptr::null(),
+            0,
+            0,
+            3,
+            ufvk.as_mut_ptr().cast(),
+            ufvk.len(),
+            &mut ufvk_len,
+            std::ptr::null_mut(),
+        );
+        assert_eq!(rc, ErrorCode::SeedInvalid as i32);
+        let rc = juno_addrgen_keygen(
+            seed.as_ptr(),
+            seed.len(),
+            1 << 31,
+            3,
+            ufvk.as_mut_ptr().cast(),
+            ufvk.len(),
+            &mut ufvk_len,
+            std::ptr::null_mut(),
+        );
+        assert_eq!(rc, ErrorCode::AccountInvalid as i32);
+    }
+
+    fn parse(address: &str) -> Result<(u32, Vec<(u64, Vec<u8>)>), i32> {
+        let address = std::ffi::CString::new(address).expect("cstring");
+        let mut network = 0u32;
+        let mut typecodes = [0u64; ADDRESS_MAX_RECEIVERS];
+        let mut lens = [0usize; ADDRESS_MAX_RECEIVERS];
+        let mut n = 0usize;
+        let mut data = vec![0u8; address.as_bytes().len()];
+        let rc = juno_addrgen_address_parse(
+            address.as_ptr(),
+            &mut network,
+            typecodes.as_mut_ptr(),
+            lens.as_mut_ptr(),
+            typecodes.len(),
+            &mut n,
+            data.as_mut_ptr(),
+            data.len(),
+        );
+        if rc != STATUS_OK {
+            return Err(rc);
+        }
+        let mut offset = 0;
+        let items = (0..n)
+            .map(|i| {
+                let value = data[offset..][..lens[i]].to_vec();
+                offset += lens[i];
+                (typecodes[i], value)
+            })
+            .collect();
+        Ok((network, items))
+    }
+
+    #[test]
+    fn address_parse_reports_every_item() {
+        let ufvk = regtest_ufvk();
+        let mut key = std::ptr::null_mut();
+        let mut network = 0u32;
+        assert_eq!(juno_addrgen_key_parse(ufvk.as_ptr(), &mut key, &mut network), STATUS_OK);
+        let key_ref = unsafe { &*key };
+        let (address, orchard) = key_ref.derive_with_receiver(Scope::External, 0).expect("derive");
+
+        let (parsed_network, items) = parse(&address).expect("parse orchard-only");
+        assert_eq!(parsed_network, network);
+        assert_eq!(items, vec![(TYPECODE_ORCHARD, orchard.to_vec())]);
+
+        // F4Jumble needs at least 48 bytes, so every case below carries 32+ bytes of items.
+        let unknown = (0x42u64, vec![7u8; 32]);
+        let p2pkh = (crate::TYPECODE_P2PKH, vec![1u8; 20]);
+        let p2sh = (crate::TYPECODE_P2SH, vec![2u8; 20]);
+        let encode = |items: &[(u64, Vec<u8>)]| {
+            let tlvs = items
+                .iter()
+                .map(|(typecode, value)| zip316::Tlv { typecode: *typecode, value })
+                .collect::<Vec<_>>();
+            zip316::encode_tlv_container(crate::HRP_JUNO_UA_REGTEST, &tlvs).expect("encode")
+        };
+
+        let mixed = [p2pkh.clone(), (TYPECODE_ORCHARD, orchard.to_vec()), unknown.clone()];
+        assert_eq!(parse(&encode(&mixed)), Ok((network, mixed.to_vec())));
+
+        let cases = [
+            (vec![unknown.clone()], ErrorCode::AddressReceiversUnknown),
+            (vec![p2pkh.clone(), p2pkh.clone()], ErrorCode::AddressTypecodeDuplicate),
+            (
+                vec![(crate::TYPECODE_P2SH, vec![1u8; 21]), unknown.clone()],
+                ErrorCode::AddressValueLenInvalid,
+            ),
+            (vec![p2pkh.clone(), p2sh.clone()], ErrorCode::AddressTransparentOnly),
+            // Non-canonical pk_d encodings: receivers of the right length that are not points.
+            (vec![(TYPECODE_ORCHARD, vec![0xffu8; 43])], ErrorCode::AddressReceiverInvalid),
+            (
+                vec![(TYPECODE_SAPLING, vec![0xffu8; 43]), (TYPECODE_ORCHARD, orchard.to_vec())],
+                ErrorCode::AddressReceiverInvalid,
+            ),
+            (
+                vec![p2pkh.clone(), p2sh.clone(), (TYPECODE_ORCHARD, orchard.to_vec())],
+                ErrorCode::AddressP2pkhAndP2sh,
+            ),
+        ];
+        for (items, want) in cases {
+            assert_eq!(parse(&encode(&items)), Err(want as i32));
+        }
+
+        let zcash = zip316::encode_unified_container("u", TYPECODE_ORCHARD, &orchard).expect("u1");
+        assert_eq!(parse(&zcash), Err(ErrorCode::AddressZcash as i32));
+
+        let mut corrupted = address.clone().into_bytes();
+        let last = corrupted.len() - 1;
+        corrupted[last] = if corrupted[last] == b'q' { b'p' } else { b'q' };
+        let corrupted = String::from_utf8(corrupted).expect("utf8");
+        assert_eq!(parse(&corrupted), Err(ErrorCode::AddressChecksumInvalid as i32));
+
+        crate::juno_addrgen_key_free(key);
+    }
+
+    #[test]
+    fn transparent_address_decode_reports_network_and_receiver() {
+        let decode = |address: &str| {
+            let address = std::ffi::CString::new(address).expect("cstring");
+            let mut network = 0u32;
+            let mut receiver = [0u8; transparent::P2PKH_RECEIVER_LEN];
+            let rc = juno_addrgen_transparent_address_decode(
+                address.as_ptr(),
+                &mut network,
+                receiver.as_mut_ptr(),
+            );
+            if rc != STATUS_OK {
+                return Err(rc);
+            }
+            Ok((network, receiver))
+        };
+
+        let receiver = [0x11u8; transparent::P2PKH_RECEIVER_LEN];
+        let mainnet = transparent::encode_p2pkh(transparent::P2PKH_PREFIX_MAINNET, &receiver);
+        let testnet = transparent::encode_p2pkh(transparent::P2PKH_PREFIX_TESTNET, &receiver);
+        assert_eq!(decode(&mainnet), Ok((1, receiver)));
+        assert_eq!(decode(&format!(" {testnet}\n")), Ok((2, receiver)));
+
+        // A P2SH prefix (zcashd's t3) is not a P2PKH address.
+        let p2sh = transparent::encode_p2pkh([0x1c, 0xbd], &receiver);
+        assert_eq!(decode(&p2sh), Err(ErrorCode::AddressTransparentInvalid as i32));
+        assert_eq!(decode(" "), Err(ErrorCode::AddressEmpty as i32));
+        let ufvk = regtest_ufvk();
+        assert_eq!(
+            decode(ufvk.to_str().expect("utf8")),
+            Err(ErrorCode::AddressTransparentInvalid as i32)
+        );
+    }
+
+    #[test]
+    fn address_decode_rejects_ufvk() {
+        let ufvk = regtest_ufvk();
+        let mut network = 0u32;
+        let mut receiver = [0u8; ORCHARD_RECEIVER_LEN];
+        let rc = juno_addrgen_address_decode(ufvk.as_ptr(), &mut network, receiver.as_mut_ptr());
+        assert_eq!(rc, ErrorCode::AddressHrpMismatch as i32);
+    }
+
+    #[test]
+    fn export_uivk_round_trips_through_key_parse() {
+        let ufvk = regtest_ufvk();
+
+        let mut key = std::ptr::null_mut();
+        let mut network = 0u32;
+        assert_eq!(juno_addrgen_key_parse(ufvk.as_ptr(), &mut key, &mut network), STATUS_OK);
+
+        let mut buf = [0u8; ADDRESS_MAX_LEN];
+        let mut len = 0usize;
+        let rc = juno_addrgen_key_export_uivk(key, buf.as_mut_ptr().cast(), buf.len(), &mut len);
+        assert_eq!(rc, STATUS_OK);
+        let uivk = std::str::from_utf8(&buf[..len]).expect("utf8");
+        assert!(uivk.starts_with("jivkregtest1"));
+
+        let uivk = std::ffi::CString::new(uivk).expect("cstring");
+        let mut viewing = std::ptr::null_mut();
+        let mut viewing_network = 0u32;
+        assert_eq!(
+            juno_addrgen_key_parse(uivk.as_ptr(), &mut viewing, &mut viewing_network),
+            STATUS_OK
+        );
+        assert_eq!(viewing_network, network);
+
+        assert_eq!(
+            derive_receivers(viewing, 0, RECEIVERS_ORCHARD, 9),
+            derive_receivers(key, 0, RECEIVERS_ORCHARD, 9)
+        );
+        assert_eq!(
+            derive_receivers(viewing, 1, RECEIVERS_ORCHARD, 0),
+            Err(ErrorCode::ScopeUnavailable as i32)
+        );
+
+        let rc = juno_addrgen_key_export_uivk(key, buf.as_mut_ptr().cast(), 100, &mut len);
+        assert_eq!(rc, ErrorCode::BufferTooSmall as i32);
+
+        crate::juno_addrgen_key_free(viewing);
+        crate::juno_addrgen_key_free(key);
+    }
+
+    #[test]
+    fn export_uivk_carries_sapling_and_transparent_items() {
+        let seed = [3u8; 64];
+        let account = AccountId::try_from(0).expect("account");
+        let sk = SpendingKey::from_zip32_seed(&seed, JUNO_COIN_TYPE, account).expect("sk");
+        let fvk = FullViewingKey::from(&sk);
+        let dfvk = sapling::zip32::ExtendedSpendingKey::master(&seed)
+            .to_diversifiable_full_viewing_key();
+        let account_key =
+            transparent::account_pubkey_from_seed(&seed, JUNO_COIN_TYPE, 0).expect("account key");
+        let ufvk = zip316::encode_tlv_container(
+            HRP_JUNO_UFVK_REGTEST,
+            &[
+                zip316::Tlv {
+                    typecode: TYPECODE_P2PKH,
+                    value: &account_key,
+                },
+                zip316::Tlv {
+                    typecode: TYPECODE_SAPLING,
+                    value: &dfvk.to_bytes(),
+                },
+                zip316::Tlv {
+                    typecode: TYPECODE_ORCHARD,
+                    value: &fvk.to_bytes(),
+                },
+            ],
+        )
+        .expect("ufvk");
+        let ufvk = std::ffi::CString::new(ufvk).expect("cstring");
+
+        let mut key = std::ptr::null_mut();
+        let mut network = 0u32;
+        assert_eq!(juno_addrgen_key_parse(ufvk.as_ptr(), &mut key, &mut network), STATUS_OK);
+
+        // Such a UIVK does not fit in an address slot.
+        let mut buf = [0u8; UIVK_MAX_LEN];
+        let mut len = 0usize;
+        let out = buf.as_mut_ptr().cast();
+        let rc = juno_addrgen_key_export_uivk(key, out, ADDRESS_MAX_LEN, &mut len);
+        assert_eq!(rc, ErrorCode::BufferTooSmall as i32);
+        let rc = juno_addrgen_key_export_uivk(key, out, buf.len(), &mut len);
+        assert_eq!(rc, STATUS_OK);
+        assert!(len > ADDRESS_MAX_LEN);
+
+        let uivk = std::ffi::CString::new(&buf[..len]).expect("cstring");
+        let mut viewing = std::ptr::null_mut();
+        assert_eq!(juno_addrgen_key_parse(uivk.as_ptr(), &mut viewing, &mut network), STATUS_OK);
+        let all = RECEIVERS_P2PKH | RECEIVERS_SAPLING | RECEIVERS_ORCHARD;
+        assert_eq!(derive_receivers(viewing, 0, all, 0), derive_receivers(key, 0, all, 0));
+        assert_eq!(
+            derive_receivers(viewing, 1, all, 0),
+            Err(ErrorCode::ScopeUnavailable as i32)
+        );
+
+        crate::juno_addrgen_key_free(viewing);
+        crate::juno_addrgen_key_free(key);
+    }
+
+    #[test]
+    fn binary_errors_use_stable_codes() {
+        let ufvk = regtest_ufvk();
+        let mut buf = vec![0u8; ADDRESS_MAX_LEN];
+        let mut lens = vec![0usize; 2];
+
+        let rc = juno_addrgen_batch(
+            ufvk.as_ptr(),
+            0,
+            2,
+            1,
+            buf.as_mut_ptr().cast(),
+            buf.len(),
+            lens.as_mut_ptr(),
+        );
+        assert_eq!(rc, ErrorCode::BufferTooSmall as i32);
+
+        let rc = juno_addrgen_batch(
+            std::ptr::null(),
+            0,
+            0,
+            1,
+            buf.as_mut_ptr().cast(),
+            buf.len(),
+            lens.as_mut_ptr(),
+        );
+        assert_eq!(rc, ErrorCode::CountZero as i32);
+
+        let name = unsafe { std::ffi::CStr::from_ptr(juno_addrgen_error_name(rc)) };
+        assert_eq!(name.to_str().expect("utf8"), "count_zero");
+    }
+}
@@ -1,46 +1,271 @@
-use orchard::keys::{FullViewingKey, Scope, SpendingKey};
+//! Prints golden vectors as JSON.
+//!
+//! Usage: `gen_vectors [external|internal|wide|transparent|sapling] [mainnet|testnet|regtest]`
+//! (default: external, mainnet). The external output is `vectors/v1.json`, the internal output
+//! `vectors/v1_internal.json`, the wide output (external addresses at indices beyond 32 bits)
+//! `vectors/v1_wide.json`, the transparent output (the same key with a transparent item:
+//! P2PKH + Orchard addresses and standalone transparent addresses in both scopes)
+//! `vectors/v1_transparent.json`, and the sapling output (the same key with the account's ZIP-32
+//! Sapling key: Sapling + Orchard addresses at the indices below 40 whose Sapling diversifier is
+//! valid) `vectors/v1_sapling.json`. On testnet and regtest, only external and internal are
+//! generated, as `vectors/v1_<network>.json` and `vectors/v1_<network>_internal.json`.
+
+use orchard::keys::{DiversifierIndex, FullViewingKey, Scope, SpendingKey};
 use serde::Serialize;
-use zip32::AccountId;
+use zip32::{AccountId, ChildIndex};
 
-use juno_addrgen::{HRP_JUNO_UA, HRP_JUNO_UFVK, JUNO_COIN_TYPE, TYPECODE_ORCHARD};
+use juno_addrgen::transparent::{self, AccountPubKey};
+use juno_addrgen::zip316::Tlv;
+use juno_addrgen::{
+    HRP_JUNO_UA, HRP_JUNO_UA_REGTEST, HRP_JUNO_UA_TESTNET, HRP_JUNO_UFVK, HRP_JUNO_UFVK_REGTEST,
+    HRP_JUNO_UFVK_TESTNET, JUNO_COIN_TYPE, TYPECODE_ORCHARD, TYPECODE_P2PKH, TYPECODE_SAPLING,
+};
 
 #[derive(Serialize)]
 struct VectorsV1 {
+    version: u32,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    scope: Option<&'static str>,
+    ufvk: String,
+    addresses: Vec<String>,
+}
+
+#[derive(Serialize)]
+struct WideVectorsV1 {
+    version: u32,
+    ufvk: String,
+    indexed: Vec<IndexedAddress>,
+}
+
+#[derive(Serialize)]
+struct TransparentVectorsV1 {
     version: u32,
     ufvk: String,
     addresses: Vec<String>,
+    transparent: Vec<String>,
+    transparent_internal: Vec<String>,
+}
+
+#[derive(Serialize)]
+struct SaplingVectorsV1 {
+    version: u32,
+    receivers: [&'static str; 2],
+    ufvk: String,
+    indexed: Vec<IndexedAddress>,
+}
+
+#[derive(Serialize)]
+struct IndexedAddress {
+    // Decimal string: most indices here don't fit in a JSON-safe integer.
+    index: String,
+    address: String,
+}
+
+// Indices around the 32-bit, 64-bit and 88-bit boundaries.
+const WIDE_INDICES: [u128; 8] = [
+    (1 << 32) - 1,
+    1 << 32,
+    (1 << 32) + 1,
+    (1 << 64) - 1,
+    1 << 64,
+    0x0012_3456_789a_bcde_f012_3456,
+    (1 << 88) - 2,
+    (1 << 88) - 1,
+];
+
+fn encode_address(
+    ua_hrp: &str,
+    fvk: &FullViewingKey,
+    index: DiversifierIndex,
+    scope: Scope,
+) -> String {
+    let raw = fvk.address_at(index, scope).to_raw_address_bytes();
+    juno_addrgen::zip316::encode_unified_container(ua_hrp, TYPECODE_ORCHARD, &raw).expect("addr")
 }
 
 fn main() {
+    let mode = std::env::args().nth(1);
+    let (scope, scope_name) = match mode.as_deref() {
+        None | Some("external") | Some("wide") | Some("transparent") | Some("sapling") => {
+            (Scope::External, None)
+        }
+        Some("internal") => (Scope::Internal, Some("internal")),
+        Some(other) => {
+            eprintln!(
+                "unknown mode: {other} (expected external, internal, wide, transparent or sapling)"
+            );
+            std::process::exit(2);
+        }
+    };
+
+    let network = std::env::args().nth(2);
+    let (ufvk_hrp, ua_hrp) = match network.as_deref() {
+        None | Some("mainnet") => (HRP_JUNO_UFVK, HRP_JUNO_UA),
+        Some("testnet") => (HRP_JUNO_UFVK_TESTNET, HRP_JUNO_UA_TESTNET),
+        Some("regtest") => (HRP_JUNO_UFVK_REGTEST, HRP_JUNO_UA_REGTEST),
+        Some(other) => {
+            eprintln!("unknown network: {other} (expected mainnet, testnet or regtest)");
+            std::process::exit(2);
+        }
+    };
+    if ufvk_hrp != HRP_JUNO_UFVK
+        && matches!(mode.as_deref(), Some("wide") | Some("transparent") | Some("sapling"))
+    {
+        eprintln!("wide, transparent and sapling vectors are generated for mainnet only");
+        std::process::exit(2);
+    }
+
     let seed = [7u8; 64];
     let account = AccountId::try_from(0).expect("account");
     let sk = SpendingKey::from_zip32_seed(&seed, JUNO_COIN_TYPE, account).expect("sk");
     let fvk = FullViewingKey::from(&sk);
 
-    let ufvk = juno_addrgen::zip316::encode_unified_container(
-        HRP_JUNO_UFVK,
-        TYPECODE_ORCHARD,
-        &fvk.to_bytes(),
-    )
-    .expect("ufvk");
-
-    let mut addresses = Vec::with_capacity(100);
-    for index in 0u32..100u32 {
-        let raw = fvk.address_at(index, Scope::External).to_raw_address_bytes();
-        let addr = juno_addrgen::zip316::encode_unified_container(
-            HRP_JUNO_UA,
-            TYPECODE_ORCHARD,
-            &raw,
+    let ufvk =
+        juno_addrgen::zip316::encode_unified_container(ufvk_hrp, TYPECODE_ORCHARD, &fvk.to_bytes())
+            .expect("ufvk");
+
+    if mode.as_deref() == Some("transparent") {
+        let account_key =
+            transparent::account_pubkey_from_seed(&seed, JUNO_COIN_TYPE, 0).expect("account key");
+        let key = AccountPubKey::from_bytes(&account_key).expect("transparent key");
+        let p2pkh = |internal, index| key.p2pkh(internal, index).expect("p2pkh");
+        let encode = |receiver: [u8; transparent::P2PKH_RECEIVER_LEN]| {
+            transparent::encode_p2pkh(transparent::P2PKH_PREFIX_MAINNET, &receiver)
+        };
+
+        let ufvk = juno_addrgen::zip316::encode_tlv_container(
+            HRP_JUNO_UFVK,
+            &[
+                Tlv {
+                    typecode: TYPECODE_P2PKH,
+                    value: &account_key,
+                },
+                Tlv {
+                    typecode: TYPECODE_ORCHARD,
+                    value: &fvk.to_bytes(),
+                },
+            ],
         )
-        .expect("addr");
-        addresses.push(addr);
+        .expect("ufvk");
+        let addresses = (0u32..100u32)
+            .map(|index| {
+                let raw = fvk.address_at(index, Scope::External).to_raw_address_bytes();
+                juno_addrgen::zip316::encode_tlv_container(
+                    HRP_JUNO_UA,
+                    &[
+                        Tlv {
+                            typecode: TYPECODE_P2PKH,
+                            value: &p2pkh(false, index),
+                        },
+                        Tlv {
+                            typecode: TYPECODE_ORCHARD,
+                            value: &raw,
+                        },
+                    ],
+                )
+                .expect("addr")
+            })
+            .collect();
+        let v = TransparentVectorsV1 {
+            version: 1,
+            ufvk,
+            addresses,
+            transparent: (0..100).map(|i| encode(p2pkh(false, i))).collect(),
+            transparent_internal: (0..100).map(|i| encode(p2pkh(true, i))).collect(),
+        };
+        println!("{}", serde_json::to_string_pretty(&v).expect("json"));
+        return;
     }
 
+    if mode.as_deref() == Some("sapling") {
+        let dfvk = sapling::zip32::ExtendedSpendingKey::from_path(
+            &sapling::zip32::ExtendedSpendingKey::master(&seed),
+            &[
+                ChildIndex::hardened(32),
+                ChildIndex::hardened(JUNO_COIN_TYPE),
+                ChildIndex::hardened(0),
+            ],
+        )
+        .to_diversifiable_full_viewing_key();
+
+        let ufvk = juno_addrgen::zip316::encode_tlv_container(
+            HRP_JUNO_UFVK,
+            &[
+                Tlv {
+                    typecode: TYPECODE_SAPLING,
+                    value: &dfvk.to_bytes(),
+                },
+                Tlv {
+                    typecode: TYPECODE_ORCHARD,
+                    value: &fvk.to_bytes(),
+                },
+            ],
+        )
+        .expect("ufvk");
+        let indexed = (0u32..40u32)
+            .filter_map(|index| {
+                let sapling = dfvk.address(index.into())?.to_bytes();
+                let raw = fvk.address_at(index, Scope::External).to_raw_address_bytes();
+                let address = juno_addrgen::zip316::encode_tlv_container(
+                    HRP_JUNO_UA,
+                    &[
+                        Tlv {
+                            typecode: TYPECODE_SAPLING,
+                            value: &sapling,
+                        },
+                        Tlv {
+                            typecode: TYPECODE_ORCHARD,
+                            value: &raw,
+                        },
+                    ],
+                )
+                .expect("addr");
+                Some(IndexedAddress {
+                    index: index.to_string(),
+                    address,
+                })
+            })
+            .collect();
+        let v = SaplingVectorsV1 {
+            version: 1,
+            receivers: ["orchard", "sapling"],
+            ufvk,
+            indexed,
+        };
+        println!("{}", serde_json::to_string_pretty(&v).expect("json"));
+        return;
+    }
+
+    if mode.as_deref() == Some("wide") {
+        let indexed = WIDE_INDICES
+            .iter()
+            .map(|&index| {
+                let mut bytes = [0u8; 11];
+                bytes.copy_from_slice(&index.to_le_bytes()[..11]);
+                IndexedAddress {
+                    index: index.to_string(),
+                    address: encode_address(ua_hrp, &fvk, DiversifierIndex::from(bytes), scope),
+                }
+            })
+            .collect();
+        let v = WideVectorsV1 {
+            version: 1,
+            ufvk,
+            indexed,
+        };
+        println!("{}", serde_json::to_string_pretty(&v).expect("json"));
+        return;
+    }
+
+    let addresses = (0u32..100u32)
+        .map(|index| encode_address(ua_hrp, &fvk, index.into(), scope))
+        .collect::<Vec<_>>();
+
     let v = VectorsV1 {
         version: 1,
+        scope: scope_name,
         ufvk,
         addresses,
     };
     println!("{}", serde_json::to_string_pretty(&v).expect("json"));
 }
-
@@ -1,53 +1,200 @@
-use core::ffi::c_char;
+use core::ffi::{c_char, CStr};
+use std::sync::OnceLock;
 
-use orchard::keys::{FullViewingKey, Scope};
+use orchard::keys::{DiversifierIndex, FullViewingKey, IncomingViewingKey, Scope};
+use sapling::zip32::{DiversifiableFullViewingKey, IncomingViewingKey as SaplingIvk};
 use serde::Serialize;
 
+mod abi;
+pub mod transparent;
+#[cfg(target_arch = "wasm32")]
+mod wasm;
 pub mod zip316;
 
 pub const HRP_JUNO_UFVK: &str = "jview";
 pub const HRP_JUNO_UFVK_TESTNET: &str = "jviewtest";
 pub const HRP_JUNO_UFVK_REGTEST: &str = "jviewregtest";
+pub const HRP_JUNO_UIVK: &str = "jivk";
+pub const HRP_JUNO_UIVK_TESTNET: &str = "jivktest";
+pub const HRP_JUNO_UIVK_REGTEST: &str = "jivkregtest";
 pub const HRP_JUNO_UA: &str = "j";
 pub const HRP_JUNO_UA_TESTNET: &str = "jtest";
 pub const HRP_JUNO_UA_REGTEST: &str = "jregtest";
+pub const TYPECODE_P2PKH: u64 = 0x00;
+pub const TYPECODE_P2SH: u64 = 0x01;
+pub const TYPECODE_SAPLING: u64 = 0x02;
 pub const TYPECODE_ORCHARD: u64 = 0x03;
+/// Length of a raw Orchard receiver: 11-byte diversifier followed by the 32-byte `pk_d`.
+pub const ORCHARD_RECEIVER_LEN: usize = 43;
+/// Length of a raw Sapling receiver, laid out like an Orchard one.
+pub const SAPLING_RECEIVER_LEN: usize = 43;
+/// Length of an encoded Sapling diversifiable full viewing key: `ak || nk || ovk || dk`.
+pub const SAPLING_DFVK_LEN: usize = 128;
+/// Length of an encoded Sapling incoming viewing key, as a UIVK carries it: `dk || ivk`.
+pub const SAPLING_IVK_LEN: usize = 64;
+
+/// Receiver-type bits used by the binary C ABI: bit `t` requests the receiver with typecode `t`.
+pub const RECEIVERS_P2PKH: u32 = 1 << TYPECODE_P2PKH;
+pub const RECEIVERS_SAPLING: u32 = 1 << TYPECODE_SAPLING;
+pub const RECEIVERS_ORCHARD: u32 = 1 << TYPECODE_ORCHARD;
 
 pub const JUNO_COIN_TYPE: u32 = 8133;
+/// Length of an Orchard spending key.
+pub const ORCHARD_SPENDING_KEY_LEN: usize = 32;
+
+/// Length of a ZIP-32 Orchard full viewing key fingerprint.
+pub const FVK_FINGERPRINT_LEN: usize = 32;
+const FVK_FINGERPRINT_PERSONALIZATION: &[u8; 16] = b"ZcashOrchardFVFP";
+// ZIP-32 defines no IVK fingerprint; keys parsed from a UIVK use this one instead.
+const IVK_FINGERPRINT_PERSONALIZATION: &[u8; 16] = b"JunoOrchardIVKFP";
+/// Length of an encoded Orchard incoming viewing key: 32-byte `dk` followed by the 32-byte `ivk`.
+pub const ORCHARD_IVK_LEN: usize = 64;
+
+/// Length of a ZIP-32 diversifier index in bytes (88 bits, little-endian).
+pub const DIVERSIFIER_INDEX_LEN: usize = 11;
+const MAX_DIVERSIFIER_INDEX: u128 = (1 << 88) - 1;
 
 const MAX_BATCH_COUNT: u32 = 100_000;
+const MAX_BATCH_JOBS: u32 = 256;
+// Below this many addresses per worker, thread start-up outweighs the derivation work.
+const MIN_ADDRESSES_PER_JOB: u32 = 64;
 
-#[derive(Clone, Copy, Debug)]
+// Numeric values are part of the binary C ABI (see `juno_addrgen.h`); never renumber.
+#[derive(Clone, Copy, Debug, PartialEq, Eq)]
+#[repr(i32)]
 enum ErrorCode {
-    UfvkEmpty,
-    UfvkInvalidBech32m,
-    UfvkHrpMismatch,
-    UfvkTlvInvalid,
-    UfvkTypecodeUnsupported,
-    UfvkValueLenInvalid,
-    UfvkFvkBytesInvalid,
-    CountZero,
-    CountTooLarge,
-    RangeOverflow,
-    Internal,
+    UfvkEmpty = 1,
+    UfvkInvalidBech32m = 2,
+    UfvkHrpMismatch = 3,
+    UfvkTlvInvalid = 4,
+    UfvkTypecodeUnsupported = 5,
+    UfvkValueLenInvalid = 6,
+    UfvkFvkBytesInvalid = 7,
+    CountZero = 8,
+    CountTooLarge = 9,
+    RangeOverflow = 10,
+    Internal = 11,
+    BufferTooSmall = 12,
+    AddressEmpty = 13,
+    AddressInvalidBech32m = 14,
+    AddressHrpMismatch = 15,
+    AddressTlvInvalid = 16,
+    AddressTypecodeUnsupported = 17,
+    AddressValueLenInvalid = 18,
+    AddressReceiverInvalid = 19,
+    ScopeInvalid = 20,
+    AddressNotOwned = 21,
+    AddressChecksumInvalid = 22,
+    AddressZcash = 23,
+    AddressPaddingInvalid = 24,
+    AddressTypecodeDuplicate = 25,
+    AddressReceiversUnknown = 26,
+    ScopeUnavailable = 27,
+    UfvkSaplingInvalid = 28,
+    ReceiversInvalid = 29,
+    ReceiversUnavailable = 30,
+    UfvkTransparentInvalid = 31,
+    AddressTransparentOnly = 32,
+    AddressP2pkhAndP2sh = 33,
+    AddressTransparentInvalid = 34,
+    // No longer returned: UIVKs carry Sapling and transparent items too.
+    UivkExportUnsupported = 35,
+    SeedInvalid = 36,
+    AccountInvalid = 37,
+    SpendingKeyInvalid = 38,
 }
 
 impl ErrorCode {
     fn as_str(self) -> &'static str {
+        self.as_c_str().to_str().expect("ascii")
+    }
+
+    fn as_c_str(self) -> &'static CStr {
         match self {
-            ErrorCode::UfvkEmpty => "ufvk_empty",
-            ErrorCode::UfvkInvalidBech32m => "ufvk_invalid_bech32m",
-            ErrorCode::UfvkHrpMismatch => "ufvk_hrp_mismatch",
-            ErrorCode::UfvkTlvInvalid => "ufvk_tlv_invalid",
-            ErrorCode::UfvkTypecodeUnsupported => "ufvk_typecode_unsupported",
-            ErrorCode::UfvkValueLenInvalid => "ufvk_value_len_invalid",
-            ErrorCode::UfvkFvkBytesInvalid => "ufvk_fvk_bytes_invalid",
-            ErrorCode::CountZero => "count_zero",
-            ErrorCode::CountTooLarge => "count_too_large",
-            ErrorCode::RangeOverflow => "range_overflow",
-            ErrorCode::Internal => "internal",
+            ErrorCode::UfvkEmpty => c"ufvk_empty",
+            ErrorCode::UfvkInvalidBech32m => c"ufvk_invalid_bech32m",
+            ErrorCode::UfvkHrpMismatch => c"ufvk_hrp_mismatch",
+            ErrorCode::UfvkTlvInvalid => c"ufvk_tlv_invalid",
+            ErrorCode::UfvkTypecodeUnsupported => c"ufvk_typecode_unsupported",
+            ErrorCode::UfvkValueLenInvalid => c"ufvk_value_len_invalid",
+            ErrorCode::UfvkFvkBytesInvalid => c"ufvk_fvk_bytes_invalid",
+            ErrorCode::CountZero => c"count_zero",
+            ErrorCode::CountTooLarge => c"count_too_large",
+            ErrorCode::RangeOverflow => c"range_overflow",
+            ErrorCode::Internal => c"internal",
+            ErrorCode::BufferTooSmall => c"buffer_too_small",
+            ErrorCode::AddressEmpty => c"address_empty",
+            ErrorCode::AddressInvalidBech32m => c"address_invalid_bech32m",
+            ErrorCode::AddressHrpMismatch => c"address_hrp_mismatch",
+            ErrorCode::AddressTlvInvalid => c"address_tlv_invalid",
+            ErrorCode::AddressTypecodeUnsupported => c"address_typecode_unsupported",
+            ErrorCode::AddressValueLenInvalid => c"address_value_len_invalid",
+            ErrorCode::AddressReceiverInvalid => c"address_receiver_invalid",
+            ErrorCode::ScopeInvalid => c"scope_invalid",
+            ErrorCode::AddressNotOwned => c"address_not_owned",
+            ErrorCode::AddressChecksumInvalid => c"address_checksum_invalid",
+            ErrorCode::AddressZcash => c"address_zcash",
+            ErrorCode::AddressPaddingInvalid => c"address_padding_invalid",
+            ErrorCode::AddressTypecodeDuplicate => c"address_typecode_duplicate",
+            ErrorCode::AddressReceiversUnknown => c"address_receivers_unknown",
+            ErrorCode::ScopeUnavailable => c"scope_unavailable",
+            ErrorCode::UfvkSaplingInvalid => c"ufvk_sapling_invalid",
+            ErrorCode::ReceiversInvalid => c"receivers_invalid",
+            ErrorCode::ReceiversUnavailable => c"receivers_unavailable",
+            ErrorCode::UfvkTransparentInvalid => c"ufvk_transparent_invalid",
+            ErrorCode::AddressTransparentOnly => c"address_transparent_only",
+            ErrorCode::AddressP2pkhAndP2sh => c"address_p2pkh_and_p2sh",
+            ErrorCode::AddressTransparentInvalid => c"address_transparent_invalid",
+            ErrorCode::UivkExportUnsupported => c"uivk_export_unsupported",
+            ErrorCode::SeedInvalid => c"seed_invalid",
+            ErrorCode::AccountInvalid => c"account_invalid",
+            ErrorCode::SpendingKeyInvalid => c"spending_key_invalid",
         }
     }
+
+    fn from_i32(code: i32) -> Option<Self> {
+        Some(match code {
+            1 => ErrorCode::UfvkEmpty,
+            2 => ErrorCode::UfvkInvalidBech32m,
+            3 => ErrorCode::UfvkHrpMismatch,
+            4 => ErrorCode::UfvkTlvInvalid,
+            5 => ErrorCode::UfvkTypecodeUnsupported,
+            6 => ErrorCode::UfvkValueLenInvalid,
+            7 => ErrorCode::UfvkFvkBytesInvalid,
+            8 => ErrorCode::CountZero,
+            9 => ErrorCode::CountTooLarge,
+            10 => ErrorCode::RangeOverflow,
+            11 => ErrorCode::Internal,
+            12 => ErrorCode::BufferTooSmall,
+            13 => ErrorCode::AddressEmpty,
+            14 => ErrorCode::AddressInvalidBech32m,
+            15 => ErrorCode::AddressHrpMismatch,
+            16 => ErrorCode::AddressTlvInvalid,
+            17 => ErrorCode::AddressTypecodeUnsupported,
+            18 => ErrorCode::AddressValueLenInvalid,
+            19 => ErrorCode::AddressReceiverInvalid,
+            20 => ErrorCode::ScopeInvalid,
+            21 => ErrorCode::AddressNotOwned,
+            22 => ErrorCode::AddressChecksumInvalid,
+            23 => ErrorCode::AddressZcash,
+            24 => ErrorCode::AddressPaddingInvalid,
+            25 => ErrorCode::AddressTypecodeDuplicate,
+            26 => ErrorCode::AddressReceiversUnknown,
+            27 => ErrorCode::ScopeUnavailable,
+            28 => ErrorCode::UfvkSaplingInvalid,
+            29 => ErrorCode::ReceiversInvalid,
+            30 => ErrorCode::ReceiversUnavailable,
+            31 => ErrorCode::UfvkTransparentInvalid,
+            32 => ErrorCode::AddressTransparentOnly,
+            33 => ErrorCode::AddressP2pkhAndP2sh,
+            34 => ErrorCode::AddressTransparentInvalid,
+            35 => ErrorCode::UivkExportUnsupported,
+            36 => ErrorCode::SeedInvalid,
+            37 => ErrorCode::AccountInvalid,
+            38 => ErrorCode::SpendingKeyInvalid,
+            _ => return None,
+        })
+    }
 }
 
 const UFVK_HRP_TO_UA_HRP: [(&str, &str); 3] = [
@@ -56,7 +203,492 @@ const UFVK_HRP_TO_UA_HRP: [(&str, &str); 3] = [
     (HRP_JUNO_UFVK_REGTEST, HRP_JUNO_UA_REGTEST),
 ];
 
-fn decode_fvk_from_ufvk(ufvk: &str) -> Result<(&'static str, FullViewingKey), ErrorCode> {
+const UIVK_HRP_TO_UA_HRP: [(&str, &str); 3] = [
+    (HRP_JUNO_UIVK, HRP_JUNO_UA),
+    (HRP_JUNO_UIVK_TESTNET, HRP_JUNO_UA_TESTNET),
+    (HRP_JUNO_UIVK_REGTEST, HRP_JUNO_UA_REGTEST),
+];
+
+// Zcash's unified address HRPs. A Zcash address is well-formed bech32m but never valid on Juno,
+// so it gets its own error instead of a generic HRP mismatch.
+const ZCASH_UA_HRPS: [&str; 3] = ["u", "utest", "uregtest"];
+
+fn network_for_ua_hrp(ua_hrp: &str) -> &'static str {
+    match ua_hrp {
+        HRP_JUNO_UA => "mainnet",
+        HRP_JUNO_UA_TESTNET => "testnet",
+        HRP_JUNO_UA_REGTEST => "regtest",
+        _ => "unknown",
+    }
+}
+
+// Numeric network identifiers used by the binary C ABI.
+fn network_id_for_ua_hrp(ua_hrp: &str) -> u32 {
+    match ua_hrp {
+        HRP_JUNO_UA => 1,
+        HRP_JUNO_UA_TESTNET => 2,
+        HRP_JUNO_UA_REGTEST => 3,
+        _ => 0,
+    }
+}
+
+fn ufvk_hrp_for_network_id(network: u32) -> Result<&'static str, ErrorCode> {
+    match network {
+        1 => Ok(HRP_JUNO_UFVK),
+        2 => Ok(HRP_JUNO_UFVK_TESTNET),
+        3 => Ok(HRP_JUNO_UFVK_REGTEST),
+        _ => Err(ErrorCode::Internal),
+    }
+}
+
+// Checks a receiver bitmask. Every address carries an Orchard receiver, so masks without it are
+// rejected, as are receiver types this library cannot derive.
+fn check_receivers(receivers: u32) -> Result<(), ErrorCode> {
+    let known = RECEIVERS_ORCHARD | RECEIVERS_SAPLING | RECEIVERS_P2PKH;
+    if receivers & RECEIVERS_ORCHARD == 0 || receivers & !known != 0 {
+        return Err(ErrorCode::ReceiversInvalid);
+    }
+    Ok(())
+}
+
+// Numeric scope identifiers used by the binary C ABI.
+fn scope_from_u32(scope: u32) -> Result<Scope, ErrorCode> {
+    match scope {
+        0 => Ok(Scope::External),
+        1 => Ok(Scope::Internal),
+        _ => Err(ErrorCode::ScopeInvalid),
+    }
+}
+
+/// A decoded UFVK or UIVK, kept alive across FFI calls so repeated derivations skip the
+/// bech32m / F4Jumble / TLV decoding and the CommitIvk computation. Immutable
+/// after construction apart from the lazily computed internal IVK, so it may be shared
+/// between threads.
+///
+/// A key parsed from a UIVK has no FVK: it derives external-scope addresses only.
+///
+/// A UFVK may also carry a Sapling key, which lets callers add a Sapling receiver to external
+/// addresses, and a transparent account key, for P2PKH receivers and standalone transparent
+/// addresses in either scope. A UIVK may carry the matching incoming keys: a Sapling IVK, and the
+/// transparent external chain key, for external P2PKH receivers and addresses only. A malformed
+/// Sapling or transparent item does not stop the key parsing: its error is reported by the calls
+/// that need that key.
+pub struct Key {
+    ua_hrp: &'static str,
+    fvk: Option<FullViewingKey>,
+    ivk: IncomingViewingKey,
+    // Most callers never derive change addresses; don't pay for a second CommitIvk up front.
+    internal_ivk: OnceLock<IncomingViewingKey>,
+    // Sapling receivers are only derived in the external scope, so its external IVK is all that
+    // is kept of a UFVK's Sapling key.
+    sapling: Option<Result<SaplingIvk, ErrorCode>>,
+    transparent: Option<Result<transparent::AccountPubKey, ErrorCode>>,
+}
+
+impl Key {
+    /// Parses a UFVK, or failing that a UIVK. Errors are reported against the UFVK codes; a
+    /// string with neither kind of HRP is `UfvkHrpMismatch`.
+    fn parse(key: &str) -> Result<Self, ErrorCode> {
+        let (ua_hrp, fvk, ivk, sapling, transparent) = match decode_fvk_from_ufvk(key) {
+            Ok(ufvk) => {
+                let ivk = ufvk.fvk.to_ivk(Scope::External);
+                let sapling = ufvk.sapling.map(|dfvk| dfvk.map(|dfvk| dfvk.to_external_ivk()));
+                (ufvk.ua_hrp, Some(ufvk.fvk), ivk, sapling, ufvk.transparent)
+            }
+            Err(ErrorCode::UfvkHrpMismatch) => {
+                let uivk = decode_ivk_from_uivk(key)?;
+                (uivk.ua_hrp, None, uivk.ivk, uivk.sapling, uivk.transparent)
+            }
+            Err(code) => return Err(code),
+        };
+        Ok(Key {
+            ua_hrp,
+            fvk,
+            ivk,
+            internal_ivk: OnceLock::new(),
+            sapling,
+            transparent,
+        })
+    }
+
+    fn ivk(&self, scope: Scope) -> Result<&IncomingViewingKey, ErrorCode> {
+        match (scope, &self.fvk) {
+            (Scope::External, _) => Ok(&self.ivk),
+            (Scope::Internal, Some(fvk)) => Ok(self
+                .internal_ivk
+                .get_or_init(|| fvk.to_ivk(Scope::Internal))),
+            // A UIVK carries the external IVK only.
+            (Scope::Internal, None) => Err(ErrorCode::ScopeUnavailable),
+        }
+    }
+
+    fn network(&self) -> &'static str {
+        network_for_ua_hrp(self.ua_hrp)
+    }
+
+    fn network_id(&self) -> u32 {
+        network_id_for_ua_hrp(self.ua_hrp)
+    }
+
+    fn derive(&self, index: u32) -> Result<String, ErrorCode> {
+        derive_address_from_ivk(&self.ivk, self.ua_hrp, index)
+    }
+
+    fn derive_batch(&self, start: u32, count: u32, jobs: u32) -> Result<Vec<String>, ErrorCode> {
+        derive_addresses_from_ivk(&self.ivk, self.ua_hrp, start, count, jobs)
+    }
+
+    fn derive_scoped(&self, scope: Scope, index: u128) -> Result<String, ErrorCode> {
+        derive_address_and_receiver_from_ivk(self.ivk(scope)?, self.ua_hrp, index)
+            .map(|(address, _)| address)
+    }
+
+    fn derive_with_receiver(
+        &self,
+        scope: Scope,
+        index: u128,
+    ) -> Result<(String, [u8; ORCHARD_RECEIVER_LEN]), ErrorCode> {
+        derive_address_and_receiver_from_ivk(self.ivk(scope)?, self.ua_hrp, index)
+    }
+
+    /// Derives the address with the requested receivers (a `RECEIVERS_*` bitmask) at the first
+    /// diversifier index at or after `index` that is valid for all of them, and returns that
+    /// index too. Every index is valid for Orchard; about half of all Sapling diversifiers are
+    /// not, and ZIP-316 has wallets skip to the next index rather than mix indices in one address.
+    /// A P2PKH receiver uses the same number as its BIP-44 address index, so it caps the range at
+    /// `transparent::MAX_ADDRESS_INDEX`.
+    fn derive_receivers(
+        &self,
+        scope: Scope,
+        receivers: u32,
+        index: u128,
+    ) -> Result<(u128, String, [u8; ORCHARD_RECEIVER_LEN]), ErrorCode> {
+        if receivers & (RECEIVERS_SAPLING | RECEIVERS_P2PKH) == 0 {
+            check_receivers(receivers)?;
+            let (address, raw) = self.derive_with_receiver(scope, index)?;
+            return Ok((index, address, raw));
+        }
+        let mut index = index;
+        loop {
+            if let Some((address, raw)) = self.derive_receivers_at(scope, receivers, index)? {
+                return Ok((index, address, raw));
+            }
+            index = index
+                .checked_add(1)
+                .filter(|&i| i <= MAX_DIVERSIFIER_INDEX)
+                .ok_or(ErrorCode::RangeOverflow)?;
+        }
+    }
+
+    /// Like `derive_receivers`, but at exactly `index`: `None` if it is not valid for every
+    /// requested receiver.
+    fn derive_receivers_at(
+        &self,
+        scope: Scope,
+        receivers: u32,
+        index: u128,
+    ) -> Result<Option<(String, [u8; ORCHARD_RECEIVER_LEN])>, ErrorCode> {
+        check_receivers(receivers)?;
+        let sapling = self.sapling_for(scope, receivers)?;
+        let transparent = self.transparent_for(scope, receivers)?;
+        if sapling.is_none() && transparent.is_none() {
+            return self.derive_with_receiver(scope, index).map(Some);
+        }
+
+        let p2pkh = match transparent {
+            Some(key) => match key.p2pkh(scope == Scope::Internal, transparent_index(index)?) {
+                Some(receiver) => Some(receiver),
+                None => return Ok(None),
+            },
+            None => None,
+        };
+        let sapling = match sapling {
+            Some(ivk) => match ivk.address_at(diversifier_index(index)?) {
+                Some(addr) => Some(addr.to_bytes()),
+                None => return Ok(None),
+            },
+            None => None,
+        };
+        let raw = self
+            .ivk(scope)?
+            .address_at(diversifier_index(index)?)
+            .to_raw_address_bytes();
+
+        // Items are encoded in ascending typecode order, as ZIP-316 requires.
+        let mut items = Vec::with_capacity(3);
+        if let Some(receiver) = &p2pkh {
+            items.push(zip316::Tlv {
+                typecode: TYPECODE_P2PKH,
+                value: receiver,
+            });
+        }
+        if let Some(receiver) = &sapling {
+            items.push(zip316::Tlv {
+                typecode: TYPECODE_SAPLING,
+                value: receiver,
+            });
+        }
+        items.push(zip316::Tlv {
+            typecode: TYPECODE_ORCHARD,
+            value: &raw,
+        });
+        let address =
+            zip316::encode_tlv_container(self.ua_hrp, &items).map_err(|_| ErrorCode::Internal)?;
+        Ok(Some((address, raw)))
+    }
+
+    // The Sapling key to derive with, if `receivers` asks for one. Sapling receivers are only
+    // derived in the external scope: change stays in Orchard.
+    fn sapling_for(
+        &self,
+        scope: Scope,
+        receivers: u32,
+    ) -> Result<Option<&SaplingIvk>, ErrorCode> {
+        if receivers & RECEIVERS_SAPLING == 0 {
+            return Ok(None);
+        }
+        let sapling = self.sapling_key()?;
+        if scope != Scope::External {
+            return Err(ErrorCode::ScopeUnavailable);
+        }
+        Ok(Some(sapling))
+    }
+
+    // The key's Sapling IVK: `ReceiversUnavailable` if it has none, or the item's error if the
+    // key's Sapling item is malformed.
+    fn sapling_key(&self) -> Result<&SaplingIvk, ErrorCode> {
+        match &self.sapling {
+            Some(sapling) => sapling.as_ref().map_err(|code| *code),
+            None => Err(ErrorCode::ReceiversUnavailable),
+        }
+    }
+
+    // Same as `sapling_key`, for the transparent account key, which must also have a chain for
+    // `scope`: a UIVK's has the external chain only.
+    fn transparent_key(&self, scope: Scope) -> Result<&transparent::AccountPubKey, ErrorCode> {
+        let key = match &self.transparent {
+            Some(key) => key.as_ref().map_err(|code| *code)?,
+            None => return Err(ErrorCode::ReceiversUnavailable),
+        };
+        if scope == Scope::Internal && !key.has_internal() {
+            return Err(ErrorCode::ScopeUnavailable);
+        }
+        Ok(key)
+    }
+
+    // The transparent account key to derive with, if `receivers` asks for a P2PKH receiver.
+    fn transparent_for(
+        &self,
+        scope: Scope,
+        receivers: u32,
+    ) -> Result<Option<&transparent::AccountPubKey>, ErrorCode> {
+        if receivers & RECEIVERS_P2PKH == 0 {
+            return Ok(None);
+        }
+        self.transparent_key(scope).map(Some)
+    }
+
+    /// Derives the standalone transparent (P2PKH) address at the first address index at or after
+    /// `index` that BIP-32 can derive, and returns that index too. Indices must stay below 2^31.
+    fn derive_transparent(&self, scope: Scope, index: u128) -> Result<(u128, String), ErrorCode> {
+        let mut index = index;
+        loop {
+            if let Some(address) = self.transparent_at(scope, index)? {
+                return Ok((index, address));
+            }
+            index += 1;
+        }
+    }
+
+    /// Like `derive_transparent`, but at exactly `index`: `None` if BIP-32 cannot derive it.
+    fn transparent_at(&self, scope: Scope, index: u128) -> Result<Option<String>, ErrorCode> {
+        let key = self.transparent_key(scope)?;
+        let Some(receiver) = key.p2pkh(scope == Scope::Internal, transparent_index(index)?) else {
+            return Ok(None);
+        };
+        let prefix = match self.ua_hrp {
+            HRP_JUNO_UA => transparent::P2PKH_PREFIX_MAINNET,
+            _ => transparent::P2PKH_PREFIX_TESTNET,
+        };
+        Ok(Some(transparent::encode_p2pkh(prefix, &receiver)))
+    }
+
+    fn fingerprint(&self) -> [u8; FVK_FINGERPRINT_LEN] {
+        match &self.fvk {
+            Some(fvk) => fvk_fingerprint(fvk),
+            None => ivk_fingerprint(&self.ivk),
+        }
+    }
+
+    /// Encodes the key's external-scope IVKs as a UIVK for the key's network: the Orchard IVK,
+    /// plus the Sapling IVK and the transparent external chain key if the key has those items.
+    /// A malformed Sapling or transparent item fails with its error rather than being left out,
+    /// since a UIVK without it would miss the funds sent to its receivers.
+    fn uivk(&self) -> Result<String, ErrorCode> {
+        let (uivk_hrp, _) = UIVK_HRP_TO_UA_HRP
+            .into_iter()
+            .find(|(_, ua_hrp)| *ua_hrp == self.ua_hrp)
+            .ok_or(ErrorCode::Internal)?;
+        let transparent = match &self.transparent {
+            Some(key) => Some(key.as_ref().map_err(|code| *code)?.external_ivk_bytes()),
+            None => None,
+        };
+        let sapling = match &self.sapling {
+            Some(ivk) => Some(ivk.as_ref().map_err(|code| *code)?.to_bytes()),
+            None => None,
+        };
+        let orchard = self.ivk.to_bytes();
+
+        // Items are encoded in ascending typecode order, as ZIP-316 requires.
+        let mut items = Vec::with_capacity(3);
+        if let Some(value) = &transparent {
+            items.push(zip316::Tlv {
+                typecode: TYPECODE_P2PKH,
+                value,
+            });
+        }
+        if let Some(value) = &sapling {
+            items.push(zip316::Tlv {
+                typecode: TYPECODE_SAPLING,
+                value,
+            });
+        }
+        items.push(zip316::Tlv {
+            typecode: TYPECODE_ORCHARD,
+            value: &orchard,
+        });
+        zip316::encode_tlv_container(uivk_hrp, &items).map_err(|_| ErrorCode::Internal)
+    }
+
+    /// Finds the scope and diversifier index at which this key derives `address`.
+    ///
+    /// The IVK's diversifier key decrypts the receiver's diversifier straight back to its index,
+    /// which is then confirmed by re-deriving the receiver, so this costs two derivations at most
+    /// rather than a scan. An address on another network is never owned, nor is one whose Sapling
+    /// or P2PKH receiver (if any) this key does not derive at the same scope and index. Checking
+    /// such a receiver against a malformed item of the key reports that item's error.
+    fn index_of(&self, address: &str) -> Result<(Scope, u128), ErrorCode> {
+        let (ua_hrp, items) = parse_address(address)?;
+        let raw = orchard_receiver(&items)?;
+        if ua_hrp != self.ua_hrp {
+            return Err(ErrorCode::AddressNotOwned);
+        }
+        let addr = Option::from(orchard::Address::from_raw_address_bytes(&raw))
+            .ok_or(ErrorCode::AddressReceiverInvalid)?;
+
+        let found = [Scope::External, Scope::Internal]
+            .into_iter()
+            .find_map(|scope| {
+                let index = self.ivk(scope).ok()?.diversifier_index(&addr)?;
+                Some((scope, index_from_le_bytes(index.as_bytes())))
+            })
+            .ok_or(ErrorCode::AddressNotOwned)?;
+
+        if let Some((_, sapling)) = items.iter().find(|(t, _)| *t == TYPECODE_SAPLING) {
+            let (scope, index) = found;
+            let ours = match (scope, &self.sapling) {
+                (_, Some(Err(code))) => return Err(*code),
+                (Scope::External, Some(Ok(ivk))) => ivk
+                    .address_at(diversifier_index(index)?)
+                    .is_some_and(|a| a.to_bytes()[..] == sapling[..]),
+                _ => false,
+            };
+            if !ours {
+                return Err(ErrorCode::AddressNotOwned);
+            }
+        }
+        if let Some((_, p2pkh)) = items.iter().find(|(t, _)| *t == TYPECODE_P2PKH) {
+            let (scope, index) = found;
+            let ours = match (&self.transparent, transparent_index(index)) {
+                (Some(Err(code)), _) => return Err(*code),
+                (Some(Ok(key)), Ok(index)) => key
+                    .p2pkh(scope == Scope::Internal, index)
+                    .is_some_and(|receiver| receiver[..] == p2pkh[..]),
+                _ => false,
+            };
+            if !ours {
+                return Err(ErrorCode::AddressNotOwned);
+            }
+        }
+        Ok(found)
+    }
+}
+
+/// The ZIP-32 Orchard FVK fingerprint: BLAKE2b-256 of `ak || nk || rivk`. It identifies the key
+/// without revealing it, and does not depend on the network the UFVK is encoded for.
+fn fvk_fingerprint(fvk: &FullViewingKey) -> [u8; FVK_FINGERPRINT_LEN] {
+    let hash = blake2b_simd::Params::new()
+        .hash_length(FVK_FINGERPRINT_LEN)
+        .personal(FVK_FINGERPRINT_PERSONALIZATION)
+        .hash(&fvk.to_bytes());
+    let mut out = [0u8; FVK_FINGERPRINT_LEN];
+    out.copy_from_slice(hash.as_bytes());
+    out
+}
+
+/// Fingerprint of a key known only by its IVK: BLAKE2b-256 of `dk || ivk`. It differs from the
+/// FVK fingerprint of the UFVK the IVK came from, which cannot be recovered from the IVK.
+fn ivk_fingerprint(ivk: &IncomingViewingKey) -> [u8; FVK_FINGERPRINT_LEN] {
+    let hash = blake2b_simd::Params::new()
+        .hash_length(FVK_FINGERPRINT_LEN)
+        .personal(IVK_FINGERPRINT_PERSONALIZATION)
+        .hash(&ivk.to_bytes());
+    let mut out = [0u8; FVK_FINGERPRINT_LEN];
+    out.copy_from_slice(hash.as_bytes());
+    out
+}
+
+/// Decodes a UFVK's container for inspection: its UA HRP and the typecode and length of every
+/// item, in encoding order. Unlike `Key::parse`, a missing or malformed Orchard FVK is not an
+/// error here; it is reported as the third value. That value is about the Orchard item only (and
+/// repeated items): Sapling and transparent items do not affect it.
+fn inspect_ufvk(
+    ufvk: &str,
+) -> Result<(&'static str, Vec<(u64, usize)>, Option<ErrorCode>), ErrorCode> {
+    let ufvk = ufvk.trim();
+    if ufvk.is_empty() {
+        return Err(ErrorCode::UfvkEmpty);
+    }
+
+    for (ufvk_hrp, ua_hrp) in UFVK_HRP_TO_UA_HRP {
+        match zip316::decode_tlv_container(ufvk_hrp, ufvk) {
+            Ok(items) => {
+                let items = items
+                    .into_iter()
+                    .map(|(typecode, value)| (typecode, value.len()))
+                    .collect();
+                let fvk_err = decode_ufvk_items(ufvk)
+                    .and_then(|items| decode_orchard_fvk(items.orchard.as_deref()))
+                    .err();
+                return Ok((ua_hrp, items, fvk_err));
+            }
+            Err(zip316::Zip316Error::HrpMismatch) => continue,
+            Err(e) => return Err(map_zip316_err(e)),
+        }
+    }
+    Err(ErrorCode::UfvkHrpMismatch)
+}
+
+/// The keys of a decoded UFVK. The Sapling and transparent items hold their decoding error if
+/// they are malformed.
+struct Ufvk {
+    ua_hrp: &'static str,
+    fvk: FullViewingKey,
+    sapling: Option<Result<DiversifiableFullViewingKey, ErrorCode>>,
+    transparent: Option<Result<transparent::AccountPubKey, ErrorCode>>,
+}
+
+/// The values of the items of a UFVK this library knows, each seen at most once.
+struct UfvkItems {
+    ua_hrp: &'static str,
+    orchard: Option<Vec<u8>>,
+    sapling: Option<Vec<u8>>,
+    transparent: Option<Vec<u8>>,
+}
+
+/// Decodes a UFVK's container and picks out its Orchard, Sapling and P2PKH items, without
+/// checking their values. A repeated item is `UfvkTlvInvalid`; other items are ignored.
+fn decode_ufvk_items(ufvk: &str) -> Result<UfvkItems, ErrorCode> {
     let ufvk = ufvk.trim();
     if ufvk.is_empty() {
         return Err(ErrorCode::UfvkEmpty);
@@ -66,28 +698,25 @@ fn decode_fvk_from_ufvk(ufvk: &str) -> Result<(&'static str, FullViewingKey), Er
     for (ufvk_hrp, ua_hrp) in UFVK_HRP_TO_UA_HRP {
         match zip316::decode_tlv_container(ufvk_hrp, ufvk) {
             Ok(items) => {
-                let mut orchard_value: Option<Vec<u8>> = None;
+                let mut known = UfvkItems {
+                    ua_hrp,
+                    orchard: None,
+                    sapling: None,
+                    transparent: None,
+                };
                 for (typecode, value) in items {
-                    if typecode != TYPECODE_ORCHARD {
-                        continue;
-                    }
-                    if orchard_value.is_some() {
+                    let slot = match typecode {
+                        TYPECODE_ORCHARD => &mut known.orchard,
+                        TYPECODE_SAPLING => &mut known.sapling,
+                        TYPECODE_P2PKH => &mut known.transparent,
+                        _ => continue,
+                    };
+                    if slot.is_some() {
                         return Err(ErrorCode::UfvkTlvInvalid);
                     }
-                    orchard_value = Some(value);
+                    *slot = Some(value);
                 }
-
-                let value = orchard_value.ok_or(ErrorCode::UfvkTypecodeUnsupported)?;
-
-                if value.len() != 96 {
-                    return Err(ErrorCode::UfvkValueLenInvalid);
-                }
-                let fvk_bytes: [u8; 96] =
-                    value.try_into().map_err(|_| ErrorCode::UfvkValueLenInvalid)?;
-
-                let fvk = FullViewingKey::from_bytes(&fvk_bytes)
-                    .ok_or(ErrorCode::UfvkFvkBytesInvalid)?;
-                return Ok((ua_hrp, fvk));
+                return Ok(known);
             }
             Err(zip316::Zip316Error::HrpMismatch) => {
                 last_err = Some(zip316::Zip316Error::HrpMismatch);
@@ -103,48 +732,421 @@ fn decode_fvk_from_ufvk(ufvk: &str) -> Result<(&'static str, FullViewingKey), Er
     Err(ErrorCode::UfvkInvalidBech32m)
 }
 
-fn derive_address_from_fvk(
-    fvk: &FullViewingKey,
+/// Decodes a UFVK's Orchard item, which every UFVK this library accepts must have.
+fn decode_orchard_fvk(value: Option<&[u8]>) -> Result<FullViewingKey, ErrorCode> {
+    let value = value.ok_or(ErrorCode::UfvkTypecodeUnsupported)?;
+    let fvk_bytes: [u8; 96] = value.try_into().map_err(|_| ErrorCode::UfvkValueLenInvalid)?;
+    FullViewingKey::from_bytes(&fvk_bytes).ok_or(ErrorCode::UfvkFvkBytesInvalid)
+}
+
+/// Decodes a UFVK's Orchard FVK and, if it has them, its Sapling key and transparent account key.
+/// The Orchard item must be valid. The others are decoded here but only checked by the calls that
+/// use them, so that a malformed Sapling or P2PKH item does not stop Orchard-only derivation.
+/// Other items are ignored.
+fn decode_fvk_from_ufvk(ufvk: &str) -> Result<Ufvk, ErrorCode> {
+    let items = decode_ufvk_items(ufvk)?;
+    let fvk = decode_orchard_fvk(items.orchard.as_deref())?;
+
+    let sapling = items.sapling.map(|value| {
+        let dfvk_bytes: [u8; SAPLING_DFVK_LEN] =
+            value.try_into().map_err(|_| ErrorCode::UfvkValueLenInvalid)?;
+        DiversifiableFullViewingKey::from_bytes(&dfvk_bytes).ok_or(ErrorCode::UfvkSaplingInvalid)
+    });
+    let transparent = items.transparent.map(|value| {
+        let key_bytes: [u8; transparent::ACCOUNT_PUBKEY_LEN] =
+            value.try_into().map_err(|_| ErrorCode::UfvkValueLenInvalid)?;
+        transparent::AccountPubKey::from_bytes(&key_bytes).ok_or(ErrorCode::UfvkTransparentInvalid)
+    });
+    Ok(Ufvk {
+        ua_hrp: items.ua_hrp,
+        fvk,
+        sapling,
+        transparent,
+    })
+}
+
+/// The keys of a decoded UIVK, its Sapling and transparent items held as in `Ufvk`.
+struct Uivk {
+    ua_hrp: &'static str,
+    ivk: IncomingViewingKey,
+    sapling: Option<Result<SaplingIvk, ErrorCode>>,
+    transparent: Option<Result<transparent::AccountPubKey, ErrorCode>>,
+}
+
+// Same rules as `decode_fvk_from_ufvk`, and the same error codes, for a UIVK: its Orchard IVK
+// must be valid, and its Sapling IVK and transparent external chain key are checked by the calls
+// that use them.
+fn decode_ivk_from_uivk(uivk: &str) -> Result<Uivk, ErrorCode> {
+    let uivk = uivk.trim();
+    if uivk.is_empty() {
+        return Err(ErrorCode::UfvkEmpty);
+    }
+
+    for (uivk_hrp, ua_hrp) in UIVK_HRP_TO_UA_HRP {
+        match zip316::decode_tlv_container(uivk_hrp, uivk) {
+            Ok(items) => {
+                let mut orchard_value: Option<Vec<u8>> = None;
+                let mut sapling_value: Option<Vec<u8>> = None;
+                let mut transparent_value: Option<Vec<u8>> = None;
+                for (typecode, value) in items {
+                    let slot = match typecode {
+                        TYPECODE_ORCHARD => &mut orchard_value,
+                        TYPECODE_SAPLING => &mut sapling_value,
+                        TYPECODE_P2PKH => &mut transparent_value,
+                        _ => continue,
+                    };
+                    if slot.is_some() {
+                        return Err(ErrorCode::UfvkTlvInvalid);
+                    }
+                    *slot = Some(value);
+                }
+
+                let value = orchard_value.ok_or(ErrorCode::UfvkTypecodeUnsupported)?;
+                let ivk_bytes: [u8; ORCHARD_IVK_LEN] =
+                    value.try_into().map_err(|_| ErrorCode::UfvkValueLenInvalid)?;
+                let ivk = Option::from(IncomingViewingKey::from_bytes(&ivk_bytes))
+                    .ok_or(ErrorCode::UfvkFvkBytesInvalid)?;
+
+                let sapling = sapling_value.map(|value| {
+                    let ivk_bytes: [u8; SAPLING_IVK_LEN] =
+                        value.try_into().map_err(|_| ErrorCode::UfvkValueLenInvalid)?;
+                    Option::from(SaplingIvk::from_bytes(&ivk_bytes))
+                        .ok_or(ErrorCode::UfvkSaplingInvalid)
+                });
+                let transparent = transparent_value.map(|value| {
+                    let key_bytes: [u8; transparent::EXTERNAL_IVK_LEN] =
+                        value.try_into().map_err(|_| ErrorCode::UfvkValueLenInvalid)?;
+                    transparent::AccountPubKey::from_external_ivk_bytes(&key_bytes)
+                        .ok_or(ErrorCode::UfvkTransparentInvalid)
+                });
+                return Ok(Uivk {
+                    ua_hrp,
+                    ivk,
+                    sapling,
+                    transparent,
+                });
+            }
+            Err(zip316::Zip316Error::HrpMismatch) => continue,
+            Err(e) => return Err(map_zip316_err(e)),
+        }
+    }
+    Err(ErrorCode::UfvkHrpMismatch)
+}
+
+/// Derives the Orchard spending key of ZIP-32 account `account` of `seed`, at
+/// `m_Orchard/32'/JUNO_COIN_TYPE'/account'`, and encodes its FVK as an Orchard-only UFVK for the
+/// network with id `network`. ZIP-32 seeds are 32 to 252 bytes.
+fn keygen(
+    seed: &[u8],
+    account: u32,
+    network: u32,
+) -> Result<(String, [u8; ORCHARD_SPENDING_KEY_LEN]), ErrorCode> {
+    let hrp = ufvk_hrp_for_network_id(network)?;
+    if !(32..=252).contains(&seed.len()) {
+        return Err(ErrorCode::SeedInvalid);
+    }
+    let account = zip32::AccountId::try_from(account).map_err(|_| ErrorCode::AccountInvalid)?;
+    let sk = orchard::keys::SpendingKey::from_zip32_seed(seed, JUNO_COIN_TYPE, account)
+        .map_err(|_| ErrorCode::SpendingKeyInvalid)?;
+    let fvk = FullViewingKey::from(&sk);
+    let ufvk = zip316::encode_unified_container(hrp, TYPECODE_ORCHARD, &fvk.to_bytes())
+        .map_err(|_| ErrorCode::Internal)?;
+    Ok((ufvk, *sk.to_bytes()))
+}
+
+// Deriving the IVK from the FVK costs a Sinsemilla commitment (CommitIvk), which is far more
+// expensive than the per-index diversification. Callers derive it once and reuse it.
+fn derive_address_from_ivk(
+    ivk: &IncomingViewingKey,
     ua_hrp: &'static str,
     index: u32,
 ) -> Result<String, ErrorCode> {
-    let addr = fvk.address_at(index, Scope::External);
+    derive_address_and_receiver_from_ivk(ivk, ua_hrp, index.into()).map(|(address, _)| address)
+}
+
+fn derive_address_and_receiver_from_ivk(
+    ivk: &IncomingViewingKey,
+    ua_hrp: &'static str,
+    index: u128,
+) -> Result<(String, [u8; ORCHARD_RECEIVER_LEN]), ErrorCode> {
+    let addr = ivk.address_at(diversifier_index(index)?);
     let raw = addr.to_raw_address_bytes();
-    zip316::encode_unified_container(ua_hrp, TYPECODE_ORCHARD, &raw)
-        .map_err(|_| ErrorCode::Internal)
+    let address = zip316::encode_unified_container(ua_hrp, TYPECODE_ORCHARD, &raw)
+        .map_err(|_| ErrorCode::Internal)?;
+    Ok((address, raw))
+}
+
+/// Decodes a Juno unified address into its UA HRP and its items, in encoding order.
+///
+/// Beyond the container checks of `zip316::decode_tlv_container`, this rejects Zcash addresses,
+/// repeated typecodes, known receivers of the wrong length, Sapling and Orchard receivers that
+/// do not decode to a valid address (a bad diversifier or `pk_d`), addresses with no receiver of
+/// a known type, and the item sets ZIP-316 forbids: both a P2PKH and a P2SH receiver, or only
+/// transparent receivers. Unknown typecodes are returned as-is alongside known ones, as ZIP-316
+/// requires; an unknown item may be shielded, so it keeps an address from being transparent-only.
+fn parse_address(address: &str) -> Result<(&'static str, Vec<(u64, Vec<u8>)>), ErrorCode> {
+    let address = address.trim();
+    if address.is_empty() {
+        return Err(ErrorCode::AddressEmpty);
+    }
+
+    let hrp = zip316::decode_hrp(address).map_err(map_address_zip316_err)?;
+    let Some(ua_hrp) = UFVK_HRP_TO_UA_HRP
+        .iter()
+        .map(|(_, ua_hrp)| *ua_hrp)
+        .find(|ua_hrp| *ua_hrp == hrp)
+    else {
+        if ZCASH_UA_HRPS.contains(&hrp.as_str()) {
+            return Err(ErrorCode::AddressZcash);
+        }
+        return Err(ErrorCode::AddressHrpMismatch);
+    };
+
+    let items = zip316::decode_tlv_container(ua_hrp, address).map_err(map_address_zip316_err)?;
+    if items.is_empty() {
+        return Err(ErrorCode::AddressTlvInvalid);
+    }
+
+    let mut known = false;
+    for (i, (typecode, value)) in items.iter().enumerate() {
+        if items[..i].iter().any(|(seen, _)| seen == typecode) {
+            return Err(ErrorCode::AddressTypecodeDuplicate);
+        }
+        let want_len = match *typecode {
+            TYPECODE_P2PKH | TYPECODE_P2SH => 20,
+            TYPECODE_SAPLING | TYPECODE_ORCHARD => ORCHARD_RECEIVER_LEN,
+            _ => continue,
+        };
+        if value.len() != want_len {
+            return Err(ErrorCode::AddressValueLenInvalid);
+        }
+        if let Ok(raw) = <&[u8; ORCHARD_RECEIVER_LEN]>::try_from(value.as_slice()) {
+            if !shielded_receiver_valid(*typecode, raw) {
+                return Err(ErrorCode::AddressReceiverInvalid);
+            }
+        }
+        known = true;
+    }
+    if !known {
+        return Err(ErrorCode::AddressReceiversUnknown);
+    }
+    let is_transparent = |typecode: u64| typecode == TYPECODE_P2PKH || typecode == TYPECODE_P2SH;
+    if items.iter().all(|(typecode, _)| is_transparent(*typecode)) {
+        return Err(ErrorCode::AddressTransparentOnly);
+    }
+    if items.iter().filter(|(typecode, _)| is_transparent(*typecode)).count() > 1 {
+        return Err(ErrorCode::AddressP2pkhAndP2sh);
+    }
+    Ok((ua_hrp, items))
+}
+
+// Whether a raw Sapling or Orchard receiver decodes to an address funds can be sent to. Other
+// receivers are hashes, so any bytes of the right length are valid.
+fn shielded_receiver_valid(typecode: u64, raw: &[u8; ORCHARD_RECEIVER_LEN]) -> bool {
+    match typecode {
+        TYPECODE_SAPLING => sapling::PaymentAddress::from_bytes(raw).is_some(),
+        TYPECODE_ORCHARD => bool::from(orchard::Address::from_raw_address_bytes(raw).is_some()),
+        _ => true,
+    }
+}
+
+/// Decodes a standalone transparent P2PKH address (`t1...` / `tm...`) into the UA HRP of its
+/// network and its receiver. Testnet and regtest share a prefix, so `tm` addresses decode as
+/// testnet ones.
+fn parse_transparent_address(
+    address: &str,
+) -> Result<(&'static str, [u8; transparent::P2PKH_RECEIVER_LEN]), ErrorCode> {
+    let address = address.trim();
+    if address.is_empty() {
+        return Err(ErrorCode::AddressEmpty);
+    }
+    let (prefix, receiver) = transparent::decode_p2pkh(address).map_err(|err| match err {
+        transparent::DecodeError::Checksum => ErrorCode::AddressChecksumInvalid,
+        transparent::DecodeError::Invalid => ErrorCode::AddressTransparentInvalid,
+    })?;
+    match prefix {
+        transparent::P2PKH_PREFIX_MAINNET => Ok((HRP_JUNO_UA, receiver)),
+        transparent::P2PKH_PREFIX_TESTNET => Ok((HRP_JUNO_UA_TESTNET, receiver)),
+        _ => Err(ErrorCode::AddressTransparentInvalid),
+    }
+}
+
+/// Decodes a Juno unified address and returns its UA HRP and raw Orchard receiver.
+fn decode_orchard_receiver(
+    address: &str,
+) -> Result<(&'static str, [u8; ORCHARD_RECEIVER_LEN]), ErrorCode> {
+    let (ua_hrp, items) = parse_address(address)?;
+    Ok((ua_hrp, orchard_receiver(&items)?))
+}
+
+// Finds the Orchard receiver among a parsed address's items and checks it is a valid address.
+fn orchard_receiver(items: &[(u64, Vec<u8>)]) -> Result<[u8; ORCHARD_RECEIVER_LEN], ErrorCode> {
+    let value = items
+        .iter()
+        .find_map(|(typecode, value)| (*typecode == TYPECODE_ORCHARD).then_some(value))
+        .ok_or(ErrorCode::AddressTypecodeUnsupported)?;
+    // parse_address checked the length of every known receiver.
+    let raw: [u8; ORCHARD_RECEIVER_LEN] = value
+        .as_slice()
+        .try_into()
+        .map_err(|_| ErrorCode::AddressValueLenInvalid)?;
+
+    if bool::from(orchard::Address::from_raw_address_bytes(&raw).is_none()) {
+        return Err(ErrorCode::AddressReceiverInvalid);
+    }
+    Ok(raw)
 }
 
 fn derive_address_from_ufvk(ufvk: &str, index: u32) -> Result<String, ErrorCode> {
-    let (ua_hrp, fvk) = decode_fvk_from_ufvk(ufvk)?;
-    derive_address_from_fvk(&fvk, ua_hrp, index)
+    let Ufvk { ua_hrp, fvk, .. } = decode_fvk_from_ufvk(ufvk)?;
+    derive_address_from_ivk(&fvk.to_ivk(Scope::External), ua_hrp, index)
 }
 
 fn derive_addresses_from_ufvk(
     ufvk: &str,
     start: u32,
     count: u32,
+    jobs: u32,
 ) -> Result<Vec<String>, ErrorCode> {
+    check_batch_range(start, count)?;
+
+    let Ufvk { ua_hrp, fvk, .. } = decode_fvk_from_ufvk(ufvk)?;
+    derive_addresses_from_ivk(&fvk.to_ivk(Scope::External), ua_hrp, start, count, jobs)
+}
+
+// A P2PKH receiver's BIP-44 address index: the diversifier index itself, if it is not hardened.
+fn transparent_index(index: u128) -> Result<u32, ErrorCode> {
+    u32::try_from(index)
+        .ok()
+        .filter(|&index| index <= transparent::MAX_ADDRESS_INDEX)
+        .ok_or(ErrorCode::RangeOverflow)
+}
+
+fn diversifier_index(index: u128) -> Result<DiversifierIndex, ErrorCode> {
+    if index > MAX_DIVERSIFIER_INDEX {
+        return Err(ErrorCode::RangeOverflow);
+    }
+    let mut bytes = [0u8; DIVERSIFIER_INDEX_LEN];
+    bytes.copy_from_slice(&index.to_le_bytes()[..DIVERSIFIER_INDEX_LEN]);
+    Ok(DiversifierIndex::from(bytes))
+}
+
+fn index_from_le_bytes(bytes: &[u8; DIVERSIFIER_INDEX_LEN]) -> u128 {
+    let mut wide = [0u8; 16];
+    wide[..DIVERSIFIER_INDEX_LEN].copy_from_slice(bytes);
+    u128::from_le_bytes(wide)
+}
+
+/// Like `check_batch_range`, over the full 88-bit index space: the last index of the batch
+/// must not exceed 2^88 - 1.
+fn check_index_range(start: u128, count: u32) -> Result<(), ErrorCode> {
     if count == 0 {
         return Err(ErrorCode::CountZero);
     }
     if count > MAX_BATCH_COUNT {
         return Err(ErrorCode::CountTooLarge);
     }
+    if start > MAX_DIVERSIFIER_INDEX - (count as u128 - 1) {
+        return Err(ErrorCode::RangeOverflow);
+    }
+    Ok(())
+}
 
-    let end_exclusive = start.checked_add(count).ok_or(ErrorCode::RangeOverflow)?;
-
-    let (ua_hrp, fvk) = decode_fvk_from_ufvk(ufvk)?;
-    let mut out = Vec::with_capacity(count as usize);
-    for index in start..end_exclusive {
-        out.push(derive_address_from_fvk(&fvk, ua_hrp, index)?);
+/// Checks a batch of `count` indices from `start`: the last index of the batch, not the
+/// exclusive end, must fit in a u32, so a batch may end at 2^32 - 1.
+fn check_batch_range(start: u32, count: u32) -> Result<(), ErrorCode> {
+    if count == 0 {
+        return Err(ErrorCode::CountZero);
+    }
+    if count > MAX_BATCH_COUNT {
+        return Err(ErrorCode::CountTooLarge);
     }
+    start.checked_add(count - 1).ok_or(ErrorCode::RangeOverflow)?;
+    Ok(())
+}
+
+fn derive_addresses_from_ivk(
+    ivk: &IncomingViewingKey,
+    ua_hrp: &'static str,
+    start: u32,
+    count: u32,
+    jobs: u32,
+) -> Result<Vec<String>, ErrorCode> {
+    check_batch_range(start, count)?;
+
+    let mut out = vec![String::new(); count as usize];
+    derive_parallel(start.into(), jobs, &mut out, |index, slot| {
+        *slot = derive_address_and_receiver_from_ivk(ivk, ua_hrp, index)?.0;
+        Ok(())
+    })?;
     Ok(out)
 }
 
+/// Calls `f(start + i, &mut slots[i])` for every slot, splitting the slots into contiguous
+/// chunks across up to `jobs` threads (0 = one per available core). Each slot is written by
+/// exactly one call, so the result is identical to the sequential derivation.
+fn derive_parallel<T, F>(start: u128, jobs: u32, slots: &mut [T], f: F) -> Result<(), ErrorCode>
+where
+    T: Send,
+    F: Fn(u128, &mut T) -> Result<(), ErrorCode> + Sync,
+{
+    let count = u32::try_from(slots.len()).map_err(|_| ErrorCode::CountTooLarge)?;
+    let jobs = effective_jobs(jobs, count);
+    if jobs <= 1 {
+        return fill_slots(start, slots, &f);
+    }
+
+    let chunk = count.div_ceil(jobs) as usize;
+    let f = &f;
+    let results = std::thread::scope(|scope| {
+        let handles = slots
+            .chunks_mut(chunk)
+            .enumerate()
+            .map(|(i, slots)| {
+                let lo = start + (i * chunk) as u128;
+                scope.spawn(move || fill_slots(lo, slots, f))
+            })
+            .collect::<Vec<_>>();
+        handles
+            .into_iter()
+            .map(|h| h.join().unwrap_or(Err(ErrorCode::Internal)))
+            .collect::<Vec<_>>()
+    });
+    results.into_iter().collect()
+}
+
+fn fill_slots<T, F>(start: u128, slots: &mut [T], f: &F) -> Result<(), ErrorCode>
+where
+    F: Fn(u128, &mut T) -> Result<(), ErrorCode>,
+{
+    for (i, slot) in slots.iter_mut().enumerate() {
+        f(start + i as u128, slot)?;
+    }
+    Ok(())
+}
+
+fn effective_jobs(requested: u32, count: u32) -> u32 {
+    // wasm32-unknown-unknown has no threads: spawning one panics.
+    if cfg!(target_arch = "wasm32") {
+        return 1;
+    }
+    let jobs = if requested == 0 {
+        std::thread::available_parallelism()
+            .map(|n| n.get() as u32)
+            .unwrap_or(1)
+    } else {
+        requested
+    };
+    jobs.min(MAX_BATCH_JOBS)
+        .min(count.div_ceil(MIN_ADDRESSES_PER_JOB))
+        .max(1)
+}
+
 fn map_zip316_err(e: zip316::Zip316Error) -> ErrorCode {
     use zip316::Zip316Error;
     match e {
         Zip316Error::Bech32DecodeFailed
+        | Zip316Error::ChecksumInvalid
         | Zip316Error::InvalidHrp
         | Zip316Error::PayloadTooShort
         | Zip316Error::PaddingInvalid
@@ -155,6 +1157,20 @@ fn map_zip316_err(e: zip316::Zip316Error) -> ErrorCode {
     }
 }
 
+fn map_address_zip316_err(e: zip316::Zip316Error) -> ErrorCode {
+    match e {
+        zip316::Zip316Error::ChecksumInvalid => return ErrorCode::AddressChecksumInvalid,
+        zip316::Zip316Error::PaddingInvalid => return ErrorCode::AddressPaddingInvalid,
+        _ => {}
+    }
+    match map_zip316_err(e) {
+        ErrorCode::UfvkInvalidBech32m => ErrorCode::AddressInvalidBech32m,
+        ErrorCode::UfvkHrpMismatch => ErrorCode::AddressHrpMismatch,
+        ErrorCode::UfvkTlvInvalid => ErrorCode::AddressTlvInvalid,
+        code => code,
+    }
+}
+
 #[derive(Serialize)]
 #[serde(tag = "status", rename_all = "snake_case")]
 enum DeriveResponse {
@@ -173,6 +1189,13 @@ enum BatchResponse {
     Err { error: String },
 }
 
+#[derive(Serialize)]
+#[serde(tag = "status", rename_all = "snake_case")]
+enum KeyParseResponse {
+    Ok { network: &'static str },
+    Err { error: String },
+}
+
 fn to_c_string<T: Serialize>(v: &T) -> *mut c_char {
     let json = serde_json::to_string(v)
         .unwrap_or_else(|_| r#"{"status":"err","error":"internal"}"#.to_string());
@@ -211,6 +1234,16 @@ pub extern "C" fn juno_addrgen_batch_json(
     ufvk_utf8: *const c_char,
     start: u32,
     count: u32,
+) -> *mut c_char {
+    juno_addrgen_batch_parallel_json(ufvk_utf8, start, count, 1)
+}
+
+#[no_mangle]
+pub extern "C" fn juno_addrgen_batch_parallel_json(
+    ufvk_utf8: *const c_char,
+    start: u32,
+    count: u32,
+    jobs: u32,
 ) -> *mut c_char {
     let res = std::panic::catch_unwind(|| {
         if ufvk_utf8.is_null() {
@@ -220,7 +1253,7 @@ pub extern "C" fn juno_addrgen_batch_json(
         }
 
         let ufvk = unsafe { std::ffi::CStr::from_ptr(ufvk_utf8) }.to_string_lossy();
-        match derive_addresses_from_ufvk(&ufvk, start, count) {
+        match derive_addresses_from_ufvk(&ufvk, start, count, jobs) {
             Ok(addresses) => BatchResponse::Ok {
                 start,
                 count,
@@ -241,9 +1274,133 @@ pub extern "C" fn juno_addrgen_batch_json(
 }
 
 #[no_mangle]
-pub extern "C" fn juno_addrgen_string_free(s: *mut c_char) {
-    if s.is_null() {
-        return;
+pub extern "C" fn juno_addrgen_key_parse_json(
+    ufvk_utf8: *const c_char,
+    key_out: *mut *mut Key,
+) -> *mut c_char {
+    let res = std::panic::catch_unwind(|| {
+        if key_out.is_null() {
+            return KeyParseResponse::Err {
+                error: ErrorCode::Internal.as_str().to_string(),
+            };
+        }
+        unsafe { *key_out = std::ptr::null_mut() };
+
+        if ufvk_utf8.is_null() {
+            return KeyParseResponse::Err {
+                error: ErrorCode::UfvkEmpty.as_str().to_string(),
+            };
+        }
+
+        let ufvk = unsafe { std::ffi::CStr::from_ptr(ufvk_utf8) }.to_string_lossy();
+        match Key::parse(&ufvk) {
+            Ok(key) => {
+                let network = key.network();
+                unsafe { *key_out = Box::into_raw(Box::new(key)) };
+                KeyParseResponse::Ok { network }
+            }
+            Err(code) => KeyParseResponse::Err {
+                error: code.as_str().to_string(),
+            },
+        }
+    });
+
+    match res {
+        Ok(v) => to_c_string(&v),
+        Err(_) => to_c_string(&KeyParseResponse::Err {
+            error: ErrorCode::Internal.as_str().to_string(),
+        }),
+    }
+}
+
+#[no_mangle]
+pub extern "C" fn juno_addrgen_key_derive_json(key: *const Key, index: u32) -> *mut c_char {
+    let res = std::panic::catch_unwind(|| {
+        let key = match unsafe { key.as_ref() } {
+            Some(key) => key,
+            None => {
+                return DeriveResponse::Err {
+                    error: ErrorCode::Internal.as_str().to_string(),
+                }
+            }
+        };
+
+        match key.derive(index) {
+            Ok(address) => DeriveResponse::Ok { address },
+            Err(code) => DeriveResponse::Err {
+                error: code.as_str().to_string(),
+            },
+        }
+    });
+
+    match res {
+        Ok(v) => to_c_string(&v),
+        Err(_) => to_c_string(&DeriveResponse::Err {
+            error: ErrorCode::Internal.as_str().to_string(),
+        }),
+    }
+}
+
+#[no_mangle]
+pub extern "C" fn juno_addrgen_key_batch_json(
+    key: *const Key,
+    start: u32,
+    count: u32,
+) -> *mut c_char {
+    juno_addrgen_key_batch_parallel_json(key, start, count, 1)
+}
+
+#[no_mangle]
+pub extern "C" fn juno_addrgen_key_batch_parallel_json(
+    key: *const Key,
+    start: u32,
+    count: u32,
+    jobs: u32,
+) -> *mut c_char {
+    let res = std::panic::catch_unwind(|| {
+        let key = match unsafe { key.as_ref() } {
+            Some(key) => key,
+            None => {
+                return BatchResponse::Err {
+                    error: ErrorCode::Internal.as_str().to_string(),
+                }
+            }
+        };
+
+        match key.derive_batch(start, count, jobs) {
+            Ok(addresses) => BatchResponse::Ok {
+                start,
+                count,
+                addresses,
+            },
+            Err(code) => BatchResponse::Err {
+                error: code.as_str().to_string(),
+            },
+        }
+    });
+
+    match res {
+        Ok(v) => to_c_string(&v),
+        Err(_) => to_c_string(&BatchResponse::Err {
+            error: ErrorCode::Internal.as_str().to_string(),
+        }),
+    }
+}
+
+#[no_mangle]
+pub extern "C" fn juno_addrgen_key_free(key: *mut Key) {
+    if key.is_null() {
+        return;
+    }
+    unsafe {
+        drop(Box::from_raw(key));
+    }
+}
+
+#[no_mangle]
+pub extern "C" fn juno_addrgen_string_free(s: *mut c_char) {
+    if s.is_null() {
+        return;
     }
     unsafe {
         drop(std::ffi::CString::from_raw(s));
@@ -323,10 +1480,33 @@ mod tests {
                 .expect("ufvk");
 
         let single = derive_address_from_ufvk(&ufvk, 5).expect("single");
-        let batch = derive_addresses_from_ufvk(&ufvk, 5, 1).expect("batch");
+        let batch = derive_addresses_from_ufvk(&ufvk, 5, 1, 1).expect("batch");
         assert_eq!(batch, vec![single]);
     }
 
+    #[test]
+    fn batch_with_shared_ivk_matches_per_index_fvk_derivation() {
+        let seed = [9u8; 64];
+        let account = AccountId::try_from(0).expect("account");
+        let sk =
+            orchard::keys::SpendingKey::from_zip32_seed(&seed, JUNO_COIN_TYPE, account).expect("sk");
+        let fvk = FullViewingKey::from(&sk);
+
+        let ufvk =
+            zip316::encode_unified_container(HRP_JUNO_UFVK, TYPECODE_ORCHARD, &fvk.to_bytes())
+                .expect("ufvk");
+
+        let batch = derive_addresses_from_ufvk(&ufvk, 1000, 16, 1).expect("batch");
+        for (i, got) in batch.iter().enumerate() {
+            let raw = fvk
+                .address_at(1000 + i as u32, Scope::External)
+                .to_raw_address_bytes();
+            let want = zip316::encode_unified_container(HRP_JUNO_UA, TYPECODE_ORCHARD, &raw)
+                .expect("want");
+            assert_eq!(got, &want);
+        }
+    }
+
     #[test]
     fn derives_from_multi_tlv_ufvk_with_orchard_not_first() {
         let seed = [7u8; 64];
@@ -362,6 +1542,78 @@ mod tests {
         assert_eq!(got, expected);
     }
 
+    #[test]
+    fn inspect_reports_items_and_fvk_status() {
+        let seed = [7u8; 64];
+        let account = AccountId::try_from(0).expect("account");
+        let sk =
+            orchard::keys::SpendingKey::from_zip32_seed(&seed, JUNO_COIN_TYPE, account).expect("sk");
+        let fvk_bytes = FullViewingKey::from(&sk).to_bytes();
+        let extra = [1u8, 2u8, 3u8];
+
+        let encode = |hrp: &str, fvk_value: &[u8]| {
+            zip316::encode_tlv_container(
+                hrp,
+                &[
+                    zip316::Tlv {
+                        typecode: 0xdead,
+                        value: &extra,
+                    },
+                    zip316::Tlv {
+                        typecode: TYPECODE_ORCHARD,
+                        value: fvk_value,
+                    },
+                ],
+            )
+            .expect("ufvk")
+        };
+
+        let ufvk = encode(HRP_JUNO_UFVK, &fvk_bytes);
+        let (ua_hrp, items, fvk_err) = inspect_ufvk(&ufvk).expect("inspect");
+        assert_eq!(ua_hrp, HRP_JUNO_UA);
+        assert_eq!(items, vec![(0xdead, 3), (TYPECODE_ORCHARD, 96)]);
+        assert!(fvk_err.is_none());
+
+        let (_, items, fvk_err) =
+            inspect_ufvk(&encode(HRP_JUNO_UFVK, &fvk_bytes[..95])).expect("inspect");
+        assert_eq!(items[1], (TYPECODE_ORCHARD, 95));
+        assert!(matches!(fvk_err, Some(ErrorCode::UfvkValueLenInvalid)));
+
+        // Malformed Sapling and P2PKH items do not make the Orchard FVK unusable.
+        let mixed = zip316::encode_tlv_container(
+            HRP_JUNO_UFVK,
+            &[
+                zip316::Tlv {
+                    typecode: TYPECODE_P2PKH,
+                    value: &[0u8; transparent::ACCOUNT_PUBKEY_LEN],
+                },
+                zip316::Tlv {
+                    typecode: TYPECODE_SAPLING,
+                    value: &[0xffu8; SAPLING_DFVK_LEN],
+                },
+                zip316::Tlv {
+                    typecode: TYPECODE_ORCHARD,
+                    value: &fvk_bytes,
+                },
+            ],
+        )
+        .expect("ufvk");
+        let (_, items, fvk_err) = inspect_ufvk(&mixed).expect("inspect");
+        assert_eq!(items.len(), 3);
+        assert!(fvk_err.is_none());
+        assert!(Key::parse(&mixed).is_ok());
+
+        // The fingerprint identifies the FVK, whatever network it is encoded for.
+        let mainnet = Key::parse(&ufvk).expect("mainnet key");
+        let testnet = Key::parse(&encode(HRP_JUNO_UFVK_TESTNET, &fvk_bytes)).expect("testnet key");
+        assert_eq!(mainnet.fingerprint(), testnet.fingerprint());
+        let other = FullViewingKey::from(
+            &orchard::keys::SpendingKey::from_zip32_seed(&[8u8; 64], JUNO_COIN_TYPE, account)
+                .expect("sk"),
+        );
+        assert_ne!(mainnet.fingerprint(), fvk_fingerprint(&other));
+    }
+
     #[test]
     fn rejects_multi_tlv_ufvk_with_duplicate_orchard_items() {
         let seed = [7u8; 64];
@@ -387,6 +1639,647 @@ mod tests {
         .expect("ufvk");
 
         let err = derive_address_from_ufvk(&ufvk, 0).expect_err("expected error");
-        assert_eq!(err.as_str(), ErrorCode::UfvkTlvInvalid.as_str());
+        assert_eq!(err, ErrorCode::UfvkTlvInvalid);
+    }
+
+    #[test]
+    fn key_matches_one_shot_derivation() {
+        let seed = [9u8; 64];
+        let account = AccountId::try_from(0).expect("account");
+        let sk =
+            orchard::keys::SpendingKey::from_zip32_seed(&seed, JUNO_COIN_TYPE, account).expect("sk");
+        let fvk = FullViewingKey::from(&sk);
+
+        let ufvk = zip316::encode_unified_container(
+            HRP_JUNO_UFVK_TESTNET,
+            TYPECODE_ORCHARD,
+            &fvk.to_bytes(),
+        )
+        .expect("ufvk");
+
+        let key = Key::parse(&ufvk).expect("key");
+        assert_eq!(key.network(), "testnet");
+        assert_eq!(
+            key.derive(7).expect("key derive"),
+            derive_address_from_ufvk(&ufvk, 7).expect("one-shot derive")
+        );
+        assert_eq!(
+            key.derive_batch(3, 4, 1).expect("key batch"),
+            derive_addresses_from_ufvk(&ufvk, 3, 4, 1).expect("one-shot batch")
+        );
+    }
+
+    // Parses a string returned by a `*_json` entry point, and frees it.
+    fn json(s: *mut c_char) -> serde_json::Value {
+        assert!(!s.is_null());
+        let v = serde_json::from_slice(unsafe { CStr::from_ptr(s) }.to_bytes()).expect("json");
+        juno_addrgen_string_free(s);
+        v
+    }
+
+    #[test]
+    fn json_api_matches_binary_derivation() {
+        let seed = [9u8; 64];
+        let account = AccountId::try_from(0).expect("account");
+        let sk =
+            orchard::keys::SpendingKey::from_zip32_seed(&seed, JUNO_COIN_TYPE, account).expect("sk");
+        let fvk = FullViewingKey::from(&sk);
+        let ufvk =
+            zip316::encode_unified_container(HRP_JUNO_UFVK, TYPECODE_ORCHARD, &fvk.to_bytes())
+                .expect("ufvk");
+        let key = Key::parse(&ufvk).expect("key");
+        let c_ufvk = std::ffi::CString::new(ufvk).expect("cstring");
+
+        let v = json(juno_addrgen_derive_json(c_ufvk.as_ptr(), 7));
+        assert_eq!(v["status"], "ok");
+        assert_eq!(v["address"], key.derive(7).expect("derive"));
+
+        let want = key.derive_batch(3, 4, 1).expect("batch");
+        let v = json(juno_addrgen_batch_json(c_ufvk.as_ptr(), 3, 4));
+        assert_eq!(v["status"], "ok");
+        assert_eq!((v["start"].clone(), v["count"].clone()), (3.into(), 4.into()));
+        assert_eq!(v["addresses"], serde_json::json!(want));
+        let v = json(juno_addrgen_batch_parallel_json(c_ufvk.as_ptr(), 3, 4, 2));
+        assert_eq!(v["addresses"], serde_json::json!(want));
+
+        let mut handle = std::ptr::null_mut();
+        let v = json(juno_addrgen_key_parse_json(c_ufvk.as_ptr(), &mut handle));
+        assert_eq!(v, serde_json::json!({"status": "ok", "network": "mainnet"}));
+        let v = json(juno_addrgen_key_derive_json(handle, 7));
+        assert_eq!(v["address"], key.derive(7).expect("derive"));
+        let v = json(juno_addrgen_key_batch_json(handle, 3, 4));
+        assert_eq!(v["addresses"], serde_json::json!(want));
+        let v = json(juno_addrgen_key_batch_parallel_json(handle, 3, 4, 2));
+        assert_eq!(v["addresses"], serde_json::json!(want));
+        juno_addrgen_key_free(handle);
+
+        let v = json(juno_addrgen_derive_json(std::ptr::null(), 0));
+        assert_eq!(v, serde_json::json!({"status": "err", "error": "ufvk_empty"}));
+        let v = json(juno_addrgen_batch_json(c_ufvk.as_ptr(), 0, 0));
+        assert_eq!(v, serde_json::json!({"status": "err", "error": "count_zero"}));
+        let v = json(juno_addrgen_key_parse_json(std::ptr::null(), &mut handle));
+        assert_eq!(v["error"], "ufvk_empty");
+        assert!(handle.is_null());
+        juno_addrgen_string_free(std::ptr::null_mut());
+    }
+
+    #[test]
+    fn internal_scope_matches_orchard_change_addresses() {
+        let seed = [7u8; 64];
+        let account = AccountId::try_from(0).expect("account");
+        let sk =
+            orchard::keys::SpendingKey::from_zip32_seed(&seed, JUNO_COIN_TYPE, account).expect("sk");
+        let fvk = FullViewingKey::from(&sk);
+
+        let ufvk =
+            zip316::encode_unified_container(HRP_JUNO_UFVK, TYPECODE_ORCHARD, &fvk.to_bytes())
+                .expect("ufvk");
+        let key = Key::parse(&ufvk).expect("key");
+
+        for index in [0u32, 1, 99, u32::MAX] {
+            let raw = fvk.address_at(index, Scope::Internal).to_raw_address_bytes();
+            let want = zip316::encode_unified_container(HRP_JUNO_UA, TYPECODE_ORCHARD, &raw)
+                .expect("addr");
+
+            let (address, receiver) = key
+                .derive_with_receiver(Scope::Internal, index.into())
+                .expect("internal derive");
+            assert_eq!(address, want);
+            assert_eq!(receiver, raw);
+            let external = key.derive_scoped(Scope::External, index.into()).expect("derive");
+            assert_ne!(address, external);
+        }
+    }
+
+    #[test]
+    fn index_of_recovers_scope_and_index() {
+        let seed = [7u8; 64];
+        let account = AccountId::try_from(0).expect("account");
+        let sk =
+            orchard::keys::SpendingKey::from_zip32_seed(&seed, JUNO_COIN_TYPE, account).expect("sk");
+        let fvk = FullViewingKey::from(&sk);
+
+        let ufvk =
+            zip316::encode_unified_container(HRP_JUNO_UFVK, TYPECODE_ORCHARD, &fvk.to_bytes())
+                .expect("ufvk");
+        let key = Key::parse(&ufvk).expect("key");
+
+        for scope in [Scope::External, Scope::Internal] {
+            for index in [0u128, 7, u32::MAX as u128 + 1, MAX_DIVERSIFIER_INDEX] {
+                let address = key.derive_scoped(scope, index).expect("derive");
+                assert_eq!(key.index_of(&address).expect("index_of"), (scope, index));
+            }
+        }
+
+        let other_sk =
+            orchard::keys::SpendingKey::from_zip32_seed(&[8u8; 64], JUNO_COIN_TYPE, account)
+                .expect("sk");
+        let raw = FullViewingKey::from(&other_sk)
+            .address_at(0u32, Scope::External)
+            .to_raw_address_bytes();
+        let foreign =
+            zip316::encode_unified_container(HRP_JUNO_UA, TYPECODE_ORCHARD, &raw).expect("addr");
+        assert!(matches!(key.index_of(&foreign), Err(ErrorCode::AddressNotOwned)));
+
+        // Same receiver, wrong network.
+        let own = key.derive_with_receiver(Scope::External, 0).expect("derive").1;
+        let testnet = zip316::encode_unified_container(HRP_JUNO_UA_TESTNET, TYPECODE_ORCHARD, &own)
+            .expect("addr");
+        assert!(matches!(key.index_of(&testnet), Err(ErrorCode::AddressNotOwned)));
+    }
+
+    #[test]
+    fn uivk_derives_the_external_addresses_of_its_ufvk() {
+        let seed = [7u8; 64];
+        let account = AccountId::try_from(0).expect("account");
+        let sk =
+            orchard::keys::SpendingKey::from_zip32_seed(&seed, JUNO_COIN_TYPE, account).expect("sk");
+        let fvk = FullViewingKey::from(&sk);
+
+        for (ufvk_hrp, _) in UFVK_HRP_TO_UA_HRP {
+            let ufvk = zip316::encode_unified_container(ufvk_hrp, TYPECODE_ORCHARD, &fvk.to_bytes())
+                .expect("ufvk");
+            let full = Key::parse(&ufvk).expect("ufvk key");
+
+            let uivk = full.uivk().expect("uivk");
+            let viewing = Key::parse(&uivk).expect("uivk key");
+            assert_eq!(viewing.network_id(), full.network_id());
+            assert_eq!(viewing.uivk().expect("uivk round trip"), uivk);
+
+            for index in [0u128, 7, MAX_DIVERSIFIER_INDEX] {
+                let address = full.derive_scoped(Scope::External, index).expect("derive");
+                assert_eq!(viewing.derive_scoped(Scope::External, index).expect("derive"), address);
+                assert_eq!(viewing.index_of(&address).expect("index_of"), (Scope::External, index));
+            }
+
+            assert!(matches!(
+                viewing.derive_scoped(Scope::Internal, 0),
+                Err(ErrorCode::ScopeUnavailable)
+            ));
+            let change = full.derive_scoped(Scope::Internal, 0).expect("derive");
+            assert!(matches!(viewing.index_of(&change), Err(ErrorCode::AddressNotOwned)));
+            assert_ne!(viewing.fingerprint(), full.fingerprint());
+        }
+
+        let uivk = Key::parse(
+            &zip316::encode_unified_container(HRP_JUNO_UFVK, TYPECODE_ORCHARD, &fvk.to_bytes())
+                .expect("ufvk"),
+        )
+        .expect("key")
+        .uivk()
+        .expect("uivk");
+        assert!(uivk.starts_with("jivk1"));
+        assert!(matches!(decode_fvk_from_ufvk(&uivk), Err(ErrorCode::UfvkHrpMismatch)));
+
+        let short = zip316::encode_unified_container(HRP_JUNO_UIVK, TYPECODE_ORCHARD, &[1u8; 63])
+            .expect("uivk");
+        assert!(matches!(Key::parse(&short), Err(ErrorCode::UfvkValueLenInvalid)));
+    }
+
+    #[test]
+    fn sapling_receivers_skip_invalid_diversifiers() {
+        let seed = [7u8; 64];
+        let account = AccountId::try_from(0).expect("account");
+        let sk =
+            orchard::keys::SpendingKey::from_zip32_seed(&seed, JUNO_COIN_TYPE, account).expect("sk");
+        let fvk = FullViewingKey::from(&sk);
+        let dfvk = sapling::zip32::ExtendedSpendingKey::master(&seed)
+            .to_diversifiable_full_viewing_key();
+        let ufvk = zip316::encode_tlv_container(
+            HRP_JUNO_UFVK,
+            &[
+                zip316::Tlv {
+                    typecode: TYPECODE_SAPLING,
+                    value: &dfvk.to_bytes(),
+                },
+                zip316::Tlv {
+                    typecode: TYPECODE_ORCHARD,
+                    value: &fvk.to_bytes(),
+                },
+            ],
+        )
+        .expect("ufvk");
+        let key = Key::parse(&ufvk).expect("key");
+        let both = RECEIVERS_ORCHARD | RECEIVERS_SAPLING;
+
+        // About half of all Sapling diversifiers are invalid; find the first one.
+        let skipped = (0u128..64)
+            .find(|&i| dfvk.address(diversifier_index(i).unwrap()).is_none())
+            .expect("invalid sapling diversifier");
+        assert!(matches!(key.derive_receivers_at(Scope::External, both, skipped), Ok(None)));
+
+        let (index, address, raw) = key
+            .derive_receivers(Scope::External, both, skipped)
+            .expect("derive");
+        assert!(index > skipped);
+        let sapling_addr = dfvk.address(diversifier_index(index).unwrap()).expect("valid");
+        let (_, items) = parse_address(&address).expect("parse");
+        assert_eq!(
+            items,
+            vec![
+                (TYPECODE_SAPLING, sapling_addr.to_bytes().to_vec()),
+                (TYPECODE_ORCHARD, raw.to_vec()),
+            ]
+        );
+        assert_eq!(raw, fvk.address_at(index as u64, Scope::External).to_raw_address_bytes());
+        assert_eq!(
+            key.derive_receivers_at(Scope::External, both, index).expect("at"),
+            Some((address.clone(), raw))
+        );
+        assert_eq!(key.index_of(&address).expect("index_of"), (Scope::External, index));
+
+        // Orchard-only requests are unaffected by the Sapling key.
+        let (orchard_index, orchard_only, _) = key
+            .derive_receivers(Scope::External, RECEIVERS_ORCHARD, skipped)
+            .expect("derive");
+        assert_eq!(orchard_index, skipped);
+        assert_eq!(orchard_only, derive_address_from_ufvk(&ufvk, skipped as u32).expect("addr"));
+
+        // Someone else's Sapling receiver next to our Orchard receiver is not ours.
+        let other = sapling::zip32::ExtendedSpendingKey::master(&[8u8; 64])
+            .to_diversifiable_full_viewing_key();
+        let (_, other_addr) = other.default_address();
+        let mixed = zip316::encode_tlv_container(
+            HRP_JUNO_UA,
+            &[
+                zip316::Tlv {
+                    typecode: TYPECODE_SAPLING,
+                    value: &other_addr.to_bytes(),
+                },
+                zip316::Tlv {
+                    typecode: TYPECODE_ORCHARD,
+                    value: &raw,
+                },
+            ],
+        )
+        .expect("mixed");
+        assert!(matches!(key.index_of(&mixed), Err(ErrorCode::AddressNotOwned)));
+
+        assert!(matches!(
+            key.derive_receivers(Scope::Internal, both, 0),
+            Err(ErrorCode::ScopeUnavailable)
+        ));
+        for bad in [0, RECEIVERS_SAPLING, both | 1 << TYPECODE_P2SH] {
+            assert!(matches!(
+                key.derive_receivers(Scope::External, bad, 0),
+                Err(ErrorCode::ReceiversInvalid)
+            ));
+        }
+        let orchard_ufvk =
+            zip316::encode_unified_container(HRP_JUNO_UFVK, TYPECODE_ORCHARD, &fvk.to_bytes())
+                .expect("ufvk");
+        assert!(matches!(
+            Key::parse(&orchard_ufvk).expect("key").derive_receivers(Scope::External, both, 0),
+            Err(ErrorCode::ReceiversUnavailable)
+        ));
+
+        let bad_sapling = zip316::encode_tlv_container(
+            HRP_JUNO_UFVK,
+            &[
+                zip316::Tlv {
+                    typecode: TYPECODE_SAPLING,
+                    value: &[0u8; SAPLING_DFVK_LEN],
+                },
+                zip316::Tlv {
+                    typecode: TYPECODE_ORCHARD,
+                    value: &fvk.to_bytes(),
+                },
+            ],
+        )
+        .expect("ufvk");
+        // A malformed Sapling item only fails the calls that need it.
+        let key = Key::parse(&bad_sapling).expect("orchard item is valid");
+        assert!(matches!(
+            key.derive_receivers(Scope::External, both, 0),
+            Err(ErrorCode::UfvkSaplingInvalid)
+        ));
+        let (_, address, _) = key
+            .derive_receivers(Scope::External, RECEIVERS_ORCHARD, 0)
+            .expect("orchard only");
+        let ivk = fvk.to_ivk(Scope::External);
+        assert_eq!(address, derive_address_from_ivk(&ivk, HRP_JUNO_UA, 0).expect("address"));
+        // Nor is it left out of the key's UIVK.
+        assert!(matches!(key.uivk(), Err(ErrorCode::UfvkSaplingInvalid)));
+    }
+
+    #[test]
+    fn keygen_matches_the_vectors_and_rejects_bad_input() {
+        let seed = [7u8; 64];
+        let (ufvk, sk) = keygen(&seed, 0, 1).expect("keygen");
+        let account = AccountId::try_from(0).expect("account");
+        let want = orchard::keys::SpendingKey::from_zip32_seed(&seed, JUNO_COIN_TYPE, account)
+            .expect("spending key");
+        assert_eq!(&sk, want.to_bytes());
+        // vectors/v1.json's UFVK.
+        let fvk = FullViewingKey::from(&want);
+        let v1 = zip316::encode_unified_container(HRP_JUNO_UFVK, TYPECODE_ORCHARD, &fvk.to_bytes())
+            .expect("ufvk");
+        assert_eq!(ufvk, v1);
+
+        let (regtest, _) = keygen(&seed, 0, 3).expect("regtest keygen");
+        assert!(regtest.starts_with("jviewregtest1"));
+        assert_ne!(keygen(&seed, 1, 1).expect("account 1").0, ufvk);
+
+        assert_eq!(keygen(&seed[..31], 0, 1), Err(ErrorCode::SeedInvalid));
+        assert_eq!(keygen(&[7u8; 253], 0, 1), Err(ErrorCode::SeedInvalid));
+        assert_eq!(keygen(&seed, 1 << 31, 1), Err(ErrorCode::AccountInvalid));
+        assert_eq!(keygen(&seed, 0, 0), Err(ErrorCode::Internal));
+    }
+
+    #[test]
+    fn spending_keys_match_pkg_orchard() {
+        // The pure-Go keygen (pkg/orchard, in addrgen_purego builds) derives spending keys too;
+        // its TestSpendingKeyFromSeed pins the same keys.
+        let seed = [7u8; 64];
+        for (account, want) in [
+            (0, "42124a673ea453515e9e595539acd51987d2c4456bae9aec60e4b22962644ad0"),
+            (1, "d6a6c5ffb369e3bcb5afddfd94bc8de24e8d5f7e66989934ec490c34e641ec95"),
+            (
+                (1 << 31) - 1,
+                "b67135bf1b4ceb98075736d93d83e930d62af5cea3ffc080cd8e204c44615776",
+            ),
+        ] {
+            let account = AccountId::try_from(account).expect("account");
+            let sk = orchard::keys::SpendingKey::from_zip32_seed(&seed, JUNO_COIN_TYPE, account)
+                .expect("sk");
+            let hex: String = sk.to_bytes().iter().map(|b| format!("{b:02x}")).collect();
+            assert_eq!(hex, want);
+        }
+    }
+
+    #[test]
+    fn sapling_receivers_match_vectors() {
+        // vectors/v1_sapling.json: the v1 keys plus the account's ZIP-32 Sapling key.
+        let seed = [7u8; 64];
+        let account = AccountId::try_from(0).expect("account");
+        let sk =
+            orchard::keys::SpendingKey::from_zip32_seed(&seed, JUNO_COIN_TYPE, account).expect("sk");
+        let fvk = FullViewingKey::from(&sk);
+        let dfvk = sapling::zip32::ExtendedSpendingKey::from_path(
+            &sapling::zip32::ExtendedSpendingKey::master(&seed),
+            &[
+                zip32::ChildIndex::hardened(32),
+                zip32::ChildIndex::hardened(JUNO_COIN_TYPE),
+                zip32::ChildIndex::hardened(0),
+            ],
+        )
+        .to_diversifiable_full_viewing_key();
+        let ufvk = zip316::encode_tlv_container(
+            HRP_JUNO_UFVK,
+            &[
+                zip316::Tlv {
+                    typecode: TYPECODE_SAPLING,
+                    value: &dfvk.to_bytes(),
+                },
+                zip316::Tlv {
+                    typecode: TYPECODE_ORCHARD,
+                    value: &fvk.to_bytes(),
+                },
+            ],
+        )
+        .expect("ufvk");
+        assert_eq!(
+            ufvk,
+            "jview1rwnrhkhxuvh244vl8wrz3xgw9e39kcfmvn90lwu8tt8sk7d58hd5z35e3ssced7gfqu7v9wdcd3l07gez0yeygwg4vv8959p7hp4remajzawnx9zrf8lmfxsry7w6uzna3mg6m73lujxdaqxcyn33w4xancdc898t7qrva8ulj09r2m0ud7fxdhm43lum7p8agnnhlmp5d2hxrjsr0dfwd6he0pl74sjpp85y2637qewdamy5t7yhmhqazsn26uthv92mjwcdj4uyuytmp6dy0hc46jpj3zkrvwuljr8vnu54hmlr5qdye7djamfjhlyysl4le8zaxvd3gkxp2f99gadrhnztxgxwyncxrl0lr43s76ctzth4z6xwfalvjvc53eekagazueuk"
+        );
+
+        // Indices 0 to 4 have no valid Sapling diversifier.
+        let key = Key::parse(&ufvk).expect("key");
+        let both = RECEIVERS_ORCHARD | RECEIVERS_SAPLING;
+        let (index, address, _) = key.derive_receivers(Scope::External, both, 0).expect("ua");
+        assert_eq!(index, 5);
+        assert_eq!(
+            address,
+            "j1dsrzrf3ayqjt7g4m6rglldvne920zpt2gdlmjdj6kdd3dhxvg8c7pqper2g6wwxrm20wmvga27jnmn0ds44hjlqvvfvhxrs804plct59a5a8hw7x5pwe4tjth27neymgck8x8d9vm26a0jjn02x4zfwr6wqxcz47pt8fks997vrsd635"
+        );
+        assert_eq!(key.index_of(&address).expect("index_of"), (Scope::External, 5));
+
+        // The key's UIVK carries the Sapling IVK, so derives the same addresses.
+        let uivk = key.uivk().expect("uivk");
+        let viewing = Key::parse(&uivk).expect("uivk key");
+        assert_eq!(viewing.uivk().expect("uivk round trip"), uivk);
+        assert_eq!(viewing.derive_receivers(Scope::External, both, 0).expect("ua").1, address);
+        assert_eq!(viewing.index_of(&address).expect("index_of"), (Scope::External, 5));
+        assert!(matches!(
+            viewing.derive_receivers(Scope::Internal, both, 0),
+            Err(ErrorCode::ScopeUnavailable)
+        ));
+
+        let bad = zip316::encode_tlv_container(
+            HRP_JUNO_UIVK,
+            &[
+                zip316::Tlv {
+                    typecode: TYPECODE_SAPLING,
+                    value: &[0u8; SAPLING_IVK_LEN - 1],
+                },
+                zip316::Tlv {
+                    typecode: TYPECODE_ORCHARD,
+                    value: &fvk.to_ivk(Scope::External).to_bytes(),
+                },
+            ],
+        )
+        .expect("uivk");
+        let viewing = Key::parse(&bad).expect("orchard item is valid");
+        assert!(matches!(
+            viewing.derive_receivers(Scope::External, both, 0),
+            Err(ErrorCode::UfvkValueLenInvalid)
+        ));
+    }
+
+    #[test]
+    fn transparent_receivers_match_vectors() {
+        // vectors/v1_transparent.json: the v1 keys plus the transparent account key.
+        let seed = [7u8; 64];
+        let account = AccountId::try_from(0).expect("account");
+        let sk =
+            orchard::keys::SpendingKey::from_zip32_seed(&seed, JUNO_COIN_TYPE, account).expect("sk");
+        let fvk = FullViewingKey::from(&sk);
+        let account_key =
+            transparent::account_pubkey_from_seed(&seed, JUNO_COIN_TYPE, 0).expect("account key");
+        let ufvk = zip316::encode_tlv_container(
+            HRP_JUNO_UFVK,
+            &[
+                zip316::Tlv {
+                    typecode: TYPECODE_P2PKH,
+                    value: &account_key,
+                },
+                zip316::Tlv {
+                    typecode: TYPECODE_ORCHARD,
+                    value: &fvk.to_bytes(),
+                },
+            ],
+        )
+        .expect("ufvk");
+        assert_eq!(
+            ufvk,
+            "jview1ue0hrvjv58qztey75mqtwj3xr3yu2hr46x7ujhuzsj62q9g8cz0uty8q6awafgecwuh3p5ljd39xgnw5n329fhe27p7uw6tn2dl7t3wq5xfml95yxcarxsre85gh3we57jsapx2eh7unzyvaxtsxx06qfzgkgtk6788w70ejl033kay8j37adnvhs4dfvhaazvm8xscsv3tjh9rz20ndy05evw6lxl4t4ehcuendawylzpyuy8nzcgp0fwad87emk57sp2qjlhhy2jh9apylmw3gjdv3tqg30v0hw4k5"
+        );
+
+        let key = Key::parse(&ufvk).expect("key");
+        let both = RECEIVERS_ORCHARD | RECEIVERS_P2PKH;
+        let (index, address, raw) = key.derive_receivers(Scope::External, both, 0).expect("ua");
+        assert_eq!(index, 0);
+        assert_eq!(
+            address,
+            "j1nksw3puu2r3dwn3h0wfqehzchj6cjhjggdpula7nygj6n8r90g4d7jhy3e884hws57wwuetys55zna2g6ggg4qjnar02ea6ecf0ktetrf00zfs40xjhd05k2k2x2yrh98et6z4uayzm"
+        );
+        assert_eq!(raw, fvk.address_at(0u64, Scope::External).to_raw_address_bytes());
+        assert_eq!(key.index_of(&address).expect("index_of"), (Scope::External, 0));
+
+        let t = |scope, index| key.derive_transparent(scope, index).expect("transparent");
+        assert_eq!(t(Scope::External, 0), (0, "t1ZKzjp9KL995MFwZ4MUgU593utre7obJnp".to_string()));
+        assert_eq!(t(Scope::Internal, 0), (0, "t1Zzcsvdh2BU3HagtzkK6Mac4NFdhPc3TUZ".to_string()));
+        assert_eq!(t(Scope::External, 99), (99, "t1XHjvA8NEes7qpjegbMZQCrTtsJa1WZL5J".to_string()));
+
+        // Change addresses pair the internal P2PKH and Orchard receivers.
+        let (_, change, _) = key.derive_receivers(Scope::Internal, both, 5).expect("change");
+        assert_eq!(key.index_of(&change).expect("index_of"), (Scope::Internal, 5));
+
+        // The P2PKH receiver must be ours at the same index.
+        let (_, items) = parse_address(&address).expect("parse");
+        let other = key.derive_receivers(Scope::External, both, 1).expect("ua 1").1;
+        let (_, other_items) = parse_address(&other).expect("parse");
+        let mixed = zip316::encode_tlv_container(
+            HRP_JUNO_UA,
+            &[
+                zip316::Tlv {
+                    typecode: TYPECODE_P2PKH,
+                    value: &other_items[0].1,
+                },
+                zip316::Tlv {
+                    typecode: TYPECODE_ORCHARD,
+                    value: &items[1].1,
+                },
+            ],
+        )
+        .expect("mixed");
+        assert!(matches!(key.index_of(&mixed), Err(ErrorCode::AddressNotOwned)));
+
+        let hardened = u128::from(transparent::MAX_ADDRESS_INDEX) + 1;
+        assert!(matches!(
+            key.derive_receivers(Scope::External, both, hardened),
+            Err(ErrorCode::RangeOverflow)
+        ));
+        assert!(matches!(
+            key.derive_transparent(Scope::External, hardened),
+            Err(ErrorCode::RangeOverflow)
+        ));
+
+        // The key's UIVK carries the external chain key, so derives the same external P2PKH
+        // receivers, and no internal ones.
+        let uivk = key.uivk().expect("uivk");
+        let items = zip316::decode_tlv_container(HRP_JUNO_UIVK, &uivk).expect("uivk items");
+        let typecodes: Vec<u64> = items.iter().map(|(t, _)| *t).collect();
+        assert_eq!(typecodes, [TYPECODE_P2PKH, TYPECODE_ORCHARD]);
+        let viewing = Key::parse(&uivk).expect("uivk key");
+        assert_eq!(viewing.uivk().expect("uivk round trip"), uivk);
+        assert_eq!(viewing.derive_receivers(Scope::External, both, 0).expect("ua").1, address);
+        assert_eq!(viewing.index_of(&address).expect("index_of"), (Scope::External, 0));
+        assert_eq!(
+            viewing.derive_transparent(Scope::External, 99),
+            Ok((99, "t1XHjvA8NEes7qpjegbMZQCrTtsJa1WZL5J".to_string()))
+        );
+        assert!(matches!(
+            viewing.derive_transparent(Scope::Internal, 0),
+            Err(ErrorCode::ScopeUnavailable)
+        ));
+        assert!(matches!(
+            viewing.derive_receivers(Scope::Internal, both, 0),
+            Err(ErrorCode::ScopeUnavailable)
+        ));
+        assert!(matches!(viewing.index_of(&change), Err(ErrorCode::AddressNotOwned)));
+
+        let uivk = zip316::encode_unified_container(
+            HRP_JUNO_UIVK,
+            TYPECODE_ORCHARD,
+            &fvk.to_ivk(Scope::External).to_bytes(),
+        )
+        .expect("uivk");
+        let uivk = Key::parse(&uivk).expect("uivk key");
+        assert!(matches!(
+            uivk.derive_transparent(Scope::External, 0),
+            Err(ErrorCode::ReceiversUnavailable)
+        ));
+
+        for (value, want) in [
+            (&[0xffu8; transparent::ACCOUNT_PUBKEY_LEN][..], ErrorCode::UfvkTransparentInvalid),
+            (&account_key[1..], ErrorCode::UfvkValueLenInvalid),
+        ] {
+            let bad = zip316::encode_tlv_container(
+                HRP_JUNO_UFVK,
+                &[
+                    zip316::Tlv {
+                        typecode: TYPECODE_P2PKH,
+                        value,
+                    },
+                    zip316::Tlv {
+                        typecode: TYPECODE_ORCHARD,
+                        value: &fvk.to_bytes(),
+                    },
+                ],
+            )
+            .expect("ufvk");
+            let key = Key::parse(&bad).expect("orchard item is valid");
+            let got = key.derive_transparent(Scope::External, 0).map(|_| ()).expect_err("bad item");
+            assert_eq!(got, want);
+            let got = key
+                .derive_receivers(Scope::External, both, 0)
+                .map(|_| ())
+                .expect_err("bad item");
+            assert_eq!(got, want);
+            assert!(key.derive_receivers(Scope::Internal, RECEIVERS_ORCHARD, 0).is_ok());
+        }
+    }
+
+    #[test]
+    fn parallel_batch_matches_sequential() {
+        let seed = [9u8; 64];
+        let account = AccountId::try_from(0).expect("account");
+        let sk =
+            orchard::keys::SpendingKey::from_zip32_seed(&seed, JUNO_COIN_TYPE, account).expect("sk");
+        let fvk = FullViewingKey::from(&sk);
+        let ivk = fvk.to_ivk(Scope::External);
+
+        // 1001 does not split evenly, exercising a short final chunk.
+        let sequential = derive_addresses_from_ivk(&ivk, HRP_JUNO_UA, 17, 1001, 1).expect("seq");
+        for jobs in [0, 2, 3, 7, 1000] {
+            let parallel =
+                derive_addresses_from_ivk(&ivk, HRP_JUNO_UA, 17, 1001, jobs).expect("parallel");
+            assert_eq!(parallel, sequential, "jobs={jobs}");
+        }
+    }
+
+    #[test]
+    fn batch_range_may_end_at_last_u32_index() {
+        assert!(check_batch_range(u32::MAX, 1).is_ok());
+        assert!(check_batch_range(u32::MAX - 1, 2).is_ok());
+        assert!(matches!(check_batch_range(u32::MAX, 2), Err(ErrorCode::RangeOverflow)));
+        assert!(matches!(check_batch_range(0, 0), Err(ErrorCode::CountZero)));
+
+        let seed = [9u8; 64];
+        let account = AccountId::try_from(0).expect("account");
+        let sk =
+            orchard::keys::SpendingKey::from_zip32_seed(&seed, JUNO_COIN_TYPE, account).expect("sk");
+        let ivk = FullViewingKey::from(&sk).to_ivk(Scope::External);
+
+        let last = derive_addresses_from_ivk(&ivk, HRP_JUNO_UA, u32::MAX - 1, 2, 1).expect("batch");
+        assert_eq!(
+            last[1],
+            derive_address_from_ivk(&ivk, HRP_JUNO_UA, u32::MAX).expect("derive")
+        );
+    }
+
+    #[test]
+    fn parallel_batch_near_end_of_index_space() {
+        let seed = [9u8; 64];
+        let account = AccountId::try_from(0).expect("account");
+        let sk =
+            orchard::keys::SpendingKey::from_zip32_seed(&seed, JUNO_COIN_TYPE, account).expect("sk");
+        let ivk = FullViewingKey::from(&sk).to_ivk(Scope::External);
+
+        let start = u32::MAX - 200;
+        let sequential = derive_addresses_from_ivk(&ivk, HRP_JUNO_UA, start, 200, 1).expect("seq");
+        let parallel = derive_addresses_from_ivk(&ivk, HRP_JUNO_UA, start, 200, 4).expect("par");
+        assert_eq!(parallel, sequential);
     }
 }
@@ -0,0 +1,261 @@
+//! BIP-32 / BIP-44 derivation of transparent P2PKH receivers.
+//!
+//! A UFVK's P2PKH item is the account-level extended public key `m/44'/coin_type'/account'`:
+//! its 32-byte chain code followed by its 33-byte compressed public key. Addresses at index `i`
+//! hang off it at `0/i` (external) and `1/i` (internal, i.e. change). A UIVK's P2PKH item is
+//! the external chain key `m/44'/coin_type'/account'/0`, encoded the same way.
+
+use hmac::{Hmac, Mac};
+use ripemd::Ripemd160;
+use secp256k1::{PublicKey, Scalar, SecretKey, SECP256K1};
+use sha2::{Digest, Sha256, Sha512};
+
+/// Length of a UFVK's P2PKH item: chain code followed by the compressed public key.
+pub const ACCOUNT_PUBKEY_LEN: usize = 65;
+/// Length of a UIVK's P2PKH item, laid out like a UFVK's.
+pub const EXTERNAL_IVK_LEN: usize = 65;
+/// Length of a P2PKH receiver: HASH160 of a compressed public key.
+pub const P2PKH_RECEIVER_LEN: usize = 20;
+/// Largest transparent address index: BIP-32 indices from 2^31 on are hardened, and cannot be
+/// derived from a public key.
+pub const MAX_ADDRESS_INDEX: u32 = HARDENED - 1;
+
+/// Base58Check version bytes of P2PKH addresses: `base58Prefixes[PUBKEY_ADDRESS]` in zcashd's
+/// `src/chainparams.cpp` (`{0x1C, 0xB8}` for main, `{0x1D, 0x25}` for test and regtest), so
+/// mainnet addresses start with `t1` and testnet / regtest ones with `tm`. These must match
+/// junocashd's chainparams, which keep zcashd's values; vectors/v1_transparent.json pins them.
+pub const P2PKH_PREFIX_MAINNET: [u8; 2] = [0x1c, 0xb8];
+pub const P2PKH_PREFIX_TESTNET: [u8; 2] = [0x1d, 0x25];
+
+const HARDENED: u32 = 1 << 31;
+
+type HmacSha512 = Hmac<Sha512>;
+
+// BIP-32 derivation is keyed HMAC-SHA512; the left half is a scalar tweak and the right half
+// the child chain code.
+fn ckd(chain_code: &[u8; 32], data: &[u8], index: u32) -> (Option<Scalar>, [u8; 32]) {
+    let mut mac = HmacSha512::new_from_slice(chain_code).expect("HMAC accepts any key length");
+    mac.update(data);
+    mac.update(&index.to_be_bytes());
+    let i = mac.finalize().into_bytes();
+    let tweak = Scalar::from_be_bytes(i[..32].try_into().expect("32 bytes")).ok();
+    (tweak, i[32..].try_into().expect("32 bytes"))
+}
+
+struct ExtendedPubKey {
+    chain_code: [u8; 32],
+    key: PublicKey,
+}
+
+impl ExtendedPubKey {
+    fn from_bytes(bytes: &[u8; ACCOUNT_PUBKEY_LEN]) -> Option<Self> {
+        Some(ExtendedPubKey {
+            chain_code: bytes[..32].try_into().expect("32 bytes"),
+            key: PublicKey::from_slice(&bytes[32..]).ok()?,
+        })
+    }
+
+    fn to_bytes(&self) -> [u8; ACCOUNT_PUBKEY_LEN] {
+        let mut out = [0u8; ACCOUNT_PUBKEY_LEN];
+        out[..32].copy_from_slice(&self.chain_code);
+        out[32..].copy_from_slice(&self.key.serialize());
+        out
+    }
+
+    // BIP-32 CKDpub for a non-hardened index. `None` for the indices (about 2^-127 of them)
+    // whose tweak is not a valid scalar or lands on the point at infinity; BIP-32 skips those.
+    fn child(&self, index: u32) -> Option<Self> {
+        debug_assert!(index < HARDENED);
+        let (tweak, chain_code) = ckd(&self.chain_code, &self.key.serialize(), index);
+        let key = self.key.add_exp_tweak(SECP256K1, &tweak?).ok()?;
+        Some(ExtendedPubKey { chain_code, key })
+    }
+}
+
+/// The account-level transparent key of a UFVK, with its external and internal chain keys
+/// derived up front, or the external chain key of a UIVK.
+pub struct AccountPubKey {
+    external: ExtendedPubKey,
+    // `None` for a key decoded from a UIVK.
+    internal: Option<ExtendedPubKey>,
+}
+
+impl AccountPubKey {
+    /// Decodes a UFVK's P2PKH item. `None` if the public key is not a valid curve point.
+    pub fn from_bytes(bytes: &[u8; ACCOUNT_PUBKEY_LEN]) -> Option<Self> {
+        let account = ExtendedPubKey::from_bytes(bytes)?;
+        Some(AccountPubKey {
+            external: account.child(0)?,
+            internal: Some(account.child(1)?),
+        })
+    }
+
+    /// Decodes a UIVK's P2PKH item into a key that derives external receivers only. `None` if
+    /// the public key is not a valid curve point.
+    pub fn from_external_ivk_bytes(bytes: &[u8; EXTERNAL_IVK_LEN]) -> Option<Self> {
+        Some(AccountPubKey {
+            external: ExtendedPubKey::from_bytes(bytes)?,
+            internal: None,
+        })
+    }
+
+    /// Encodes the external chain key as a UIVK's P2PKH item.
+    pub fn external_ivk_bytes(&self) -> [u8; EXTERNAL_IVK_LEN] {
+        self.external.to_bytes()
+    }
+
+    /// Whether the key derives internal receivers: false for a key decoded from a UIVK.
+    pub fn has_internal(&self) -> bool {
+        self.internal.is_some()
+    }
+
+    /// The P2PKH receiver at `index` on the external or internal chain: HASH160 of the child
+    /// public key. `None` if `index` is hardened or BIP-32 skips it, or for the internal chain of
+    /// a key without one.
+    pub fn p2pkh(&self, internal: bool, index: u32) -> Option<[u8; P2PKH_RECEIVER_LEN]> {
+        if index > MAX_ADDRESS_INDEX {
+            return None;
+        }
+        let chain = if internal {
+            self.internal.as_ref()?
+        } else {
+            &self.external
+        };
+        Some(hash160(&chain.child(index)?.key.serialize()))
+    }
+}
+
+/// Derives the encoded account key `m/44'/coin_type'/account'` from a BIP-32 seed, as a UFVK's
+/// P2PKH item. `None` for the negligible fraction of seeds BIP-32 cannot derive from.
+pub fn account_pubkey_from_seed(
+    seed: &[u8],
+    coin_type: u32,
+    account: u32,
+) -> Option<[u8; ACCOUNT_PUBKEY_LEN]> {
+    let mut mac = HmacSha512::new_from_slice(b"Bitcoin seed").expect("HMAC accepts any key length");
+    mac.update(seed);
+    let i = mac.finalize().into_bytes();
+    let mut key = SecretKey::from_slice(&i[..32]).ok()?;
+    let mut chain_code: [u8; 32] = i[32..].try_into().expect("32 bytes");
+
+    for index in [44, coin_type, account] {
+        let mut data = [0u8; 33];
+        data[1..].copy_from_slice(&key.secret_bytes());
+        let (tweak, child_chain) = ckd(&chain_code, &data, index | HARDENED);
+        key = key.add_tweak(&tweak?).ok()?;
+        chain_code = child_chain;
+    }
+
+    let account = ExtendedPubKey {
+        chain_code,
+        key: PublicKey::from_secret_key(SECP256K1, &key),
+    };
+    Some(account.to_bytes())
+}
+
+/// Encodes a P2PKH receiver as a Base58Check address with the given version bytes.
+pub fn encode_p2pkh(prefix: [u8; 2], receiver: &[u8; P2PKH_RECEIVER_LEN]) -> String {
+    let mut payload = [0u8; 2 + P2PKH_RECEIVER_LEN];
+    payload[..2].copy_from_slice(&prefix);
+    payload[2..].copy_from_slice(receiver);
+    bs58::encode(payload).with_check().into_string()
+}
+
+/// Why `decode_p2pkh` rejected an address.
+#[derive(Clone, Copy, Debug, PartialEq, Eq)]
+pub enum DecodeError {
+    /// Base58 with a payload of the right shape, but the wrong checksum: most likely a typo.
+    Checksum,
+    /// Not Base58, or not a 2-byte prefix followed by a P2PKH receiver.
+    Invalid,
+}
+
+/// Decodes a Base58Check P2PKH address into its version bytes and receiver. The version bytes
+/// are returned as-is; callers decide which networks they accept.
+pub fn decode_p2pkh(address: &str) -> Result<([u8; 2], [u8; P2PKH_RECEIVER_LEN]), DecodeError> {
+    // Check the shape first, so that only a P2PKH-sized payload can be a typo.
+    let raw = bs58::decode(address).into_vec().map_err(|_| DecodeError::Invalid)?;
+    if raw.len() != 2 + P2PKH_RECEIVER_LEN + 4 {
+        return Err(DecodeError::Invalid);
+    }
+    let payload = bs58::decode(address)
+        .with_check(None)
+        .into_vec()
+        .map_err(|_| DecodeError::Checksum)?;
+    let payload: [u8; 2 + P2PKH_RECEIVER_LEN] =
+        payload.try_into().map_err(|_| DecodeError::Invalid)?;
+    Ok((
+        payload[..2].try_into().expect("2 bytes"),
+        payload[2..].try_into().expect("20 bytes"),
+    ))
+}
+
+fn hash160(data: &[u8]) -> [u8; P2PKH_RECEIVER_LEN] {
+    Ripemd160::digest(Sha256::digest(data)).into()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn hex(bytes: &[u8]) -> String {
+        bytes.iter().map(|b| format!("{b:02x}")).collect()
+    }
+
+    #[test]
+    fn account_key_matches_bip44_derivation() {
+        // BIP-32 test vector 1's seed, at m/44'/0'/0' (Bitcoin's coin type).
+        let seed: Vec<u8> = (0u8..16).collect();
+        let account = account_pubkey_from_seed(&seed, 0, 0).expect("account key");
+        assert_eq!(
+            hex(&account),
+            "09474c1a527134dc6ad2ef7a73f72f464c84f7425ad7cb049615bd243fae12f5\
+             022d2d3d0e6e2000a7c3d569b476f967e1f2760bb4c787cb6e0e2ea1a254020460"
+        );
+
+        let key = AccountPubKey::from_bytes(&account).expect("valid key");
+        let external = key.p2pkh(false, 0).expect("external 0");
+        let internal = key.p2pkh(true, 0).expect("internal 0");
+        assert_eq!(hex(&external), "eadbac7f36c37e39361168b7aaee3cb24a25312d");
+        assert_eq!(hex(&internal), "922c2841f27c4778f97ba6c71e0a79685a6f2c40");
+        assert!(key.p2pkh(false, MAX_ADDRESS_INDEX).is_some());
+        assert!(key.p2pkh(false, MAX_ADDRESS_INDEX + 1).is_none());
+
+        // The external chain key derives the same external receivers, and no internal ones.
+        let ivk = AccountPubKey::from_external_ivk_bytes(&key.external_ivk_bytes()).expect("ivk");
+        assert!(key.has_internal() && !ivk.has_internal());
+        assert_eq!(ivk.external_ivk_bytes(), key.external_ivk_bytes());
+        assert_eq!(ivk.p2pkh(false, 0), Some(external));
+        assert_eq!(ivk.p2pkh(true, 0), None);
+    }
+
+    #[test]
+    fn p2pkh_addresses_round_trip() {
+        let receiver = [0x11u8; P2PKH_RECEIVER_LEN];
+        let address = encode_p2pkh(P2PKH_PREFIX_MAINNET, &receiver);
+        assert!(address.starts_with("t1"));
+        assert_eq!(decode_p2pkh(&address), Ok((P2PKH_PREFIX_MAINNET, receiver)));
+        let testnet = encode_p2pkh(P2PKH_PREFIX_TESTNET, &receiver);
+        assert!(testnet.starts_with("tm"));
+        assert_eq!(decode_p2pkh(&testnet), Ok((P2PKH_PREFIX_TESTNET, receiver)));
+
+        let mut typo = address.clone().into_bytes();
+        typo[5] = if typo[5] == b'2' { b'3' } else { b'2' };
+        let typo = String::from_utf8(typo).expect("utf8");
+        assert_eq!(decode_p2pkh(&typo), Err(DecodeError::Checksum));
+        assert_eq!(decode_p2pkh("t1-not-base58"), Err(DecodeError::Invalid));
+        // A well-formed Base58Check string of the wrong length.
+        let short = bs58::encode([0x1cu8, 0xb8, 1, 2, 3])
+            .with_check()
+            .into_string();
+        assert_eq!(decode_p2pkh(&short), Err(DecodeError::Invalid));
+    }
+
+    #[test]
+    fn invalid_public_key_is_rejected() {
+        let mut bytes = [0u8; ACCOUNT_PUBKEY_LEN];
+        bytes[32] = 0x02;
+        bytes[33..].fill(0xff);
+        assert!(AccountPubKey::from_bytes(&bytes).is_none());
+    }
+}
@@ -0,0 +1,42 @@
+//! Exports for the WebAssembly build (`wasm32-unknown-unknown`), which the Go `addrgen_wasm`
+//! backend runs in place of the static library.
+//!
+//! The C ABI is exported unchanged; a host passes it pointers into the module's linear memory,
+//! so it needs a way to allocate there. Batches run on one thread, as the target has none.
+
+use std::alloc::{alloc, dealloc, Layout};
+
+// Enough for every buffer type the C ABI takes, including `uint64_t` arrays.
+const ALIGN: usize = 8;
+
+fn layout(len: usize) -> Option<Layout> {
+    Layout::from_size_align(len.max(1), ALIGN).ok()
+}
+
+/// Allocates `len` bytes, 8-byte aligned, for the host to pass to the C ABI. Returns NULL if the
+/// allocation fails. Free with `juno_addrgen_wasm_free` and the same `len`.
+#[no_mangle]
+pub extern "C" fn juno_addrgen_wasm_alloc(len: usize) -> *mut u8 {
+    match layout(len) {
+        Some(layout) => unsafe { alloc(layout) },
+        None => core::ptr::null_mut(),
+    }
+}
+
+/// Frees memory returned by `juno_addrgen_wasm_alloc`. NULL is ignored.
+#[no_mangle]
+pub extern "C" fn juno_addrgen_wasm_free(ptr: *mut u8, len: usize) {
+    if ptr.is_null() {
+        return;
+    }
+    if let Some(layout) = layout(len) {
+        unsafe { dealloc(ptr, layout) };
+    }
+}
+
+// Address derivation is deterministic and never asks for randomness; fail if anything does.
+fn no_entropy(_: &mut [u8]) -> Result<(), getrandom::Error> {
+    Err(getrandom::Error::UNSUPPORTED)
+}
+
+getrandom::register_custom_getrandom!(no_entropy);
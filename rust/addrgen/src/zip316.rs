@@ -1,5 +1,5 @@
 use bech32::primitives::checksum::Checksum;
-use bech32::primitives::decode::CheckedHrpstring;
+use bech32::primitives::decode::{CheckedHrpstring, CheckedHrpstringError};
 use bech32::Hrp;
 use thiserror::Error;
 
@@ -25,6 +25,8 @@ impl Checksum for Bech32mUnlimited {
 
 const PADDING_LEN: usize = 16;
 
+// pkg/zip316 is a pure-Go port of this module: keep its errors, and which input yields which,
+// in step.
 #[derive(Debug, Error)]
 pub enum Zip316Error {
     #[error("hrp_too_long")]
@@ -39,6 +41,8 @@ pub enum Zip316Error {
     Bech32EncodeFailed,
     #[error("bech32_decode_failed")]
     Bech32DecodeFailed,
+    #[error("checksum_invalid")]
+    ChecksumInvalid,
     #[error("hrp_mismatch")]
     HrpMismatch,
     #[error("padding_invalid")]
@@ -126,9 +130,21 @@ fn encode_zip316_bech32m(hrp: &str, raw_items_tlv: &[u8]) -> Result<String, Zip3
     bech32::encode::<Bech32mUnlimited>(hrp, &jumbled).map_err(|_| Zip316Error::Bech32EncodeFailed)
 }
 
+fn checked_hrpstring(s: &str) -> Result<CheckedHrpstring<'_>, Zip316Error> {
+    CheckedHrpstring::new::<Bech32mUnlimited>(s).map_err(|e| match e {
+        CheckedHrpstringError::Checksum(_) => Zip316Error::ChecksumInvalid,
+        _ => Zip316Error::Bech32DecodeFailed,
+    })
+}
+
+/// Returns the HRP of a bech32m string after verifying its checksum, without decoding the
+/// payload. Lets callers pick the expected HRP (or reject a foreign one) before decoding.
+pub fn decode_hrp(s: &str) -> Result<String, Zip316Error> {
+    Ok(checked_hrpstring(s)?.hrp().as_str().to_owned())
+}
+
 fn decode_zip316_bech32m(hrp_expected: &str, s: &str) -> Result<Vec<u8>, Zip316Error> {
-    let checked =
-        CheckedHrpstring::new::<Bech32mUnlimited>(s).map_err(|_| Zip316Error::Bech32DecodeFailed)?;
+    let checked = checked_hrpstring(s)?;
 
     if checked.hrp().as_str() != hrp_expected {
         return Err(Zip316Error::HrpMismatch);
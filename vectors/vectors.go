@@ -0,0 +1,71 @@
+// Package vectors embeds the golden vectors in this directory, so that a
+// binary can check the library it links against them at run time (see
+// addrgen.SelfTest and the selftest command).
+//
+// Every file is generated by rust/addrgen's gen_vectors from the same seed:
+// account 0 of [7; 64] at coin type 8133. v1_sapling.json adds that
+// account's ZIP-32 Sapling key (m/32'/8133'/0') to the UFVK.
+package vectors
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+//go:embed *.json
+var files embed.FS
+
+// File is one vectors file. Which fields are set depends on the file: every
+// file has a UFVK, and one of Addresses (index i at position i) and Indexed.
+type File struct {
+	Name string `json:"-"`
+	// Network is the network of the UFVK and addresses, from the file name:
+	// v1_testnet*.json and v1_regtest*.json, or mainnet.
+	Network string `json:"-"`
+	Version int    `json:"version"`
+	// Scope is "internal", or empty for the external scope.
+	Scope string `json:"scope"`
+	// Receivers lists the receiver types of the addresses ("orchard",
+	// "sapling"), or is empty for Orchard receivers alone.
+	Receivers []string `json:"receivers"`
+	UFVK      string   `json:"ufvk"`
+	Addresses []string `json:"addresses"`
+	Indexed   []struct {
+		// Index is a decimal diversifier index.
+		Index   string `json:"index"`
+		Address string `json:"address"`
+	} `json:"indexed"`
+	// Transparent and TransparentInternal are standalone P2PKH addresses
+	// in the external and internal scopes, at BIP-44 address index i.
+	Transparent         []string `json:"transparent"`
+	TransparentInternal []string `json:"transparent_internal"`
+}
+
+// Names lists the embedded files, sorted.
+func Names() []string {
+	names, _ := fs.Glob(files, "*.json")
+	sort.Strings(names)
+	return names
+}
+
+// Load parses the embedded file name (e.g. "v1.json").
+func Load(name string) (File, error) {
+	b, err := files.ReadFile(name)
+	if err != nil {
+		return File{}, err
+	}
+	f := File{Name: name, Network: "mainnet"}
+	if err := json.Unmarshal(b, &f); err != nil {
+		return File{}, fmt.Errorf("vectors: %s: %w", name, err)
+	}
+	for _, network := range []string{"testnet", "regtest"} {
+		if strings.HasPrefix(name, "v1_"+network) {
+			f.Network = network
+		}
+	}
+	return f, nil
+}
@@ -0,0 +1,47 @@
+package vectors
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	names := Names()
+	for _, want := range []string{"v1.json", "v1_internal.json", "v1_regtest.json", "v1_regtest_internal.json", "v1_sapling.json", "v1_testnet.json", "v1_testnet_internal.json", "v1_transparent.json", "v1_wide.json"} {
+		if !slices.Contains(names, want) {
+			t.Fatalf("Names() = %v, missing %s", names, want)
+		}
+	}
+
+	hrps := map[string]struct{ ufvk, address string }{
+		"mainnet": {"jview1", "j1"},
+		"testnet": {"jviewtest1", "jtest1"},
+		"regtest": {"jviewregtest1", "jregtest1"},
+	}
+	for _, name := range names {
+		f, err := Load(name)
+		if err != nil {
+			t.Fatalf("Load(%s) error: %v", name, err)
+		}
+		hrp := hrps[f.Network]
+		if f.Name != name || f.Version != 1 || !strings.HasPrefix(f.UFVK, hrp.ufvk) {
+			t.Fatalf("Load(%s) = %s %s version %d", name, f.Name, f.Network, f.Version)
+		}
+		if (f.Scope != "" && f.Scope != "internal") || (f.Scope == "internal") != strings.Contains(name, "internal") {
+			t.Fatalf("%s: scope %q", name, f.Scope)
+		}
+		if len(f.Addresses) == 0 && len(f.Indexed) == 0 {
+			t.Fatalf("%s: no addresses", name)
+		}
+		for _, a := range f.Addresses {
+			if !strings.HasPrefix(a, hrp.address) {
+				t.Fatalf("%s: address %s is not on %s", name, a, f.Network)
+			}
+		}
+	}
+
+	if _, err := Load("v2.json"); err == nil {
+		t.Fatalf("Load(v2.json) succeeded")
+	}
+}